@@ -22,7 +22,17 @@ func newArchivePruneCommand() *cobra.Command {
 
 By default runs in dry-run mode, listing candidates without deleting.
 Use --execute to perform actual deletion. The archive/ directory is
-git-tracked, so deletions should be reviewed and committed.`,
+git-tracked, so deletions should be reviewed and committed.
+
+Expired daily event files are not deleted: the expedition projection is
+snapshotted first, then the files move into a compressed, checksummed
+segment under .expedition/events-archive/, which rebuild, events list
+--archived and events verify still read.
+
+Pruned D-Mails take their <name>.attachments/ directory with them. Blobs in
+.expedition/artifacts/ that no D-Mail in inbox/, outbox/, archive/ or the
+outbox queue references any more are garbage-collected too (blobs stored
+within the last hour are kept for D-Mails still being sent).`,
 		Example: `  # Dry run: list files older than 30 days (current directory)
   paintress archive-prune
 
@@ -111,10 +121,19 @@ func runArchivePrune(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list expired events: %w", eventErr)
 	}
 
+	// Collect blobs no D-Mail will reference once the candidates are gone.
+	artifacts, artifactErr := archiveOps.PruneArtifacts(cmd.Context(), repoPath, archiveResult.Candidates, false)
+	if artifactErr != nil {
+		return fmt.Errorf("failed to list unreferenced artifacts: %w", artifactErr)
+	}
+
 	w := cmd.OutOrStdout()
 	ew := cmd.ErrOrStderr()
 
-	totalCandidates := len(archiveResult.Candidates) + len(eventFiles)
+	totalCandidates := len(archiveResult.Candidates) + len(eventFiles) + len(artifacts)
+	// Artifacts are collected against the archive files actually removed:
+	// a D-Mail whose removal failed keeps its blobs.
+	var removed []string
 
 	if outputFmt == "json" {
 		out := struct {
@@ -124,11 +143,17 @@ func runArchivePrune(cmd *cobra.Command, args []string) error {
 			EventCandidates int      `json:"event_candidates"`
 			EventDeleted    int      `json:"event_deleted"`
 			EventFiles      []string `json:"event_files"`
+			// Artifact fields cover unreferenced attachment blobs.
+			ArtifactCandidates int      `json:"artifact_candidates"`
+			ArtifactDeleted    int      `json:"artifact_deleted"`
+			ArtifactFiles      []string `json:"artifact_files"`
 		}{
-			Candidates:      len(archiveResult.Candidates),
-			Files:           archiveResult.Candidates,
-			EventCandidates: len(eventFiles),
-			EventFiles:      eventFiles,
+			Candidates:         len(archiveResult.Candidates),
+			Files:              archiveResult.Candidates,
+			EventCandidates:    len(eventFiles),
+			EventFiles:         eventFiles,
+			ArtifactCandidates: len(artifacts),
+			ArtifactFiles:      artifacts,
 		}
 		if execute {
 			indexPaintressArchive(archiveResult.Candidates, stateDir, ew)
@@ -138,6 +163,7 @@ func runArchivePrune(cmd *cobra.Command, args []string) error {
 				return execErr
 			}
 			out.Deleted = execResult.Deleted
+			removed = execResult.Removed
 
 			if len(eventFiles) > 0 {
 				deleted, delErr := archiveOps.PruneEventFiles(cmd.Context(), stateDir, eventFiles)
@@ -147,6 +173,14 @@ func runArchivePrune(cmd *cobra.Command, args []string) error {
 				out.EventDeleted = len(deleted)
 			}
 
+			if len(artifacts) > 0 {
+				deleted, delErr := archiveOps.PruneArtifacts(cmd.Context(), repoPath, removed, true)
+				if delErr != nil {
+					return fmt.Errorf("artifact prune failed: %w", delErr)
+				}
+				out.ArtifactDeleted = len(deleted)
+			}
+
 		}
 		data, jsonErr := json.Marshal(out)
 		if jsonErr != nil {
@@ -174,7 +208,13 @@ func runArchivePrune(cmd *cobra.Command, args []string) error {
 			fmt.Fprintln(ew, "  "+f)
 		}
 	}
-	fmt.Fprintf(ew, "%d file(s) older than %d days.\n", totalCandidates, days)
+	if len(artifacts) > 0 {
+		fmt.Fprintf(ew, "Unreferenced artifacts (%d):\n", len(artifacts))
+		for _, f := range artifacts {
+			fmt.Fprintln(ew, "  "+f)
+		}
+	}
+	fmt.Fprintf(ew, "%d file(s) to prune (retention %d days).\n", totalCandidates, days)
 
 	if !execute {
 		fmt.Fprintln(ew, "(dry-run — pass --execute to delete)")
@@ -209,6 +249,7 @@ func runArchivePrune(cmd *cobra.Command, args []string) error {
 			return execErr
 		}
 		fmt.Fprintf(ew, "Pruned %d archive file(s).\n", execResult.Deleted)
+		removed = execResult.Removed
 	}
 
 	// Execute: event file deletion
@@ -217,7 +258,16 @@ func runArchivePrune(cmd *cobra.Command, args []string) error {
 		if delErr != nil {
 			return fmt.Errorf("event prune failed: %w", delErr)
 		}
-		fmt.Fprintf(ew, "Pruned %d event file(s).\n", len(deleted))
+		fmt.Fprintf(ew, "Archived %d event file(s) into %s.\n", len(deleted), filepath.Join(domain.StateDir, "events-archive"))
+	}
+
+	// Execute: unreferenced artifact deletion
+	if len(artifacts) > 0 {
+		deleted, delErr := archiveOps.PruneArtifacts(cmd.Context(), repoPath, removed, true)
+		if delErr != nil {
+			return fmt.Errorf("artifact prune failed: %w", delErr)
+		}
+		fmt.Fprintf(ew, "Removed %d unreferenced artifact(s).\n", len(deleted))
 	}
 
 	// Prune flushed outbox DB rows + incremental vacuum.
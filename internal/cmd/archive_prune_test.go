@@ -390,3 +390,39 @@ func TestArchivePruneCommand_RebuildIndex_CreatesIndex(t *testing.T) {
 		t.Error("expected index.jsonl to be created by --rebuild-index")
 	}
 }
+
+func TestArchivePruneCommand_RemovesUnreferencedArtifacts(t *testing.T) {
+	// given: one old blob no D-Mail references
+	repoDir := t.TempDir()
+	artifactsDir := filepath.Join(repoDir, ".expedition", "artifacts")
+	if err := os.MkdirAll(artifactsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	orphan := filepath.Join(artifactsDir, strings.Repeat("ab", 32))
+	if err := os.WriteFile(orphan, []byte("orphan"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(orphan, past, past); err != nil {
+		t.Fatal(err)
+	}
+	root := cmd.NewRootCommand()
+	stdout := new(bytes.Buffer)
+	root.SetOut(stdout)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"archive-prune", "--execute", "-o", "json", repoDir})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"artifact_candidates":1`) || !strings.Contains(stdout.String(), `"artifact_deleted":1`) {
+		t.Errorf("output = %s, want one artifact candidate deleted", stdout.String())
+	}
+	if _, err := os.Stat(orphan); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("orphan blob should be removed: %v", err)
+	}
+}
@@ -3,24 +3,35 @@ package cmd
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/hironow/paintress/internal/domain"
 	"github.com/hironow/paintress/internal/session"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 func newDeadLettersCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "dead-letters",
 		Short: "Manage dead-lettered outbox items",
-		Long:  "Inspect and purge outbox items that have exceeded the maximum retry count.",
+		Long: `Inspect, requeue and purge outbox items that have exceeded the maximum
+retry count. Flush records the error of every failed attempt and backs off
+exponentially between attempts; after 3 failures an item is dead-lettered.`,
 	}
 
-	cmd.AddCommand(newDeadLettersPurgeCommand())
+	cmd.AddCommand(
+		newDeadLettersListCommand(),
+		newDeadLettersShowCommand(),
+		newDeadLettersRequeueCommand(),
+		newDeadLettersPurgeCommand(),
+	)
 
 	return cmd
 }
@@ -61,8 +72,7 @@ func runDeadLettersPurge(cmd *cobra.Command, args []string) error {
 	}
 
 	// Pre-flight: check DB exists to avoid creating dirs/DB as side effect
-	dbPath := filepath.Join(repoPath, domain.StateDir, ".run", "outbox.db")
-	if _, statErr := os.Stat(dbPath); statErr != nil {
+	if !outboxDBExists(repoPath) {
 		outputFmt := mustString(cmd, "output")
 		if outputFmt == "json" {
 			fmt.Fprintln(cmd.OutOrStdout(), `{"dead_letters":0,"purged":0}`)
@@ -149,3 +159,198 @@ func runDeadLettersPurge(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func newDeadLettersListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [path]",
+		Short: "List dead-lettered outbox items",
+		Long:  "List dead-lettered outbox items with their retry count, last error and timestamps.",
+		Example: `  paintress dead-letters list
+  paintress dead-letters list -o json /path/to/repo`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runDeadLettersList,
+	}
+}
+
+func runDeadLettersList(cmd *cobra.Command, args []string) error {
+	repoPath, err := resolveTargetDir(args)
+	if err != nil {
+		return err
+	}
+	letters := make([]session.DeadLetter, 0)
+	if outboxDBExists(repoPath) {
+		store, err := session.NewOutboxStoreForDir(repoPath)
+		if err != nil {
+			return fmt.Errorf("open outbox store: %w", err)
+		}
+		defer func() { _ = store.Close() }()
+		if letters, err = store.ListDeadLetters(cmd.Context()); err != nil {
+			return err
+		}
+	}
+
+	if mustString(cmd, "output") == "json" {
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(letters)
+	}
+	if len(letters) == 0 {
+		fmt.Fprintln(cmd.ErrOrStderr(), "No dead-lettered items.")
+		return nil
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tRETRIES\tFIRST STAGED\tLAST ATTEMPT\tLAST ERROR")
+	for _, dl := range letters {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n",
+			dl.Name, dl.RetryCount, formatDeadLetterTime(dl.FirstStagedAt),
+			formatDeadLetterTime(dl.LastAttemptAt), dl.LastError)
+	}
+	return w.Flush()
+}
+
+func newDeadLettersShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <name> [path]",
+		Short: "Show a dead-lettered outbox item",
+		Long: `Show a dead-lettered outbox item: its decoded D-Mail frontmatter, body and
+the error of every failed flush attempt. The .md extension may be omitted.`,
+		Example: `  paintress dead-letters show report-my-42
+  paintress dead-letters show report-my-42.md -o json`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runDeadLettersShow,
+	}
+}
+
+func runDeadLettersShow(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	repoPath, err := resolveTargetDir(args[1:])
+	if err != nil {
+		return err
+	}
+	if !outboxDBExists(repoPath) {
+		return fmt.Errorf("%w: %s", session.ErrDeadLetterNotFound, name)
+	}
+	store, err := session.NewOutboxStoreForDir(repoPath)
+	if err != nil {
+		return fmt.Errorf("open outbox store: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+	detail, err := store.ShowDeadLetter(cmd.Context(), name)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	if mustString(cmd, "output") == "json" {
+		return json.NewEncoder(w).Encode(detail)
+	}
+	fmt.Fprintf(w, "Name:          %s\n", detail.Name)
+	fmt.Fprintf(w, "Retries:       %d\n", detail.RetryCount)
+	fmt.Fprintf(w, "First staged:  %s\n", formatDeadLetterTime(detail.FirstStagedAt))
+	fmt.Fprintf(w, "Last attempt:  %s\n", formatDeadLetterTime(detail.LastAttemptAt))
+	fmt.Fprintf(w, "Last error:    %s\n", detail.LastError)
+	if detail.DecodeError != "" {
+		fmt.Fprintf(w, "\nNot a valid D-Mail (%s); raw content:\n%s\n", detail.DecodeError, detail.Body)
+	} else {
+		frontmatter, err := yaml.Marshal(detail.Frontmatter)
+		if err != nil {
+			return fmt.Errorf("render frontmatter: %w", err)
+		}
+		fmt.Fprintf(w, "\nFrontmatter:\n%s\nBody:\n%s\n", indentLines(string(frontmatter)), indentLines(detail.Body))
+	}
+	fmt.Fprintln(w, "\nErrors:")
+	if len(detail.Errors) == 0 {
+		fmt.Fprintln(w, "  (none recorded)")
+	}
+	for _, f := range detail.Errors {
+		fmt.Fprintf(w, "  #%d  %s  %s\n", f.Attempt, formatDeadLetterTime(f.At), f.Error)
+	}
+	return nil
+}
+
+func newDeadLettersRequeueCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "requeue <name|--all> [path]",
+		Short: "Give dead-lettered outbox items another chance",
+		Long: `Reset the retry count of a dead-lettered outbox item (or of all of them with
+--all), so the next outbox flush attempts it again right away. The error
+history is kept. The .md extension may be omitted.`,
+		Example: `  paintress dead-letters requeue report-my-42
+  paintress dead-letters requeue --all
+  paintress dead-letters requeue --all /path/to/repo`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if mustBool(cmd, "all") {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			if len(args) == 0 {
+				return errors.New("requires a dead letter name or --all")
+			}
+			return cobra.RangeArgs(1, 2)(cmd, args)
+		},
+		RunE: runDeadLettersRequeue,
+	}
+	cmd.Flags().Bool("all", false, "Requeue every dead-lettered item")
+	return cmd
+}
+
+func runDeadLettersRequeue(cmd *cobra.Command, args []string) error {
+	all := mustBool(cmd, "all")
+	var name string
+	if !all {
+		name, args = args[0], args[1:]
+	}
+	repoPath, err := resolveTargetDir(args)
+	if err != nil {
+		return err
+	}
+
+	requeued := 0
+	if outboxDBExists(repoPath) {
+		store, err := session.NewOutboxStoreForDir(repoPath)
+		if err != nil {
+			return fmt.Errorf("open outbox store: %w", err)
+		}
+		defer func() { _ = store.Close() }()
+		if all {
+			requeued, err = store.RequeueDeadLetters(cmd.Context())
+		} else if err = store.RequeueDeadLetter(cmd.Context(), name); err == nil {
+			requeued = 1
+		}
+		if err != nil {
+			return err
+		}
+	} else if !all {
+		return fmt.Errorf("%w: %s", session.ErrDeadLetterNotFound, name)
+	}
+
+	if mustString(cmd, "output") == "json" {
+		fmt.Fprintf(cmd.OutOrStdout(), "{\"requeued\":%d}\n", requeued)
+		return nil
+	}
+	if requeued == 0 {
+		fmt.Fprintln(cmd.ErrOrStderr(), "No dead-lettered items.")
+		return nil
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Requeued %d dead-lettered item(s); the next outbox flush delivers them.\n", requeued)
+	return nil
+}
+
+// outboxDBExists reports whether repoPath has an outbox database, so the
+// read-only subcommands do not create one as a side effect.
+func outboxDBExists(repoPath string) bool {
+	_, err := os.Stat(filepath.Join(repoPath, domain.StateDir, ".run", "outbox.db"))
+	return err == nil
+}
+
+func formatDeadLetterTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+func indentLines(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "  " + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -2,13 +2,17 @@ package cmd_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/hironow/paintress/internal/cmd"
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
 
 	_ "modernc.org/sqlite"
 )
@@ -255,3 +259,162 @@ func TestDeadLettersPurge_FlagDefaults(t *testing.T) {
 		t.Errorf("--yes default = %q, want %q", yesFlag.DefValue, "false")
 	}
 }
+
+// seedFailedDMail stages a report D-Mail through the outbox store and
+// marks it as dead-lettered after two recorded failures.
+func seedFailedDMail(t *testing.T, repoDir, name string) {
+	t.Helper()
+	store, err := session.NewOutboxStoreForDir(repoDir)
+	if err != nil {
+		t.Fatalf("open outbox store: %v", err)
+	}
+	data, err := domain.DMail{Name: name, Kind: "report", Description: "expedition report", Body: "# Report\n"}.Marshal()
+	if err == nil {
+		err = store.Stage(context.Background(), name+".md", data)
+	}
+	store.Close()
+	if err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(repoDir, ".expedition", ".run", "outbox.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`UPDATE staged SET retry_count = 3, last_error = 'write archive: disk full', last_attempt_at = '2026-03-01T10:00:00Z'`,
+		`INSERT INTO staged_errors (name, attempt, error, at) VALUES
+			('` + name + `.md', 1, 'write archive: permission denied', '2026-03-01T09:00:00Z'),
+			('` + name + `.md', 2, 'write archive: disk full', '2026-03-01T10:00:00Z')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("seed dead letter: %v", err)
+		}
+	}
+}
+
+func TestDeadLettersList_JSONOutput(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	seedFailedDMail(t, dir, "report-my-42")
+
+	root := cmd.NewRootCommand()
+	outBuf := new(bytes.Buffer)
+	root.SetOut(outBuf)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"dead-letters", "list", "-o", "json", dir})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var letters []session.DeadLetter
+	if err := json.Unmarshal(outBuf.Bytes(), &letters); err != nil {
+		t.Fatalf("decode output %q: %v", outBuf.String(), err)
+	}
+	if len(letters) != 1 || letters[0].Name != "report-my-42.md" || letters[0].LastError != "write archive: disk full" {
+		t.Errorf("dead letters: got %+v", letters)
+	}
+	if letters[0].FirstStagedAt.IsZero() {
+		t.Error("first_staged_at: got zero, want the staging time")
+	}
+}
+
+func TestDeadLettersShow_TextOutput(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	seedFailedDMail(t, dir, "report-my-42")
+
+	root := cmd.NewRootCommand()
+	outBuf := new(bytes.Buffer)
+	root.SetOut(outBuf)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"dead-letters", "show", "report-my-42", dir})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := outBuf.String()
+	for _, want := range []string{"Name:          report-my-42.md", "kind: report", "# Report", "#1  ", "permission denied", "#2  "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDeadLettersShow_UnknownName(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	seedFailedDMail(t, dir, "report-my-42")
+
+	root := cmd.NewRootCommand()
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"dead-letters", "show", "missing", dir})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected a not-found error naming the item, got %v", err)
+	}
+}
+
+func TestDeadLettersRequeue_All(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	seedFailedDMail(t, dir, "report-my-42")
+	seedFailedDMail(t, dir, "report-my-43")
+
+	root := cmd.NewRootCommand()
+	outBuf := new(bytes.Buffer)
+	root.SetOut(outBuf)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"dead-letters", "requeue", "--all", "-o", "json", dir})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(outBuf.String()) != `{"requeued":2}` {
+		t.Errorf("output: got %q", outBuf.String())
+	}
+	store, err := session.NewOutboxStoreForDir(dir)
+	if err != nil {
+		t.Fatalf("open outbox store: %v", err)
+	}
+	defer store.Close()
+	if n, _ := store.DeadLetterCount(context.Background()); n != 0 {
+		t.Errorf("dead letters after requeue: got %d, want 0", n)
+	}
+	if n, err := store.Flush(context.Background()); err != nil || n != 2 {
+		t.Errorf("flush after requeue: n=%d err=%v, want 2 and nil", n, err)
+	}
+}
+
+func TestDeadLettersRequeue_RequiresNameOrAll(t *testing.T) {
+	// given
+	root := cmd.NewRootCommand()
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"dead-letters", "requeue"})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err == nil || !strings.Contains(err.Error(), "--all") {
+		t.Errorf("expected a usage error mentioning --all, got %v", err)
+	}
+}
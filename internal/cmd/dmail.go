@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func newDMailCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dmail",
+		Short: "Inspect D-Mail conversations",
+		Long: `Inspect the D-Mails paintress received and sent. Reports emitted through the
+dmail MCP tool carry in_reply_to / thread_id metadata naming the inbox D-Mail
+they answer, so a specification, its report, the feedback on it and the rerun
+form one thread.`,
+	}
+
+	cmd.AddCommand(newDMailThreadCommand())
+
+	return cmd
+}
+
+func newDMailThreadCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "thread <name|issue> [path]",
+		Short: "Show the conversation of a D-Mail or issue",
+		Long: `Show a D-Mail conversation in time order: the inbox D-Mails, the reports
+answering them and the expeditions run in between, with timestamps and
+outcomes. D-Mails come from archive/ and inbox/, times and outcomes from the
+event store.
+
+A D-Mail name selects its thread (thread_id, in_reply_to links and the
+expeditions on its issues); any other argument is an issue ID and selects
+every D-Mail and expedition about that issue.`,
+		Example: `  paintress dmail thread spec-my-42
+  paintress dmail thread MY-42 /path/to/repo
+  paintress dmail thread MY-42 -o json`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runDMailThread,
+	}
+}
+
+func runDMailThread(cmd *cobra.Command, args []string) error {
+	repoPath, err := resolveTargetDir(args[1:])
+	if err != nil {
+		return err
+	}
+	thread, err := session.LoadDMailThread(cmd.Context(), repoPath, args[0], loggerFrom(cmd))
+	if err != nil {
+		return err
+	}
+
+	if mustString(cmd, "output") == "json" {
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(thread)
+	}
+	out := cmd.OutOrStdout()
+	if thread.ThreadID != "" {
+		fmt.Fprintf(out, "Thread %s (issues: %s)\n\n", thread.ThreadID, strings.Join(thread.Issues, ", "))
+	} else {
+		fmt.Fprintf(out, "Issue %s\n\n", thread.Selector)
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tTYPE\tNAME\tKIND\tOUTCOME\tDESCRIPTION")
+	for _, e := range thread.Entries {
+		name, desc := e.Name, e.Description
+		switch {
+		case e.Type == domain.ThreadEntryExpedition:
+			name = fmt.Sprintf("#%d", e.Expedition)
+			if e.Rerun {
+				desc += " (rerun)"
+			}
+		case e.InReplyTo != "":
+			desc = fmt.Sprintf("%s (re: %s)", desc, e.InReplyTo)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.At.Local().Format("2006-01-02 15:04:05"), e.Type, name, e.Kind, e.Outcome, desc)
+	}
+	return w.Flush()
+}
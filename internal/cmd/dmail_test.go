@@ -0,0 +1,130 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/cmd"
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+// seedDMailThread archives a specification and the report answering it,
+// with the events of their expedition.
+func seedDMailThread(t *testing.T, dir string) {
+	t.Helper()
+	archive := domain.ArchiveDir(dir)
+	if err := os.MkdirAll(archive, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, dm := range []domain.DMail{
+		{Name: "spec-my-42", Kind: domain.KindSpecification, Description: "Add login", Issues: []string{"MY-42"}},
+		{Name: "pt-report-my-42", Kind: domain.KindReport, Description: "Expedition #1 completed", Issues: []string{"MY-42"},
+			Metadata: map[string]string{domain.MetadataInReplyTo: "spec-my-42", domain.MetadataThreadID: "spec-my-42"}},
+	} {
+		data, err := dm.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(archive, dm.Name+".md"), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	var events []domain.Event
+	for i, e := range []struct {
+		typ  domain.EventType
+		data any
+	}{
+		{domain.EventInboxReceived, domain.InboxReceivedData{Name: "spec-my-42"}},
+		{domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 1, Status: "success", IssueID: "MY-42"}},
+		{domain.EventDMailStaged, domain.DMailStagedData{Name: "pt-report-my-42"}},
+	} {
+		ev, err := domain.NewEvent(e.typ, e.data, base.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, ev)
+	}
+	if _, err := session.NewEventStore(filepath.Join(dir, domain.StateDir), &domain.NopLogger{}).Append(context.Background(), events...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDMailThreadCommand_RendersChain(t *testing.T) {
+	// given
+	repoDir := t.TempDir()
+	seedDMailThread(t, repoDir)
+	root := cmd.NewRootCommand()
+	stdout := new(bytes.Buffer)
+	root.SetOut(stdout)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"dmail", "thread", "pt-report-my-42", repoDir})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "Thread spec-my-42 (issues: MY-42)") {
+		t.Errorf("missing thread header:\n%s", out)
+	}
+	spec, exp, report := strings.Index(out, "spec-my-42  "), strings.Index(out, "#1"), strings.Index(out, "pt-report-my-42")
+	if spec < 0 || exp < spec || report < exp {
+		t.Errorf("want spec, expedition, report in order:\n%s", out)
+	}
+	if !strings.Contains(out, "(re: spec-my-42)") {
+		t.Errorf("report does not show what it answers:\n%s", out)
+	}
+}
+
+func TestDMailThreadCommand_IssueJSON(t *testing.T) {
+	// given
+	repoDir := t.TempDir()
+	seedDMailThread(t, repoDir)
+	root := cmd.NewRootCommand()
+	stdout := new(bytes.Buffer)
+	root.SetOut(stdout)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"dmail", "thread", "MY-42", "-o", "json", repoDir})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var thread domain.DMailThread
+	if err := json.Unmarshal(stdout.Bytes(), &thread); err != nil {
+		t.Fatalf("invalid JSON: %v\nraw: %s", err, stdout.String())
+	}
+	if len(thread.Entries) != 3 || thread.Entries[1].Type != domain.ThreadEntryExpedition || thread.Entries[1].Outcome != "success" {
+		t.Errorf("entries = %+v", thread.Entries)
+	}
+}
+
+func TestDMailThreadCommand_UnknownSelectorFails(t *testing.T) {
+	// given
+	root := cmd.NewRootCommand()
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"dmail", "thread", "MY-404", t.TempDir()})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err == nil {
+		t.Fatal("want an error for an unknown D-Mail or issue")
+	}
+}
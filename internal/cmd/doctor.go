@@ -86,10 +86,11 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	var metrics *domain.DoctorMetrics
+	metrics := &domain.DoctorMetrics{SuccessRate: "no events"}
 	stateDir := filepath.Join(continent, domain.StateDir)
-	eventStore := session.NewEventStore(stateDir, loggerFrom(cmd))
-	metrics = usecase.ComputeSuccessRate(cmd.Context(), eventStore, loggerFrom(cmd))
+	if state, _, stateErr := session.NewProjectionCache(stateDir, loggerFrom(cmd)).State(cmd.Context()); stateErr == nil {
+		metrics = usecase.ComputeSuccessRate(state.Succeeded, state.TotalExpeditions-state.Skipped)
+	}
 
 	if outputFmt == "json" {
 		jsonChecks := make([]doctorJSONCheck, len(checks))
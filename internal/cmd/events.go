@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func newEventsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Manage the event store",
+		Long:  "Inspect and maintain the event store under .expedition/ (JSONL daily files or SQLite).",
+	}
+
+	cmd.AddCommand(
+		newEventsListCommand(),
+		newEventsShowCommand(),
+		newEventsTailCommand(),
+		newEventsMigrateCommand(),
+		newEventsUpgradeCommand(),
+		newEventsVerifyCommand(),
+		newEventsFsckCommand(),
+	)
+
+	return cmd
+}
+
+func newEventsMigrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate [path]",
+		Short: "Convert the event store between JSONL and SQLite",
+		Long: `Convert the event store to another backend and switch event_store in
+.expedition/config.yaml to it.
+
+Every event is copied with all of its fields, in replay order, and read
+back for verification before the config is switched; the conversion is
+lossless in both directions. The previous store is then kept as a
+timestamped backup (events.bak-<stamp>/ or events.db.bak-<stamp>).
+
+The migration refuses to run when the source has corrupt lines or the
+destination already holds events.`,
+		Example: `  # Move the current directory's events into .expedition/events.db
+  paintress events migrate --to sqlite
+
+  # Convert a project back to daily JSONL files
+  paintress events migrate --to jsonl /path/to/repo`,
+		Args: cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if to := mustString(cmd, "to"); !domain.ValidEventStoreBackend(to) {
+				return fmt.Errorf("--to must be %s or %s (got %q)", domain.EventStoreJSONL, domain.EventStoreSQLite, to)
+			}
+			return nil
+		},
+		RunE: runEventsMigrate,
+	}
+
+	cmd.Flags().String("to", "", "Target backend: jsonl or sqlite")
+
+	return cmd
+}
+
+func runEventsMigrate(cmd *cobra.Command, args []string) error {
+	repoPath, err := resolveTargetDir(args)
+	if err != nil {
+		return err
+	}
+
+	result, err := session.MigrateEventStore(cmd.Context(), repoPath, mustString(cmd, "to"), loggerFrom(cmd))
+	if err != nil {
+		return fmt.Errorf("events migrate: %w", err)
+	}
+
+	if mustString(cmd, "output") == "json" {
+		data, jsonErr := json.Marshal(result)
+		if jsonErr != nil {
+			return jsonErr
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+
+	ew := cmd.ErrOrStderr()
+	fmt.Fprintf(ew, "Migrated %d event(s) from %s to %s.\n", result.Events, result.From, result.To)
+	if result.Backup != "" {
+		fmt.Fprintf(ew, "Previous store kept at %s\n", result.Backup)
+	}
+	return nil
+}
+
+func newEventsUpgradeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade [path]",
+		Short: "Rewrite stored events at their current schema version",
+		Long: `Upcast every stored event to the current schema version of its type and
+rewrite the event store in place.
+
+Reads already upcast old events on the fly; upgrading makes the stored
+payloads match so other tools see the current shape too. The previous
+store is kept as a timestamped backup (events.bak-<stamp>/ or
+events.db.bak-<stamp>) and restored if the rewrite cannot be verified.
+Nothing is written when every event is already current.
+
+The upgrade refuses to run when the store has corrupt lines.`,
+		Example: `  # Show how many events would be rewritten
+  paintress events upgrade --dry-run
+
+  # Upgrade a project's event store
+  paintress events upgrade /path/to/repo`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runEventsUpgrade,
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Count events that would be upgraded without writing")
+
+	return cmd
+}
+
+func runEventsUpgrade(cmd *cobra.Command, args []string) error {
+	repoPath, err := resolveTargetDir(args)
+	if err != nil {
+		return err
+	}
+
+	result, err := session.UpgradeEventStore(cmd.Context(), repoPath, mustBool(cmd, "dry-run"), loggerFrom(cmd))
+	if err != nil {
+		return fmt.Errorf("events upgrade: %w", err)
+	}
+
+	if mustString(cmd, "output") == "json" {
+		data, jsonErr := json.Marshal(result)
+		if jsonErr != nil {
+			return jsonErr
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+
+	ew := cmd.ErrOrStderr()
+	switch {
+	case result.Upgraded == 0:
+		fmt.Fprintf(ew, "All %d event(s) in the %s store are at their current schema version.\n", result.Events, result.Backend)
+	case result.DryRun:
+		fmt.Fprintf(ew, "%d of %d event(s) in the %s store would be upgraded.\n", result.Upgraded, result.Events, result.Backend)
+	default:
+		fmt.Fprintf(ew, "Upgraded %d of %d event(s) in the %s store.\n", result.Upgraded, result.Events, result.Backend)
+		fmt.Fprintf(ew, "Previous store kept at %s\n", result.Backup)
+	}
+	return nil
+}
+
+func newEventsVerifyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify [path]",
+		Short: "Check the event store's hash chains and integrity",
+		Long: `Check that the event store has not been altered or truncated.
+
+Every appended event carries the hash of the previous event of its
+stream (its aggregate) in prev_hash. verify follows these links and
+reports:
+
+  broken_link       prev_hash matches no stored event (altered or removed)
+  missing_segment   a daily file or SQLite rows are missing
+  fork              two events link to the same predecessor
+  duplicate_id      an event ID is stored more than once
+  seq_out_of_order  SeqNr does not increase along a chain
+  corrupt           a line or row does not decode
+
+Events written before the hash chain existed are counted as legacy and
+not checked. Exits non-zero when any problem is found.`,
+		Example: `  # Verify the current project's event store
+  paintress events verify
+
+  # Machine-readable report
+  paintress events verify -o json /path/to/repo`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runEventsVerify,
+	}
+	return cmd
+}
+
+func runEventsVerify(cmd *cobra.Command, args []string) error {
+	repoPath, err := resolveTargetDir(args)
+	if err != nil {
+		return err
+	}
+
+	report, err := session.VerifyEventStore(cmd.Context(), repoPath)
+	if err != nil {
+		return fmt.Errorf("events verify: %w", err)
+	}
+
+	if mustString(cmd, "output") == "json" {
+		data, jsonErr := json.Marshal(report)
+		if jsonErr != nil {
+			return jsonErr
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	} else {
+		ew := cmd.ErrOrStderr()
+		for _, p := range report.Problems {
+			fmt.Fprintf(ew, "  [FAIL] %-16s %s", p.Kind, p.Where)
+			if p.EventID != "" {
+				fmt.Fprintf(ew, " (event %s)", p.EventID)
+			}
+			fmt.Fprintf(ew, ": %s\n", p.Detail)
+		}
+		fmt.Fprintf(ew, "%s store: %d event(s) in %d stream(s), %d chained, %d legacy, %d problem(s)\n",
+			report.Backend, report.Events, report.Streams, report.Chained, report.Legacy, len(report.Problems))
+	}
+	if !report.OK() {
+		return &domain.SilentError{Err: fmt.Errorf("events verify: %d problem(s)", len(report.Problems))}
+	}
+	return nil
+}
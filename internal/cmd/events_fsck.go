@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func newEventsFsckCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fsck [path]",
+		Short: "Check stored event lines and optionally repair them",
+		Long: `Check every stored line (or SQLite row) of the event store and report:
+
+  undecodable   the line is not a JSON event
+  invalid       it decodes, but is not a valid event (missing ID, type, ...)
+  duplicate_id  a later copy of an already stored event ID
+  misfiled      a JSONL event whose timestamp belongs in another daily file
+  seq_ahead     a SeqNr the SeqCounter (seq.db) has not allocated yet
+
+SeqNr gaps against seq.db are listed too. They are informational: an
+append that fails after allocating its SeqNr leaves one behind.
+
+With --repair, undecodable and invalid lines move into a quarantine file
+under .expedition/events/quarantine/ together with their origin file and
+line number, duplicates are dropped (the first copy stays) and misfiled
+events move into their daily file. Gaps and seq_ahead are only reported.
+Exits non-zero when issues remain.`,
+		Example: `  # Report problems without changing anything
+  paintress events fsck
+
+  # Quarantine corrupt lines, drop duplicates, refile misfiled events
+  paintress events fsck --repair
+
+  # Machine-readable report
+  paintress events fsck -o json /path/to/repo`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runEventsFsck,
+	}
+	cmd.Flags().Bool("repair", false, "Quarantine corrupt lines, drop duplicates and move misfiled events")
+	return cmd
+}
+
+func runEventsFsck(cmd *cobra.Command, args []string) error {
+	repoPath, err := resolveTargetDir(args)
+	if err != nil {
+		return err
+	}
+
+	report, err := session.FsckEventStore(cmd.Context(), repoPath, mustBool(cmd, "repair"))
+	if err != nil {
+		return fmt.Errorf("events fsck: %w", err)
+	}
+
+	if mustString(cmd, "output") == "json" {
+		data, jsonErr := json.Marshal(report)
+		if jsonErr != nil {
+			return jsonErr
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	} else {
+		ew := cmd.ErrOrStderr()
+		for _, issue := range report.Issues {
+			status := "FAIL"
+			switch {
+			case report.Repaired && issue.Action != "":
+				status = "FIXED"
+			case issue.Action == "":
+				status = "WARN"
+			}
+			fmt.Fprintf(ew, "  [%s] %-12s %s", status, issue.Kind, issue.Where)
+			if issue.EventID != "" {
+				fmt.Fprintf(ew, " (event %s)", issue.EventID)
+			}
+			fmt.Fprintf(ew, ": %s", issue.Detail)
+			if issue.Action != "" && !report.Repaired {
+				fmt.Fprintf(ew, " [--repair: %s]", issue.Action)
+			}
+			fmt.Fprintln(ew)
+		}
+		for _, gap := range report.SeqGaps {
+			if gap.From == gap.To {
+				fmt.Fprintf(ew, "  [INFO] seq_gap      SeqNr %d allocated but not stored\n", gap.From)
+			} else {
+				fmt.Fprintf(ew, "  [INFO] seq_gap      SeqNrs %d-%d allocated but not stored\n", gap.From, gap.To)
+			}
+		}
+		fmt.Fprintf(ew, "%s store: %d event(s), %d issue(s), %d SeqNr gap(s)\n",
+			report.Backend, report.Events, len(report.Issues), len(report.SeqGaps))
+		if report.Quarantine != "" {
+			fmt.Fprintf(ew, "Quarantined lines: %s\n", report.Quarantine)
+		}
+	}
+	if n := report.Unresolved(); n > 0 {
+		return &domain.SilentError{Err: fmt.Errorf("events fsck: %d unresolved issue(s)", n)}
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func newEventsListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list [path]",
+		Short: "List stored events, optionally filtered",
+		Long: `List the events of the event store in store order.
+
+Filters combine (AND). --since and --until take an RFC 3339 time, a date
+(2006-01-02) or a duration back from now (24h). --issue matches the
+issue_id of a completion, a "<wave>:<step>" target or an escalated or
+resolved issue; --expedition matches the payload's expedition number.
+
+Events retired by archive-prune live in compressed segments under
+.expedition/events-archive/; --archived decompresses and includes them.
+
+Output (-o): text (one line per event), json (an array) or ndjson (one
+JSON event per line).`,
+		Example: `  # Today's completions
+  paintress events list --type expedition.completed --since 24h
+
+  # The whole history of an issue, archived segments included
+  paintress events list --archived --issue MY-42
+
+  # Everything recorded for one expedition run, as NDJSON
+  paintress events list --correlation-id exp-42 -o ndjson`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runEventsList,
+	}
+	addEventFilterFlags(cmd)
+	cmd.Flags().Bool("archived", false, "Include events from archived segments")
+	return cmd
+}
+
+func newEventsShowCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show <id> [path]",
+		Short: "Show one event and its causation chain",
+		Long: `Show one event in full, followed by the chain of events that caused it
+(causation_id links, nearest first). A unique ID prefix is enough.
+Archived segments are searched too.`,
+		Example: `  paintress events show 3f2a9c1e`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE:    runEventsShow,
+	}
+	return cmd
+}
+
+func newEventsTailCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tail [path]",
+		Short: "Show the latest events and optionally follow new ones",
+		Long: `Print the last --lines matching events. With -f, keep running and print
+each new event as it is appended (by the MCP server or a run) until
+interrupted. Takes the same filters as "events list".
+
+Output (-o): text, or json/ndjson for one JSON event per line.`,
+		Example: `  # Follow every new event
+  paintress events tail -f
+
+  # Follow gradient changes as NDJSON
+  paintress events tail -f -n 0 --type gradient.changed -o ndjson`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runEventsTail,
+	}
+	addEventFilterFlags(cmd)
+	cmd.Flags().BoolP("follow", "f", false, "Keep printing events as they are appended")
+	cmd.Flags().IntP("lines", "n", 10, "Number of existing events to print first")
+	return cmd
+}
+
+func addEventFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("type", nil, "Only events of these types (repeatable or comma-separated)")
+	cmd.Flags().String("since", "", "Only events at or after this time")
+	cmd.Flags().String("until", "", "Only events before this time")
+	cmd.Flags().String("issue", "", "Only events about this issue or <wave>:<step> target")
+	cmd.Flags().Int("expedition", 0, "Only events of this expedition number")
+	cmd.Flags().String("correlation-id", "", "Only events with this correlation ID")
+}
+
+func eventFilterFromFlags(cmd *cobra.Command, now time.Time) (domain.EventFilter, error) {
+	types, err := cmd.Flags().GetStringSlice("type")
+	if err != nil {
+		return domain.EventFilter{}, err
+	}
+	filter := domain.EventFilter{
+		IssueID:       mustString(cmd, "issue"),
+		Expedition:    mustInt(cmd, "expedition"),
+		CorrelationID: mustString(cmd, "correlation-id"),
+	}
+	for _, t := range types {
+		if !domain.ValidEventType(domain.EventType(t)) {
+			return domain.EventFilter{}, fmt.Errorf("--type: unknown event type %q", t)
+		}
+		filter.Types = append(filter.Types, domain.EventType(t))
+	}
+	if filter.Since, err = parseEventTime(mustString(cmd, "since"), now); err != nil {
+		return domain.EventFilter{}, fmt.Errorf("--since: %w", err)
+	}
+	if filter.Until, err = parseEventTime(mustString(cmd, "until"), now); err != nil {
+		return domain.EventFilter{}, fmt.Errorf("--until: %w", err)
+	}
+	return filter, nil
+}
+
+// parseEventTime accepts RFC 3339, a local date, or a duration back from now.
+func parseEventTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not an RFC 3339 time, a date or a duration", value)
+}
+
+func validateEventsOutput(cmd *cobra.Command) (string, error) {
+	format := mustString(cmd, "output")
+	switch format {
+	case "text", "json", "ndjson":
+		return format, nil
+	}
+	return "", fmt.Errorf("--output: %q is not text, json or ndjson", format)
+}
+
+func runEventsList(cmd *cobra.Command, args []string) error {
+	format, err := validateEventsOutput(cmd)
+	if err != nil {
+		return err
+	}
+	filter, err := eventFilterFromFlags(cmd, time.Now())
+	if err != nil {
+		return err
+	}
+	repoPath, err := resolveTargetDir(args)
+	if err != nil {
+		return err
+	}
+
+	events, err := session.ListEvents(cmd.Context(), repoPath, filter, mustBool(cmd, "archived"), loggerFrom(cmd))
+	if err != nil {
+		return fmt.Errorf("events list: %w", err)
+	}
+
+	switch format {
+	case "json":
+		if events == nil {
+			events = []domain.Event{}
+		}
+		data, jsonErr := json.Marshal(events)
+		if jsonErr != nil {
+			return jsonErr
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	case "ndjson":
+		return writeEventsNDJSON(cmd.OutOrStdout(), events)
+	}
+	for _, ev := range events {
+		fmt.Fprintln(cmd.OutOrStdout(), formatEventLine(ev))
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "%d event(s)\n", len(events))
+	return nil
+}
+
+func runEventsShow(cmd *cobra.Command, args []string) error {
+	repoPath, err := resolveTargetDir(args[1:])
+	if err != nil {
+		return err
+	}
+
+	ev, chain, err := session.FindEvent(cmd.Context(), repoPath, args[0], loggerFrom(cmd))
+	if err != nil {
+		return fmt.Errorf("events show: %w", err)
+	}
+
+	if mustString(cmd, "output") == "json" {
+		if chain == nil {
+			chain = []domain.Event{}
+		}
+		data, jsonErr := json.Marshal(map[string]any{"event": ev, "causation_chain": chain})
+		if jsonErr != nil {
+			return jsonErr
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+
+	w := cmd.OutOrStdout()
+	data, err := json.MarshalIndent(ev, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(data))
+	if ev.CausationID == "" {
+		return nil
+	}
+	fmt.Fprintln(w, "\nCausation chain:")
+	for _, cause := range chain {
+		fmt.Fprintf(w, "  <- %s\n", formatEventLine(cause))
+	}
+	last := ev
+	if len(chain) > 0 {
+		last = chain[len(chain)-1]
+	}
+	if last.CausationID != "" {
+		fmt.Fprintf(w, "  <- %s (not in the store)\n", last.CausationID)
+	}
+	return nil
+}
+
+func runEventsTail(cmd *cobra.Command, args []string) error {
+	format, err := validateEventsOutput(cmd)
+	if err != nil {
+		return err
+	}
+	filter, err := eventFilterFromFlags(cmd, time.Now())
+	if err != nil {
+		return err
+	}
+	repoPath, err := resolveTargetDir(args)
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	logger := loggerFrom(cmd)
+
+	// Subscribe before reading the backlog so no append is missed.
+	var follow <-chan domain.Event
+	if mustBool(cmd, "follow") {
+		if follow, err = session.FollowEvents(ctx, repoPath, filter, logger); err != nil {
+			return fmt.Errorf("events tail: %w", err)
+		}
+	}
+	if n := mustInt(cmd, "lines"); n > 0 {
+		events, listErr := session.ListEvents(ctx, repoPath, filter, false, logger)
+		if listErr != nil {
+			return fmt.Errorf("events tail: %w", listErr)
+		}
+		if len(events) > n {
+			events = events[len(events)-n:]
+		}
+		for _, ev := range events {
+			if err := writeTailEvent(cmd.OutOrStdout(), format, ev); err != nil {
+				return err
+			}
+		}
+	}
+	for ev := range follow {
+		if err := writeTailEvent(cmd.OutOrStdout(), format, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTailEvent(w io.Writer, format string, ev domain.Event) error {
+	if format == "text" {
+		_, err := fmt.Fprintln(w, formatEventLine(ev))
+		return err
+	}
+	return writeEventsNDJSON(w, []domain.Event{ev})
+}
+
+func writeEventsNDJSON(w io.Writer, events []domain.Event) error {
+	enc := json.NewEncoder(w)
+	for _, ev := range events {
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatEventLine renders one event as "<time> <type> <id> [seq] <data>".
+func formatEventLine(ev domain.Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %-22s  %s", ev.Timestamp.Format(time.RFC3339), ev.Type, ev.ID)
+	if ev.SeqNr > 0 {
+		fmt.Fprintf(&b, "  seq=%d", ev.SeqNr)
+	}
+	fmt.Fprintf(&b, "  %s", ev.Data)
+	return b.String()
+}
@@ -0,0 +1,296 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/cmd"
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+func runEventsMigrate(t *testing.T, dir, to string) (map[string]any, error) {
+	t.Helper()
+	root := cmd.NewRootCommand()
+	out := new(bytes.Buffer)
+	root.SetOut(out)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"events", "migrate", "--to", to, "-o", "json", dir})
+	if err := root.Execute(); err != nil {
+		return nil, err
+	}
+	var result map[string]any
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("decode %q: %v", out.String(), err)
+	}
+	return result, nil
+}
+
+func TestEventsMigrate_RoundTrip(t *testing.T) {
+	// given: two JSONL events
+	dir := t.TempDir()
+	stateDir := filepath.Join(dir, domain.StateDir)
+	ctx := context.Background()
+	var want []domain.Event
+	for i := 1; i <= 2; i++ {
+		ev, err := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: i}, time.Now().Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, ev)
+	}
+	if _, err := session.NewEventStore(stateDir, &domain.NopLogger{}).Append(ctx, want...); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	toSQLite, err := runEventsMigrate(t, dir, "sqlite")
+	if err != nil {
+		t.Fatalf("migrate --to sqlite: %v", err)
+	}
+	viaSQLite, _, _ := session.NewEventStore(stateDir, &domain.NopLogger{}).LoadAll(ctx)
+	toJSONL, err := runEventsMigrate(t, dir, "jsonl")
+	if err != nil {
+		t.Fatalf("migrate --to jsonl: %v", err)
+	}
+	viaJSONL, _, _ := session.NewEventStore(stateDir, &domain.NopLogger{}).LoadAll(ctx)
+
+	// then
+	if toSQLite["events"] != float64(2) || toSQLite["from"] != "jsonl" || toJSONL["to"] != "jsonl" {
+		t.Errorf("results = %v / %v", toSQLite, toJSONL)
+	}
+	if len(viaSQLite) != 2 || len(viaJSONL) != 2 || viaJSONL[0].ID != want[0].ID || viaJSONL[1].ID != want[1].ID {
+		t.Errorf("events after migration: sqlite=%d jsonl=%v", len(viaSQLite), viaJSONL)
+	}
+	for _, backup := range []any{toSQLite["backup"], toJSONL["backup"]} {
+		if path, _ := backup.(string); path == "" {
+			t.Errorf("no backup reported: %v", backup)
+		} else if _, statErr := os.Stat(path); statErr != nil {
+			t.Errorf("backup %s: %v", path, statErr)
+		}
+	}
+	cfg, err := session.LoadProjectConfig(dir)
+	if err != nil || cfg.EventStore != domain.EventStoreJSONL {
+		t.Errorf("config event_store = %+v %v, want jsonl", cfg, err)
+	}
+}
+
+func TestEventsMigrate_RejectsUnknownBackend(t *testing.T) {
+	// when
+	_, err := runEventsMigrate(t, t.TempDir(), "postgres")
+
+	// then
+	if err == nil || !strings.Contains(err.Error(), "--to") {
+		t.Errorf("err = %v, want --to validation error", err)
+	}
+}
+
+func TestEventsUpgrade_DryRunReportsWithoutWriting(t *testing.T) {
+	// given: an initialized continent with only current events
+	dir := t.TempDir()
+	ev, err := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 1}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.NewEventStore(filepath.Join(dir, domain.StateDir), &domain.NopLogger{}).Append(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	root := cmd.NewRootCommand()
+	out := new(bytes.Buffer)
+	root.SetOut(out)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"events", "upgrade", "--dry-run", "-o", "json", dir})
+
+	// when
+	err = root.Execute()
+
+	// then
+	if err != nil {
+		t.Fatalf("events upgrade: %v", err)
+	}
+	var result map[string]any
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("decode %q: %v", out.String(), err)
+	}
+	if result["events"] != float64(1) || result["upgraded"] != float64(0) || result["dry_run"] != true || result["backup"] != nil {
+		t.Errorf("result = %v", result)
+	}
+}
+
+func TestEventsVerify_ReportsTamperedEvent(t *testing.T) {
+	// given: two chained events of one stream, the first edited on disk
+	dir := t.TempDir()
+	stateDir := filepath.Join(dir, domain.StateDir)
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 1; i <= 2; i++ {
+		ev, err := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: i}, at.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev.AggregateType, ev.AggregateID = "expedition", "run-1"
+		if _, err := session.NewEventStore(stateDir, &domain.NopLogger{}).Append(context.Background(), ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(stateDir, "events", "2026-03-01.jsonl")
+	raw, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(string(raw), `"expedition":1`, `"expedition":9`, 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := cmd.NewRootCommand()
+	out := new(bytes.Buffer)
+	root.SetOut(out)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"events", "verify", "-o", "json", dir})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err == nil {
+		t.Fatal("events verify succeeded over a tampered store")
+	}
+	var report struct {
+		Chained  int `json:"chained"`
+		Problems []struct {
+			Kind  string `json:"kind"`
+			Where string `json:"where"`
+		} `json:"problems"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("decode %q: %v", out.String(), err)
+	}
+	if report.Chained != 2 || len(report.Problems) != 1 || report.Problems[0].Kind != "broken_link" || report.Problems[0].Where != "2026-03-01.jsonl:2" {
+		t.Errorf("report = %+v, want one broken_link at 2026-03-01.jsonl:2", report)
+	}
+}
+
+func seedQueryEvents(t *testing.T, dir string) []domain.Event {
+	t.Helper()
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	started, _ := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 4}, at)
+	completed, _ := domain.NewEvent(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 4, Status: "success", IssueID: "PROJ-4"}, at.Add(time.Minute))
+	other, _ := domain.NewEvent(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 5, Status: "failed", IssueID: "PROJ-5"}, at.Add(time.Hour))
+	started.CorrelationID, completed.CorrelationID = "exp-4", "exp-4"
+	completed.CausationID = started.ID
+	events := []domain.Event{started, completed, other}
+	if _, err := session.NewEventStore(filepath.Join(dir, domain.StateDir), &domain.NopLogger{}).Append(context.Background(), events...); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func runEventsQuery(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	root := cmd.NewRootCommand()
+	out := new(bytes.Buffer)
+	root.SetOut(out)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs(append([]string{"events"}, args...))
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestEventsList_FiltersAndFormats(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	events := seedQueryEvents(t, dir)
+
+	// when
+	ndjson, ndErr := runEventsQuery(t, "list", "--type", "expedition.completed", "--until", "2026-03-01T09:30:00Z", "-o", "ndjson", dir)
+	byIssue, jsonErr := runEventsQuery(t, "list", "--issue", "PROJ-5", "-o", "json", dir)
+	byCorrelation, textErr := runEventsQuery(t, "list", "--correlation-id", "exp-4", "--expedition", "4", dir)
+	_, typeErr := runEventsQuery(t, "list", "--type", "no.such", dir)
+
+	// then
+	if ndErr != nil || jsonErr != nil || textErr != nil {
+		t.Fatalf("errors: %v / %v / %v", ndErr, jsonErr, textErr)
+	}
+	lines := strings.Split(strings.TrimSpace(ndjson), "\n")
+	var first domain.Event
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &first) != nil || first.ID != events[1].ID {
+		t.Errorf("ndjson = %q, want only the first completion", ndjson)
+	}
+	var listed []domain.Event
+	if err := json.Unmarshal([]byte(byIssue), &listed); err != nil || len(listed) != 1 || listed[0].ID != events[2].ID {
+		t.Errorf("json = %q (%v), want only PROJ-5", byIssue, err)
+	}
+	if strings.Count(byCorrelation, "\n") != 2 || !strings.Contains(byCorrelation, events[0].ID) || !strings.Contains(byCorrelation, events[1].ID) {
+		t.Errorf("text = %q, want the two exp-4 events", byCorrelation)
+	}
+	if typeErr == nil {
+		t.Error("unknown --type accepted")
+	}
+}
+
+func TestEventsShow_IncludesCausationChain(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	events := seedQueryEvents(t, dir)
+
+	// when: looked up by ID prefix
+	out, err := runEventsQuery(t, "show", events[1].ID[:13], "-o", "json", dir)
+
+	// then
+	if err != nil {
+		t.Fatalf("events show: %v", err)
+	}
+	var shown struct {
+		Event domain.Event   `json:"event"`
+		Chain []domain.Event `json:"causation_chain"`
+	}
+	if err := json.Unmarshal([]byte(out), &shown); err != nil {
+		t.Fatalf("decode %q: %v", out, err)
+	}
+	if shown.Event.ID != events[1].ID || len(shown.Chain) != 1 || shown.Chain[0].ID != events[0].ID {
+		t.Errorf("shown = %+v, want the completion caused by the start", shown)
+	}
+}
+
+func TestEventsFsck_RepairQuarantinesCorruptLine(t *testing.T) {
+	// given: a daily file with one good event and one garbage line
+	dir := t.TempDir()
+	stateDir := filepath.Join(dir, domain.StateDir)
+	ev, err := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 1}, time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.NewEventStore(stateDir, &domain.NopLogger{}).Append(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(stateDir, "events", "2026-03-01.jsonl")
+	raw, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, append(raw, "{truncated\n"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) (string, error) {
+		root := cmd.NewRootCommand()
+		errBuf := new(bytes.Buffer)
+		root.SetOut(new(bytes.Buffer))
+		root.SetErr(errBuf)
+		root.SetArgs(append([]string{"events", "fsck"}, append(args, dir)...))
+		err := root.Execute()
+		return errBuf.String(), err
+	}
+
+	// when
+	checkOut, checkErr := run()
+	repairOut, repairErr := run("--repair")
+
+	// then
+	if checkErr == nil || !strings.Contains(checkOut, "undecodable") || !strings.Contains(checkOut, "2026-03-01.jsonl:2") {
+		t.Errorf("fsck = %v, output %q; want the undecodable line reported as a failure", checkErr, checkOut)
+	}
+	if repairErr != nil || !strings.Contains(repairOut, "[FIXED]") || !strings.Contains(repairOut, "quarantine") {
+		t.Errorf("fsck --repair = %v, output %q; want the line quarantined", repairErr, repairOut)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "truncated") {
+		t.Errorf("corrupt line still in %s", path)
+	}
+}
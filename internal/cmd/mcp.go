@@ -1,14 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hironow/paintress/internal/domain"
 	"github.com/hironow/paintress/internal/session"
 	"github.com/hironow/paintress/internal/usecase"
+	"github.com/hironow/paintress/internal/usecase/port"
 )
 
 // newMCPCommand exposes `paintress mcp` as a stdio MCP server entry
@@ -20,10 +23,14 @@ import (
 // mcp` from the project root). The real-impl tools (next_issue /
 // update_gradient / append_journal) use it to read/write journal /
 // pr-index / event-store state. ping is continent-agnostic.
+//
+// With --listen the same dispatch is served over the MCP Streamable HTTP
+// transport so one long-lived process can back several claude-code
+// windows instead of each spawning its own stdio server.
 func newMCPCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "mcp",
-		Short: "Run paintress as an MCP server over stdio (expedition journal/gradient data plane)",
+		Short: "Run paintress as an MCP server over stdio or HTTP (expedition journal/gradient data plane)",
 		Long: `Start a Model Context Protocol server reading JSON-RPC 2.0
 messages on stdin and writing responses on stdout.
 
@@ -43,7 +50,19 @@ Exposes ping, next_issue (reads journal +
 pr-index to surface completed issue ids + next expedition number),
 and update_gradient + append_journal (persist
 gradient / expedition-completed events to the event store, with a
-journal/ + pr-index filesystem write).`,
+journal/ + pr-index filesystem write).
+
+--listen 127.0.0.1:PORT serves the MCP Streamable HTTP transport at
+/mcp instead of stdio: POST for requests, GET for the server-to-client
+SSE stream, DELETE to end a session (Mcp-Session-Id header). Requests
+with a non-loopback Origin are rejected unless listed via
+--allow-origin. Set --token (or PAINTRESS_MCP_TOKEN) to require
+"Authorization: Bearer <token>".`,
+		Example: `  # stdio (embedded via --mcp-config)
+  paintress mcp
+
+  # one shared data-plane process for several sessions
+  paintress mcp --listen 127.0.0.1:7331 --token "$PAINTRESS_MCP_TOKEN"`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			continent, err := os.Getwd()
 			if err != nil {
@@ -57,18 +76,82 @@ journal/ + pr-index filesystem write).`,
 			// session calls the MCP tool.
 			stateDir := filepath.Join(continent, domain.StateDir)
 			store := session.NewEventStore(stateDir, nil)
+			policies := usecase.NewPolicyEngine(loggerFrom(cmd))
 			emitter := usecase.NewExpeditionEventEmitter(
 				cmd.Context(),
 				domain.NewExpeditionAggregate(),
 				store,
-				nil,
+				policies,
 				&domain.NopLogger{},
 				"paintress.mcp",
 			)
-			srv := session.NewMCPServer(cmd.InOrStdin(), cmd.OutOrStdout(), nil).
+			// Globally sequenced events let the projection cache serve
+			// reads from a snapshot plus LoadAfterSeqNr. Only initialized
+			// continents get a counter: mcp must not create .expedition/.
+			if _, statErr := os.Stat(stateDir); statErr == nil {
+				seqCounter, seqErr := session.EnsureCutover(cmd.Context(), stateDir, session.ExpeditionStateAggregateType, loggerFrom(cmd))
+				if seqErr != nil {
+					return seqErr
+				}
+				defer func() { _ = seqCounter.Close() }()
+				if seq, ok := emitter.(interface{ SetSeqAllocator(port.SeqAllocator) }); ok {
+					seq.SetSeqAllocator(seqCounter)
+				}
+				// The deterministic policies follow the event store through
+				// checkpointed cursors; catching up at start replays what a
+				// previous process left unhandled.
+				cursors, cursorErr := session.NewPolicyCursorStoreForDir(continent)
+				if cursorErr != nil {
+					return cursorErr
+				}
+				defer func() { _ = cursors.Close() }()
+				outbox, outboxErr := session.NewOutboxStoreForDir(continent)
+				if outboxErr != nil {
+					return outboxErr
+				}
+				defer func() { _ = outbox.Close() }()
+				usecase.RegisterBuiltinPolicies(policies, store, outbox, emitter, session.NewDMailSender(outbox, emitter), time.Now,
+					session.NewProjectionCache(stateDir, loggerFrom(cmd)).GradientLevel)
+				if err := policies.WithSubscriptionStore(store, cursors).CatchUp(cmd.Context()); err != nil {
+					loggerFrom(cmd).Warn("policy catch-up: %v", err)
+				}
+				// Deferred events are retried when they fall due, not
+				// only when the next event arrives. Stopped before the
+				// stores it uses are closed.
+				policyCtx, stopPolicies := context.WithCancel(cmd.Context())
+				policiesDone := make(chan struct{})
+				go func() {
+					defer close(policiesDone)
+					policies.RunDeferred(policyCtx)
+				}()
+				defer func() {
+					stopPolicies()
+					<-policiesDone
+				}()
+			}
+			listen := mustString(cmd, "listen")
+			srv := session.NewMCPServer(cmd.InOrStdin(), cmd.OutOrStdout(), loggerFrom(cmd)).
 				WithContinent(continent).
 				WithEmitter(emitter)
-			return srv.Serve(cmd.Context())
+			if listen == "" {
+				return srv.Serve(cmd.Context())
+			}
+			token := mustString(cmd, "token")
+			if token == "" {
+				token = os.Getenv("PAINTRESS_MCP_TOKEN")
+			}
+			origins, err := cmd.Flags().GetStringSlice("allow-origin")
+			if err != nil {
+				return err
+			}
+			return srv.ListenHTTP(cmd.Context(), listen, session.MCPHTTPOptions{
+				Token:          token,
+				AllowedOrigins: origins,
+			})
 		},
 	}
+	cmd.Flags().String("listen", "", "Serve MCP Streamable HTTP on this address (e.g. 127.0.0.1:7331) instead of stdio")
+	cmd.Flags().String("token", "", "Bearer token required on HTTP requests (default: $PAINTRESS_MCP_TOKEN)")
+	cmd.Flags().StringSlice("allow-origin", nil, "Additional browser Origin allowed on HTTP requests (loopback origins are always allowed)")
+	return cmd
 }
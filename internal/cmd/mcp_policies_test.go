@@ -0,0 +1,58 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hironow/paintress/internal/cmd"
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+func TestMCPCommand_PolicySubscriptionsFollowDMailTool(t *testing.T) {
+	// given: an initialized continent and a dmail tool call
+	continent := t.TempDir()
+	stateDir := filepath.Join(continent, domain.StateDir)
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(continent)
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"dmail","arguments":{"kind":"report","name":"pt-report-my-1-1","description":"done","body":"ok\n","issues":["MY-1"]}}}` + "\n"
+	root := cmd.NewRootCommand()
+	stdout := new(bytes.Buffer)
+	root.SetIn(strings.NewReader(call))
+	root.SetOut(stdout)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"mcp"})
+
+	// when
+	err := root.Execute()
+
+	// then: the send succeeds with the flush policy subscribed, and both
+	// policies checkpointed past the staged/flushed events
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stdout.String(), `"isError":true`) || !strings.Contains(stdout.String(), `\"sent\":true`) {
+		t.Fatalf("dmail response = %s", stdout.String())
+	}
+	ctx := context.Background()
+	latest, err := session.NewEventStore(stateDir, nil).LatestSeqNr(ctx)
+	if err != nil || latest == 0 {
+		t.Fatalf("latest seq nr = %d, %v", latest, err)
+	}
+	cursors, err := session.NewPolicyCursorStoreForDir(continent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cursors.Close() }()
+	for _, name := range []string{"DMailStagedFlushOutbox", "ExpeditionCompletedStageReport"} {
+		if seq, ok, err := cursors.LoadCursor(ctx, name); err != nil || !ok || seq != latest {
+			t.Errorf("cursor %s = %d (ok=%v, err=%v), want %d", name, seq, ok, err, latest)
+		}
+	}
+}
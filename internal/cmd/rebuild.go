@@ -13,7 +13,8 @@ func newRebuildCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "rebuild [path]",
 		Short: "Rebuild projections from event store",
-		Long: `Replays all events from .expedition/events/ to regenerate materialized projection state from scratch.
+		Long: `Replays all events from the event store (.expedition/events/ or events.db) to regenerate materialized projection state from scratch.
+Archived segments written by archive-prune (.expedition/events-archive/) are decompressed, checksum-verified and replayed first.
 
 If path is omitted, the current working directory is used.`,
 		Example: `  # Rebuild projections for the current directory
@@ -30,7 +31,7 @@ If path is omitted, the current working directory is used.`,
 
 			logger := loggerFrom(cmd)
 			stateDir := filepath.Join(repoRoot, domain.StateDir)
-			eventStore := session.NewEventStore(stateDir, logger)
+			eventStore := session.NewEventHistoryStore(stateDir, logger)
 			snapshotStore := session.NewSnapshotStore(stateDir)
 			projector := session.NewProjectionApplier()
 			// SeqCounter for global watermark (may not exist yet on fresh repos)
@@ -46,7 +47,7 @@ If path is omitted, the current working directory is used.`,
 			if rpErr != nil {
 				return rpErr
 			}
-			if err := usecase.Rebuild(cmd.Context(), domain.NewRebuildCommand(rp), eventStore, projector, snapshotStore, seqLatest, "paintress.state", logger); err != nil {
+			if err := usecase.Rebuild(cmd.Context(), domain.NewRebuildCommand(rp), eventStore, projector, snapshotStore, seqLatest, session.ExpeditionStateAggregateType, logger); err != nil {
 				return err
 			}
 			state := projector.State()
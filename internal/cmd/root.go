@@ -107,6 +107,9 @@ func NewRootCommand() *cobra.Command {
 		newMCPConfigCommand(),
 		newSessionsCommand(),
 		newDeadLettersCommand(),
+		newEventsCommand(),
+		newDMailCommand(),
+		newWatchCommand(),
 	)
 
 	return rootCmd
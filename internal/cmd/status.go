@@ -3,19 +3,27 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/hironow/paintress/internal/domain"
 	"github.com/hironow/paintress/internal/session"
 	"github.com/spf13/cobra"
 )
 
 // newStatusCommand creates the status subcommand that displays operational status.
 func newStatusCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "status [path]",
 		Short: "Show paintress operational status",
 		Long: `Display operational status including expedition history, success rate,
 gradient level, and pending d-mail counts.
 
+With --as-of (an RFC 3339 time or "expedition N"), the ExpeditionState,
+pending wave steps and windowed success rate are projected from the
+events recorded up to that point, archived segments included. Inbox,
+archive and provider state have no history and are not shown then.
+"status diff" compares two such points.
+
 Output goes to stdout by default (human-readable text).
 Use -o json for machine-readable JSON output to stdout.`,
 		Example: `  # Show status for current directory
@@ -25,13 +33,19 @@ Use -o json for machine-readable JSON output to stdout.`,
   paintress status /path/to/repo
 
   # JSON output for scripting
-  paintress status -o json /path/to/repo`,
+  paintress status -o json /path/to/repo
+
+  # Status right after expedition 12 completed
+  paintress status --as-of "expedition 12"`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			baseDir, err := resolveTargetDir(args)
 			if err != nil {
 				return err
 			}
+			if value := mustString(cmd, "as-of"); value != "" {
+				return runStatusAsOf(cmd, baseDir, value)
+			}
 
 			report := session.Status(cmd.Context(), baseDir, loggerFrom(cmd))
 
@@ -50,4 +64,116 @@ Use -o json for machine-readable JSON output to stdout.`,
 			return nil
 		},
 	}
+	cmd.Flags().String("as-of", "", `Show the status at an RFC 3339 time or "expedition N"`)
+	cmd.AddCommand(newStatusDiffCommand())
+	return cmd
+}
+
+func newStatusDiffCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <t1> <t2> [path]",
+		Short: "Show what changed in the status between two points in time",
+		Long: `Project the status at t1 and at t2 (each an RFC 3339 time or
+"expedition N") from the event history and list the fields that changed,
+the wave steps completed in between and the steps registered in between.`,
+		Example: `  # What expeditions 10 to 12 changed
+  paintress status diff "expedition 10" "expedition 12"
+
+  # Changes over a day, as JSON
+  paintress status diff 2026-10-01T00:00:00Z 2026-10-02T00:00:00Z -o json`,
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := resolveTargetDir(args[2:])
+			if err != nil {
+				return err
+			}
+			from, err := domain.ParseAsOf(args[0])
+			if err != nil {
+				return fmt.Errorf("t1: %w", err)
+			}
+			to, err := domain.ParseAsOf(args[1])
+			if err != nil {
+				return fmt.Errorf("t2: %w", err)
+			}
+
+			diff, err := session.DiffStatus(cmd.Context(), baseDir, from, to, 0, loggerFrom(cmd))
+			if err != nil {
+				return fmt.Errorf("status diff: %w", err)
+			}
+
+			if mustString(cmd, "output") == "json" {
+				data, jsonErr := json.Marshal(diff)
+				if jsonErr != nil {
+					return fmt.Errorf("marshal status diff: %w", jsonErr)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "paintress status diff: %s (%s) -> %s (%s)\n",
+				diff.From.AsOf, diff.From.Cutoff.Format(time.RFC3339), diff.To.AsOf, diff.To.Cutoff.Format(time.RFC3339))
+			if len(diff.Changes) == 0 && len(diff.StepsCompleted) == 0 && len(diff.StepsAdded) == 0 {
+				fmt.Fprintln(w, "  no changes")
+				return nil
+			}
+			for _, c := range diff.Changes {
+				fmt.Fprintf(w, "  %-22s %s -> %s\n", c.Field+":", formatStatusValue(c.From), formatStatusValue(c.To))
+			}
+			for _, id := range diff.StepsCompleted {
+				fmt.Fprintf(w, "  %-22s %s\n", "step completed:", id)
+			}
+			for _, id := range diff.StepsAdded {
+				fmt.Fprintf(w, "  %-22s %s\n", "step added:", id)
+			}
+			return nil
+		},
+	}
+}
+
+func runStatusAsOf(cmd *cobra.Command, baseDir, value string) error {
+	asOf, err := domain.ParseAsOf(value)
+	if err != nil {
+		return fmt.Errorf("--as-of: %w", err)
+	}
+	snapshot, err := session.StatusAsOf(cmd.Context(), baseDir, asOf, 0, loggerFrom(cmd))
+	if err != nil {
+		return fmt.Errorf("status --as-of: %w", err)
+	}
+
+	if mustString(cmd, "output") == "json" {
+		data, jsonErr := json.Marshal(snapshot)
+		if jsonErr != nil {
+			return fmt.Errorf("marshal status: %w", jsonErr)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+
+	w := cmd.OutOrStdout()
+	state := snapshot.State
+	fmt.Fprintf(w, "paintress status as of %s (%s, %d events)\n\n", snapshot.AsOf, snapshot.Cutoff.Format(time.RFC3339), snapshot.Events)
+	fmt.Fprintf(w, "  %-16s %d (%d ok, %d failed, %d skipped)\n", "Expeditions:", state.TotalExpeditions, state.Succeeded, state.Failed, state.Skipped)
+	fmt.Fprintf(w, "  %-16s %s\n", "Success rate:", formatStatusValue(snapshot.SuccessRate))
+	fmt.Fprintf(w, "  %-16s %s over the last %d (%s)\n", "Windowed rate:", formatStatusValue(snapshot.WindowedSuccessRate), snapshot.Window, snapshot.Trend)
+	fmt.Fprintf(w, "  %-16s %d\n", "Gradient:", state.GradientLevel)
+	if state.LastExpedition > 0 {
+		fmt.Fprintf(w, "  %-16s #%d %s %s\n", "Last expedition:", state.LastExpedition, state.LastStatus, state.LastIssueID)
+	}
+	fmt.Fprintf(w, "  %-16s %d\n", "Pending steps:", len(snapshot.PendingSteps))
+	for _, id := range snapshot.PendingSteps {
+		fmt.Fprintf(w, "    %s\n", id)
+	}
+	return nil
+}
+
+// formatStatusValue renders rates as percentages and everything else as is.
+func formatStatusValue(v any) string {
+	if f, ok := v.(float64); ok {
+		return fmt.Sprintf("%.1f%%", f*100)
+	}
+	if s, ok := v.(string); ok && s == "" {
+		return `""`
+	}
+	return fmt.Sprint(v)
 }
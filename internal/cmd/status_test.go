@@ -2,6 +2,7 @@ package cmd_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -10,6 +11,8 @@ import (
 	"time"
 
 	"github.com/hironow/paintress/internal/cmd"
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
 )
 
 func TestStatusCommand_NoArgs(t *testing.T) {
@@ -160,3 +163,75 @@ func TestStatusCommand_EmptyRepo(t *testing.T) {
 		t.Errorf("expected expeditions=0, got %v", parsed["expeditions"])
 	}
 }
+
+func seedStatusHistory(t *testing.T, dir string) {
+	t.Helper()
+	base := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	var events []domain.Event
+	for i, status := range []string{"success", "failed", "success"} {
+		ev, err := domain.NewEvent(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: i + 1, Status: status}, base.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, ev)
+	}
+	if _, err := session.NewEventStore(filepath.Join(dir, domain.StateDir), &domain.NopLogger{}).Append(context.Background(), events...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatusCommand_AsOfExpedition(t *testing.T) {
+	// given
+	repoDir := t.TempDir()
+	seedStatusHistory(t, repoDir)
+	root := cmd.NewRootCommand()
+	stdout := new(bytes.Buffer)
+	root.SetOut(stdout)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"status", "--as-of", "expedition 2", "-o", "json", repoDir})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var snap struct {
+		State struct {
+			TotalExpeditions int `json:"total_expeditions"`
+			Failed           int `json:"failed"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &snap); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+	}
+	if snap.State.TotalExpeditions != 2 || snap.State.Failed != 1 {
+		t.Errorf("state = %+v, want 2 expeditions with 1 failure", snap.State)
+	}
+}
+
+func TestStatusDiffCommand_TextListsChanges(t *testing.T) {
+	// given
+	repoDir := t.TempDir()
+	seedStatusHistory(t, repoDir)
+	root := cmd.NewRootCommand()
+	stdout := new(bytes.Buffer)
+	root.SetOut(stdout)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"status", "diff", "expedition 1", "expedition 3", repoDir})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := stdout.String()
+	for _, want := range []string{"total_expeditions:", "1 -> 3", "success_rate:", "100.0% -> 66.7%"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("diff output missing %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+	"github.com/hironow/paintress/internal/usecase"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+func newWatchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch [path]",
+		Short: "Triage inbox D-Mails headlessly and notify when a session is needed",
+		Long: `Run a long-lived, LLM-free daemon over .expedition/inbox/.
+
+Every new D-Mail gets the deterministic pre-flight triage: action: escalate,
+action: resolve and retries over max_retries are archived with the
+inbox-received / escalated / resolved events archive_inbox would record.
+Everything else stays in inbox/ for the next /expedition-next session.
+
+HIGH severity and stall-escalation D-Mails send a desktop notification (or run
+--notify-cmd / notify_cmd, with {title} and {message} placeholders) once, so a
+human knows to start a session. Notified D-Mails are recorded in
+.expedition/.run/inbox.db, so repeated --once runs do not notify again.
+
+The inbox is triaged at start, on every file change and every --interval.
+Only one daemon runs per project (daemon.lock in .expedition/.run/).`,
+		Example: `  # run in the foreground until interrupted
+  paintress watch
+
+  # notify through a custom command
+  paintress watch --notify-cmd 'ntfy publish paintress {message}' /path/to/repo
+
+  # triage once and exit (e.g. from cron)
+  paintress watch --once -o json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runWatch,
+	}
+
+	cmd.Flags().String("notify-cmd", "", "Notification command with {title}/{message} placeholders (default: notify_cmd from config, else desktop)")
+	cmd.Flags().Duration("interval", 30*time.Second, "Rescan the inbox at least this often")
+	cmd.Flags().Bool("once", false, "Run a single triage pass and exit")
+
+	return cmd
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	continent, err := resolveTargetDir(args)
+	if err != nil {
+		return err
+	}
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive (got %s)", interval)
+	}
+	stateDir := filepath.Join(continent, domain.StateDir)
+	if _, err := os.Stat(stateDir); err != nil {
+		return fmt.Errorf("%s is not initialized (run 'paintress init'): %w", continent, err)
+	}
+	unlock, err := session.TryLockDaemon(domain.RunDir(continent))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	logger := loggerFrom(cmd)
+	emitter := usecase.NewExpeditionEventEmitter(
+		cmd.Context(),
+		domain.NewExpeditionAggregate(),
+		session.NewEventStore(stateDir, logger),
+		usecase.NewPolicyEngine(logger),
+		&domain.NopLogger{},
+		"paintress.watch",
+	)
+	seqCounter, err := session.EnsureCutover(cmd.Context(), stateDir, session.ExpeditionStateAggregateType, logger)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = seqCounter.Close() }()
+	if seq, ok := emitter.(interface{ SetSeqAllocator(port.SeqAllocator) }); ok {
+		seq.SetSeqAllocator(seqCounter)
+	}
+
+	notifyCmd := mustString(cmd, "notify-cmd")
+	if notifyCmd == "" {
+		if cfg, cfgErr := session.LoadProjectConfig(continent); cfgErr == nil {
+			notifyCmd = cfg.NotifyCmd
+		}
+	}
+	daemon := session.NewInboxWatchDaemon(continent, emitter, session.BuildNotifier(notifyCmd), logger)
+
+	if !mustBool(cmd, "once") {
+		logger.Info("watching %s (every %s)", domain.InboxDir(continent), interval)
+		return daemon.Run(cmd.Context(), interval)
+	}
+	pass, err := daemon.TriagePass(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if mustString(cmd, "output") == "json" {
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(pass)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Escalated %d, resolved %d, notified %d, pending %d.\n",
+		pass.Escalated, pass.Resolved, pass.Notified, pass.Pending)
+	return nil
+}
@@ -0,0 +1,109 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hironow/paintress/internal/cmd"
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+func writeWatchInbox(t *testing.T, dir string, dm domain.DMail) {
+	t.Helper()
+	inbox := domain.InboxDir(dir)
+	if err := os.MkdirAll(inbox, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := dm.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inbox, dm.Name+".md"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchCommand_OnceTriagesInbox(t *testing.T) {
+	// given
+	repoDir := t.TempDir()
+	writeWatchInbox(t, repoDir, domain.DMail{Name: "fb-resolve", Kind: domain.KindImplFeedback, Description: "Done", Issues: []string{"MY-2"}, Action: "resolve"})
+	writeWatchInbox(t, repoDir, domain.DMail{Name: "spec-my-4", Kind: domain.KindSpecification, Description: "Next", Issues: []string{"MY-4"}})
+	root := cmd.NewRootCommand()
+	stdout := new(bytes.Buffer)
+	root.SetOut(stdout)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"watch", "--once", "--notify-cmd", "true", "-o", "json", repoDir})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var pass session.WatchPass
+	if err := json.Unmarshal(stdout.Bytes(), &pass); err != nil {
+		t.Fatalf("invalid JSON: %v\nraw: %s", err, stdout.String())
+	}
+	if pass.Resolved != 1 || pass.Pending != 1 {
+		t.Errorf("pass = %+v, want 1 resolved and 1 pending", pass)
+	}
+	if _, err := os.Stat(filepath.Join(domain.ArchiveDir(repoDir), "fb-resolve.md")); err != nil {
+		t.Errorf("fb-resolve not archived: %v", err)
+	}
+	events, _, err := session.NewEventStore(filepath.Join(repoDir, domain.StateDir), &domain.NopLogger{}).LoadAll(t.Context())
+	if err != nil {
+		t.Fatalf("load events: %v", err)
+	}
+	var types []string
+	for _, ev := range events {
+		types = append(types, string(ev.Type))
+	}
+	if !strings.Contains(strings.Join(types, " "), string(domain.EventResolved)) {
+		t.Errorf("events = %v, want a resolved event", types)
+	}
+}
+
+func TestWatchCommand_RefusesSecondDaemon(t *testing.T) {
+	// given
+	repoDir := t.TempDir()
+	writeWatchInbox(t, repoDir, domain.DMail{Name: "spec-my-4", Kind: domain.KindSpecification, Description: "Next"})
+	unlock, err := session.TryLockDaemon(domain.RunDir(repoDir))
+	if err != nil {
+		t.Fatalf("TryLockDaemon: %v", err)
+	}
+	defer unlock()
+	root := cmd.NewRootCommand()
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"watch", "--once", repoDir})
+
+	// when
+	err = root.Execute()
+
+	// then
+	if err == nil || !strings.Contains(err.Error(), "daemon already running") {
+		t.Errorf("err = %v, want daemon already running", err)
+	}
+}
+
+func TestWatchCommand_RequiresInitializedProject(t *testing.T) {
+	// given
+	root := cmd.NewRootCommand()
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"watch", "--once", t.TempDir()})
+
+	// when
+	err := root.Execute()
+
+	// then
+	if err == nil || !strings.Contains(err.Error(), "not initialized") {
+		t.Errorf("err = %v, want not initialized", err)
+	}
+}
@@ -24,7 +24,7 @@ func TestExpeditionAggregate_SeqNrIncrements(t *testing.T) {
 	}
 
 	// Second event should increment
-	ev2, err := agg.RecordGradientChange(3, "charge", now)
+	ev2, err := agg.RecordGommage(1, now)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -32,3 +32,28 @@ func TestExpeditionAggregate_SeqNrIncrements(t *testing.T) {
 		t.Errorf("ev2.SeqNr = %d, want 2", ev2.SeqNr)
 	}
 }
+
+func TestExpeditionAggregate_GradientChangesHaveTheirOwnStream(t *testing.T) {
+	// given: an expedition stream already holding one event
+	agg := NewExpeditionAggregate()
+	agg.SetExpeditionID("exp-1")
+	now := time.Now()
+	if _, err := agg.StartExpedition(1, 0, "opus", now); err != nil {
+		t.Fatal(err)
+	}
+	agg.SetGradientVersion(4)
+
+	// when
+	ev, err := agg.RecordGradientChange(3, 1, "charge", now)
+
+	// then: the gradient stream continues from its own version
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.StreamID() != GradientStreamID || ev.SeqNr != 5 {
+		t.Errorf("gradient event in %q with SeqNr %d, want %q with 5", ev.StreamID(), ev.SeqNr, GradientStreamID)
+	}
+	if next, _ := agg.RecordGommage(1, now); next.SeqNr != 2 {
+		t.Errorf("next expedition SeqNr = %d, want 2 (gradient changes are not counted)", next.SeqNr)
+	}
+}
@@ -100,6 +100,7 @@ type ProjectConfig struct { // nosemgrep: domain-primitives.public-string-field-
 	AutoApprove    bool               `yaml:"auto_approve,omitempty"`
 	MaxRetries     int                `yaml:"max_retries,omitempty"`
 	IdleTimeout    time.Duration      `yaml:"idle_timeout,omitempty"`
+	EventStore     string             `yaml:"event_store,omitempty"`
 	Computed       ComputedConfig     `yaml:"computed,omitempty"`
 }
 
@@ -170,6 +171,28 @@ func ValidLang(lang string) bool {
 	return lang == "ja" || lang == "en"
 }
 
+// Event store backends selectable via ProjectConfig.EventStore. An empty
+// value means EventStoreJSONL. Switch backends with `paintress events
+// migrate`, which also moves the existing events.
+const (
+	EventStoreJSONL  = "jsonl"
+	EventStoreSQLite = "sqlite"
+)
+
+// ValidEventStoreBackend reports whether backend is a supported event store backend.
+func ValidEventStoreBackend(backend string) bool {
+	return backend == EventStoreJSONL || backend == EventStoreSQLite
+}
+
+// EventStoreBackend returns the configured event store backend,
+// defaulting to EventStoreJSONL.
+func (c ProjectConfig) EventStoreBackend() string {
+	if c.EventStore == "" {
+		return EventStoreJSONL
+	}
+	return c.EventStore
+}
+
 // ValidateProjectConfig checks the project config for consistency and returns errors.
 // An empty slice means the config is valid.
 func ValidateProjectConfig(cfg ProjectConfig) []string {
@@ -200,6 +223,9 @@ func ValidateProjectConfig(cfg ProjectConfig) []string {
 	if cfg.MaxRetries < 0 {
 		errs = append(errs, fmt.Sprintf("max_retries must be non-negative (got %d)", cfg.MaxRetries))
 	}
+	if cfg.EventStore != "" && !ValidEventStoreBackend(cfg.EventStore) {
+		errs = append(errs, fmt.Sprintf("event_store must be \"jsonl\" or \"sqlite\" (got %q)", cfg.EventStore))
+	}
 	if !cfg.NoDev && cfg.DevCmd == "" {
 		errs = append(errs, "dev_cmd must not be empty when no_dev is false")
 	}
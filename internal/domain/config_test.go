@@ -181,6 +181,22 @@ func TestValidateProjectConfig_NegativeFields(t *testing.T) {
 	}
 }
 
+func TestValidateProjectConfig_EventStore(t *testing.T) {
+	// given
+	cfg := domain.DefaultProjectConfig()
+
+	// then: empty defaults to jsonl; only jsonl and sqlite are accepted
+	if got := cfg.EventStoreBackend(); got != domain.EventStoreJSONL {
+		t.Errorf("EventStoreBackend() = %q, want jsonl", got)
+	}
+	for backend, valid := range map[string]bool{"jsonl": true, "sqlite": true, "postgres": false} {
+		cfg.EventStore = backend
+		if errs := domain.ValidateProjectConfig(cfg); (len(errs) == 0) != valid {
+			t.Errorf("event_store %q: errs = %v, want valid=%v", backend, errs, valid)
+		}
+	}
+}
+
 func TestProjectConfig_TrackerMethods(t *testing.T) {
 	// given
 	empty := domain.ProjectConfig{}
@@ -29,11 +29,23 @@ func ArchiveDir(continent string) string {
 	return filepath.Join(continent, StateDir, "archive")
 }
 
+// ArchiveIndexPath returns the path to the archive index JSONL file
+// maintained by archive-prune (one domain.IndexEntry per line).
+func ArchiveIndexPath(continent string) string {
+	return filepath.Join(ArchiveDir(continent), "index.jsonl")
+}
+
 // InsightsDir returns the path to the insights directory.
 func InsightsDir(continent string) string {
 	return filepath.Join(continent, StateDir, "insights")
 }
 
+// ArtifactsDir returns the path to the content-addressed store of D-Mail
+// attachment blobs.
+func ArtifactsDir(continent string) string {
+	return filepath.Join(continent, StateDir, "artifacts")
+}
+
 // RunDir returns the path to the run directory (SQLite, locks, logs).
 func RunDir(continent string) string {
 	return filepath.Join(continent, StateDir, ".run")
@@ -122,6 +134,7 @@ type DMail struct { // nosemgrep: first-class-collection.raw-slice-field-domain-
 	Wave          *WaveReference    `yaml:"wave,omitempty"`
 	Metadata      map[string]string `yaml:"metadata,omitempty"`
 	Context       *InsightContext   `yaml:"context,omitempty" json:"context,omitempty"`
+	Attachments   []DMailAttachment `yaml:"attachments,omitempty" json:"attachments,omitempty"`
 	Body          string            `yaml:"-"`
 }
 
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// AttachmentsDirSuffix names the directory holding the attachments of a
+// delivered D-Mail: <name>.attachments/<filename>, next to <name>.md.
+const AttachmentsDirSuffix = ".attachments"
+
+// DMailAttachment describes a file shipped with a D-Mail (a screenshot,
+// a test log, a coverage diff). The blob itself is stored once in the
+// content-addressed artifacts/ store under SHA256 and copied next to the
+// D-Mail when it is flushed.
+type DMailAttachment struct {
+	Filename  string `yaml:"filename" json:"filename"`
+	MediaType string `yaml:"media_type" json:"media_type"`
+	SHA256    string `yaml:"sha256" json:"sha256"`
+	Size      int64  `yaml:"size" json:"size"`
+}
+
+// AttachmentsDirName returns the attachments directory name of the
+// D-Mail called name.
+func AttachmentsDirName(name string) string {
+	return name + AttachmentsDirSuffix
+}
+
+// Validate checks that a can be stored and delivered: a plain file name
+// (no directories) and a lowercase hex SHA256.
+func (a DMailAttachment) Validate() error {
+	if a.Filename == "" || a.Filename == "." || a.Filename == ".." || strings.ContainsAny(a.Filename, `/\`) {
+		return fmt.Errorf("attachment: invalid filename %q", a.Filename)
+	}
+	if !IsArtifactDigest(a.SHA256) {
+		return fmt.Errorf("attachment %s: invalid sha256 %q", a.Filename, a.SHA256)
+	}
+	if a.Size < 0 {
+		return fmt.Errorf("attachment %s: negative size %d", a.Filename, a.Size)
+	}
+	return nil
+}
+
+// ValidateAttachments validates every attachment and rejects two with
+// the same filename, which would collide in the attachments directory.
+func ValidateAttachments(attachments []DMailAttachment) error {
+	seen := make(map[string]bool, len(attachments))
+	for _, a := range attachments {
+		if err := a.Validate(); err != nil {
+			return err
+		}
+		if seen[a.Filename] {
+			return fmt.Errorf("attachment: duplicate filename %q", a.Filename)
+		}
+		seen[a.Filename] = true
+	}
+	return nil
+}
+
+// IsArtifactDigest reports whether s is a lowercase hex SHA256, the key
+// of a blob in the artifacts store.
+func IsArtifactDigest(s string) bool {
+	if len(s) != 64 || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,87 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+func TestDMailAttachment_Validate(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	tests := []struct {
+		name    string
+		a       domain.DMailAttachment
+		wantErr string
+	}{
+		{"valid", domain.DMailAttachment{Filename: "shot.png", MediaType: "image/png", SHA256: digest, Size: 3}, ""},
+		{"empty filename", domain.DMailAttachment{SHA256: digest}, "invalid filename"},
+		{"path in filename", domain.DMailAttachment{Filename: "../shot.png", SHA256: digest}, "invalid filename"},
+		{"dot dot", domain.DMailAttachment{Filename: "..", SHA256: digest}, "invalid filename"},
+		{"uppercase digest", domain.DMailAttachment{Filename: "a.log", SHA256: strings.ToUpper(digest)}, "invalid sha256"},
+		{"short digest", domain.DMailAttachment{Filename: "a.log", SHA256: "abc"}, "invalid sha256"},
+		{"negative size", domain.DMailAttachment{Filename: "a.log", SHA256: digest, Size: -1}, "negative size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			err := tt.a.Validate()
+
+			// then
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAttachments_RejectsDuplicateFilenames(t *testing.T) {
+	// given
+	a := domain.DMailAttachment{Filename: "test.log", SHA256: strings.Repeat("0", 64)}
+	b := domain.DMailAttachment{Filename: "test.log", SHA256: strings.Repeat("1", 64)}
+
+	// when
+	err := domain.ValidateAttachments([]domain.DMailAttachment{a, b})
+
+	// then
+	if err == nil || !strings.Contains(err.Error(), "duplicate filename") {
+		t.Errorf("err = %v, want duplicate filename", err)
+	}
+}
+
+func TestDMail_AttachmentsRoundTrip(t *testing.T) {
+	// given
+	dm := domain.DMail{
+		Name:          "pt-report-my-1",
+		Kind:          domain.KindReport,
+		Description:   "Report",
+		SchemaVersion: domain.DMailSchemaVersion,
+		Attachments: []domain.DMailAttachment{
+			{Filename: "coverage.diff", MediaType: "text/x-diff", SHA256: strings.Repeat("cd", 32), Size: 42},
+		},
+	}
+
+	// when
+	data, err := dm.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	parsed, err := domain.ParseDMail(data)
+
+	// then
+	if err != nil {
+		t.Fatalf("ParseDMail: %v", err)
+	}
+	if !strings.Contains(string(data), "media_type: text/x-diff") {
+		t.Errorf("frontmatter missing attachment:\n%s", data)
+	}
+	if len(parsed.Attachments) != 1 || parsed.Attachments[0] != dm.Attachments[0] {
+		t.Errorf("attachments = %+v, want %+v", parsed.Attachments, dm.Attachments)
+	}
+}
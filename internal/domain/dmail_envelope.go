@@ -2,6 +2,8 @@ package domain
 
 import (
 	"fmt"
+	"path"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -90,3 +92,65 @@ func (e DMailEnvelope) validate() error {
 func (e DMailEnvelope) IsConsumed() bool {
 	return e.AckAt != nil
 }
+
+// File extensions of the envelope layout in inbox/ and archive/: the
+// envelope is <message_id>.yaml, its body conventionally
+// <message_id>.body.md.
+const (
+	EnvelopeFileExt = ".yaml"
+	EnvelopeBodyExt = ".body.md"
+)
+
+// Marshal encodes the envelope in its YAML file format.
+func (e DMailEnvelope) Marshal() ([]byte, error) {
+	data, err := yaml.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("encode dmail envelope: %w", err)
+	}
+	return data, nil
+}
+
+// BodyFile returns the file name BodyPath refers to. The body must sit
+// next to the envelope, so a path into another directory is an error.
+func (e DMailEnvelope) BodyFile() (string, error) {
+	name := path.Clean(strings.ReplaceAll(e.BodyPath, `\`, "/"))
+	if name == "." || name == ".." || strings.Contains(name, "/") {
+		return "", fmt.Errorf("dmail envelope %s: body_path %q must name a file next to the envelope", e.MessageID, e.BodyPath)
+	}
+	return name, nil
+}
+
+// ToDMail maps the envelope and its body onto the v1 DMail that inbox
+// consumers work with. A body that is itself a v1 D-Mail (frontmatter
+// and markdown) keeps its fields; a plain markdown body becomes the
+// Body, with the first heading as Description. Either way Name is the
+// MessageID and the envelope fields are added to Metadata.
+func (e DMailEnvelope) ToDMail(body []byte) DMail {
+	dm, err := ParseDMail(body)
+	if err != nil {
+		dm = DMail{Body: string(body), Description: envelopeDescription(e, string(body))}
+	}
+	dm.Name = e.MessageID
+	if dm.Kind == "" {
+		dm.Kind = DMailKind(e.Kind)
+	}
+	meta := make(map[string]string, len(dm.Metadata)+4)
+	for k, v := range dm.Metadata {
+		meta[k] = v
+	}
+	meta["message_id"] = e.MessageID
+	meta["source_tool"] = e.SourceTool
+	meta["target_tool"] = e.TargetTool
+	meta["idempotency_key"] = e.IdempotencyKey
+	dm.Metadata = meta
+	return dm
+}
+
+func envelopeDescription(e DMailEnvelope, body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if heading, ok := strings.CutPrefix(strings.TrimSpace(line), "# "); ok && strings.TrimSpace(heading) != "" {
+			return strings.TrimSpace(heading)
+		}
+	}
+	return fmt.Sprintf("%s from %s", e.Kind, e.SourceTool)
+}
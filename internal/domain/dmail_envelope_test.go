@@ -235,3 +235,99 @@ func contains(s, sub string) bool {
 	}
 	return false
 }
+
+func TestDMailEnvelope_ToDMail_FixtureBody(t *testing.T) {
+	// given
+	data, err := os.ReadFile(fixturePath(t, "dmail-2026-06-01T10-00-00Z-abc123.yaml"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	env, err := domain.ParseDMailEnvelope(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	bodyFile, err := env.BodyFile()
+	if err != nil {
+		t.Fatalf("BodyFile: %v", err)
+	}
+	body, err := os.ReadFile(fixturePath(t, bodyFile))
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	// when
+	dm := env.ToDMail(body)
+
+	// then
+	if dm.Name != env.MessageID || dm.Kind != "scan_report" {
+		t.Errorf("name/kind = %q/%q", dm.Name, dm.Kind)
+	}
+	if dm.Description != "Scan report from sightjack" {
+		t.Errorf("description = %q, want the first heading", dm.Description)
+	}
+	if dm.Body != string(body) {
+		t.Errorf("body not carried over")
+	}
+	if dm.Metadata["idempotency_key"] != env.IdempotencyKey || dm.Metadata["target_tool"] != "paintress" {
+		t.Errorf("metadata = %v", dm.Metadata)
+	}
+}
+
+func TestDMailEnvelope_ToDMail_V1Body(t *testing.T) {
+	// given: the body is itself a v1 D-Mail
+	body, err := domain.DMail{Name: "inner", Kind: domain.KindImplFeedback, Description: "inner feedback", Issues: []string{"MY-1"}}.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := domain.DMailEnvelope{MessageID: "dmail-x", Kind: "feedback", SourceTool: "amadeus", IdempotencyKey: "sha256:x"}
+
+	// when
+	dm := env.ToDMail(body)
+
+	// then: frontmatter fields kept, named after the envelope
+	if dm.Name != "dmail-x" || dm.Kind != domain.KindImplFeedback || dm.Description != "inner feedback" || len(dm.Issues) != 1 {
+		t.Errorf("dmail = %+v", dm)
+	}
+	if dm.Metadata["idempotency_key"] != "sha256:x" {
+		t.Errorf("idempotency_key = %q, want the envelope's", dm.Metadata["idempotency_key"])
+	}
+}
+
+func TestDMailEnvelope_BodyFile_RejectsOtherDirectories(t *testing.T) {
+	for _, bodyPath := range []string{"../secret.md", "/etc/passwd", "sub/body.md", `..\x.md`, "."} {
+		// given
+		env := domain.DMailEnvelope{MessageID: "m", BodyPath: bodyPath}
+
+		// when
+		_, err := env.BodyFile()
+
+		// then
+		if err == nil {
+			t.Errorf("BodyFile(%q): want an error", bodyPath)
+		}
+	}
+}
+
+func TestDMailEnvelope_MarshalRoundTrip(t *testing.T) {
+	// given
+	seen := time.Date(2026, 6, 1, 11, 0, 0, 0, time.UTC)
+	env := domain.DMailEnvelope{
+		MessageID: "m", SourceTool: "sightjack", TargetTool: "paintress", Kind: "scan_report",
+		BodyPath: "./m.body.md", CreatedAt: seen.Add(-time.Hour), SeenAt: &seen, IdempotencyKey: "k",
+	}
+
+	// when
+	data, err := env.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	got, err := domain.ParseDMailEnvelope(data)
+
+	// then
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got.SeenAt == nil || !got.SeenAt.Equal(seen) || got.AckAt != nil {
+		t.Errorf("round trip = %+v", got)
+	}
+}
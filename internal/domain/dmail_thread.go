@@ -0,0 +1,277 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// D-Mail conversation metadata. in_reply_to names the D-Mail a D-Mail
+// answers; thread_id names the conversation, after the D-Mail that
+// started it.
+const (
+	MetadataInReplyTo = "in_reply_to"
+	MetadataThreadID  = "thread_id"
+)
+
+// ThreadIDOf returns the conversation d belongs to: its thread_id, else
+// the correlation_id correction D-Mails carry, else its own name (d
+// starts the conversation).
+func ThreadIDOf(d DMail) string {
+	if id := d.Metadata[MetadataThreadID]; id != "" {
+		return id
+	}
+	if id := d.Metadata[MetadataCorrelationID]; id != "" {
+		return id
+	}
+	return d.Name
+}
+
+// ReplyTo returns a copy of meta carrying in_reply_to and thread_id for
+// a reply to parent. Keys meta already sets are kept.
+func ReplyTo(meta map[string]string, parent DMail) map[string]string {
+	out := make(map[string]string, len(meta)+2)
+	for k, v := range meta {
+		out[k] = v
+	}
+	if out[MetadataInReplyTo] == "" {
+		out[MetadataInReplyTo] = parent.Name
+	}
+	if out[MetadataThreadID] == "" {
+		out[MetadataThreadID] = ThreadIDOf(parent)
+	}
+	return out
+}
+
+// RepliedDMail picks the D-Mail a report on issues answers: the last of
+// candidates (oldest first) that paintress did not produce itself and
+// that shares an issue with the report.
+func RepliedDMail(candidates []DMail, issues []string) (DMail, bool) {
+	for i := len(candidates) - 1; i >= 0; i-- {
+		c := candidates[i]
+		if ProducesKinds[c.Kind] {
+			continue
+		}
+		for _, issue := range c.Issues {
+			if slices.Contains(issues, issue) {
+				return c, true
+			}
+		}
+	}
+	return DMail{}, false
+}
+
+// ThreadDMail is a D-Mail found in archive/ or inbox/, for BuildDMailThread.
+type ThreadDMail struct {
+	DMail   DMail
+	InInbox bool      // still waiting in inbox/
+	ModTime time.Time // file time, used when no event dates the D-Mail
+}
+
+// Thread entry types.
+const (
+	ThreadEntryInbox      = "inbox"      // a D-Mail paintress received
+	ThreadEntryReport     = "report"     // a D-Mail paintress sent
+	ThreadEntryExpedition = "expedition" // an expedition on a thread issue
+)
+
+// DMailThreadEntry is one step of a conversation.
+type DMailThreadEntry struct { // nosemgrep: first-class-collection.raw-slice-field-domain-go,structure.multiple-exported-structs-go -- Issues is a JSON read-model field; thread read model family [permanent]
+	At          time.Time `json:"at"`
+	Type        string    `json:"type"`
+	Name        string    `json:"name,omitempty"`
+	Kind        string    `json:"kind,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Issues      []string  `json:"issues,omitempty"`
+	InReplyTo   string    `json:"in_reply_to,omitempty"`
+	Expedition  int       `json:"expedition,omitempty"`
+	Rerun       bool      `json:"rerun,omitempty"`
+	Outcome     string    `json:"outcome"`
+}
+
+// DMailThread is a conversation in time order: the D-Mails received and
+// sent and the expeditions run on its issues.
+type DMailThread struct { // nosemgrep: first-class-collection.raw-slice-field-domain-go,structure.multiple-exported-structs-go -- Issues/Entries are JSON read-model fields; thread read model family [permanent]
+	Selector string             `json:"selector"`
+	ThreadID string             `json:"thread_id,omitempty"`
+	Issues   []string           `json:"issues"`
+	Entries  []DMailThreadEntry `json:"entries"`
+}
+
+// BuildDMailThread assembles the conversation selector names: a D-Mail
+// name selects its thread (thread_id, and in_reply_to links both ways);
+// anything else is an issue ID and selects every D-Mail about it.
+// Expeditions that completed on the thread's issues (from its first
+// D-Mail on) are interleaved; a later expedition on the same issue is a
+// rerun. Entries are dated by inbox.received / dmail.staged events,
+// falling back to the file time.
+func BuildDMailThread(selector string, mails []ThreadDMail, events []Event) (DMailThread, error) {
+	selector = strings.TrimSuffix(selector, ".md")
+	thread := DMailThread{Selector: selector, Issues: []string{}, Entries: []DMailThreadEntry{}}
+
+	var members []ThreadDMail
+	if i := slices.IndexFunc(mails, func(m ThreadDMail) bool { return m.DMail.Name == selector }); i >= 0 {
+		thread.ThreadID = ThreadIDOf(mails[i].DMail)
+		members = threadMembers(mails, mails[i].DMail)
+		for _, m := range members {
+			for _, issue := range m.DMail.Issues {
+				if !slices.Contains(thread.Issues, issue) {
+					thread.Issues = append(thread.Issues, issue)
+				}
+			}
+		}
+	} else {
+		thread.Issues = []string{selector}
+		for _, m := range mails {
+			if slices.Contains(m.DMail.Issues, selector) {
+				members = append(members, m)
+			}
+		}
+	}
+
+	idx := indexThreadEvents(events)
+	var since time.Time
+	for _, m := range members {
+		entry := idx.mailEntry(m)
+		if since.IsZero() || entry.At.Before(since) {
+			since = entry.At
+		}
+		thread.Entries = append(thread.Entries, entry)
+	}
+	if thread.ThreadID == "" {
+		since = time.Time{} // an issue's thread includes all its expeditions
+	}
+	ran := make(map[string]bool)
+	for _, c := range idx.completed {
+		if !slices.Contains(thread.Issues, c.data.IssueID) || c.at.Before(since) {
+			continue
+		}
+		thread.Entries = append(thread.Entries, DMailThreadEntry{
+			At:          c.at,
+			Type:        ThreadEntryExpedition,
+			Description: fmt.Sprintf("expedition #%d on %s", c.data.Expedition, c.data.IssueID),
+			Issues:      []string{c.data.IssueID},
+			Expedition:  c.data.Expedition,
+			Rerun:       ran[c.data.IssueID],
+			Outcome:     c.data.Status,
+		})
+		ran[c.data.IssueID] = true
+	}
+	if len(thread.Entries) == 0 {
+		return DMailThread{}, fmt.Errorf("no D-Mail named %q and no D-Mail or expedition on issue %q", selector, selector)
+	}
+	sort.SliceStable(thread.Entries, func(i, j int) bool { return thread.Entries[i].At.Before(thread.Entries[j].At) })
+	return thread, nil
+}
+
+// threadMembers returns the D-Mails in the conversation of start: those
+// sharing its thread ID, and those linked to a member by in_reply_to in
+// either direction.
+func threadMembers(mails []ThreadDMail, start DMail) []ThreadDMail {
+	id := ThreadIDOf(start)
+	in := make(map[string]bool)
+	for _, m := range mails {
+		if ThreadIDOf(m.DMail) == id || m.DMail.Name == id {
+			in[m.DMail.Name] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, m := range mails {
+			parent := m.DMail.Metadata[MetadataInReplyTo]
+			if parent == "" || in[m.DMail.Name] == in[parent] {
+				continue
+			}
+			in[m.DMail.Name], in[parent] = true, true
+			changed = true
+		}
+	}
+	var members []ThreadDMail
+	for _, m := range mails {
+		if in[m.DMail.Name] {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+type threadCompletion struct {
+	at   time.Time
+	data ExpeditionCompletedData
+}
+
+type threadEventIndex struct {
+	received  map[string]time.Time
+	staged    map[string]time.Time
+	outcome   map[string]string
+	completed []threadCompletion
+}
+
+func indexThreadEvents(events []Event) threadEventIndex {
+	idx := threadEventIndex{received: map[string]time.Time{}, staged: map[string]time.Time{}, outcome: map[string]string{}}
+	for _, ev := range events {
+		switch ev.Type {
+		case EventInboxReceived:
+			var data InboxReceivedData
+			if json.Unmarshal(ev.Data, &data) == nil {
+				if _, ok := idx.received[data.Name]; !ok {
+					idx.received[data.Name] = ev.Timestamp
+				}
+			}
+		case EventDMailStaged:
+			var data DMailStagedData
+			if json.Unmarshal(ev.Data, &data) == nil {
+				idx.staged[data.Name] = ev.Timestamp
+			}
+		case EventEscalated:
+			var data EscalatedData
+			if json.Unmarshal(ev.Data, &data) == nil {
+				idx.outcome[data.DMail] = "escalated"
+			}
+		case EventResolved:
+			var data ResolvedData
+			if json.Unmarshal(ev.Data, &data) == nil {
+				idx.outcome[data.DMail] = "resolved"
+			}
+		case EventExpeditionCompleted:
+			var data ExpeditionCompletedData
+			if json.Unmarshal(ev.Data, &data) == nil && data.IssueID != "" {
+				idx.completed = append(idx.completed, threadCompletion{at: ev.Timestamp, data: data})
+			}
+		}
+	}
+	return idx
+}
+
+func (idx threadEventIndex) mailEntry(m ThreadDMail) DMailThreadEntry {
+	d := m.DMail
+	entry := DMailThreadEntry{
+		At:          m.ModTime,
+		Name:        d.Name,
+		Kind:        string(d.Kind),
+		Description: d.Description,
+		Issues:      d.Issues,
+		InReplyTo:   d.Metadata[MetadataInReplyTo],
+	}
+	if ProducesKinds[d.Kind] {
+		entry.Type, entry.Outcome = ThreadEntryReport, "archived"
+		if at, ok := idx.staged[d.Name]; ok {
+			entry.At, entry.Outcome = at, "sent"
+		}
+		return entry
+	}
+	entry.Type, entry.Outcome = ThreadEntryInbox, "consumed"
+	if at, ok := idx.received[d.Name]; ok {
+		entry.At = at
+	}
+	switch {
+	case idx.outcome[d.Name] != "":
+		entry.Outcome = idx.outcome[d.Name]
+	case m.InInbox:
+		entry.Outcome = "pending"
+	}
+	return entry
+}
@@ -0,0 +1,141 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+func TestReplyTo_InheritsThreadAndKeepsCallerKeys(t *testing.T) {
+	// given: feedback correcting an earlier report, carrying its correlation_id
+	parent := domain.DMail{
+		Name:     "fb-my-1",
+		Kind:     domain.KindImplFeedback,
+		Metadata: map[string]string{domain.MetadataCorrelationID: "spec-my-1"},
+	}
+
+	// when
+	auto := domain.ReplyTo(map[string]string{"project_id": "p"}, parent)
+	kept := domain.ReplyTo(map[string]string{domain.MetadataThreadID: "custom"}, parent)
+
+	// then
+	if auto[domain.MetadataInReplyTo] != "fb-my-1" || auto[domain.MetadataThreadID] != "spec-my-1" || auto["project_id"] != "p" {
+		t.Errorf("auto metadata = %v", auto)
+	}
+	if kept[domain.MetadataThreadID] != "custom" {
+		t.Errorf("caller thread_id overwritten: %v", kept)
+	}
+	if got := domain.ThreadIDOf(domain.DMail{Name: "spec-my-1"}); got != "spec-my-1" {
+		t.Errorf("ThreadIDOf(unthreaded) = %q, want its own name", got)
+	}
+}
+
+func TestRepliedDMail_PicksLatestReceivedOnSharedIssue(t *testing.T) {
+	// given: oldest first
+	candidates := []domain.DMail{
+		{Name: "spec-my-1", Kind: domain.KindSpecification, Issues: []string{"MY-1"}},
+		{Name: "fb-my-1", Kind: domain.KindImplFeedback, Issues: []string{"MY-1"}},
+		{Name: "pt-report-my-1", Kind: domain.KindReport, Issues: []string{"MY-1"}},
+		{Name: "spec-my-2", Kind: domain.KindSpecification, Issues: []string{"MY-2"}},
+	}
+
+	// when
+	got, ok := domain.RepliedDMail(candidates, []string{"MY-1"})
+	_, none := domain.RepliedDMail(candidates, []string{"MY-9"})
+
+	// then: the feedback, never paintress' own report
+	if !ok || got.Name != "fb-my-1" {
+		t.Errorf("RepliedDMail = %q, %v; want fb-my-1", got.Name, ok)
+	}
+	if none {
+		t.Error("RepliedDMail matched an unrelated issue")
+	}
+}
+
+func threadEvent(t *testing.T, typ domain.EventType, data any, at time.Time) domain.Event {
+	t.Helper()
+	ev, err := domain.NewEvent(typ, data, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ev
+}
+
+func TestBuildDMailThread_ChainsInboxReportFeedbackRerun(t *testing.T) {
+	// given: spec -> expedition 1 -> report -> feedback -> expedition 2 -> report
+	base := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	reply := func(parent, thread string) map[string]string {
+		return map[string]string{domain.MetadataInReplyTo: parent, domain.MetadataThreadID: thread}
+	}
+	mails := []domain.ThreadDMail{
+		{DMail: domain.DMail{Name: "spec-my-1", Kind: domain.KindSpecification, Issues: []string{"MY-1"}}},
+		{DMail: domain.DMail{Name: "pt-report-1", Kind: domain.KindReport, Issues: []string{"MY-1"}, Metadata: reply("spec-my-1", "spec-my-1")}},
+		{DMail: domain.DMail{Name: "fb-my-1", Kind: domain.KindImplFeedback, Issues: []string{"MY-1"}, Metadata: reply("pt-report-1", "spec-my-1")}},
+		{DMail: domain.DMail{Name: "pt-report-2", Kind: domain.KindReport, Issues: []string{"MY-1"}, Metadata: reply("fb-my-1", "spec-my-1")}},
+		{DMail: domain.DMail{Name: "spec-my-2", Kind: domain.KindSpecification, Issues: []string{"MY-2"}}, InInbox: true, ModTime: base},
+	}
+	events := []domain.Event{
+		threadEvent(t, domain.EventInboxReceived, domain.InboxReceivedData{Name: "spec-my-1"}, base),
+		threadEvent(t, domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 1, Status: "failed", IssueID: "MY-1"}, base.Add(1*time.Hour)),
+		threadEvent(t, domain.EventDMailStaged, domain.DMailStagedData{Name: "pt-report-1"}, base.Add(2*time.Hour)),
+		threadEvent(t, domain.EventInboxReceived, domain.InboxReceivedData{Name: "fb-my-1"}, base.Add(3*time.Hour)),
+		threadEvent(t, domain.EventResolved, domain.ResolvedData{DMail: "fb-my-1"}, base.Add(3*time.Hour)),
+		threadEvent(t, domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 2, Status: "success", IssueID: "MY-1"}, base.Add(4*time.Hour)),
+		threadEvent(t, domain.EventDMailStaged, domain.DMailStagedData{Name: "pt-report-2"}, base.Add(5*time.Hour)),
+	}
+
+	// when
+	thread, err := domain.BuildDMailThread("pt-report-2", mails, events)
+
+	// then
+	if err != nil {
+		t.Fatalf("BuildDMailThread: %v", err)
+	}
+	if thread.ThreadID != "spec-my-1" {
+		t.Errorf("thread id = %q, want spec-my-1", thread.ThreadID)
+	}
+	want := []struct{ typ, name, outcome string }{
+		{domain.ThreadEntryInbox, "spec-my-1", "consumed"},
+		{domain.ThreadEntryExpedition, "", "failed"},
+		{domain.ThreadEntryReport, "pt-report-1", "sent"},
+		{domain.ThreadEntryInbox, "fb-my-1", "resolved"},
+		{domain.ThreadEntryExpedition, "", "success"},
+		{domain.ThreadEntryReport, "pt-report-2", "sent"},
+	}
+	if len(thread.Entries) != len(want) {
+		t.Fatalf("entries = %+v, want %d", thread.Entries, len(want))
+	}
+	for i, w := range want {
+		e := thread.Entries[i]
+		if e.Type != w.typ || e.Name != w.name || e.Outcome != w.outcome {
+			t.Errorf("entry %d = %s %s %s, want %s %s %s", i, e.Type, e.Name, e.Outcome, w.typ, w.name, w.outcome)
+		}
+	}
+	if thread.Entries[1].Rerun || !thread.Entries[4].Rerun {
+		t.Errorf("rerun flags = %v / %v, want the second expedition only", thread.Entries[1].Rerun, thread.Entries[4].Rerun)
+	}
+}
+
+func TestBuildDMailThread_IssueSelectorAndUnknown(t *testing.T) {
+	// given
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	mails := []domain.ThreadDMail{
+		{DMail: domain.DMail{Name: "spec-my-2", Kind: domain.KindSpecification, Issues: []string{"MY-2"}}, InInbox: true, ModTime: at},
+	}
+
+	// when
+	thread, err := domain.BuildDMailThread("MY-2", mails, nil)
+	_, unknownErr := domain.BuildDMailThread("MY-404", mails, nil)
+
+	// then
+	if err != nil {
+		t.Fatalf("BuildDMailThread: %v", err)
+	}
+	if thread.ThreadID != "" || len(thread.Entries) != 1 || thread.Entries[0].Outcome != "pending" || !thread.Entries[0].At.Equal(at) {
+		t.Errorf("issue thread = %+v, want the pending spec dated by its file", thread)
+	}
+	if unknownErr == nil {
+		t.Error("unknown selector: want an error")
+	}
+}
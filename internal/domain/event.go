@@ -77,8 +77,9 @@ func AllValidEventTypes() map[EventType]bool {
 	return cp
 }
 
-// CurrentEventSchemaVersion is the schema version set by NewEvent.
-// Version 0 represents pre-Phase2 legacy events.
+// CurrentEventSchemaVersion is the baseline schema version set by NewEvent;
+// types whose payload changed shape since are ahead of it (see
+// EventSchemaVersion). Version 0 represents pre-Phase2 legacy events.
 const CurrentEventSchemaVersion uint8 = 1
 
 // Event is the envelope for all domain events in the event store.
@@ -94,9 +95,11 @@ type Event struct { // nosemgrep: structure.multiple-exported-structs-go -- even
 	AggregateID   string          `json:"aggregate_id,omitempty"`
 	AggregateType string          `json:"aggregate_type,omitempty"`
 	SeqNr         uint64          `json:"seq_nr,omitempty"`
+	PrevHash      string          `json:"prev_hash,omitempty"` // hash chain link; see ChainEvents
 }
 
-// ParseEvent validates an Event and returns the validated Event or an error.
+// ParseEvent validates an Event and returns it upcast to the current
+// schema version of its type (see UpcastEvent), or an error.
 func ParseEvent(e Event) (Event, error) {
 	var errs []string
 	if e.ID == "" {
@@ -113,13 +116,17 @@ func ParseEvent(e Event) (Event, error) {
 	if len(e.Data) == 0 {
 		errs = append(errs, "Data must not be empty")
 	}
-	if e.SchemaVersion > CurrentEventSchemaVersion {
-		errs = append(errs, fmt.Sprintf("schema_version %d exceeds supported version %d", e.SchemaVersion, CurrentEventSchemaVersion))
+	if current := EventSchemaVersion(e.Type); e.SchemaVersion > current {
+		errs = append(errs, fmt.Sprintf("schema_version %d exceeds supported version %d", e.SchemaVersion, current))
 	}
 	if len(errs) > 0 {
 		return Event{}, errors.New("invalid event: " + strings.Join(errs, "; "))
 	}
-	return e, nil
+	up, err := UpcastEvent(e)
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid event: %w", err)
+	}
+	return up, nil
 }
 
 // ValidateEvent checks that an Event has all required fields populated.
@@ -137,7 +144,7 @@ func NewEvent(eventType EventType, data any, timestamp time.Time) (Event, error)
 		return Event{}, fmt.Errorf("marshal event data: %w", err)
 	}
 	return Event{
-		SchemaVersion: CurrentEventSchemaVersion,
+		SchemaVersion: EventSchemaVersion(eventType),
 		ID:            uuid.NewString(),
 		Type:          eventType,
 		Timestamp:     timestamp,
@@ -147,7 +154,8 @@ func NewEvent(eventType EventType, data any, timestamp time.Time) (Event, error)
 
 // AppendResult captures metrics from an event store Append operation.
 type AppendResult struct { // nosemgrep: structure.multiple-exported-structs-go -- event payload family cohesive set; see Event [permanent]
-	BytesWritten int // total bytes written to event files
+	BytesWritten int      // total bytes written to event files
+	SeqNrs       []uint64 // SeqNrs allocated by AppendAllocating, in event order
 }
 
 // LoadResult captures metrics from an event store Load operation.
@@ -182,7 +190,8 @@ type ExpeditionCompletedData struct { // nosemgrep: structure.multiple-exported-
 
 // DMailStagedData is the payload for EventDMailStaged.
 type DMailStagedData struct { // nosemgrep: structure.multiple-exported-structs-go -- event payload family cohesive set; see Event [permanent]
-	Name string `json:"name"`
+	Name   string   `json:"name"`
+	Issues []string `json:"issues,omitempty"` // the D-Mail's issues; lets policies tell which expedition it reports on
 }
 
 // DMailFlushedData is the payload for EventDMailFlushed.
@@ -196,8 +205,11 @@ type DMailArchivedData struct { // nosemgrep: structure.multiple-exported-struct
 }
 
 // GradientChangedData is the payload for EventGradientChanged.
+// Level is absolute; Delta is the change that produced it (nil on events
+// recorded before deltas were) and Operator who or what applied it.
 type GradientChangedData struct { // nosemgrep: structure.multiple-exported-structs-go -- event payload family cohesive set; see Event [permanent]
 	Level    int    `json:"level"`
+	Delta    *int   `json:"delta,omitempty"`
 	Operator string `json:"operator"`
 }
 
@@ -233,6 +245,20 @@ type InboxReceivedData struct { // nosemgrep: structure.multiple-exported-struct
 	Severity string `json:"severity"`
 }
 
+// InboxConsumption describes one consumed inbox D-Mail and the triage
+// outcome to record for it (see ExpeditionAggregate.RecordInboxConsumed).
+type InboxConsumption struct { // nosemgrep: structure.multiple-exported-structs-go -- event payload family cohesive set; see Event [permanent]
+	Name     string
+	Severity string
+	Issues   []string
+	Escalate bool
+	Resolve  bool
+	// RetryKey is set when the retry is tracked; RetryAttempt is then
+	// the attempt to record.
+	RetryKey     string
+	RetryAttempt int
+}
+
 // RetryAttemptedData is the payload for EventRetryAttempted.
 type RetryAttemptedData struct { // nosemgrep: structure.multiple-exported-structs-go -- event payload family cohesive set; see Event [permanent]
 	DMail   string `json:"dmail"`
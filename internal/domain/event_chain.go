@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// GenesisHash is the PrevHash of the first chained event of a stream.
+const GenesisHash = "sha256:" + "0000000000000000000000000000000000000000000000000000000000000000"
+
+// StreamID identifies the hash chain stream an event belongs to: its
+// aggregate ("<type>/<id>"), or "" for events outside any aggregate.
+func (e Event) StreamID() string {
+	if e.AggregateType == "" && e.AggregateID == "" {
+		return ""
+	}
+	return e.AggregateType + "/" + e.AggregateID
+}
+
+// EventHash returns "sha256:<hex>" over the event's JSON encoding, the
+// exact bytes both event store backends persist. The encoding includes
+// PrevHash, so every hash commits to the whole stream before it.
+func EventHash(e Event) (string, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("hash event %s: %w", e.ID, err)
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// ChainHeads returns the hash of the last chained event of every stream in
+// events: the one no other chained event of its stream links to. On a
+// fork (several such events) the last in events order wins; `events
+// verify` reports the fork. Events without PrevHash predate the chain and
+// are ignored.
+func ChainHeads(events []Event) (map[string]string, error) {
+	linked := make(map[string]bool)
+	for _, ev := range events {
+		if ev.PrevHash != "" {
+			linked[ev.StreamID()+"\x00"+ev.PrevHash] = true
+		}
+	}
+	heads := make(map[string]string)
+	for _, ev := range events {
+		if ev.PrevHash == "" {
+			continue
+		}
+		hash, err := EventHash(ev)
+		if err != nil {
+			return nil, err
+		}
+		if !linked[ev.StreamID()+"\x00"+hash] {
+			heads[ev.StreamID()] = hash
+		}
+	}
+	return heads, nil
+}
+
+// ChainEvents links events onto their streams: each gets the hash of its
+// stream's head as PrevHash (GenesisHash for a new stream) and becomes the
+// new head. heads is updated in place. Events are returned in order.
+func ChainEvents(events []Event, heads map[string]string) ([]Event, error) {
+	chained := make([]Event, len(events))
+	for i, ev := range events {
+		stream := ev.StreamID()
+		ev.PrevHash = heads[stream]
+		if ev.PrevHash == "" {
+			ev.PrevHash = GenesisHash
+		}
+		hash, err := EventHash(ev)
+		if err != nil {
+			return nil, err
+		}
+		heads[stream] = hash
+		chained[i] = ev
+	}
+	return chained, nil
+}
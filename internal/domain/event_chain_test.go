@@ -0,0 +1,48 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+func TestChainEvents_LinksPerStreamAndResumesFromHeads(t *testing.T) {
+	// given: two streams and an event outside any aggregate
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	newEvent := func(aggregateID string, n int) domain.Event {
+		ev, _ := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: n}, at)
+		if aggregateID != "" {
+			ev.AggregateType, ev.AggregateID = "expedition", aggregateID
+		}
+		return ev
+	}
+	first, err := domain.ChainEvents([]domain.Event{newEvent("a", 1), newEvent("b", 1), newEvent("", 1)}, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	heads, err := domain.ChainHeads(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	second, err := domain.ChainEvents([]domain.Event{newEvent("a", 2)}, heads)
+
+	// then
+	if err != nil {
+		t.Fatalf("ChainEvents: %v", err)
+	}
+	for _, ev := range first {
+		if ev.PrevHash != domain.GenesisHash {
+			t.Errorf("%s PrevHash = %q, want genesis", ev.StreamID(), ev.PrevHash)
+		}
+	}
+	headA, _ := domain.EventHash(first[0])
+	if second[0].PrevHash != headA {
+		t.Errorf("PrevHash = %q, want the hash of stream a's head %q", second[0].PrevHash, headA)
+	}
+	if len(heads) != 3 {
+		t.Errorf("heads = %v, want one per stream", heads)
+	}
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// EventFilter selects events for `paintress events list` and `tail`.
+// Zero fields match every event.
+type EventFilter struct { // nosemgrep: first-class-collection.raw-slice-field-domain-go -- Types is a flag-backed filter set (no FCC benefit) [permanent]
+	Types         []EventType
+	Since         time.Time // inclusive
+	Until         time.Time // exclusive
+	IssueID       string    // issue_id, "<wave_id>:<step_id>" or an escalated/resolved issue
+	Expedition    int
+	CorrelationID string
+}
+
+// eventRefs holds the payload fields EventFilter matches on.
+type eventRefs struct {
+	Expedition int      `json:"expedition"`
+	IssueID    string   `json:"issue_id"`
+	WaveID     string   `json:"wave_id"`
+	StepID     string   `json:"step_id"`
+	Issues     []string `json:"issues"`
+}
+
+// Match reports whether e passes every set condition of the filter.
+func (f EventFilter) Match(e Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Timestamp.Before(f.Until) {
+		return false
+	}
+	if f.CorrelationID != "" && e.CorrelationID != f.CorrelationID {
+		return false
+	}
+	if f.IssueID == "" && f.Expedition == 0 {
+		return true
+	}
+	var refs eventRefs
+	if json.Unmarshal(e.Data, &refs) != nil {
+		return false
+	}
+	if f.Expedition != 0 && refs.Expedition != f.Expedition {
+		return false
+	}
+	if f.IssueID != "" && refs.IssueID != f.IssueID && !slices.Contains(refs.Issues, f.IssueID) &&
+		(refs.WaveID == "" || refs.WaveID+":"+refs.StepID != f.IssueID) {
+		return false
+	}
+	return true
+}
+
+// FilterEvents returns the events matching f, in order.
+func FilterEvents(events []Event, f EventFilter) []Event {
+	var matched []Event
+	for _, ev := range events {
+		if f.Match(ev) {
+			matched = append(matched, ev)
+		}
+	}
+	return matched
+}
+
+// CausationChain returns the events that led to e by following
+// CausationID, nearest first. It stops at an ID not in events (the last
+// returned event, or e itself, then still names it) and at cycles.
+func CausationChain(events []Event, e Event) []Event {
+	byID := make(map[string]Event, len(events))
+	for _, ev := range events {
+		byID[ev.ID] = ev
+	}
+	seen := map[string]bool{e.ID: true}
+	var chain []Event
+	for cur := e; cur.CausationID != "" && !seen[cur.CausationID]; {
+		cause, ok := byID[cur.CausationID]
+		if !ok {
+			break
+		}
+		seen[cause.ID] = true
+		chain = append(chain, cause)
+		cur = cause
+	}
+	return chain
+}
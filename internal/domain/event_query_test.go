@@ -0,0 +1,63 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+func TestEventFilter_Match(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	completed, _ := domain.NewEvent(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 3, Status: "success", IssueID: "PROJ-7", WaveID: "wave-a", StepID: "s1"}, at)
+	completed.CorrelationID = "exp-3"
+	escalated, _ := domain.NewEvent(domain.EventEscalated, domain.EscalatedData{DMail: "d", Issues: []string{"PROJ-9"}}, at.Add(time.Hour))
+
+	tests := []struct {
+		name   string
+		filter domain.EventFilter
+		event  domain.Event
+		want   bool
+	}{
+		{"empty filter", domain.EventFilter{}, completed, true},
+		{"type", domain.EventFilter{Types: []domain.EventType{domain.EventEscalated}}, completed, false},
+		{"since inclusive", domain.EventFilter{Since: at}, completed, true},
+		{"until exclusive", domain.EventFilter{Until: at}, completed, false},
+		{"correlation", domain.EventFilter{CorrelationID: "exp-4"}, completed, false},
+		{"expedition", domain.EventFilter{Expedition: 3}, completed, true},
+		{"issue_id", domain.EventFilter{IssueID: "PROJ-7"}, completed, true},
+		{"wave step target", domain.EventFilter{IssueID: "wave-a:s1"}, completed, true},
+		{"escalated issues", domain.EventFilter{IssueID: "PROJ-9"}, escalated, true},
+		{"other issue", domain.EventFilter{IssueID: "PROJ-1"}, escalated, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			got := tt.filter.Match(tt.event)
+
+			// then
+			if got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCausationChain_NearestFirstStopsAtMissingCause(t *testing.T) {
+	// given: c <- b <- a <- (missing)
+	at := time.Now()
+	a, _ := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 1}, at)
+	a.CausationID = "gone"
+	b, _ := domain.NewEvent(domain.EventExpeditionCheckpoint, domain.ExpeditionCheckpointData{Expedition: 1}, at)
+	b.CausationID = a.ID
+	c, _ := domain.NewEvent(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 1}, at)
+	c.CausationID = b.ID
+
+	// when
+	chain := domain.CausationChain([]domain.Event{a, b, c}, c)
+
+	// then
+	if len(chain) != 2 || chain[0].ID != b.ID || chain[1].ID != a.ID {
+		t.Errorf("chain = %v, want [b a]", chain)
+	}
+}
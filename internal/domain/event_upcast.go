@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// EventUpcaster rewrites the payload of one EventType from schema version
+// From to From+1. Chained per type, upcasters bring events written by older
+// releases to the current payload shape before projections decode them.
+type EventUpcaster struct { // nosemgrep: structure.multiple-exported-structs-go -- upcaster registry entry; lives with the chain it forms [permanent]
+	Type   EventType
+	From   uint8
+	Upcast func(data json.RawMessage) (json.RawMessage, error)
+}
+
+// eventUpcasters is the registry, in chain order per type. A version hop
+// without an entry only bumps SchemaVersion: the payload shape did not
+// change for that type. Append a hop (and a testdata/upcast fixture)
+// whenever a payload changes shape in a way old events cannot satisfy by
+// decoding to zero values.
+var eventUpcasters = []EventUpcaster{
+	{Type: EventExpeditionCompleted, From: 0, Upcast: upcastExpeditionCompletedV0},
+}
+
+// EventUpcasters returns a copy of the registered upcasters in chain order
+// (for testing and reporting).
+func EventUpcasters() []EventUpcaster {
+	return append([]EventUpcaster(nil), eventUpcasters...)
+}
+
+// EventSchemaVersion returns the current schema version of t's payload:
+// CurrentEventSchemaVersion, or higher once t has upcasters past it. NewEvent
+// writes events at this version and ParseEvent rejects newer ones.
+func EventSchemaVersion(t EventType) uint8 {
+	version := CurrentEventSchemaVersion
+	for _, u := range eventUpcasters {
+		if u.Type == t {
+			version = max(version, u.From+1)
+		}
+	}
+	return version
+}
+
+// UpcastEvent brings e to the current schema version of its type by
+// applying each registered hop from e.SchemaVersion on. Events of unknown
+// types and events already at (or, from a newer release, past) the current
+// version are returned unchanged. On error e is returned as is.
+func UpcastEvent(e Event) (Event, error) {
+	if !ValidEventType(e.Type) {
+		return e, nil
+	}
+	current := EventSchemaVersion(e.Type)
+	if e.SchemaVersion >= current {
+		return e, nil
+	}
+	data := e.Data
+	for v := e.SchemaVersion; v < current; v++ {
+		for _, u := range eventUpcasters {
+			if u.Type != e.Type || u.From != v {
+				continue
+			}
+			next, err := u.Upcast(data)
+			if err != nil {
+				return e, fmt.Errorf("upcast %s event %s from schema %d: %w", e.Type, e.ID, v, err)
+			}
+			data = next
+		}
+	}
+	up := e
+	up.Data = data
+	up.SchemaVersion = current
+	return up, nil
+}
+
+// upcastExpeditionCompletedV0 fills wave_id / step_id of legacy completions,
+// which only carried the expedition target in issue_id as "<wave>:<step>".
+// Tracker issue IDs contain no colon and are left alone. Other fields,
+// including ones this release does not know, pass through untouched.
+func upcastExpeditionCompletedV0(data json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if _, ok := fields["wave_id"]; ok {
+		return data, nil
+	}
+	var issueID string
+	if raw, ok := fields["issue_id"]; ok {
+		if err := json.Unmarshal(raw, &issueID); err != nil {
+			return nil, fmt.Errorf("issue_id: %w", err)
+		}
+	}
+	waveID, stepID, ok := strings.Cut(issueID, ":")
+	if !ok || waveID == "" || stepID == "" {
+		return data, nil
+	}
+	fields["wave_id"], _ = json.Marshal(waveID)
+	fields["step_id"], _ = json.Marshal(stepID)
+	return json.Marshal(fields)
+}
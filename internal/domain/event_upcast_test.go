@@ -0,0 +1,134 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// TestEventUpcasters_Golden runs every registered hop over its fixtures in
+// testdata/upcast/<type>/v<from>/: each <case>.input.json payload must
+// upcast to <case>.golden.json. Every hop needs at least one fixture.
+func TestEventUpcasters_Golden(t *testing.T) {
+	for _, u := range domain.EventUpcasters() {
+		dir := filepath.Join("testdata", "upcast", string(u.Type), fmt.Sprintf("v%d", u.From))
+		inputs, _ := filepath.Glob(filepath.Join(dir, "*.input.json"))
+		if len(inputs) == 0 {
+			t.Errorf("no fixtures in %s for the %s v%d->v%d upcaster", dir, u.Type, u.From, u.From+1)
+			continue
+		}
+		for _, input := range inputs {
+			name := strings.TrimSuffix(filepath.Base(input), ".input.json")
+			t.Run(fmt.Sprintf("%s/v%d/%s", u.Type, u.From, name), func(t *testing.T) {
+				// given
+				in, err := os.ReadFile(input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				golden, err := os.ReadFile(filepath.Join(dir, name+".golden.json"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// when
+				out, err := u.Upcast(in)
+
+				// then
+				if err != nil {
+					t.Fatalf("Upcast: %v", err)
+				}
+				var got, want any
+				if err := json.Unmarshal(out, &got); err != nil {
+					t.Fatalf("output is not JSON: %v (%s)", err, out)
+				}
+				if err := json.Unmarshal(golden, &want); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("got %s\nwant %s", out, golden)
+				}
+			})
+		}
+	}
+}
+
+func TestUpcastEvent_LegacyCompletionReachesWaveProgress(t *testing.T) {
+	// given: a v0 completion whose wave target only lives in issue_id
+	legacy := domain.Event{
+		ID:        "legacy-1",
+		Type:      domain.EventExpeditionCompleted,
+		Timestamp: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC),
+		Data:      json.RawMessage(`{"expedition":1,"status":"success","issue_id":"wave-a:s1"}`),
+	}
+	spec, _ := domain.NewEvent(domain.EventSpecRegistered, domain.SpecRegisteredData{
+		WaveID: "wave-a",
+		Steps:  []domain.WaveStepDef{{ID: "s1", Title: "first"}},
+	}, legacy.Timestamp.Add(-time.Hour))
+
+	// when
+	up, err := domain.UpcastEvent(legacy)
+
+	// then
+	if err != nil {
+		t.Fatalf("UpcastEvent: %v", err)
+	}
+	if up.SchemaVersion != domain.EventSchemaVersion(domain.EventExpeditionCompleted) {
+		t.Errorf("SchemaVersion = %d, want %d", up.SchemaVersion, domain.EventSchemaVersion(domain.EventExpeditionCompleted))
+	}
+	if targets := domain.ProjectWaveStepProgress([]domain.Event{spec, up}).PendingTargets(); len(targets) != 0 {
+		t.Errorf("pending targets = %v, want none (legacy completion applied)", targets)
+	}
+}
+
+func TestUpcastEvent_CurrentAndUnknownEventsUnchanged(t *testing.T) {
+	// given
+	current, _ := domain.NewEvent(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 1, Status: "success", IssueID: "a:b"}, time.Now())
+	unknown := domain.Event{ID: "x", Type: "legacy.removed", Data: json.RawMessage(`{}`)}
+
+	// when
+	upCurrent, errCurrent := domain.UpcastEvent(current)
+	upUnknown, errUnknown := domain.UpcastEvent(unknown)
+
+	// then
+	if errCurrent != nil || errUnknown != nil {
+		t.Fatalf("errors: %v / %v", errCurrent, errUnknown)
+	}
+	if string(upCurrent.Data) != string(current.Data) || upCurrent.SchemaVersion != current.SchemaVersion {
+		t.Errorf("current event changed: %+v", upCurrent)
+	}
+	if upUnknown.SchemaVersion != 0 {
+		t.Errorf("unknown type upcast to %d", upUnknown.SchemaVersion)
+	}
+}
+
+func TestParseEvent_ReturnsUpcastEvent(t *testing.T) {
+	// given
+	legacy := domain.Event{
+		ID:        "legacy-2",
+		Type:      domain.EventExpeditionCompleted,
+		Timestamp: time.Now(),
+		Data:      json.RawMessage(`{"expedition":2,"status":"failed","issue_id":"w:s"}`),
+	}
+
+	// when
+	parsed, err := domain.ParseEvent(legacy)
+
+	// then
+	if err != nil {
+		t.Fatalf("ParseEvent: %v", err)
+	}
+	var data domain.ExpeditionCompletedData
+	if err := json.Unmarshal(parsed.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.SchemaVersion == 0 || data.WaveID != "w" || data.StepID != "s" {
+		t.Errorf("parsed = v%d %+v, want current schema with wave w / step s", parsed.SchemaVersion, data)
+	}
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ExpectedVersion is the number of events an aggregate stream (see
+// Event.StreamID) must already hold for an append to it to be accepted.
+// It makes read-modify-write cycles safe across processes: a writer
+// that read version N appends expecting N and loses to any writer that
+// appended first.
+type ExpectedVersion int64
+
+// AnyVersion appends without checking the stream version.
+const AnyVersion ExpectedVersion = -1
+
+// ConcurrencyConflictError reports an append rejected because its stream
+// moved past the expected version. Nothing of the batch was written.
+type ConcurrencyConflictError struct {
+	Stream   string
+	Expected ExpectedVersion
+	Actual   uint64
+}
+
+func (e *ConcurrencyConflictError) Error() string {
+	return fmt.Sprintf("concurrency conflict on stream %q: expected version %d, found %d", e.Stream, e.Expected, e.Actual)
+}
+
+// IsConcurrencyConflict reports whether err is or wraps a
+// *ConcurrencyConflictError.
+func IsConcurrencyConflict(err error) bool {
+	var conflict *ConcurrencyConflictError
+	return errors.As(err, &conflict)
+}
+
+// StreamVersion returns the number of events of stream in events.
+func StreamVersion(events []Event, stream string) uint64 {
+	var n uint64
+	for _, ev := range events {
+		if ev.StreamID() == stream {
+			n++
+		}
+	}
+	return n
+}
+
+// CheckExpectedVersion decides whether batch may be appended to a store
+// where version reports how many events a stream holds. With an
+// expected version every event of batch must belong to one stream, and
+// that stream must hold exactly expected events.
+func CheckExpectedVersion(batch []Event, expected ExpectedVersion, version func(stream string) uint64) error {
+	if expected == AnyVersion || len(batch) == 0 {
+		return nil
+	}
+	stream := batch[0].StreamID()
+	for _, ev := range batch[1:] {
+		if ev.StreamID() != stream {
+			return fmt.Errorf("expected version %d: batch spans streams %q and %q", expected, stream, ev.StreamID())
+		}
+	}
+	if actual := version(stream); expected < 0 || uint64(expected) != actual {
+		return &ConcurrencyConflictError{Stream: stream, Expected: expected, Actual: actual}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package domain_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+func TestCheckExpectedVersion(t *testing.T) {
+	// given: a stream holding two events
+	agg := domain.NewExpeditionAggregate()
+	agg.SetExpeditionID("exp-1")
+	first, _ := agg.RecordGradientChange(1, 1, "charge", time.Now())
+	second, _ := agg.RecordGradientChange(2, 1, "charge", time.Now())
+	stored := []domain.Event{first, second}
+	next, _ := agg.RecordGradientChange(3, 1, "charge", time.Now())
+	other := domain.NewExpeditionAggregate()
+	foreign, _ := other.StartExpedition(1, 0, "opus", time.Now())
+	version := func(stream string) uint64 { return domain.StreamVersion(stored, stream) }
+
+	// when
+	okErr := domain.CheckExpectedVersion([]domain.Event{next}, 2, version)
+	anyErr := domain.CheckExpectedVersion([]domain.Event{next}, domain.AnyVersion, version)
+	staleErr := domain.CheckExpectedVersion([]domain.Event{next}, 1, version)
+	mixedErr := domain.CheckExpectedVersion([]domain.Event{next, foreign}, 2, version)
+
+	// then
+	if okErr != nil || anyErr != nil {
+		t.Errorf("matching version: %v / %v", okErr, anyErr)
+	}
+	var conflict *domain.ConcurrencyConflictError
+	if !errors.As(fmt.Errorf("wrapped: %w", staleErr), &conflict) || conflict.Actual != 2 || conflict.Stream != domain.GradientStreamID {
+		t.Errorf("stale version err = %v, want a conflict at version 2 on %s", staleErr, domain.GradientStreamID)
+	}
+	if mixedErr == nil || domain.IsConcurrencyConflict(mixedErr) {
+		t.Errorf("mixed streams err = %v, want a non-conflict error", mixedErr)
+	}
+}
@@ -8,6 +8,18 @@ import (
 // AggregateTypeExpedition is the aggregate type for expedition events.
 const AggregateTypeExpedition = "expedition"
 
+// AggregateTypeGradient is the aggregate type for gradient.changed events.
+// The gradient is one value per continent, so its changes form a single
+// stream (GradientStreamID) whichever expedition records them, and
+// gradient writers conflict only with each other.
+const AggregateTypeGradient = "gradient"
+
+// GradientAggregateID is the aggregate ID of the gradient stream.
+const GradientAggregateID = "continent"
+
+// GradientStreamID is the stream of gradient.changed events.
+const GradientStreamID = AggregateTypeGradient + "/" + GradientAggregateID
+
 // ExpeditionAggregate owns expedition lifecycle state and produces events.
 // It tracks consecutive failures for gommage decisions and gradient state.
 type ExpeditionAggregate struct {
@@ -16,6 +28,7 @@ type ExpeditionAggregate struct {
 	escalationFired     bool
 	recoveryAttempts    int
 	seqNr               uint64
+	gradientVersion     uint64
 }
 
 // NewExpeditionAggregate creates an empty ExpeditionAggregate.
@@ -33,6 +46,13 @@ func (a *ExpeditionAggregate) ExpeditionID() string {
 	return a.expeditionID
 }
 
+// SetGradientVersion syncs the aggregate with the stored version of the
+// gradient stream, so the next gradient.changed event continues the
+// stream's SeqNr and appends can expect it.
+func (a *ExpeditionAggregate) SetGradientVersion(version uint64) {
+	a.gradientVersion = version
+}
+
 // nextEvent creates an event tagged with expedition aggregate identity.
 func (a *ExpeditionAggregate) nextEvent(eventType EventType, data any, now time.Time) (Event, error) {
 	a.seqNr++
@@ -127,12 +147,23 @@ func (a *ExpeditionAggregate) RecordGommage(expedition int, now time.Time) (Even
 	}, now)
 }
 
-// RecordGradientChange produces a gradient.changed event.
-func (a *ExpeditionAggregate) RecordGradientChange(level int, operator string, now time.Time) (Event, error) {
-	return a.nextEvent(EventGradientChanged, GradientChangedData{
+// RecordGradientChange produces a gradient.changed event recording the
+// new absolute level and the delta that led to it, in the gradient
+// stream rather than the expedition's.
+func (a *ExpeditionAggregate) RecordGradientChange(level, delta int, operator string, now time.Time) (Event, error) {
+	ev, err := NewEvent(EventGradientChanged, GradientChangedData{
 		Level:    level,
+		Delta:    &delta,
 		Operator: operator,
 	}, now)
+	if err != nil {
+		return ev, err
+	}
+	a.gradientVersion++
+	ev.AggregateID = GradientAggregateID
+	ev.AggregateType = AggregateTypeGradient
+	ev.SeqNr = a.gradientVersion
+	return ev, nil
 }
 
 // RecordInboxReceived produces an inbox.received event.
@@ -143,6 +174,43 @@ func (a *ExpeditionAggregate) RecordInboxReceived(name, severity string, now tim
 	}, now)
 }
 
+// RecordInboxConsumed produces the events of one consumed inbox D-Mail,
+// to be appended together: inbox.received, then escalated / resolved /
+// retry.attempted as decided, then dmail.archived.
+func (a *ExpeditionAggregate) RecordInboxConsumed(c InboxConsumption, now time.Time) ([]Event, error) {
+	received, err := a.RecordInboxReceived(c.Name, c.Severity, now)
+	if err != nil {
+		return nil, err
+	}
+	events := []Event{received}
+	if c.Escalate {
+		ev, err := a.RecordEscalated(c.Name, c.Issues, now)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	if c.Resolve {
+		ev, err := a.RecordResolved(c.Name, c.Issues, now)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	if c.RetryKey != "" {
+		ev, err := a.RecordRetryAttempted(c.RetryKey, c.RetryAttempt, now)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	archived, err := a.RecordDMailArchived(c.Name, now)
+	if err != nil {
+		return nil, err
+	}
+	return append(events, archived), nil
+}
+
 // RecordRetryAttempted produces a retry.attempted event.
 func (a *ExpeditionAggregate) RecordRetryAttempted(dmailKey string, attempt int, now time.Time) (Event, error) {
 	return a.nextEvent(EventRetryAttempted, RetryAttemptedData{
@@ -168,8 +236,8 @@ func (a *ExpeditionAggregate) RecordResolved(dmailName string, issues []string,
 }
 
 // RecordDMailStaged produces a dmail.staged event.
-func (a *ExpeditionAggregate) RecordDMailStaged(name string, now time.Time) (Event, error) {
-	return a.nextEvent(EventDMailStaged, DMailStagedData{Name: name}, now)
+func (a *ExpeditionAggregate) RecordDMailStaged(name string, issues []string, now time.Time) (Event, error) {
+	return a.nextEvent(EventDMailStaged, DMailStagedData{Name: name, Issues: issues}, now)
 }
 
 // RecordDMailFlushed produces a dmail.flushed event.
@@ -373,3 +373,29 @@ func TestExpeditionAggregate_RecordResolved_DotCase(t *testing.T) {
 		t.Errorf("event type string = %q, want %q", string(ev.Type), "issue.resolved")
 	}
 }
+
+func TestExpeditionAggregate_RecordInboxConsumed_Order(t *testing.T) {
+	// given
+	agg := domain.NewExpeditionAggregate()
+	consumed := domain.InboxConsumption{
+		Name: "fb-my-1", Severity: "high", Issues: []string{"MY-1"},
+		Escalate: true, RetryKey: "fb-my-1", RetryAttempt: 2,
+	}
+
+	// when
+	events, err := agg.RecordInboxConsumed(consumed, time.Now().UTC())
+
+	// then
+	if err != nil {
+		t.Fatalf("RecordInboxConsumed error: %v", err)
+	}
+	want := []domain.EventType{domain.EventInboxReceived, domain.EventEscalated, domain.EventRetryAttempted, domain.EventDMailArchived}
+	if len(events) != len(want) {
+		t.Fatalf("events = %d, want %d", len(events), len(want))
+	}
+	for i, ev := range events {
+		if ev.Type != want[i] {
+			t.Errorf("events[%d] = %q, want %q", i, ev.Type, want[i])
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "path/filepath"
+import (
+	"path/filepath"
+	"time"
+)
 
 func JournalDir(continent string) string {
 	return filepath.Join(continent, StateDir, "journal")
@@ -19,3 +22,25 @@ type JournalEntry struct { // nosemgrep: domain-primitives.public-string-field-g
 	BugsFound   int
 	BugIssues   string
 }
+
+// ExpeditionReservation records an expedition number handed out by
+// start_expedition before the expedition is journaled, so concurrent
+// sessions never share a number.
+type ExpeditionReservation struct { // nosemgrep: structure.multiple-exported-structs-go -- journal record family (JournalEntry/ExpeditionReservation) [permanent]
+	Expedition int       `json:"expedition"`
+	IssueID    string    `json:"issue_id"`
+	ReservedAt time.Time `json:"reserved_at"`
+	Closed     bool      `json:"closed"` // the expedition was journaled
+}
+
+// ExpeditionReservationTTL is how long an unjournaled reservation stays
+// open. After that it counts as abandoned: start_expedition allocates a
+// fresh number instead of resuming it and next_issue no longer lists it
+// in flight. Its number is still never handed out again.
+const ExpeditionReservationTTL = 24 * time.Hour
+
+// Expired reports whether the reservation is older than
+// ExpeditionReservationTTL at now.
+func (r ExpeditionReservation) Expired(now time.Time) bool {
+	return now.Sub(r.ReservedAt) >= ExpeditionReservationTTL
+}
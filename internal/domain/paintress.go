@@ -79,4 +79,5 @@ func ExitCode(err error) int {
 type PruneResult struct { // nosemgrep: first-class-collection.raw-slice-field-domain-go -- Candidates is an operation result slice, not a domain aggregate; FCC wrapping adds complexity with no safety benefit [permanent]
 	Candidates []string // basenames of files older than threshold
 	Deleted    int      // number of files actually removed (0 in dry-run)
+	Removed    []string // basenames of the files actually removed (nil in dry-run)
 }
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AsOf is the cut-off of a historical status view: a point in time, or
+// the completion of an expedition. Exactly one field is set.
+type AsOf struct {
+	Time       time.Time
+	Expedition int
+}
+
+var asOfExpeditionPattern = regexp.MustCompile(`^(?i)(?:expedition|exp|#)[\s:#-]*(\d+)$`)
+
+// ParseAsOf parses an RFC 3339 time or "expedition N" (also "exp N",
+// "expedition:N" and "#N").
+func ParseAsOf(value string) (AsOf, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return AsOf{Time: t}, nil
+	}
+	if m := asOfExpeditionPattern.FindStringSubmatch(value); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err == nil && n > 0 {
+			return AsOf{Expedition: n}, nil
+		}
+	}
+	return AsOf{}, fmt.Errorf("%q is not an RFC 3339 time or \"expedition N\"", value)
+}
+
+// String renders the cut-off the way ParseAsOf reads it.
+func (a AsOf) String() string {
+	if a.Expedition > 0 {
+		return fmt.Sprintf("expedition %d", a.Expedition)
+	}
+	return a.Time.Format(time.RFC3339)
+}
+
+// Cutoff resolves the cut-off against events: a time is itself, an
+// expedition is the timestamp of its (last) expedition.completed event.
+// An expedition that never completed is an error.
+func (a AsOf) Cutoff(events []Event) (time.Time, error) {
+	if a.Expedition == 0 {
+		return a.Time, nil
+	}
+	var at time.Time
+	for _, ev := range events {
+		if ev.Type != EventExpeditionCompleted {
+			continue
+		}
+		var data ExpeditionCompletedData
+		if err := json.Unmarshal(ev.Data, &data); err != nil || data.Expedition != a.Expedition {
+			continue
+		}
+		if ev.Timestamp.After(at) {
+			at = ev.Timestamp
+		}
+	}
+	if at.IsZero() {
+		return time.Time{}, fmt.Errorf("expedition %d has no expedition.completed event", a.Expedition)
+	}
+	return at, nil
+}
+
+// EventsAsOf returns the events recorded at or before cutoff, in order.
+func EventsAsOf(events []Event, cutoff time.Time) []Event {
+	var kept []Event
+	for _, ev := range events {
+		if !ev.Timestamp.After(cutoff) {
+			kept = append(kept, ev)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,58 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+func TestParseAsOf(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		in   string
+		want domain.AsOf
+	}{
+		{"2026-03-01T12:00:00Z", domain.AsOf{Time: at}},
+		{"expedition 7", domain.AsOf{Expedition: 7}},
+		{"Expedition:7", domain.AsOf{Expedition: 7}},
+		{"exp 7", domain.AsOf{Expedition: 7}},
+		{"#7", domain.AsOf{Expedition: 7}},
+	}
+	for _, tc := range cases {
+		// when
+		got, err := domain.ParseAsOf(tc.in)
+
+		// then
+		if err != nil || !got.Time.Equal(tc.want.Time) || got.Expedition != tc.want.Expedition {
+			t.Errorf("ParseAsOf(%q) = %+v, %v; want %+v", tc.in, got, err, tc.want)
+		}
+	}
+	for _, bad := range []string{"", "yesterday", "expedition 0", "expedition x", "2026-03-01"} {
+		if _, err := domain.ParseAsOf(bad); err == nil {
+			t.Errorf("ParseAsOf(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestAsOf_CutoffAndEventsAsOf(t *testing.T) {
+	// given: expeditions 1 and 2 completed an hour apart
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	first, _ := domain.NewEvent(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 1, Status: "success"}, base)
+	second, _ := domain.NewEvent(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 2, Status: "failed"}, base.Add(time.Hour))
+	events := []domain.Event{first, second}
+
+	// when
+	cutoff, err := domain.AsOf{Expedition: 1}.Cutoff(events)
+
+	// then: the cut-off is expedition 1's completion, inclusive
+	if err != nil || !cutoff.Equal(base) {
+		t.Fatalf("Cutoff = %v, %v; want %v", cutoff, err, base)
+	}
+	if past := domain.EventsAsOf(events, cutoff); len(past) != 1 || past[0].ID != first.ID {
+		t.Errorf("EventsAsOf = %v, want only expedition 1", past)
+	}
+	if _, err := (domain.AsOf{Expedition: 3}).Cutoff(events); err == nil {
+		t.Error("Cutoff of an expedition that never completed succeeded, want error")
+	}
+}
@@ -0,0 +1,336 @@
+package eventsource
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// archiveManifestName is the manifest of EventArchiveDir: one
+// ArchiveSegment per line, in archive order.
+const archiveManifestName = "segments.jsonl"
+
+// ErrArchiveChecksum reports an archived segment whose bytes no longer
+// match the checksum recorded when it was written.
+var ErrArchiveChecksum = errors.New("archive segment checksum mismatch")
+
+// EventArchiveDir returns the directory holding archived event segments
+// under stateDir. It sits beside events/ so it survives backend
+// migrations, which move events/ or events.db aside.
+func EventArchiveDir(stateDir string) string {
+	return filepath.Join(stateDir, "events-archive")
+}
+
+// ArchivedStream is what the archive holds of one stream.
+type ArchivedStream struct {
+	Head   string `json:"head,omitempty"` // hash of its last chained archived event
+	Events uint64 `json:"events"`
+}
+
+// ArchiveSegment is one manifest entry: a gzip-compressed concatenation
+// of retired daily JSONL files, kept verbatim.
+type ArchiveSegment struct { // nosemgrep: structure.multiple-exported-structs-go -- segment and its ArchivedStream summary form one manifest record [permanent]
+	Name      string    `json:"name"`
+	Files     []string  `json:"files"`
+	Lines     int       `json:"lines"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"` // of the compressed file
+	CreatedAt time.Time `json:"created_at"`
+	// Streams is cumulative over this and every earlier segment, so the
+	// stores continue archived chains and versions from the last entry.
+	Streams map[string]ArchivedStream `json:"streams"`
+}
+
+// ArchiveEventFiles moves the named daily files from {stateDir}/events/
+// into a new compressed segment of EventArchiveDir. The segment is
+// written, read back and checked against its checksum, and recorded in
+// the manifest before the files are removed, so a failure at any step
+// leaves every event readable (at worst twice; readers skip the copy).
+// Files that no longer exist are skipped. It holds the append lock of the
+// file store throughout.
+func ArchiveEventFiles(stateDir string, files []string, now time.Time) (ArchiveSegment, error) {
+	unlock, err := NewFileEventStore(EventsDir(stateDir), &domain.NopLogger{}).lockAppend()
+	if err != nil {
+		return ArchiveSegment{}, err
+	}
+	defer unlock()
+
+	names := append([]string(nil), files...)
+	sort.Strings(names)
+	seg := ArchiveSegment{CreatedAt: now.UTC()}
+	var content bytes.Buffer
+	for _, name := range names {
+		data, readErr := os.ReadFile(filepath.Join(EventsDir(stateDir), name))
+		if errors.Is(readErr, fs.ErrNotExist) {
+			continue
+		}
+		if readErr != nil {
+			return ArchiveSegment{}, fmt.Errorf("read event file %s: %w", name, readErr)
+		}
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			data = append(data, '\n')
+		}
+		content.Write(data)
+		seg.Files = append(seg.Files, name)
+	}
+	if len(seg.Files) == 0 {
+		return ArchiveSegment{}, nil
+	}
+
+	segments, err := ListArchiveSegments(stateDir)
+	if err != nil {
+		return ArchiveSegment{}, err
+	}
+	var prior map[string]ArchivedStream
+	if len(segments) > 0 {
+		prior = segments[len(segments)-1].Streams
+	}
+	events, lines := parseArchiveLines(content.Bytes())
+	seg.Lines = lines
+	if seg.Streams, err = summarizeStreams(prior, events); err != nil {
+		return ArchiveSegment{}, err
+	}
+
+	dir := EventArchiveDir(stateDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ArchiveSegment{}, fmt.Errorf("create event archive dir: %w", err)
+	}
+	seg.Name = "seg-" + now.UTC().Format("20060102T150405Z") + ".jsonl.gz"
+	for n := 2; ; n++ {
+		if _, statErr := os.Stat(filepath.Join(dir, seg.Name)); errors.Is(statErr, fs.ErrNotExist) {
+			break
+		}
+		seg.Name = fmt.Sprintf("seg-%s-%d.jsonl.gz", now.UTC().Format("20060102T150405Z"), n)
+	}
+	if seg.SHA256, seg.Size, err = writeSegment(filepath.Join(dir, seg.Name), content.Bytes()); err != nil {
+		return ArchiveSegment{}, err
+	}
+	if back, readErr := ReadArchiveSegment(stateDir, seg); readErr != nil || !bytes.Equal(back, content.Bytes()) {
+		_ = os.Remove(filepath.Join(dir, seg.Name))
+		return ArchiveSegment{}, fmt.Errorf("verify archive segment %s: %w", seg.Name, errors.Join(readErr, errors.New("content differs from the archived files")))
+	}
+	if err := appendManifest(dir, seg); err != nil {
+		_ = os.Remove(filepath.Join(dir, seg.Name))
+		return ArchiveSegment{}, err
+	}
+	if _, err := PruneEventFiles(stateDir, seg.Files); err != nil {
+		return seg, fmt.Errorf("archived into %s, but removing the daily files failed: %w", seg.Name, err)
+	}
+	return seg, nil
+}
+
+// writeSegment gzips content into path via a temporary file and returns
+// the checksum and size of the compressed bytes.
+func writeSegment(path string, content []byte) (string, int64, error) {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", 0, fmt.Errorf("create archive segment: %w", err)
+	}
+	sum := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(f, sum)}
+	zw := gzip.NewWriter(counter)
+	_, err = zw.Write(content)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return "", 0, fmt.Errorf("write archive segment %s: %w", filepath.Base(path), err)
+	}
+	return "sha256:" + hex.EncodeToString(sum.Sum(nil)), counter.n, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func appendManifest(dir string, seg ArchiveSegment) error {
+	line, err := json.Marshal(seg)
+	if err != nil {
+		return fmt.Errorf("marshal archive manifest entry: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, archiveManifestName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open archive manifest: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write archive manifest: %w", err)
+	}
+	return nil
+}
+
+// summarizeStreams adds events to the cumulative stream summary prior.
+func summarizeStreams(prior map[string]ArchivedStream, events []domain.Event) (map[string]ArchivedStream, error) {
+	streams := make(map[string]ArchivedStream, len(prior))
+	for stream, s := range prior {
+		streams[stream] = s
+	}
+	for _, ev := range events {
+		s := streams[ev.StreamID()]
+		s.Events++
+		streams[ev.StreamID()] = s
+	}
+	heads, err := domain.ChainHeads(events)
+	if err != nil {
+		return nil, err
+	}
+	for stream, head := range heads {
+		s := streams[stream]
+		s.Head = head
+		streams[stream] = s
+	}
+	return streams, nil
+}
+
+// ListArchiveSegments returns the manifest of stateDir's event archive
+// in archive order; nil when nothing was archived.
+func ListArchiveSegments(stateDir string) ([]ArchiveSegment, error) {
+	f, err := os.Open(filepath.Join(EventArchiveDir(stateDir), archiveManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open archive manifest: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	var segments []ArchiveSegment
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var seg ArchiveSegment
+		if err := json.Unmarshal(scanner.Bytes(), &seg); err != nil {
+			return nil, fmt.Errorf("archive manifest line %d: %w", n, err)
+		}
+		segments = append(segments, seg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan archive manifest: %w", err)
+	}
+	return segments, nil
+}
+
+// ReadArchiveSegment returns the decompressed JSONL of seg after checking
+// the compressed file against its recorded checksum (ErrArchiveChecksum).
+func ReadArchiveSegment(stateDir string, seg ArchiveSegment) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(EventArchiveDir(stateDir), seg.Name))
+	if err != nil {
+		return nil, fmt.Errorf("read archive segment: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if got := "sha256:" + hex.EncodeToString(sum[:]); got != seg.SHA256 {
+		return nil, fmt.Errorf("%w: %s is %s, manifest records %s", ErrArchiveChecksum, seg.Name, short(got), short(seg.SHA256))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompress archive segment %s: %w", seg.Name, err)
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("decompress archive segment %s: %w", seg.Name, err)
+	}
+	return content, nil
+}
+
+// LoadArchivedEvents decodes every archived segment of stateDir in archive
+// order, skipping (and counting) undecodable lines like the live stores.
+// A segment failing its checksum is an error: replaying around the hole
+// would silently change every projection.
+func LoadArchivedEvents(stateDir string) ([]domain.Event, domain.LoadResult, error) {
+	segments, err := ListArchiveSegments(stateDir)
+	if err != nil {
+		return nil, domain.LoadResult{}, err
+	}
+	var events []domain.Event
+	result := domain.LoadResult{FileCount: len(segments)}
+	for _, seg := range segments {
+		content, err := ReadArchiveSegment(stateDir, seg)
+		if err != nil {
+			return nil, result, err
+		}
+		decoded, lines := parseArchiveLines(content)
+		events = append(events, decoded...)
+		result.CorruptLineCount += lines - len(decoded)
+	}
+	return events, result, nil
+}
+
+// archivedStreams returns the cumulative stream summary of the archive
+// beside a store rooted at storePath (events/ or events.db), together
+// with the name of the latest segment it comes from.
+func archivedStreams(storePath string) (string, map[string]ArchivedStream, error) {
+	segments, err := ListArchiveSegments(filepath.Dir(storePath))
+	if err != nil || len(segments) == 0 {
+		return "", nil, err
+	}
+	latest := segments[len(segments)-1]
+	return latest.Name, latest.Streams, nil
+}
+
+// continueArchivedChains sets the head of every stream without live
+// chained events to its archived head, so appends extend the chain
+// across the archive boundary instead of starting a new one.
+func continueArchivedChains(heads map[string]string, archived map[string]ArchivedStream) {
+	for stream, s := range archived {
+		if _, live := heads[stream]; !live && s.Head != "" {
+			heads[stream] = s.Head
+		}
+	}
+}
+
+// parseArchiveLines decodes the non-empty lines of content and returns
+// the events together with the number of lines.
+func parseArchiveLines(content []byte) ([]domain.Event, int) {
+	var events []domain.Event
+	lines := 0
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		lines++
+		var ev domain.Event
+		if err := json.Unmarshal(line, &ev); err == nil {
+			events = append(events, ev)
+		}
+	}
+	return events, lines
+}
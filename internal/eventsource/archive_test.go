@@ -0,0 +1,90 @@
+package eventsource_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/eventsource"
+)
+
+func TestArchiveEventFiles_KeepsHistoryChainAndVersion(t *testing.T) {
+	// given: one stream spread over two daily files
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	store := eventsource.NewFileEventStore(eventsource.EventsDir(stateDir), &domain.NopLogger{})
+	agg := domain.NewExpeditionAggregate()
+	agg.SetExpeditionID("exp-1")
+	day1 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	for i, at := range []time.Time{day1, day1.AddDate(0, 0, 1)} {
+		ev, _ := agg.RecordGradientChange(i+1, 1, "charge", at)
+		if _, err := store.Append(ctx, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// when
+	seg, err := eventsource.ArchiveEventFiles(stateDir, []string{"2026-01-02.jsonl", "2026-01-01.jsonl", "2025-12-31.jsonl"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, _ := agg.RecordGradientChange(3, 1, "charge", time.Now())
+	_, appendErr := store.AppendExpecting(ctx, 2, next)
+
+	// then: the files left the live store but not the history
+	if len(seg.Files) != 2 || seg.Lines != 2 || seg.Streams[domain.GradientStreamID].Events != 2 {
+		t.Errorf("segment = %+v, want the two existing files with 2 events of %s", seg, domain.GradientStreamID)
+	}
+	if live, _, _ := store.LoadAll(ctx); len(live) != 1 {
+		t.Errorf("live events = %d, want only the event appended after archiving", len(live))
+	}
+	archived, _, err := eventsource.LoadArchivedEvents(stateDir)
+	if err != nil || len(archived) != 2 {
+		t.Errorf("archived events = %d (%v), want 2", len(archived), err)
+	}
+	if appendErr != nil {
+		t.Errorf("append expecting version 2 (archived events count) = %v", appendErr)
+	}
+	report, err := eventsource.VerifyEvents(ctx, stateDir, domain.EventStoreJSONL)
+	if err != nil || !report.OK() || report.Events != 3 {
+		t.Errorf("verify = %+v (%v), want an intact chain of 3 events across the archive", report, err)
+	}
+}
+
+func TestReadArchiveSegment_RejectsTamperedSegment(t *testing.T) {
+	// given
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	store := eventsource.NewFileEventStore(eventsource.EventsDir(stateDir), &domain.NopLogger{})
+	agg := domain.NewExpeditionAggregate()
+	ev, _ := agg.RecordGradientChange(1, 1, "charge", time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local))
+	if _, err := store.Append(ctx, ev); err != nil {
+		t.Fatal(err)
+	}
+	seg, err := eventsource.ArchiveEventFiles(stateDir, []string{"2026-01-01.jsonl"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(eventsource.EventArchiveDir(stateDir), seg.Name)
+	data, _ := os.ReadFile(path)
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	_, _, loadErr := eventsource.LoadArchivedEvents(stateDir)
+	report, verifyErr := eventsource.VerifyEvents(ctx, stateDir, domain.EventStoreJSONL)
+
+	// then
+	if !errors.Is(loadErr, eventsource.ErrArchiveChecksum) {
+		t.Errorf("load err = %v, want ErrArchiveChecksum", loadErr)
+	}
+	if verifyErr != nil || len(report.Problems) != 1 || report.Problems[0].Kind != eventsource.ProblemCorrupt {
+		t.Errorf("verify = %+v (%v), want one corrupt segment", report.Problems, verifyErr)
+	}
+}
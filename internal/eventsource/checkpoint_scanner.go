@@ -1,6 +1,7 @@
 package eventsource
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -32,9 +33,7 @@ func (s *CheckpointScanner) FindIncompleteCheckpoints() []domain.ExpeditionCheck
 		return files[i].Name() < files[j].Name()
 	})
 
-	checkpoints := make(map[int]domain.ExpeditionCheckpointData)
-	completed := make(map[int]bool)
-
+	var events []domain.Event
 	for _, f := range files {
 		if !strings.HasSuffix(f.Name(), ".jsonl") {
 			continue
@@ -52,17 +51,49 @@ func (s *CheckpointScanner) FindIncompleteCheckpoints() []domain.ExpeditionCheck
 			if jsonErr := json.Unmarshal([]byte(line), &ev); jsonErr != nil {
 				continue
 			}
-			switch ev.Type {
-			case domain.EventExpeditionCheckpoint:
-				var cpData domain.ExpeditionCheckpointData
-				if jsonErr := json.Unmarshal(ev.Data, &cpData); jsonErr == nil {
-					checkpoints[cpData.Expedition] = cpData
-				}
-			case domain.EventExpeditionCompleted:
-				var compData domain.ExpeditionCompletedData
-				if jsonErr := json.Unmarshal(ev.Data, &compData); jsonErr == nil {
-					completed[compData.Expedition] = true
-				}
+			events = append(events, ev)
+		}
+	}
+	return incompleteCheckpoints(events)
+}
+
+// SQLiteCheckpointScanner finds incomplete checkpoints in a SQLiteEventStore.
+type SQLiteCheckpointScanner struct {
+	store *SQLiteEventStore
+}
+
+// NewSQLiteCheckpointScanner creates a scanner over the events.db under stateDir.
+func NewSQLiteCheckpointScanner(stateDir string) *SQLiteCheckpointScanner {
+	return &SQLiteCheckpointScanner{store: NewSQLiteEventStore(EventsDBPath(stateDir), nil)}
+}
+
+// FindIncompleteCheckpoints returns checkpoint data for expeditions that have
+// a checkpoint event but no subsequent expedition.completed event. Like the
+// JSONL scanner it is best-effort: an unreadable database yields nil.
+func (s *SQLiteCheckpointScanner) FindIncompleteCheckpoints() []domain.ExpeditionCheckpointData {
+	events, _, err := s.store.LoadAll(context.Background())
+	if err != nil {
+		return nil
+	}
+	return incompleteCheckpoints(events)
+}
+
+// incompleteCheckpoints keeps the latest checkpoint per expedition and
+// drops expeditions with an expedition.completed event.
+func incompleteCheckpoints(events []domain.Event) []domain.ExpeditionCheckpointData {
+	checkpoints := make(map[int]domain.ExpeditionCheckpointData)
+	completed := make(map[int]bool)
+	for _, ev := range events {
+		switch ev.Type {
+		case domain.EventExpeditionCheckpoint:
+			var cpData domain.ExpeditionCheckpointData
+			if jsonErr := json.Unmarshal(ev.Data, &cpData); jsonErr == nil {
+				checkpoints[cpData.Expedition] = cpData
+			}
+		case domain.EventExpeditionCompleted:
+			var compData domain.ExpeditionCompletedData
+			if jsonErr := json.Unmarshal(ev.Data, &compData); jsonErr == nil {
+				completed[compData.Expedition] = true
 			}
 		}
 	}
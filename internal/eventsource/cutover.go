@@ -15,6 +15,13 @@ type CutoverResult struct {
 	CutoverSeqNr uint64 // the SeqNr assigned to the cutover event (1 if performed)
 }
 
+// CutoverStore is the part of an event store RunCutover uses; both
+// FileEventStore and SQLiteEventStore satisfy it.
+type CutoverStore interface { // nosemgrep: structure.exported-struct-and-interface-go -- RunCutover's input port co-locates with its CutoverResult [permanent]
+	LoadAll(ctx context.Context) ([]domain.Event, domain.LoadResult, error)
+	Append(ctx context.Context, events ...domain.Event) (domain.AppendResult, error)
+}
+
 // RunCutover performs a one-time migration from legacy (no global SeqNr) to
 // the new snapshot-based event sourcing model. It is idempotent — running it
 // on an already-cutover system is a no-op.
@@ -27,7 +34,7 @@ type CutoverResult struct {
 //  5. Emit a system.cutover event with SeqNr=1
 func RunCutover(
 	ctx context.Context,
-	store *FileEventStore,
+	store CutoverStore,
 	snapshotStore *FileSnapshotStore,
 	seqCounter *SeqCounter,
 	aggregateType string,
@@ -0,0 +1,14 @@
+//go:build windows
+
+package eventsource
+
+// flockLock is a no-op on Windows. Concurrent appenders there may fork a
+// hash chain stream; `paintress events verify` reports such forks.
+func flockLock(_ uintptr) error {
+	return nil
+}
+
+// flockUnlock is a no-op on Windows.
+func flockUnlock(_ uintptr) error {
+	return nil
+}
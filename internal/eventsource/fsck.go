@@ -0,0 +1,457 @@
+package eventsource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// Kinds of FsckIssue reported by FsckEvents.
+const (
+	FsckUndecodable = "undecodable"  // line or row that is not a JSON event
+	FsckInvalid     = "invalid"      // decodes, but domain.ParseEvent rejects it
+	FsckDuplicate   = "duplicate_id" // later copy of an already stored event ID
+	FsckMisfiled    = "misfiled"     // JSONL event stored in another day's file
+	FsckSeqAhead    = "seq_ahead"    // SeqNr the SeqCounter has not allocated yet
+)
+
+// FsckIssue is one finding of FsckEvents. Action is what --repair does
+// (or did) about it; "" means it is reported only.
+type FsckIssue struct {
+	Kind    string `json:"kind"`
+	EventID string `json:"event_id,omitempty"`
+	Where   string `json:"where"` // file:line (jsonl) or pos N (sqlite)
+	Detail  string `json:"detail"`
+	Action  string `json:"action,omitempty"`
+}
+
+// SeqRange is an inclusive range of SeqNrs.
+type SeqRange struct { // nosemgrep: structure.multiple-exported-structs-go -- fsck report parts form one result type [permanent]
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+// FsckReport is the result of FsckEvents. SeqGaps are SeqNrs the
+// SeqCounter allocated that no stored or archived event carries; an
+// append that failed after allocating (a rejected expected version, a
+// crash) leaves one, so they are informational.
+type FsckReport struct { // nosemgrep: structure.multiple-exported-structs-go -- fsck report parts form one result type [permanent]
+	Backend      string      `json:"backend"`
+	Events       int         `json:"events"`
+	CounterSeqNr uint64      `json:"counter_seq_nr"`
+	Issues       []FsckIssue `json:"issues"`
+	SeqGaps      []SeqRange  `json:"seq_gaps"`
+	Repaired     bool        `json:"repaired"`
+	Quarantine   string      `json:"quarantine,omitempty"` // file the repair moved lines into
+}
+
+// Unresolved returns the number of issues still in the store: all of
+// them, or after a repair those it does not act on.
+func (r FsckReport) Unresolved() int {
+	if !r.Repaired {
+		return len(r.Issues)
+	}
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Action == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// QuarantinedLine is one line of a quarantine file: a stored line or
+// row fsck removed from the store, with where it came from and why.
+type QuarantinedLine struct { // nosemgrep: structure.multiple-exported-structs-go -- fsck report parts form one result type [permanent]
+	Origin string `json:"origin"` // daily file name, or events.db
+	Line   int64  `json:"line"`   // 1-based line number, or row pos
+	Reason string `json:"reason"`
+	Raw    string `json:"raw"`
+}
+
+// QuarantineDir returns the directory fsck moves removed lines into.
+func QuarantineDir(stateDir string) string {
+	return filepath.Join(EventsDir(stateDir), "quarantine")
+}
+
+// fsckRecord is one stored line or row under examination.
+type fsckRecord struct {
+	origin string // daily file name; "" for SQLite
+	line   int64  // line number or pos
+	raw    []byte
+	where  string
+	remove string // quarantine reason; "drop" for duplicates
+	moveTo string // destination daily file
+}
+
+// FsckEvents checks backend's stored events under stateDir line by line:
+// lines that do not decode or fail domain.ParseEvent, later copies of an
+// event ID (archived events count as stored), JSONL events whose
+// timestamp belongs in another daily file, and SeqNrs against seq.db
+// (gaps, and SeqNrs past the counter). With repair, undecodable and
+// invalid lines move into a quarantine file under QuarantineDir with
+// their origin, duplicates are dropped and misfiled events move to their
+// daily file; gaps and SeqNrs past the counter are only reported. The
+// file store's append lock is held throughout.
+func FsckEvents(ctx context.Context, stateDir, backend string, repair bool, now time.Time) (FsckReport, error) { // nosemgrep: domain-primitives.multiple-string-params-go -- stateDir/backend are semantically distinct [permanent]
+	report := FsckReport{Backend: backend, Issues: []FsckIssue{}, SeqGaps: []SeqRange{}}
+	unlock, err := NewFileEventStore(EventsDir(stateDir), &domain.NopLogger{}).lockAppend()
+	if err != nil {
+		return report, err
+	}
+	defer unlock()
+
+	var records []*fsckRecord
+	switch backend {
+	case domain.EventStoreJSONL:
+		records, err = readFsckLines(EventsDir(stateDir))
+	case domain.EventStoreSQLite:
+		records, err = readFsckRows(ctx, EventsDBPath(stateDir))
+	default:
+		_, err = openBackend(stateDir, backend, nil)
+	}
+	if err != nil {
+		return report, err
+	}
+	archived, _, err := LoadArchivedEvents(stateDir)
+	if err != nil {
+		return report, err
+	}
+
+	firstWhere := make(map[string]string)
+	seqs := make(map[uint64]bool)
+	for _, ev := range archived {
+		firstWhere[ev.ID] = "the event archive"
+		seqs[ev.SeqNr] = true
+	}
+	var stored []fsckRecordEvent
+	for _, rec := range records {
+		var ev domain.Event
+		if jsonErr := json.Unmarshal(rec.raw, &ev); jsonErr != nil {
+			rec.remove = jsonErr.Error()
+			report.Issues = append(report.Issues, FsckIssue{Kind: FsckUndecodable, Where: rec.where, Detail: jsonErr.Error(), Action: "quarantine"})
+			continue
+		}
+		if _, parseErr := domain.ParseEvent(ev); parseErr != nil {
+			rec.remove = parseErr.Error()
+			report.Issues = append(report.Issues, FsckIssue{Kind: FsckInvalid, EventID: ev.ID, Where: rec.where, Detail: parseErr.Error(), Action: "quarantine"})
+			continue
+		}
+		if where, dup := firstWhere[ev.ID]; dup {
+			rec.remove = "drop"
+			report.Issues = append(report.Issues, FsckIssue{Kind: FsckDuplicate, EventID: ev.ID, Where: rec.where, Detail: "also stored at " + where, Action: "drop"})
+			continue
+		}
+		firstWhere[ev.ID] = rec.where
+		report.Events++
+		seqs[ev.SeqNr] = true
+		stored = append(stored, fsckRecordEvent{rec: rec, ev: ev})
+		if want := ev.Timestamp.Format("2006-01-02") + ".jsonl"; rec.origin != "" && isDailyFile(rec.origin) && rec.origin != want {
+			rec.moveTo = want
+			report.Issues = append(report.Issues, FsckIssue{Kind: FsckMisfiled, EventID: ev.ID, Where: rec.where,
+				Detail: fmt.Sprintf("timestamp %s belongs in %s", ev.Timestamp.Format(time.RFC3339), want), Action: "move to " + want})
+		}
+	}
+
+	if latest, ok := counterSeqNr(ctx, stateDir); ok {
+		report.CounterSeqNr = latest
+		report.SeqGaps = seqGaps(seqs, latest)
+		for _, se := range stored {
+			if se.ev.SeqNr > latest {
+				report.Issues = append(report.Issues, FsckIssue{Kind: FsckSeqAhead, EventID: se.ev.ID, Where: se.rec.where,
+					Detail: fmt.Sprintf("SeqNr %d is past the counter's latest allocation %d; later allocations will repeat it", se.ev.SeqNr, latest)})
+			}
+		}
+	}
+
+	if !repair || !needsRepair(records) {
+		return report, nil
+	}
+	if report.Quarantine, err = writeQuarantine(stateDir, records, now); err != nil {
+		return report, err
+	}
+	switch backend {
+	case domain.EventStoreJSONL:
+		err = repairFiles(EventsDir(stateDir), records)
+	case domain.EventStoreSQLite:
+		err = repairRows(ctx, EventsDBPath(stateDir), records)
+	}
+	if err != nil {
+		return report, fmt.Errorf("repair %s store (removed lines are kept in %s): %w", backend, report.Quarantine, err)
+	}
+	report.Repaired = true
+	return report, nil
+}
+
+type fsckRecordEvent struct {
+	rec *fsckRecord
+	ev  domain.Event
+}
+
+func needsRepair(records []*fsckRecord) bool {
+	for _, rec := range records {
+		if rec.remove != "" || rec.moveTo != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// readFsckLines reads every non-empty line of the .jsonl files in dir,
+// in file name and line order.
+func readFsckLines(dir string) ([]*fsckRecord, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read event store dir: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".jsonl") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+	var records []*fsckRecord
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		for i, line := range bytes.Split(data, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			records = append(records, &fsckRecord{origin: name, line: int64(i + 1), raw: line, where: fmt.Sprintf("%s:%d", name, i+1)})
+		}
+	}
+	return records, nil
+}
+
+// readFsckRows reads every row of events.db in pos order.
+func readFsckRows(ctx context.Context, dbPath string) ([]*fsckRecord, error) {
+	db, err := openEventsDB(dbPath, false)
+	if err != nil || db == nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+	rows, err := db.QueryContext(ctx, `SELECT pos, event FROM events ORDER BY pos`)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite event store: query: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var records []*fsckRecord
+	for rows.Next() {
+		var pos int64
+		var line string
+		if err := rows.Scan(&pos, &line); err != nil {
+			return nil, fmt.Errorf("sqlite event store: scan: %w", err)
+		}
+		records = append(records, &fsckRecord{line: pos, raw: []byte(line), where: fmt.Sprintf("pos %d", pos)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite event store: rows: %w", err)
+	}
+	return records, nil
+}
+
+// counterSeqNr reads seq.db without creating it.
+func counterSeqNr(ctx context.Context, stateDir string) (uint64, bool) {
+	path := filepath.Join(stateDir, "seq.db")
+	if _, err := os.Stat(path); err != nil {
+		return 0, false
+	}
+	counter, err := NewSeqCounter(path)
+	if err != nil {
+		return 0, false
+	}
+	defer func() { _ = counter.Close() }()
+	latest, err := counter.LatestSeqNr(ctx)
+	return latest, err == nil
+}
+
+// seqGaps returns the ranges of 1..latest missing from seqs.
+func seqGaps(seqs map[uint64]bool, latest uint64) []SeqRange {
+	gaps := []SeqRange{}
+	for n := uint64(1); n <= latest; n++ {
+		if seqs[n] {
+			continue
+		}
+		if len(gaps) > 0 && gaps[len(gaps)-1].To == n-1 {
+			gaps[len(gaps)-1].To = n
+			continue
+		}
+		gaps = append(gaps, SeqRange{From: n, To: n})
+	}
+	return gaps
+}
+
+// writeQuarantine records every line the repair removes (duplicates
+// included) in a new quarantine file, synced before the store changes.
+func writeQuarantine(stateDir string, records []*fsckRecord, now time.Time) (string, error) {
+	var buf bytes.Buffer
+	for _, rec := range records {
+		if rec.remove == "" {
+			continue
+		}
+		origin := rec.origin
+		if origin == "" {
+			origin = filepath.Base(EventsDBPath(stateDir))
+		}
+		reason := rec.remove
+		if reason == "drop" {
+			reason = "duplicate event ID"
+		}
+		line, err := json.Marshal(QuarantinedLine{Origin: origin, Line: rec.line, Reason: reason, Raw: string(rec.raw)})
+		if err != nil {
+			return "", err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	if buf.Len() == 0 {
+		return "", nil
+	}
+	dir := QuarantineDir(stateDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create quarantine dir: %w", err)
+	}
+	path := filepath.Join(dir, "fsck-"+now.UTC().Format("20060102T150405Z")+".jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("open quarantine file: %w", err)
+	}
+	_, err = f.Write(buf.Bytes())
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("write quarantine file: %w", err)
+	}
+	return path, nil
+}
+
+// repairFiles rewrites the daily files the records change. Files gaining
+// moved events are written before the files losing them, so an
+// interruption leaves a duplicate (which the next fsck drops) rather
+// than a lost event.
+func repairFiles(dir string, records []*fsckRecord) error {
+	content := make(map[string][][]byte)
+	changed := make(map[string]bool)
+	gaining := make(map[string]bool)
+	var order []string
+	for _, rec := range records {
+		if _, seen := content[rec.origin]; !seen {
+			content[rec.origin] = [][]byte{}
+			order = append(order, rec.origin)
+		}
+		switch {
+		case rec.remove != "":
+			changed[rec.origin] = true
+		case rec.moveTo != "":
+			changed[rec.origin], changed[rec.moveTo], gaining[rec.moveTo] = true, true, true
+		default:
+			content[rec.origin] = append(content[rec.origin], rec.raw)
+		}
+	}
+	for _, rec := range records {
+		if rec.remove == "" && rec.moveTo != "" {
+			if _, seen := content[rec.moveTo]; !seen {
+				order = append(order, rec.moveTo)
+			}
+			content[rec.moveTo] = append(content[rec.moveTo], rec.raw)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return gaining[order[i]] && !gaining[order[j]] })
+	for _, name := range order {
+		if !changed[name] {
+			continue
+		}
+		if err := rewriteEventFile(filepath.Join(dir, name), content[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteEventFile atomically replaces path with lines; an empty result
+// removes the file.
+func rewriteEventFile(path string, lines [][]byte) error {
+	if len(lines) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove %s: %w", filepath.Base(path), err)
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("create tmp file for %s: %w", filepath.Base(path), err)
+	}
+	_, err = f.Write(buf.Bytes())
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rewrite %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// repairRows deletes the removed rows of events.db in one transaction.
+func repairRows(ctx context.Context, dbPath string, records []*fsckRecord) error {
+	db, err := openEventsDB(dbPath, false)
+	if err != nil || db == nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite event store: begin: %w", err)
+	}
+	for _, rec := range records {
+		if rec.remove == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM events WHERE pos = ?`, rec.line); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("sqlite event store: delete pos %d: %w", rec.line, err)
+		}
+	}
+	// Removed rows leave the stream index behind; the next append
+	// rebuilds it.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM streams_indexed`); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("sqlite event store: invalidate stream index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite event store: commit: %w", err)
+	}
+	return nil
+}
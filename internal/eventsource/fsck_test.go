@@ -0,0 +1,146 @@
+package eventsource_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/eventsource"
+)
+
+func fsckEvent(t *testing.T, expedition int, at time.Time, seq uint64) domain.Event {
+	t.Helper()
+	ev, err := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: expedition}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev.SeqNr = seq
+	return ev
+}
+
+func jsonLine(t *testing.T, ev domain.Event) string {
+	t.Helper()
+	data, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func issueKinds(report eventsource.FsckReport) []string {
+	var kinds []string
+	for _, issue := range report.Issues {
+		kinds = append(kinds, issue.Kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func TestFsckEvents_JSONLReportsThenRepairs(t *testing.T) {
+	// given: day 1 holds a good event, garbage and an invalid event; day 2
+	// a duplicate of the good event and an event timestamped on day 3
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	eventsDir := eventsource.EventsDir(stateDir)
+	if err := os.MkdirAll(eventsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	day1 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	good := fsckEvent(t, 1, day1, 1)
+	misfiled := fsckEvent(t, 2, day1.AddDate(0, 0, 2), 2)
+	ahead := fsckEvent(t, 3, day1.Add(time.Minute), 9)
+	files := map[string]string{
+		"2026-03-01.jsonl": jsonLine(t, good) + "\nnot json\n" + `{"id":"x","type":"expedition.started"}` + "\n" + jsonLine(t, ahead) + "\n",
+		"2026-03-02.jsonl": jsonLine(t, good) + "\n" + jsonLine(t, misfiled) + "\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(eventsDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	counter, err := eventsource.NewSeqCounter(filepath.Join(stateDir, "seq.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		if _, err := counter.AllocSeqNr(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = counter.Close()
+
+	// when
+	checked, checkErr := eventsource.FsckEvents(ctx, stateDir, domain.EventStoreJSONL, false, time.Now())
+	repaired, repairErr := eventsource.FsckEvents(ctx, stateDir, domain.EventStoreJSONL, true, time.Now())
+
+	// then: the check reports without touching the store
+	if checkErr != nil || repairErr != nil {
+		t.Fatalf("fsck: %v / %v", checkErr, repairErr)
+	}
+	if got := strings.Join(issueKinds(checked), ","); got != "duplicate_id,invalid,misfiled,seq_ahead,undecodable" {
+		t.Errorf("issues = %s", got)
+	}
+	if len(checked.SeqGaps) != 1 || checked.SeqGaps[0] != (eventsource.SeqRange{From: 3, To: 5}) || checked.Repaired {
+		t.Errorf("gaps = %v repaired = %v, want SeqNrs 3-5 unrepaired", checked.SeqGaps, checked.Repaired)
+	}
+	// then: the repair quarantines with origins and leaves only seq_ahead
+	if !repaired.Repaired || repaired.Unresolved() != 1 {
+		t.Errorf("repaired = %v unresolved = %d, want 1 (seq_ahead)", repaired.Repaired, repaired.Unresolved())
+	}
+	f, err := os.Open(repaired.Quarantine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var origins []string
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		var q eventsource.QuarantinedLine
+		_ = json.Unmarshal(scanner.Bytes(), &q)
+		origins = append(origins, fmt.Sprintf("%s:%d", q.Origin, q.Line))
+	}
+	if strings.Join(origins, ",") != "2026-03-01.jsonl:2,2026-03-01.jsonl:3,2026-03-02.jsonl:1" {
+		t.Errorf("quarantined = %v, want the garbage, the invalid line and the duplicate", origins)
+	}
+	events, result, err := eventsource.NewFileEventStore(eventsDir, &domain.NopLogger{}).LoadAll(ctx)
+	if err != nil || result.CorruptLineCount != 0 || len(events) != 3 {
+		t.Errorf("after repair: %d events, %d corrupt (%v), want 3 clean", len(events), result.CorruptLineCount, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(eventsDir, "2026-03-03.jsonl")); !strings.Contains(string(data), misfiled.ID) {
+		t.Errorf("misfiled event not moved to its daily file")
+	}
+	if again, _ := eventsource.FsckEvents(ctx, stateDir, domain.EventStoreJSONL, false, time.Now()); strings.Join(issueKinds(again), ",") != "seq_ahead" {
+		t.Errorf("second fsck issues = %v, want only seq_ahead", issueKinds(again))
+	}
+}
+
+func TestFsckEvents_SQLiteDropsDuplicateRows(t *testing.T) {
+	// given: the same event stored twice
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	ev := fsckEvent(t, 1, time.Now(), 0)
+	store := eventsource.NewSQLiteEventStore(eventsource.EventsDBPath(stateDir), &domain.NopLogger{})
+	for range 2 {
+		if _, err := store.Append(ctx, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// when
+	report, err := eventsource.FsckEvents(ctx, stateDir, domain.EventStoreSQLite, true, time.Now())
+
+	// then
+	if err != nil || !report.Repaired || strings.Join(issueKinds(report), ",") != "duplicate_id" || report.Issues[0].Where != "pos 2" {
+		t.Fatalf("report = %+v (%v), want pos 2 dropped as a duplicate", report, err)
+	}
+	if events, _, _ := store.LoadAll(ctx); len(events) != 1 {
+		t.Errorf("events after repair = %d, want 1", len(events))
+	}
+}
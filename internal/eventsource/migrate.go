@@ -0,0 +1,195 @@
+package eventsource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// eventBackend is the replay surface MigrateEvents reads both
+// FileEventStore and SQLiteEventStore through.
+type eventBackend interface {
+	LoadAll(ctx context.Context) ([]domain.Event, domain.LoadResult, error)
+}
+
+// openBackend returns the store for backend under stateDir.
+func openBackend(stateDir, backend string, logger domain.Logger) (eventBackend, error) {
+	switch backend {
+	case domain.EventStoreJSONL:
+		return NewFileEventStore(EventsDir(stateDir), logger), nil
+	case domain.EventStoreSQLite:
+		return NewSQLiteEventStore(EventsDBPath(stateDir), logger), nil
+	default:
+		return nil, fmt.Errorf("unknown event store backend %q (want %s or %s)", backend, domain.EventStoreJSONL, domain.EventStoreSQLite)
+	}
+}
+
+// MigrateEvents copies every event of the from backend under stateDir
+// into the empty to backend, in replay order and without re-validation,
+// then reloads the destination and checks it matches the source field for
+// field, removing the copy again if it does not. The source is left
+// untouched (see BackupEvents). It refuses to run when the source has
+// corrupt lines, which cannot be carried over, or when the destination
+// already holds data. Returns the number of events copied.
+func MigrateEvents(ctx context.Context, stateDir, from, to string, logger domain.Logger) (int, error) { // nosemgrep: domain-primitives.multiple-string-params-go -- stateDir/from/to are semantically distinct [permanent]
+	if from == to {
+		return 0, fmt.Errorf("event store already uses %s", to)
+	}
+	src, err := openBackend(stateDir, from, logger)
+	if err != nil {
+		return 0, err
+	}
+	dst, err := openBackend(stateDir, to, logger)
+	if err != nil {
+		return 0, err
+	}
+	if present, err := backendPresent(stateDir, to); err != nil {
+		return 0, err
+	} else if present {
+		return 0, fmt.Errorf("destination %s event store is not empty; move %s aside first", to, backendPath(stateDir, to))
+	}
+
+	events, result, err := src.LoadAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("load %s events: %w", from, err)
+	}
+	if result.CorruptLineCount > 0 {
+		return 0, fmt.Errorf("%d corrupt event line(s) in the %s store cannot be migrated losslessly; repair them first", result.CorruptLineCount, from)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	switch d := dst.(type) {
+	case *FileEventStore:
+		_, err = d.write(events)
+	case *SQLiteEventStore:
+		_, err = d.write(ctx, events)
+	}
+	if err == nil {
+		var copied []domain.Event
+		if copied, _, err = dst.LoadAll(ctx); err == nil {
+			err = sameEvents(events, copied)
+		}
+	}
+	if err != nil {
+		// The destination was empty before; do not leave a partial copy.
+		return 0, errors.Join(fmt.Errorf("copy to %s: %w", to, err), RemoveEvents(stateDir, to))
+	}
+	return len(events), nil
+}
+
+// sameEvents compares two replays by their JSON encoding, which covers
+// every persisted field.
+func sameEvents(want, got []domain.Event) error {
+	if len(want) != len(got) {
+		return fmt.Errorf("copied %d events, read back %d", len(want), len(got))
+	}
+	for i := range want {
+		a, errA := json.Marshal(want[i])
+		b, errB := json.Marshal(got[i])
+		if err := errors.Join(errA, errB); err != nil {
+			return err
+		}
+		if !bytes.Equal(a, b) {
+			return fmt.Errorf("event %d (%s) differs after copy", i, want[i].ID)
+		}
+	}
+	return nil
+}
+
+// backendPath is the directory (jsonl) or database file (sqlite) holding
+// backend's events.
+func backendPath(stateDir, backend string) string {
+	if backend == domain.EventStoreSQLite {
+		return EventsDBPath(stateDir)
+	}
+	return EventsDir(stateDir)
+}
+
+// backendPresent reports whether backend already holds persisted data:
+// any .jsonl file in events/, or an existing events.db.
+func backendPresent(stateDir, backend string) (bool, error) {
+	if backend == domain.EventStoreSQLite {
+		_, err := os.Stat(EventsDBPath(stateDir))
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	entries, err := os.ReadDir(EventsDir(stateDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".jsonl") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// BackupEvents moves backend's data aside to a timestamped sibling
+// (events.bak-<UTC stamp>/ or events.db.bak-<UTC stamp>, including the
+// WAL side files) so a migrated-away store is kept but no longer read.
+// Returns the backup path, or "" when there was nothing to move.
+func BackupEvents(stateDir, backend string, now time.Time) (string, error) {
+	path := backendPath(stateDir, backend)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	backup := path + ".bak-" + now.UTC().Format("20060102T150405Z")
+	if err := os.Rename(path, backup); err != nil {
+		return "", fmt.Errorf("back up %s: %w", path, err)
+	}
+	if backend == domain.EventStoreSQLite {
+		for _, suffix := range []string{"-wal", "-shm"} {
+			if err := os.Rename(path+suffix, backup+suffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return backup, fmt.Errorf("back up %s%s: %w", path, suffix, err)
+			}
+		}
+	}
+	return backup, nil
+}
+
+// RemoveEvents deletes backend's data: the .jsonl files, or the database.
+// Migration uses it to roll back a destination it filled.
+func RemoveEvents(stateDir, backend string) error {
+	path := backendPath(stateDir, backend)
+	if backend == domain.EventStoreSQLite {
+		for _, p := range []string{path, path + "-wal", path + "-shm"} {
+			if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		return nil
+	}
+	entries, err := os.ReadDir(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(path, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
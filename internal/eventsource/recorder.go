@@ -13,6 +13,7 @@ import (
 // (prohibited by semgrep). FileEventStore satisfies this via duck typing.
 type eventStore interface {
 	Append(ctx context.Context, events ...domain.Event) (domain.AppendResult, error)
+	AppendAllocating(ctx context.Context, expected domain.ExpectedVersion, alloc func(context.Context) (uint64, error), events ...domain.Event) (domain.AppendResult, error)
 	LoadAll(ctx context.Context) ([]domain.Event, domain.LoadResult, error)
 }
 
@@ -53,7 +54,8 @@ func NewSessionRecorder(ctx context.Context, store eventStore, sessionID string,
 }
 
 // SetSeqCounter attaches a SeqCounter for global SeqNr allocation.
-// When set, Record() assigns a monotonic SeqNr to each event before persistence.
+// When set, Record() has the store assign a monotonic SeqNr to each event
+// under its append lock.
 func (r *SessionRecorder) SetSeqCounter(sc *SeqCounter) {
 	r.seqCounter = sc
 }
@@ -69,14 +71,11 @@ func (r *SessionRecorder) Record(ctx context.Context, ev domain.Event) error {
 	if r.prevID != "" {
 		ev.CausationID = r.prevID
 	}
+	var alloc func(context.Context) (uint64, error)
 	if r.seqCounter != nil {
-		seq, err := r.seqCounter.AllocSeqNr(ctx)
-		if err != nil {
-			return fmt.Errorf("alloc seq nr: %w", err)
-		}
-		ev.SeqNr = seq
+		alloc = r.seqCounter.AllocSeqNr
 	}
-	if _, err := r.store.Append(ctx, ev); err != nil {
+	if _, err := r.store.AppendAllocating(ctx, domain.AnyVersion, alloc, ev); err != nil {
 		return err
 	}
 	r.prevID = ev.ID
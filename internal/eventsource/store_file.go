@@ -30,13 +30,108 @@ func NewFileEventStore(dir string, logger domain.Logger) *FileEventStore {
 
 // Append persists events as JSONL lines to the daily file based on each event's timestamp.
 // All events are validated before any writes occur; if any event is invalid, the entire batch is rejected.
-func (s *FileEventStore) Append(_ context.Context, events ...domain.Event) (domain.AppendResult, error) {
-	for _, ev := range events {
-		if _, err := domain.ParseEvent(ev); err != nil {
+// Events are written upcast to the current schema version of their type and
+// linked onto their stream's hash chain (PrevHash).
+func (s *FileEventStore) Append(ctx context.Context, events ...domain.Event) (domain.AppendResult, error) {
+	return s.AppendExpecting(ctx, domain.AnyVersion, events...)
+}
+
+// AppendExpecting is Append with an expected stream version, checked
+// under the same lock as the write (see domain.CheckExpectedVersion).
+func (s *FileEventStore) AppendExpecting(ctx context.Context, expected domain.ExpectedVersion, events ...domain.Event) (domain.AppendResult, error) {
+	return s.AppendAllocating(ctx, expected, nil, events...)
+}
+
+// AppendAllocating is AppendExpecting that takes the events' SeqNrs from
+// alloc while holding the append lock.
+func (s *FileEventStore) AppendAllocating(ctx context.Context, expected domain.ExpectedVersion, alloc func(context.Context) (uint64, error), events ...domain.Event) (domain.AppendResult, error) {
+	parsed := make([]domain.Event, len(events))
+	for i, ev := range events {
+		up, err := domain.ParseEvent(ev)
+		if err != nil {
 			return domain.AppendResult{}, fmt.Errorf("validate event %s: %w", ev.ID, err)
 		}
+		parsed[i] = up
+	}
+
+	unlock, err := s.lockAppend()
+	if err != nil {
+		return domain.AppendResult{}, err
+	}
+	defer unlock()
+	idx, err := s.streamIndex()
+	if err != nil {
+		return domain.AppendResult{}, err
+	}
+	if err := domain.CheckExpectedVersion(parsed, expected, idx.version); err != nil {
+		return domain.AppendResult{}, err
+	}
+	seqs, err := allocSeqNrs(ctx, parsed, alloc)
+	if err != nil {
+		return domain.AppendResult{}, err
+	}
+	heads := idx.heads()
+	chained, err := domain.ChainEvents(parsed, heads)
+	if err != nil {
+		return domain.AppendResult{}, err
+	}
+	result, err := s.write(chained)
+	if err != nil {
+		return domain.AppendResult{}, err
+	}
+	result.SeqNrs = seqs
+	idx.apply(chained, heads)
+	idx.noteFiles(chained)
+	if err := s.saveStreamIndex(idx); err != nil {
+		// The events are stored; the next append rebuilds the index.
+		s.logger.Warn("event store: %v", err)
+	}
+	return result, nil
+}
+
+// allocSeqNrs stamps events with SeqNrs from alloc, if any, and returns
+// them. Both stores call it inside their append critical section, so
+// concurrent writers store SeqNrs in allocation order.
+func allocSeqNrs(ctx context.Context, events []domain.Event, alloc func(context.Context) (uint64, error)) ([]uint64, error) {
+	if alloc == nil {
+		return nil, nil
+	}
+	seqs := make([]uint64, len(events))
+	for i := range events {
+		seq, err := alloc(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("alloc seq nr: %w", err)
+		}
+		events[i].SeqNr = seq
+		seqs[i] = seq
 	}
+	return seqs, nil
+}
 
+// lockAppend takes the cross-process append lock (events/.append.lock), so
+// concurrent appenders read each stream's chain head and extend it one
+// at a time, and the stream index (events/.streams.json) with it. The returned func releases it.
+func (s *FileEventStore) lockAppend() (func(), error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create event store dir: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, ".append.lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open append lock: %w", err)
+	}
+	if err := flockLock(f.Fd()); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("acquire append lock: %w", err)
+	}
+	return func() {
+		_ = flockUnlock(f.Fd())
+		_ = f.Close()
+	}, nil
+}
+
+// write appends events without validation. Migration uses it to copy
+// events verbatim from another backend.
+func (s *FileEventStore) write(events []domain.Event) (domain.AppendResult, error) {
 	if err := os.MkdirAll(s.dir, 0o755); err != nil {
 		return domain.AppendResult{}, fmt.Errorf("create event store dir: %w", err)
 	}
@@ -89,14 +184,30 @@ func (s *FileEventStore) LoadSince(_ context.Context, after time.Time) ([]domain
 	return s.loadEvents(after)
 }
 
+// StreamVersion returns the number of stored and archived events of
+// stream, read from the stream index (see streamIndex).
+func (s *FileEventStore) StreamVersion(_ context.Context, stream string) (uint64, error) {
+	idx, err := s.streamIndex()
+	if err != nil {
+		return 0, err
+	}
+	return idx.version(stream), nil
+}
+
 // LoadAfterSeqNr returns all events with SeqNr > afterSeqNr, ordered by SeqNr ascending.
 // Only events with globally-allocated SeqNr (assigned by SeqCounter after cutover)
 // are included. Pre-cutover events may carry aggregate-local SeqNr values that
 // overlap with global SeqNr space; callers must use afterSeqNr >= cutover SeqNr
 // to avoid double-replaying legacy events.
 // Events with SeqNr == 0 are always excluded.
+// While the stream index is current, only files holding a SeqNr above
+// afterSeqNr are read.
 func (s *FileEventStore) LoadAfterSeqNr(_ context.Context, afterSeqNr uint64) ([]domain.Event, domain.LoadResult, error) {
-	all, result, err := s.loadEvents(time.Time{})
+	keep := func(string) bool { return true }
+	if idx, ok := s.currentStreamIndex(); ok {
+		keep = func(name string) bool { return idx.MaxSeqNr[name] > afterSeqNr }
+	}
+	all, result, err := s.loadFiles(keep, time.Time{})
 	if err != nil {
 		return nil, result, err
 	}
@@ -115,6 +226,13 @@ func (s *FileEventStore) LoadAfterSeqNr(_ context.Context, afterSeqNr uint64) ([
 // LatestSeqNr returns the highest SeqNr across all persisted events.
 // Returns 0 if no events exist or none have a SeqNr assigned.
 func (s *FileEventStore) LatestSeqNr(_ context.Context) (uint64, error) {
+	if idx, ok := s.currentStreamIndex(); ok {
+		var latest uint64
+		for _, seq := range idx.MaxSeqNr {
+			latest = max(latest, seq)
+		}
+		return latest, nil
+	}
 	all, _, err := s.loadEvents(time.Time{})
 	if err != nil {
 		return 0, err
@@ -129,14 +247,59 @@ func (s *FileEventStore) LatestSeqNr(_ context.Context) (uint64, error) {
 }
 
 func (s *FileEventStore) loadEvents(after time.Time) ([]domain.Event, domain.LoadResult, error) {
+	// Daily files are named after their events' local date; a file dated
+	// more than a day before after (the margin absorbs time zones) cannot
+	// hold a later event, so LoadSince skips it without parsing.
+	var oldest string
+	if !after.IsZero() {
+		oldest = after.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	return s.loadFiles(func(name string) bool {
+		return oldest == "" || !isDailyFile(name) || name >= oldest
+	}, after)
+}
+
+// loadFiles reads the JSONL files keep accepts and returns their events
+// with timestamps after after (all with a zero after) chronologically.
+func (s *FileEventStore) loadFiles(keep func(name string) bool, after time.Time) ([]domain.Event, domain.LoadResult, error) {
+	files, err := s.eventFiles()
+	if err != nil {
+		return nil, domain.LoadResult{}, err
+	}
+	var events []domain.Event
+	var corruptCount int
+	for _, name := range files {
+		if !keep(name) {
+			continue
+		}
+		fileEvents, corrupt, err := s.readEventFile(name)
+		if err != nil {
+			return nil, domain.LoadResult{}, err
+		}
+		corruptCount += corrupt
+		for _, ev := range fileEvents {
+			if after.IsZero() || ev.Timestamp.After(after) {
+				events = append(events, ev)
+			}
+		}
+	}
+
+	// Stable sort preserves insertion order for events with equal timestamps.
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, domain.LoadResult{FileCount: len(files), CorruptLineCount: corruptCount}, nil
+}
+
+// eventFiles returns the names of the JSONL files in lexicographic order.
+func (s *FileEventStore) eventFiles() ([]string, error) {
 	entries, err := os.ReadDir(s.dir)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
-			return nil, domain.LoadResult{}, nil
+			return nil, nil
 		}
-		return nil, domain.LoadResult{}, fmt.Errorf("read event store dir: %w", err)
+		return nil, fmt.Errorf("read event store dir: %w", err)
 	}
-
 	var files []string
 	for _, e := range entries {
 		if !e.IsDir() && strings.HasSuffix(e.Name(), ".jsonl") {
@@ -144,43 +307,42 @@ func (s *FileEventStore) loadEvents(after time.Time) ([]domain.Event, domain.Loa
 		}
 	}
 	sort.Strings(files)
+	return files, nil
+}
 
+// readEventFile decodes the events of one JSONL file in line order,
+// skipping corrupt lines and returning how many there were.
+func (s *FileEventStore) readEventFile(name string) ([]domain.Event, int, error) {
+	f, err := os.Open(filepath.Join(s.dir, name))
+	if err != nil {
+		return nil, 0, fmt.Errorf("open %s: %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
 	var events []domain.Event
 	var corruptCount int
-	for _, name := range files {
-		path := filepath.Join(s.dir, name)
-		f, err := os.Open(path)
-		if err != nil {
-			return nil, domain.LoadResult{}, fmt.Errorf("open %s: %w", name, err)
-		}
-		scanner := bufio.NewScanner(f)
-		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
-		for scanner.Scan() {
-			line := scanner.Bytes()
-			if len(line) == 0 {
-				continue
-			}
-			var ev domain.Event
-			if jsonErr := json.Unmarshal(line, &ev); jsonErr != nil {
-				s.logger.Warn("corrupt event line in %s, skipping: %v", name, jsonErr)
-				corruptCount++
-				continue
-			}
-			if !after.IsZero() && !ev.Timestamp.After(after) {
-				continue
-			}
-			events = append(events, ev)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
 		}
-		if err := scanner.Err(); err != nil {
-			_ = f.Close()
-			return nil, domain.LoadResult{}, fmt.Errorf("scan %s: %w", name, err)
+		var ev domain.Event
+		if jsonErr := json.Unmarshal(line, &ev); jsonErr != nil {
+			s.logger.Warn("corrupt event line in %s, skipping: %v", name, jsonErr)
+			corruptCount++
+			continue
 		}
-		_ = f.Close()
+		events = append(events, ev)
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("scan %s: %w", name, err)
+	}
+	return events, corruptCount, nil
+}
 
-	// Stable sort preserves insertion order for events with equal timestamps.
-	sort.SliceStable(events, func(i, j int) bool {
-		return events[i].Timestamp.Before(events[j].Timestamp)
-	})
-	return events, domain.LoadResult{FileCount: len(files), CorruptLineCount: corruptCount}, nil
+// isDailyFile reports whether name is a YYYY-MM-DD.jsonl daily file.
+func isDailyFile(name string) bool {
+	_, err := time.Parse("2006-01-02", strings.TrimSuffix(name, ".jsonl"))
+	return err == nil
 }
@@ -0,0 +1,208 @@
+package eventsource
+
+// white-box-reason: eventsource internals: tests SQLiteEventStore schema and unexported migration writes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+func sqliteTestEvent(t *testing.T, typ domain.EventType, data any, at time.Time, seq uint64) domain.Event {
+	t.Helper()
+	ev, err := domain.NewEvent(typ, data, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev.SeqNr = seq
+	return ev
+}
+
+func TestSQLiteEventStore_MatchesFileEventStoreOrdering(t *testing.T) {
+	// given: the same batch, out of timestamp order, in both backends
+	ctx := context.Background()
+	base := time.Date(2026, 2, 27, 10, 0, 0, 0, time.UTC)
+	batch := []domain.Event{
+		sqliteTestEvent(t, domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 2}, base.Add(time.Hour), 3),
+		sqliteTestEvent(t, domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 1}, base, 2),
+		sqliteTestEvent(t, domain.EventGradientChanged, domain.GradientChangedData{Level: 1, Operator: "charge"}, base, 0),
+	}
+	dir := t.TempDir()
+	file := NewFileEventStore(EventsDir(dir), &domain.NopLogger{})
+	db := NewSQLiteEventStore(EventsDBPath(dir), &domain.NopLogger{})
+	for _, s := range []interface {
+		Append(context.Context, ...domain.Event) (domain.AppendResult, error)
+	}{file, db} {
+		if _, err := s.Append(ctx, batch...); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	// when
+	fileAll, _, _ := file.LoadAll(ctx)
+	dbAll, _, err := db.LoadAll(ctx)
+	dbSince, _, _ := db.LoadSince(ctx, base)
+	dbAfter, _, _ := db.LoadAfterSeqNr(ctx, 2)
+	latest, _ := db.LatestSeqNr(ctx)
+
+	// then
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if err := sameEvents(fileAll, dbAll); err != nil {
+		t.Errorf("LoadAll differs from FileEventStore: %v", err)
+	}
+	if len(dbSince) != 1 || dbSince[0].ID != batch[0].ID {
+		t.Errorf("LoadSince(base) = %v, want only the later event", dbSince)
+	}
+	if len(dbAfter) != 1 || dbAfter[0].SeqNr != 3 {
+		t.Errorf("LoadAfterSeqNr(2) = %v, want SeqNr 3 only", dbAfter)
+	}
+	if latest != 3 {
+		t.Errorf("LatestSeqNr = %d, want 3", latest)
+	}
+}
+
+func TestSQLiteEventStore_RejectsInvalidBatch(t *testing.T) {
+	// given
+	store := NewSQLiteEventStore(filepath.Join(t.TempDir(), "events.db"), &domain.NopLogger{})
+	valid := sqliteTestEvent(t, domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 1}, time.Now(), 0)
+
+	// when
+	_, err := store.Append(context.Background(), valid, domain.Event{ID: "bad"})
+
+	// then
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	if n, _ := store.Count(context.Background()); n != 0 {
+		t.Errorf("Count = %d, want 0 (whole batch rejected)", n)
+	}
+}
+
+func TestSQLiteEventStore_ReadsDoNotCreateDatabase(t *testing.T) {
+	// given
+	dbPath := filepath.Join(t.TempDir(), "state", "events.db")
+	store := NewSQLiteEventStore(dbPath, &domain.NopLogger{})
+
+	// when
+	events, result, err := store.LoadAll(context.Background())
+	latest, latestErr := store.LatestSeqNr(context.Background())
+
+	// then
+	if err != nil || latestErr != nil || events != nil || result.FileCount != 0 || latest != 0 {
+		t.Errorf("LoadAll = %v %+v %v, LatestSeqNr = %d %v", events, result, err, latest, latestErr)
+	}
+	if _, statErr := os.Stat(dbPath); !os.IsNotExist(statErr) {
+		t.Errorf("read created %s", dbPath)
+	}
+}
+
+func TestSQLiteEventStore_IndexesAndWAL(t *testing.T) {
+	// given
+	dbPath := filepath.Join(t.TempDir(), "events.db")
+	store := NewSQLiteEventStore(dbPath, &domain.NopLogger{})
+	ev := sqliteTestEvent(t, domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 1}, time.Now(), 0)
+	if _, err := store.Append(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var mode string
+	if err := db.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'events'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	indexes := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		_ = rows.Scan(&name)
+		indexes[name] = true
+	}
+
+	// then
+	if mode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", mode)
+	}
+	for _, want := range []string{"idx_events_type", "idx_events_seq_nr", "idx_events_timestamp", "idx_events_aggregate_id"} {
+		if !indexes[want] {
+			t.Errorf("missing index %s (have %v)", want, indexes)
+		}
+	}
+}
+
+func TestMigrateEvents_RoundTripIsLossless(t *testing.T) {
+	// given: JSONL events using every envelope field, including an event
+	// that would no longer pass validation (unknown type)
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	at := time.Date(2026, 3, 1, 9, 30, 0, 123456789, time.FixedZone("JST", 9*3600))
+	full := sqliteTestEvent(t, domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 1, Status: "success"}, at, 7)
+	full.SessionID, full.CorrelationID, full.CausationID = "sess", "corr", "cause"
+	full.AggregateID, full.AggregateType = "exp-1", "expedition"
+	legacy := domain.Event{ID: "legacy-1", Type: "legacy.removed", Timestamp: at.Add(-time.Minute), Data: json.RawMessage(`{"x":1}`)}
+	jsonl := NewFileEventStore(EventsDir(stateDir), &domain.NopLogger{})
+	if _, err := jsonl.write([]domain.Event{full, legacy}); err != nil {
+		t.Fatal(err)
+	}
+	original, _, _ := jsonl.LoadAll(ctx)
+
+	// when
+	n, toSQLite := MigrateEvents(ctx, stateDir, domain.EventStoreJSONL, domain.EventStoreSQLite, &domain.NopLogger{})
+	if _, err := BackupEvents(stateDir, domain.EventStoreJSONL, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	_, toJSONL := MigrateEvents(ctx, stateDir, domain.EventStoreSQLite, domain.EventStoreJSONL, &domain.NopLogger{})
+	back, _, _ := jsonl.LoadAll(ctx)
+
+	// then
+	if toSQLite != nil || toJSONL != nil {
+		t.Fatalf("migrate: %v / %v", toSQLite, toJSONL)
+	}
+	if n != 2 {
+		t.Errorf("migrated %d events, want 2", n)
+	}
+	if err := sameEvents(original, back); err != nil {
+		t.Errorf("round trip not lossless: %v", err)
+	}
+}
+
+func TestMigrateEvents_RefusesNonEmptyDestination(t *testing.T) {
+	// given: both backends hold events
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	ev := sqliteTestEvent(t, domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 1}, time.Now(), 0)
+	if _, err := NewFileEventStore(EventsDir(stateDir), &domain.NopLogger{}).Append(ctx, ev); err != nil {
+		t.Fatal(err)
+	}
+	sqlite := NewSQLiteEventStore(EventsDBPath(stateDir), &domain.NopLogger{})
+	if _, err := sqlite.Append(ctx, ev); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	_, err := MigrateEvents(ctx, stateDir, domain.EventStoreJSONL, domain.EventStoreSQLite, &domain.NopLogger{})
+
+	// then
+	if err == nil {
+		t.Fatal("expected refusal for a non-empty destination")
+	}
+	if n, _ := sqlite.Count(ctx); n != 1 {
+		t.Errorf("destination Count = %d, want 1 (untouched)", n)
+	}
+}
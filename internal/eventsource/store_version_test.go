@@ -0,0 +1,126 @@
+package eventsource_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/eventsource"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+func TestAppendExpecting_RejectsStaleVersion(t *testing.T) {
+	for name, open := range map[string]func(stateDir string) port.EventStore{
+		domain.EventStoreJSONL: func(stateDir string) port.EventStore {
+			return eventsource.NewFileEventStore(eventsource.EventsDir(stateDir), &domain.NopLogger{})
+		},
+		domain.EventStoreSQLite: func(stateDir string) port.EventStore {
+			return eventsource.NewSQLiteEventStore(eventsource.EventsDBPath(stateDir), &domain.NopLogger{})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// given: two writers that both read version 0 of the same stream
+			ctx := context.Background()
+			store := open(t.TempDir())
+			agg := domain.NewExpeditionAggregate()
+			agg.SetExpeditionID("exp-1")
+			a, _ := agg.RecordGradientChange(1, 1, "session-a", time.Now())
+			b, _ := agg.RecordGradientChange(1, 1, "session-b", time.Now())
+
+			// when
+			_, errA := store.AppendExpecting(ctx, 0, a)
+			_, errB := store.AppendExpecting(ctx, 0, b)
+			version, versionErr := store.StreamVersion(ctx, domain.GradientStreamID)
+			events, _, _ := store.LoadAll(ctx)
+
+			// then
+			if errA != nil || versionErr != nil {
+				t.Fatalf("errors: %v / %v", errA, versionErr)
+			}
+			if !domain.IsConcurrencyConflict(errB) {
+				t.Errorf("second append err = %v, want a concurrency conflict", errB)
+			}
+			if version != 1 || len(events) != 1 || events[0].ID != a.ID {
+				t.Errorf("version = %d, events = %v, want only the first writer's event", version, events)
+			}
+		})
+	}
+}
+
+func TestAppendAllocating_CursorReaderSeesEverySeqNr(t *testing.T) {
+	for name, open := range map[string]func(stateDir string) port.EventStore{
+		domain.EventStoreJSONL: func(stateDir string) port.EventStore {
+			return eventsource.NewFileEventStore(eventsource.EventsDir(stateDir), &domain.NopLogger{})
+		},
+		domain.EventStoreSQLite: func(stateDir string) port.EventStore {
+			return eventsource.NewSQLiteEventStore(eventsource.EventsDBPath(stateDir), &domain.NopLogger{})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// given: writers standing in for separate processes, each with
+			// its own store and seq counter handle
+			ctx := context.Background()
+			stateDir := t.TempDir()
+			const writers, appends = 4, 20
+			var wg sync.WaitGroup
+			for w := range writers {
+				counter, err := eventsource.NewSeqCounter(filepath.Join(stateDir, "seq.db"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer counter.Close()
+				store := open(stateDir)
+				agg := domain.NewExpeditionAggregate()
+				agg.SetExpeditionID(fmt.Sprintf("exp-%d", w))
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range appends {
+						ev, _ := agg.RecordInboxReceived(fmt.Sprintf("fb-%d", i), "low", time.Now())
+						if _, err := store.AppendAllocating(ctx, domain.AnyVersion, counter.AllocSeqNr, ev); err != nil {
+							t.Error(err)
+							return
+						}
+					}
+				}()
+			}
+
+			// when: a reader follows a cursor the way policy catch-up does
+			reader := open(stateDir)
+			seen := make(map[uint64]bool)
+			var cursor uint64
+			follow := func() {
+				events, _, err := reader.LoadAfterSeqNr(ctx, cursor)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, ev := range events {
+					seen[ev.SeqNr] = true
+					cursor = ev.SeqNr
+				}
+			}
+			writing := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(writing)
+			}()
+			for done := false; !done; {
+				select {
+				case <-writing:
+					done = true
+				default:
+				}
+				follow()
+			}
+
+			// then: the cursor never moved past an event not yet stored
+			if len(seen) != writers*appends {
+				t.Errorf("reader saw %d of %d SeqNrs", len(seen), writers*appends)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package eventsource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// streamIndexFile is the FileEventStore sidecar holding the stream index.
+const streamIndexFile = ".streams.json"
+
+// streamIndexSchema is the sidecar layout; a sidecar of another schema is
+// rebuilt.
+const streamIndexSchema = 1
+
+// streamIndex is what appends need to know about every stream: its
+// version (archived events included) and its chain head. Keeping it
+// beside the events spares each append a replay of the whole history.
+// FileEventStore keeps it in events/.streams.json together with the size,
+// mtime and highest SeqNr of every daily file it covers, so SeqNr reads
+// skip the files below their cursor; SQLiteEventStore keeps it in the
+// streams table. An index that no longer describes the stored events
+// (files rewritten by fsck --repair or upgrade, rows copied by migrate,
+// files archived) is rebuilt from a full scan by the next append.
+type streamIndex struct {
+	Schema   int                      `json:"schema,omitempty"`
+	Archive  string                   `json:"archive,omitempty"` // latest archive segment covered
+	Files    map[string]fileStamp     `json:"files,omitempty"`
+	MaxSeqNr map[string]uint64        `json:"max_seq_nr,omitempty"` // per file; absent when it holds no SeqNr
+	Streams  map[string]indexedStream `json:"streams"`
+}
+
+// fileStamp identifies the content of a daily file as last indexed.
+type fileStamp struct {
+	Size    int64 `json:"size"`
+	ModTime int64 `json:"mtime"` // UnixNano
+}
+
+// indexedStream is one stream's entry in a streamIndex.
+type indexedStream struct {
+	Version uint64 `json:"version"`
+	Head    string `json:"head,omitempty"`
+}
+
+// buildStreamIndex indexes stored events on top of the archive summary.
+func buildStreamIndex(stored []domain.Event, archive string, archived map[string]ArchivedStream) (streamIndex, error) {
+	heads, err := domain.ChainHeads(stored)
+	if err != nil {
+		return streamIndex{}, err
+	}
+	continueArchivedChains(heads, archived)
+	idx := streamIndex{Archive: archive, Streams: make(map[string]indexedStream)}
+	for stream, a := range archived {
+		idx.Streams[stream] = indexedStream{Version: a.Events}
+	}
+	for _, ev := range stored {
+		st := idx.Streams[ev.StreamID()]
+		st.Version++
+		idx.Streams[ev.StreamID()] = st
+	}
+	for stream, head := range heads {
+		st := idx.Streams[stream]
+		st.Head = head
+		idx.Streams[stream] = st
+	}
+	return idx, nil
+}
+
+// version reports how many events stream holds.
+func (idx streamIndex) version(stream string) uint64 {
+	return idx.Streams[stream].Version
+}
+
+// heads returns the chain heads for domain.ChainEvents, which updates
+// them in place.
+func (idx streamIndex) heads() map[string]string {
+	heads := make(map[string]string, len(idx.Streams))
+	for stream, st := range idx.Streams {
+		if st.Head != "" {
+			heads[stream] = st.Head
+		}
+	}
+	return heads
+}
+
+// apply records chained, appended after every indexed event, with heads
+// as updated by domain.ChainEvents.
+func (idx *streamIndex) apply(chained []domain.Event, heads map[string]string) {
+	if idx.Streams == nil {
+		idx.Streams = make(map[string]indexedStream)
+	}
+	for _, ev := range chained {
+		stream := ev.StreamID()
+		st := idx.Streams[stream]
+		st.Version++
+		st.Head = heads[stream]
+		idx.Streams[stream] = st
+	}
+}
+
+// noteFiles records the SeqNrs of events FileEventStore.write routed to
+// their daily files.
+func (idx *streamIndex) noteFiles(written []domain.Event) {
+	for _, ev := range written {
+		name := ev.Timestamp.Format("2006-01-02") + ".jsonl"
+		if ev.SeqNr > idx.MaxSeqNr[name] {
+			if idx.MaxSeqNr == nil {
+				idx.MaxSeqNr = make(map[string]uint64)
+			}
+			idx.MaxSeqNr[name] = ev.SeqNr
+		}
+	}
+}
+
+// dailyFileStamps stats every JSONL file in dir.
+func dailyFileStamps(dir string) (map[string]fileStamp, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read event store dir: %w", err)
+	}
+	stamps := make(map[string]fileStamp)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, fmt.Errorf("stat %s: %w", e.Name(), err)
+		}
+		stamps[e.Name()] = fileStamp{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+	}
+	return stamps, nil
+}
+
+// streamIndex returns the sidecar index when it still describes the daily
+// files and the archive, or one rebuilt from a full scan otherwise.
+func (s *FileEventStore) streamIndex() (streamIndex, error) {
+	if idx, ok := s.currentStreamIndex(); ok {
+		return idx, nil
+	}
+	archive, archived, err := archivedStreams(s.dir)
+	if err != nil {
+		return streamIndex{}, err
+	}
+	files, err := s.eventFiles()
+	if err != nil {
+		return streamIndex{}, err
+	}
+	// Corrupt lines were already reported by the reads that met them.
+	quiet := &FileEventStore{dir: s.dir, logger: &domain.NopLogger{}}
+	var stored []domain.Event
+	maxSeqNr := make(map[string]uint64)
+	for _, name := range files {
+		events, _, err := quiet.readEventFile(name)
+		if err != nil {
+			return streamIndex{}, err
+		}
+		for _, ev := range events {
+			if ev.SeqNr > maxSeqNr[name] {
+				maxSeqNr[name] = ev.SeqNr
+			}
+		}
+		stored = append(stored, events...)
+	}
+	sort.SliceStable(stored, func(i, j int) bool {
+		return stored[i].Timestamp.Before(stored[j].Timestamp)
+	})
+	idx, err := buildStreamIndex(stored, archive, archived)
+	if err != nil {
+		return streamIndex{}, err
+	}
+	idx.MaxSeqNr = maxSeqNr
+	return idx, nil
+}
+
+// currentStreamIndex returns the sidecar index if it still describes the
+// daily files and the archive. Any problem reading it only means no.
+func (s *FileEventStore) currentStreamIndex() (streamIndex, bool) {
+	data, err := os.ReadFile(filepath.Join(s.dir, streamIndexFile))
+	if err != nil {
+		return streamIndex{}, false
+	}
+	var idx streamIndex
+	if json.Unmarshal(data, &idx) != nil || idx.Schema != streamIndexSchema || idx.Streams == nil {
+		return streamIndex{}, false
+	}
+	archive, _, err := archivedStreams(s.dir)
+	if err != nil || idx.Archive != archive {
+		return streamIndex{}, false
+	}
+	stamps, err := dailyFileStamps(s.dir)
+	if err != nil || !maps.Equal(idx.Files, stamps) {
+		return streamIndex{}, false
+	}
+	return idx, true
+}
+
+// saveStreamIndex stamps idx with the daily files as they are now and
+// replaces the sidecar atomically. The caller holds the append lock.
+func (s *FileEventStore) saveStreamIndex(idx streamIndex) error {
+	stamps, err := dailyFileStamps(s.dir)
+	if err != nil {
+		return err
+	}
+	idx.Schema = streamIndexSchema
+	idx.Files = stamps
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("marshal stream index: %w", err)
+	}
+	path := filepath.Join(s.dir, streamIndexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write stream index: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write stream index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package eventsource
+
+// white-box-reason: eventsource internals: writes behind the stream index's back and reads it directly
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// indexedAppender is a backend under test: Append and the index-unaware
+// write migration and upgrade use.
+type indexedAppender interface {
+	eventBackend
+	Append(context.Context, ...domain.Event) (domain.AppendResult, error)
+	StreamVersion(context.Context, string) (uint64, error)
+}
+
+func TestStreamIndex_RebuiltAfterWritesBehindItsBack(t *testing.T) {
+	for _, backend := range []string{domain.EventStoreJSONL, domain.EventStoreSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			// given: two indexed appends, then one event written the way
+			// migrate does, without the index
+			ctx := context.Background()
+			stateDir := t.TempDir()
+			opened, _ := openBackend(stateDir, backend, &domain.NopLogger{})
+			store := opened.(indexedAppender)
+			at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+			for i := 1; i <= 2; i++ {
+				if _, err := store.Append(ctx, streamEvent(t, at.Add(time.Duration(i)*time.Minute), uint64(i), i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			stored, _, _ := store.LoadAll(ctx)
+			heads, _ := domain.ChainHeads(stored)
+			behind, _ := domain.ChainEvents([]domain.Event{streamEvent(t, at.Add(3*time.Minute), 3, 3)}, heads)
+			var err error
+			switch s := store.(type) {
+			case *FileEventStore:
+				_, err = s.write(behind)
+			case *SQLiteEventStore:
+				_, err = s.write(ctx, behind)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// when
+			_, appendErr := store.Append(ctx, streamEvent(t, at.Add(4*time.Minute), 4, 4))
+			version, versionErr := store.StreamVersion(ctx, "expedition/run-1")
+			report, verifyErr := VerifyEvents(ctx, stateDir, backend)
+
+			// then: the append extended the chain past the unindexed event
+			if appendErr != nil || versionErr != nil || verifyErr != nil {
+				t.Fatalf("errors: %v / %v / %v", appendErr, versionErr, verifyErr)
+			}
+			if version != 4 {
+				t.Errorf("version = %d, want 4", version)
+			}
+			if !report.OK() || report.Chained != 4 {
+				t.Errorf("report = %+v, want 4 chained events and no problems", report)
+			}
+		})
+	}
+}
+
+func TestFileEventStore_StreamIndexTracksAppends(t *testing.T) {
+	// given
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	store := NewFileEventStore(EventsDir(stateDir), &domain.NopLogger{})
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	// when: appends on two days
+	for i, day := range []time.Time{at, at.AddDate(0, 0, 1)} {
+		if _, err := store.Append(ctx, streamEvent(t, day, uint64(i+1), i+1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// then: the sidecar is current, so appends take it as it is
+	if _, err := os.Stat(filepath.Join(EventsDir(stateDir), streamIndexFile)); err != nil {
+		t.Fatalf("sidecar missing: %v", err)
+	}
+	stamps, _ := dailyFileStamps(store.dir)
+	idx, err := store.streamIndex()
+	if err != nil {
+		t.Fatalf("streamIndex: %v", err)
+	}
+	if len(idx.Files) != 2 || len(stamps) != 2 {
+		t.Fatalf("index covers %v, want both daily files (%v)", idx.Files, stamps)
+	}
+	events, _, _ := store.LoadAll(ctx)
+	last, _ := domain.EventHash(events[1])
+	if st := idx.Streams["expedition/run-1"]; st.Version != 2 || st.Head != last {
+		t.Errorf("indexed stream = %+v, want version 2 headed by %s", st, last)
+	}
+}
+
+func TestSQLiteEventStore_StreamIndexInvalidatedByFsckRepair(t *testing.T) {
+	// given: a duplicated first row, so repair removes a row other than
+	// the last
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	store := NewSQLiteEventStore(EventsDBPath(stateDir), &domain.NopLogger{})
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	first := streamEvent(t, at, 1, 1)
+	if _, err := store.Append(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	stored, _, _ := store.LoadAll(ctx)
+	if _, err := store.write(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Append(ctx, streamEvent(t, at.Add(time.Minute), 2, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FsckEvents(ctx, stateDir, domain.EventStoreSQLite, true, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	version, err := store.StreamVersion(ctx, "expedition/run-1")
+
+	// then
+	if err != nil {
+		t.Fatalf("StreamVersion: %v", err)
+	}
+	if version != 2 {
+		t.Errorf("version = %d, want 2 after the duplicate was dropped", version)
+	}
+}
+
+func TestFileEventStore_LoadAfterSeqNrSkipsFilesBelowTheCursor(t *testing.T) {
+	// given: SeqNrs 1 and 2 on one day and 3 on the next, then the first
+	// file garbled without changing its size or mtime
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	store := NewFileEventStore(EventsDir(stateDir), &domain.NopLogger{})
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local)
+	for i, at := range []time.Time{day, day.Add(time.Hour), day.AddDate(0, 0, 1)} {
+		if _, err := store.Append(ctx, streamEvent(t, at, uint64(i+1), i+1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := filepath.Join(EventsDir(stateDir), "2026-03-01.jsonl")
+	info, err := os.Stat(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	garbled := make([]byte, info.Size())
+	for i := range garbled {
+		garbled[i] = 'x'
+	}
+	if err := os.WriteFile(old, garbled, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(old, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	events, result, err := store.LoadAfterSeqNr(ctx, 2)
+	latest, latestErr := store.LatestSeqNr(ctx)
+
+	// then: only the newer file was read
+	if err != nil || latestErr != nil {
+		t.Fatalf("errors: %v / %v", err, latestErr)
+	}
+	if len(events) != 1 || events[0].SeqNr != 3 || result.CorruptLineCount != 0 {
+		t.Errorf("events = %v, corrupt lines = %d; want SeqNr 3 read from the newer file only", events, result.CorruptLineCount)
+	}
+	if latest != 3 {
+		t.Errorf("latest SeqNr = %d, want 3", latest)
+	}
+}
@@ -0,0 +1,171 @@
+package eventsource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// EventTail reads the events appended to a store since its previous read.
+// For JSONL it keeps a byte offset per daily file (appends may land in an
+// older file when an event carries an older timestamp); for SQLite it
+// keeps the last row position. Lines that are not yet complete are left
+// for the next read; undecodable ones are skipped.
+type EventTail struct {
+	stateDir string
+	backend  string
+	offsets  map[string]int64
+	pos      int64
+}
+
+// NewEventTail returns a tail positioned at the current end of backend's
+// store under stateDir: the first Next returns only later appends.
+func NewEventTail(ctx context.Context, stateDir, backend string) (*EventTail, error) { // nosemgrep: domain-primitives.multiple-string-params-go -- stateDir/backend are semantically distinct [permanent]
+	t := &EventTail{stateDir: stateDir, backend: backend, offsets: make(map[string]int64)}
+	switch backend {
+	case domain.EventStoreJSONL:
+		files, err := t.dailyFiles()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range files {
+			info, statErr := os.Stat(filepath.Join(EventsDir(stateDir), name))
+			if statErr != nil {
+				return nil, fmt.Errorf("stat %s: %w", name, statErr)
+			}
+			t.offsets[name] = info.Size()
+		}
+	case domain.EventStoreSQLite:
+		db, err := openEventsDB(EventsDBPath(stateDir), false)
+		if err != nil || db == nil {
+			return t, err
+		}
+		defer func() { _ = db.Close() }()
+		if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(pos), 0) FROM events`).Scan(&t.pos); err != nil {
+			return nil, fmt.Errorf("sqlite event store: tail position: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown event store backend %q", backend)
+	}
+	return t, nil
+}
+
+// Next returns the events appended since the previous call, in append order.
+func (t *EventTail) Next(ctx context.Context) ([]domain.Event, error) {
+	if t.backend == domain.EventStoreSQLite {
+		return t.nextRows(ctx)
+	}
+	files, err := t.dailyFiles()
+	if err != nil {
+		return nil, err
+	}
+	var events []domain.Event
+	for _, name := range files {
+		read, err := t.readFrom(name)
+		if err != nil {
+			return events, err
+		}
+		events = append(events, read...)
+	}
+	return events, nil
+}
+
+func (t *EventTail) dailyFiles() ([]string, error) {
+	entries, err := os.ReadDir(EventsDir(t.stateDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read event store dir: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".jsonl") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// readFrom decodes the complete lines of name past its offset.
+func (t *EventTail) readFrom(name string) ([]domain.Event, error) {
+	f, err := os.Open(filepath.Join(EventsDir(t.stateDir), name))
+	if errors.Is(err, fs.ErrNotExist) {
+		delete(t.offsets, name)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: %w", name, err)
+	}
+	offset := t.offsets[name]
+	if info.Size() < offset {
+		// Truncated or replaced (prune, rewrite): follow from its new end.
+		t.offsets[name] = info.Size()
+		return nil, nil
+	}
+	if info.Size() == offset {
+		return nil, nil
+	}
+	data, err := io.ReadAll(io.NewSectionReader(f, offset, info.Size()-offset))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	t.offsets[name] = offset + int64(complete)
+
+	var events []domain.Event
+	for _, line := range bytes.Split(data[:complete], []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var ev domain.Event
+		if json.Unmarshal(line, &ev) == nil {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+func (t *EventTail) nextRows(ctx context.Context) ([]domain.Event, error) {
+	db, err := openEventsDB(EventsDBPath(t.stateDir), false)
+	if err != nil || db == nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+	rows, err := db.QueryContext(ctx, `SELECT pos, event FROM events WHERE pos > ? ORDER BY pos`, t.pos)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite event store: tail: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var events []domain.Event
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&t.pos, &line); err != nil {
+			return events, fmt.Errorf("sqlite event store: scan: %w", err)
+		}
+		var ev domain.Event
+		if json.Unmarshal([]byte(line), &ev) == nil {
+			events = append(events, ev)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return events, fmt.Errorf("sqlite event store: rows: %w", err)
+	}
+	return events, nil
+}
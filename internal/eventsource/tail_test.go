@@ -0,0 +1,54 @@
+package eventsource_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/eventsource"
+)
+
+func TestEventTail_ReturnsOnlyCompleteNewLines(t *testing.T) {
+	// given: one stored event, then a tail, then a complete and a partial line
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	store := eventsource.NewFileEventStore(eventsource.EventsDir(stateDir), &domain.NopLogger{})
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	old, _ := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 1}, at)
+	if _, err := store.Append(ctx, old); err != nil {
+		t.Fatal(err)
+	}
+	tail, err := eventsource.NewEventTail(ctx, stateDir, domain.EventStoreJSONL)
+	if err != nil {
+		t.Fatalf("NewEventTail: %v", err)
+	}
+	next, _ := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 2}, at)
+	if _, err := store.Append(ctx, next); err != nil {
+		t.Fatal(err)
+	}
+	partial, _ := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 3}, at)
+	line, _ := json.Marshal(partial)
+	f, _ := os.OpenFile(filepath.Join(eventsource.EventsDir(stateDir), "2026-03-01.jsonl"), os.O_APPEND|os.O_WRONLY, 0o644)
+	_, _ = f.Write(line[:20])
+
+	// when
+	first, err1 := tail.Next(ctx)
+	_, _ = f.Write(append(line[20:], '\n'))
+	_ = f.Close()
+	second, err2 := tail.Next(ctx)
+
+	// then
+	if err1 != nil || err2 != nil {
+		t.Fatalf("Next: %v / %v", err1, err2)
+	}
+	if len(first) != 1 || first[0].ID != next.ID {
+		t.Errorf("first Next = %v, want only the appended event", first)
+	}
+	if len(second) != 1 || second[0].ID != partial.ID {
+		t.Errorf("second Next = %v, want the completed line", second)
+	}
+}
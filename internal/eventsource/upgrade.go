@@ -0,0 +1,158 @@
+package eventsource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// UpgradeResult reports what UpgradeEvents did (or would do).
+type UpgradeResult struct {
+	Events   int    // events in the store
+	Upgraded int    // events whose schema version or payload changed
+	Backup   string // where the pre-upgrade store was moved; "" if untouched
+}
+
+// UpgradeEvents rewrites backend's events under stateDir upcast to the
+// current schema version of their type (domain.UpcastEvent). The store is
+// first moved aside with BackupEvents, then written afresh in the same
+// order and daily-file layout, with hash chain links recomputed over the
+// rewritten payloads, and read back for verification; on any
+// failure the backup is put back. Nothing is written when no event needs
+// upcasting or dryRun is set. Like MigrateEvents it refuses to run over
+// corrupt lines, which a rewrite would drop.
+func UpgradeEvents(ctx context.Context, stateDir, backend string, dryRun bool, now time.Time, logger domain.Logger) (UpgradeResult, error) { // nosemgrep: domain-primitives.multiple-string-params-go -- stateDir/backend are semantically distinct [permanent]
+	store, err := openBackend(stateDir, backend, logger)
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+	events, loadResult, err := store.LoadAll(ctx)
+	if err != nil {
+		return UpgradeResult{}, fmt.Errorf("load %s events: %w", backend, err)
+	}
+	if loadResult.CorruptLineCount > 0 {
+		return UpgradeResult{}, fmt.Errorf("%d corrupt event line(s) in the %s store would be lost by a rewrite; repair them first", loadResult.CorruptLineCount, backend)
+	}
+
+	result := UpgradeResult{Events: len(events)}
+	upgraded := make([]domain.Event, len(events))
+	for i, ev := range events {
+		up, upErr := domain.UpcastEvent(ev)
+		if upErr != nil {
+			return result, upErr
+		}
+		if up.SchemaVersion != ev.SchemaVersion || !bytes.Equal(up.Data, ev.Data) {
+			result.Upgraded++
+		}
+		upgraded[i] = up
+	}
+	if result.Upgraded == 0 || dryRun {
+		return result, nil
+	}
+	if err := rechain(events, upgraded); err != nil {
+		return result, err
+	}
+
+	backup, err := BackupEvents(stateDir, backend, now)
+	if err != nil {
+		return result, err
+	}
+	switch s := store.(type) {
+	case *FileEventStore:
+		_, err = s.write(upgraded)
+	case *SQLiteEventStore:
+		_, err = s.write(ctx, upgraded)
+	}
+	if err == nil {
+		var written []domain.Event
+		if written, _, err = store.LoadAll(ctx); err == nil {
+			err = sameEvents(upgraded, written)
+		}
+	}
+	if err != nil {
+		return result, errors.Join(fmt.Errorf("rewrite %s store: %w", backend, err), restoreBackup(stateDir, backend, backup))
+	}
+	result.Backup = backup
+	return result, nil
+}
+
+// restoreBackup discards a partial rewrite and moves the BackupEvents
+// backup back into place.
+func restoreBackup(stateDir, backend, backup string) error { // nosemgrep: domain-primitives.multiple-string-params-go -- stateDir/backend/backup are semantically distinct [permanent]
+	if err := RemoveEvents(stateDir, backend); err != nil {
+		return err
+	}
+	path := backendPath(stateDir, backend)
+	if backend == domain.EventStoreJSONL {
+		// RemoveEvents keeps the directory; an empty one is in the way.
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(backup, path); err != nil {
+		return fmt.Errorf("restore %s: %w", backup, err)
+	}
+	if backend == domain.EventStoreSQLite {
+		for _, suffix := range []string{"-wal", "-shm"} {
+			if err := os.Rename(backup+suffix, path+suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("restore %s%s: %w", backup, suffix, err)
+			}
+		}
+	}
+	return nil
+}
+
+// rechain recomputes PrevHash along every hash chain of orig after its
+// events were rewritten into upgraded (same order), so links that held
+// before the rewrite hold after it. Links that were already broken stay
+// broken: their successors keep the PrevHash they had.
+func rechain(orig, upgraded []domain.Event) error {
+	stored := make(map[string]bool)    // stream\x00hash of orig
+	children := make(map[string][]int) // stream\x00prev -> indexes
+	origHash := make([]string, len(orig))
+	for i, ev := range orig {
+		if ev.PrevHash == "" {
+			continue
+		}
+		h, err := domain.EventHash(ev)
+		if err != nil {
+			return err
+		}
+		origHash[i] = h
+		stored[ev.StreamID()+"\x00"+h] = true
+		key := ev.StreamID() + "\x00" + ev.PrevHash
+		children[key] = append(children[key], i)
+	}
+	type link struct{ stream, old, new string }
+	var queue []link
+	for _, ev := range orig {
+		if ev.PrevHash != "" && !stored[ev.StreamID()+"\x00"+ev.PrevHash] {
+			// Chain roots: GenesisHash, or a predecessor that is not stored.
+			queue = append(queue, link{stream: ev.StreamID(), old: ev.PrevHash, new: ev.PrevHash})
+		}
+	}
+	visited := make(map[string]bool)
+	for len(queue) > 0 {
+		l := queue[0]
+		queue = queue[1:]
+		key := l.stream + "\x00" + l.old
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+		for _, i := range children[key] {
+			upgraded[i].PrevHash = l.new
+			h, err := domain.EventHash(upgraded[i])
+			if err != nil {
+				return err
+			}
+			queue = append(queue, link{stream: l.stream, old: origHash[i], new: h})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package eventsource
+
+// white-box-reason: eventsource internals: seeds legacy events through the unexported verbatim write path
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+func legacyCompletion(id string, at time.Time, issueID string) domain.Event {
+	return domain.Event{
+		ID:        id,
+		Type:      domain.EventExpeditionCompleted,
+		Timestamp: at,
+		Data:      json.RawMessage(`{"expedition":1,"status":"success","issue_id":"` + issueID + `"}`),
+	}
+}
+
+func TestUpgradeEvents_RewritesLegacyEventsWithBackup(t *testing.T) {
+	for _, backend := range []string{domain.EventStoreJSONL, domain.EventStoreSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			// given: two legacy v0 events and one current event
+			ctx := context.Background()
+			stateDir := t.TempDir()
+			at := time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC)
+			current := sqliteTestEvent(t, domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 2}, at.Add(time.Hour), 0)
+			seed := []domain.Event{legacyCompletion("old-1", at, "wave-a:s1"), legacyCompletion("old-2", at.Add(time.Minute), "PROJ-1"), current}
+			store, _ := openBackend(stateDir, backend, &domain.NopLogger{})
+			switch s := store.(type) {
+			case *FileEventStore:
+				_, _ = s.write(seed)
+			case *SQLiteEventStore:
+				_, _ = s.write(ctx, seed)
+			}
+
+			// when
+			dry, dryErr := UpgradeEvents(ctx, stateDir, backend, true, time.Now(), &domain.NopLogger{})
+			result, err := UpgradeEvents(ctx, stateDir, backend, false, time.Now(), &domain.NopLogger{})
+			again, againErr := UpgradeEvents(ctx, stateDir, backend, false, time.Now(), &domain.NopLogger{})
+			after, _, _ := store.LoadAll(ctx)
+
+			// then
+			if dryErr != nil || err != nil || againErr != nil {
+				t.Fatalf("UpgradeEvents: %v / %v / %v", dryErr, err, againErr)
+			}
+			if dry.Upgraded != 2 || dry.Backup != "" {
+				t.Errorf("dry run = %+v, want 2 upgradable and no backup", dry)
+			}
+			if result.Events != 3 || result.Upgraded != 2 || result.Backup == "" {
+				t.Errorf("result = %+v, want 2 of 3 upgraded with a backup", result)
+			}
+			if _, statErr := os.Stat(result.Backup); statErr != nil {
+				t.Errorf("backup %s: %v", result.Backup, statErr)
+			}
+			if again.Upgraded != 0 || again.Backup != "" {
+				t.Errorf("second run = %+v, want a no-op", again)
+			}
+			if len(after) != 3 || after[0].ID != "old-1" || after[2].ID != current.ID {
+				t.Fatalf("events after upgrade = %v", after)
+			}
+			var data domain.ExpeditionCompletedData
+			_ = json.Unmarshal(after[0].Data, &data)
+			if after[0].SchemaVersion != domain.EventSchemaVersion(domain.EventExpeditionCompleted) || data.WaveID != "wave-a" || data.StepID != "s1" {
+				t.Errorf("stored old-1 = v%d %+v, want current schema with wave fields", after[0].SchemaVersion, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package eventsource
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// Kinds of ChainProblem reported by VerifyEvents.
+const (
+	ProblemCorrupt        = "corrupt"          // line or row that does not decode
+	ProblemDuplicateID    = "duplicate_id"     // event ID stored more than once
+	ProblemBrokenLink     = "broken_link"      // PrevHash matches no event of the stream
+	ProblemMissingSegment = "missing_segment"  // events missing between stored ones
+	ProblemFork           = "fork"             // two events link to the same predecessor
+	ProblemSeqOrder       = "seq_out_of_order" // SeqNr does not increase along the chain
+)
+
+// ChainProblem is one integrity finding of VerifyEvents.
+type ChainProblem struct {
+	Kind    string `json:"kind"`
+	EventID string `json:"event_id,omitempty"`
+	Stream  string `json:"stream,omitempty"`
+	Where   string `json:"where,omitempty"` // file:line (jsonl) or pos N (sqlite)
+	Detail  string `json:"detail"`
+}
+
+// VerifyReport is the result of VerifyEvents. Legacy events predate the
+// hash chain (no PrevHash) and are counted, not checked.
+type VerifyReport struct { // nosemgrep: structure.multiple-exported-structs-go -- report and its ChainProblem entries form one result type [permanent]
+	Backend  string         `json:"backend"`
+	Events   int            `json:"events"`
+	Streams  int            `json:"streams"`
+	Chained  int            `json:"chained"`
+	Legacy   int            `json:"legacy"`
+	Problems []ChainProblem `json:"problems"`
+}
+
+// OK reports whether the store passed every check.
+func (r VerifyReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// storedEvent is an event with its location in the store.
+type storedEvent struct {
+	ev      domain.Event
+	segment string // daily file name; "" for SQLite
+	where   string
+	hash    string
+}
+
+// VerifyEvents checks the integrity of backend's events under stateDir:
+// every chained event must link to an existing predecessor in its stream
+// (a broken link means an event was altered or removed), no two events may
+// link to the same predecessor, SeqNrs must increase along each chain,
+// and IDs must be unique. Undecodable lines and, for SQLite, gaps in the
+// row sequence are reported too. Archived segments (EventArchiveDir) are
+// checked first, as the start of every chain they hold; a segment failing
+// its checksum is reported as corrupt. Only a failure to read the store
+// is returned as an error.
+func VerifyEvents(ctx context.Context, stateDir, backend string) (VerifyReport, error) { // nosemgrep: domain-primitives.multiple-string-params-go -- stateDir/backend are semantically distinct [permanent]
+	report := VerifyReport{Backend: backend, Problems: []ChainProblem{}}
+	events, problems, err := scanArchivedEvents(stateDir)
+	if err != nil {
+		return report, err
+	}
+	var live []storedEvent
+	switch backend {
+	case domain.EventStoreJSONL:
+		live, report.Problems, err = scanFileEvents(EventsDir(stateDir))
+	case domain.EventStoreSQLite:
+		live, report.Problems, err = scanSQLiteEvents(ctx, EventsDBPath(stateDir))
+	default:
+		_, err = openBackend(stateDir, backend, nil)
+	}
+	if err != nil {
+		return report, err
+	}
+	events = append(events, live...)
+	report.Problems = append(problems, report.Problems...)
+	report.Events = len(events)
+	report.Problems = append(report.Problems, verifyChains(events, &report)...)
+	return report, nil
+}
+
+// verifyChains runs the ID, link, fork and SeqNr checks over events in
+// store order and fills the report counters.
+func verifyChains(events []storedEvent, report *VerifyReport) []ChainProblem {
+	var problems []ChainProblem
+	firstWhere := make(map[string]string)
+	byHash := make(map[string]*storedEvent) // stream\x00hash
+	children := make(map[string][]*storedEvent)
+	streams := make(map[string]bool)
+	for i := range events {
+		se := &events[i]
+		if se.ev.ID != "" {
+			if where, dup := firstWhere[se.ev.ID]; dup {
+				// Only the first copy takes part in the chain checks.
+				problems = append(problems, ChainProblem{Kind: ProblemDuplicateID, EventID: se.ev.ID, Stream: se.ev.StreamID(), Where: se.where, Detail: "also stored at " + where})
+				continue
+			} else {
+				firstWhere[se.ev.ID] = se.where
+			}
+		}
+		streams[se.ev.StreamID()] = true
+		if se.ev.PrevHash == "" {
+			report.Legacy++
+			continue
+		}
+		report.Chained++
+		hash, err := domain.EventHash(se.ev)
+		if err != nil {
+			problems = append(problems, ChainProblem{Kind: ProblemCorrupt, EventID: se.ev.ID, Where: se.where, Detail: err.Error()})
+			continue
+		}
+		se.hash = hash
+		byHash[se.ev.StreamID()+"\x00"+hash] = se
+		children[se.ev.StreamID()+"\x00"+se.ev.PrevHash] = append(children[se.ev.StreamID()+"\x00"+se.ev.PrevHash], se)
+	}
+	report.Streams = len(streams)
+
+	seenInSegment := make(map[string]bool) // stream\x00segment
+	for i := range events {
+		se := &events[i]
+		if se.hash == "" {
+			continue
+		}
+		stream := se.ev.StreamID()
+		firstInSegment := se.segment != "" && !seenInSegment[stream+"\x00"+se.segment]
+		seenInSegment[stream+"\x00"+se.segment] = true
+
+		key := stream + "\x00" + se.ev.PrevHash
+		if siblings := children[key]; len(siblings) > 1 && siblings[0] != se {
+			problems = append(problems, ChainProblem{Kind: ProblemFork, EventID: se.ev.ID, Stream: stream, Where: se.where,
+				Detail: fmt.Sprintf("links to the same predecessor as %s (%s)", siblings[0].ev.ID, siblings[0].where)})
+		}
+		if se.ev.PrevHash == domain.GenesisHash {
+			continue
+		}
+		prev, ok := byHash[key]
+		switch {
+		case !ok && firstInSegment && se.segment != events[0].segment:
+			problems = append(problems, ChainProblem{Kind: ProblemMissingSegment, EventID: se.ev.ID, Stream: stream, Where: se.where,
+				Detail: fmt.Sprintf("predecessor %s is not stored; events before %s are missing or were altered", short(se.ev.PrevHash), se.segment)})
+		case !ok:
+			problems = append(problems, ChainProblem{Kind: ProblemBrokenLink, EventID: se.ev.ID, Stream: stream, Where: se.where,
+				Detail: fmt.Sprintf("predecessor %s matches no stored event; it was altered or removed", short(se.ev.PrevHash))})
+		case se.ev.SeqNr > 0 && prev.ev.SeqNr > 0 && se.ev.SeqNr <= prev.ev.SeqNr:
+			problems = append(problems, ChainProblem{Kind: ProblemSeqOrder, EventID: se.ev.ID, Stream: stream, Where: se.where,
+				Detail: fmt.Sprintf("SeqNr %d follows SeqNr %d of %s", se.ev.SeqNr, prev.ev.SeqNr, prev.ev.ID)})
+		}
+	}
+	return problems
+}
+
+func short(hash string) string {
+	if len(hash) > len("sha256:")+12 {
+		return hash[:len("sha256:")+12]
+	}
+	return hash
+}
+
+// scanFileEvents reads every daily file in name order, keeping lines in
+// file order (the append order within a file).
+func scanFileEvents(dir string) ([]storedEvent, []ChainProblem, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, []ChainProblem{}, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("read event store dir: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".jsonl") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+
+	var events []storedEvent
+	problems := []ChainProblem{}
+	for _, name := range files {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		for n := 1; scanner.Scan(); n++ {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			where := fmt.Sprintf("%s:%d", name, n)
+			var ev domain.Event
+			if jsonErr := json.Unmarshal(line, &ev); jsonErr != nil {
+				problems = append(problems, ChainProblem{Kind: ProblemCorrupt, Where: where, Detail: jsonErr.Error()})
+				continue
+			}
+			events = append(events, storedEvent{ev: ev, segment: name, where: where})
+		}
+		err = scanner.Err()
+		_ = f.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("scan %s: %w", name, err)
+		}
+	}
+	return events, problems, nil
+}
+
+// scanArchivedEvents reads every archived segment in archive order,
+// keeping lines in segment order.
+func scanArchivedEvents(stateDir string) ([]storedEvent, []ChainProblem, error) {
+	segments, err := ListArchiveSegments(stateDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var events []storedEvent
+	problems := []ChainProblem{}
+	for _, seg := range segments {
+		content, readErr := ReadArchiveSegment(stateDir, seg)
+		if readErr != nil {
+			problems = append(problems, ChainProblem{Kind: ProblemCorrupt, Where: seg.Name, Detail: readErr.Error()})
+			continue
+		}
+		n := 0
+		for _, line := range bytes.Split(content, []byte("\n")) {
+			n++
+			if len(line) == 0 {
+				continue
+			}
+			where := fmt.Sprintf("%s:%d", seg.Name, n)
+			var ev domain.Event
+			if jsonErr := json.Unmarshal(line, &ev); jsonErr != nil {
+				problems = append(problems, ChainProblem{Kind: ProblemCorrupt, Where: where, Detail: jsonErr.Error()})
+				continue
+			}
+			events = append(events, storedEvent{ev: ev, segment: seg.Name, where: where})
+		}
+	}
+	return events, problems, nil
+}
+
+// scanSQLiteEvents reads every row in pos (append) order. Rows deleted
+// from the middle or the end show up as gaps against the AUTOINCREMENT
+// sequence.
+func scanSQLiteEvents(ctx context.Context, dbPath string) ([]storedEvent, []ChainProblem, error) {
+	db, err := openEventsDB(dbPath, false)
+	if err != nil || db == nil {
+		return nil, []ChainProblem{}, err
+	}
+	defer func() { _ = db.Close() }()
+
+	rows, err := db.QueryContext(ctx, `SELECT pos, event FROM events ORDER BY pos`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sqlite event store: query: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var events []storedEvent
+	problems := []ChainProblem{}
+	var last int64
+	for rows.Next() {
+		var pos int64
+		var line string
+		if err := rows.Scan(&pos, &line); err != nil {
+			return nil, nil, fmt.Errorf("sqlite event store: scan: %w", err)
+		}
+		where := fmt.Sprintf("pos %d", pos)
+		if pos != last+1 {
+			problems = append(problems, ChainProblem{Kind: ProblemMissingSegment, Where: where, Detail: fmt.Sprintf("rows pos %d-%d are missing", last+1, pos-1)})
+		}
+		last = pos
+		var ev domain.Event
+		if jsonErr := json.Unmarshal([]byte(line), &ev); jsonErr != nil {
+			problems = append(problems, ChainProblem{Kind: ProblemCorrupt, Where: where, Detail: jsonErr.Error()})
+			continue
+		}
+		events = append(events, storedEvent{ev: ev, where: where})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("sqlite event store: rows: %w", err)
+	}
+
+	var seq int64
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), 0) FROM sqlite_sequence WHERE name = 'events'`).Scan(&seq); err == nil && seq > last {
+		problems = append(problems, ChainProblem{Kind: ProblemMissingSegment, Where: fmt.Sprintf("pos %d", seq), Detail: fmt.Sprintf("rows pos %d-%d at the end are missing", last+1, seq)})
+	}
+	return events, problems, nil
+}
@@ -0,0 +1,237 @@
+package eventsource
+
+// white-box-reason: eventsource internals: tampers with stored lines and rows to exercise VerifyEvents
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+func streamEvent(t *testing.T, at time.Time, seq uint64, expedition int) domain.Event {
+	t.Helper()
+	ev := sqliteTestEvent(t, domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: expedition}, at, seq)
+	ev.AggregateType = "expedition"
+	ev.AggregateID = "run-1"
+	return ev
+}
+
+func problemKinds(report VerifyReport) []string {
+	var kinds []string
+	for _, p := range report.Problems {
+		kinds = append(kinds, p.Kind)
+	}
+	return kinds
+}
+
+func TestAppend_ChainsEventsPerStream(t *testing.T) {
+	for _, backend := range []string{domain.EventStoreJSONL, domain.EventStoreSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			// given
+			ctx := context.Background()
+			stateDir := t.TempDir()
+			opened, _ := openBackend(stateDir, backend, &domain.NopLogger{})
+			store := opened.(interface {
+				eventBackend
+				Append(context.Context, ...domain.Event) (domain.AppendResult, error)
+			})
+			at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+			// when: two appends to one stream, one event outside any aggregate
+			_, err1 := store.Append(ctx, streamEvent(t, at, 1, 1))
+			_, err2 := store.Append(ctx, streamEvent(t, at.Add(time.Minute), 2, 2),
+				sqliteTestEvent(t, domain.EventGradientChanged, domain.GradientChangedData{Level: 1}, at, 0))
+			all, _, _ := store.LoadAll(ctx)
+			var events []domain.Event
+			for _, ev := range all {
+				if ev.AggregateID == "run-1" {
+					events = append(events, ev)
+				}
+			}
+			report, err := VerifyEvents(ctx, stateDir, backend)
+
+			// then
+			if err1 != nil || err2 != nil || err != nil {
+				t.Fatalf("errors: %v / %v / %v", err1, err2, err)
+			}
+			if events[0].PrevHash != domain.GenesisHash {
+				t.Errorf("first PrevHash = %q, want genesis", events[0].PrevHash)
+			}
+			first, _ := domain.EventHash(events[0])
+			if events[1].PrevHash != first {
+				t.Errorf("second PrevHash = %q, want %q", events[1].PrevHash, first)
+			}
+			if !report.OK() || report.Chained != 3 || report.Streams != 2 {
+				t.Errorf("report = %+v, want 3 chained events in 2 streams and no problems", report)
+			}
+		})
+	}
+}
+
+func TestVerifyEvents_JSONLTamperingAndDuplicates(t *testing.T) {
+	// given: a three-event chain with the middle line edited and a line duplicated
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	store := NewFileEventStore(EventsDir(stateDir), &domain.NopLogger{})
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		if _, err := store.Append(ctx, streamEvent(t, at.Add(time.Duration(i)*time.Minute), uint64(i), i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(EventsDir(stateDir), "2026-03-01.jsonl")
+	raw, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	lines[1] = strings.Replace(lines[1], `"expedition":2`, `"expedition":7`, 1)
+	lines = append(lines, lines[2])
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	report, err := VerifyEvents(ctx, stateDir, domain.EventStoreJSONL)
+
+	// then
+	if err != nil {
+		t.Fatalf("VerifyEvents: %v", err)
+	}
+	got := strings.Join(problemKinds(report), ",")
+	if got != ProblemDuplicateID+","+ProblemBrokenLink {
+		t.Errorf("problems = %s (%+v)", got, report.Problems)
+	}
+	if report.Problems[0].Where != "2026-03-01.jsonl:4" || report.Problems[1].Where != "2026-03-01.jsonl:3" {
+		t.Errorf("problems at %q and %q, want the copy and the line after the edit", report.Problems[0].Where, report.Problems[1].Where)
+	}
+}
+
+func TestVerifyEvents_MissingDailyFile(t *testing.T) {
+	// given: a stream spanning three days whose middle file was deleted
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	store := NewFileEventStore(EventsDir(stateDir), &domain.NopLogger{})
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if _, err := store.Append(ctx, streamEvent(t, at.AddDate(0, 0, i), uint64(i+1), i+1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = os.Remove(filepath.Join(EventsDir(stateDir), "2026-03-02.jsonl"))
+
+	// when
+	report, err := VerifyEvents(ctx, stateDir, domain.EventStoreJSONL)
+
+	// then
+	if err != nil {
+		t.Fatalf("VerifyEvents: %v", err)
+	}
+	if len(report.Problems) != 1 || report.Problems[0].Kind != ProblemMissingSegment || report.Problems[0].Where != "2026-03-03.jsonl:1" {
+		t.Errorf("problems = %+v, want one missing_segment at 2026-03-03.jsonl:1", report.Problems)
+	}
+}
+
+func TestVerifyEvents_SeqNrOutOfOrder(t *testing.T) {
+	// given: a chain whose second event carries a lower SeqNr
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	store := NewFileEventStore(EventsDir(stateDir), &domain.NopLogger{})
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	_, _ = store.Append(ctx, streamEvent(t, at, 5, 1))
+	_, _ = store.Append(ctx, streamEvent(t, at.Add(time.Minute), 4, 2))
+
+	// when
+	report, err := VerifyEvents(ctx, stateDir, domain.EventStoreJSONL)
+
+	// then
+	if err != nil {
+		t.Fatalf("VerifyEvents: %v", err)
+	}
+	if len(report.Problems) != 1 || report.Problems[0].Kind != ProblemSeqOrder {
+		t.Errorf("problems = %+v, want one seq_out_of_order", report.Problems)
+	}
+}
+
+func TestVerifyEvents_ConcurrentWritersKeepSeqNrOrder(t *testing.T) {
+	for _, backend := range []string{domain.EventStoreJSONL, domain.EventStoreSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			// given: writers standing in for separate processes, appending
+			// to one stream with their own store and seq counter handles
+			ctx := context.Background()
+			stateDir := t.TempDir()
+			const writers, appends = 4, 10
+			var wg sync.WaitGroup
+			for range writers {
+				counter, err := NewSeqCounter(filepath.Join(stateDir, "seq.db"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer counter.Close()
+				opened, _ := openBackend(stateDir, backend, &domain.NopLogger{})
+				store := opened.(interface {
+					AppendAllocating(context.Context, domain.ExpectedVersion, func(context.Context) (uint64, error), ...domain.Event) (domain.AppendResult, error)
+				})
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range appends {
+						if _, err := store.AppendAllocating(ctx, domain.AnyVersion, counter.AllocSeqNr, streamEvent(t, time.Now(), 0, i+1)); err != nil {
+							t.Error(err)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			// when
+			report, err := VerifyEvents(ctx, stateDir, backend)
+
+			// then: each event's SeqNr is above its predecessor's
+			if err != nil {
+				t.Fatalf("VerifyEvents: %v", err)
+			}
+			if !report.OK() || report.Chained != writers*appends {
+				t.Errorf("report = %+v, want %d chained events and no problems", report, writers*appends)
+			}
+		})
+	}
+}
+
+func TestVerifyEvents_SQLiteDeletedRows(t *testing.T) {
+	// given: four chained rows; the second and the last are deleted
+	ctx := context.Background()
+	stateDir := t.TempDir()
+	store := NewSQLiteEventStore(EventsDBPath(stateDir), &domain.NopLogger{})
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 1; i <= 4; i++ {
+		if _, err := store.Append(ctx, streamEvent(t, at.Add(time.Duration(i)*time.Minute), uint64(i), i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := sql.Open("sqlite", EventsDBPath(stateDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`DELETE FROM events WHERE pos IN (2, 4)`); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	// when
+	report, err := VerifyEvents(ctx, stateDir, domain.EventStoreSQLite)
+
+	// then
+	if err != nil {
+		t.Fatalf("VerifyEvents: %v", err)
+	}
+	got := strings.Join(problemKinds(report), ",")
+	if got != ProblemMissingSegment+","+ProblemMissingSegment+","+ProblemBrokenLink {
+		t.Errorf("problems = %s (%+v)", got, report.Problems)
+	}
+}
@@ -56,6 +56,18 @@ var FilterHighSeverity = policy.FilterHighSeverity
 // SummarizeReview normalizes and truncates review output.
 var SummarizeReview = policy.SummarizeReview
 
+// --- policy: strategy ---
+
+// FixStrategy is a type alias for the policy FixStrategy.
+type FixStrategy = policy.FixStrategy
+
+// Review-fix strategies.
+const (
+	StrategyDirect    = policy.StrategyDirect
+	StrategyDecompose = policy.StrategyDecompose
+	StrategyRewrite   = policy.StrategyRewrite
+)
+
 // --- policy: wave ---
 
 // ProjectWaveState builds wave progress from D-Mails.
@@ -96,6 +108,9 @@ var FormatLuminaForPrompt = filter.FormatLuminaForPrompt
 // BuildReviewFixPrompt creates a fix prompt for review comments.
 var BuildReviewFixPrompt = filter.BuildReviewFixPrompt
 
+// BuildReviewFixPromptWithStrategy creates a fix prompt with a strategy hint.
+var BuildReviewFixPromptWithStrategy = filter.BuildReviewFixPromptWithStrategy
+
 // ExpandReviewCmd replaces placeholders in the review command.
 var ExpandReviewCmd = filter.ExpandReviewCmd
 
@@ -114,7 +114,7 @@ func TestGradientGauge_ExpeditionAggregate_Linkage(t *testing.T) {
 	gauge.Charge()
 	gauge.Charge()
 	gauge.Charge()
-	ev, err := agg.RecordGradientChange(gauge.Level(), "charge", now)
+	ev, err := agg.RecordGradientChange(gauge.Level(), 3, "charge", now)
 
 	// then
 	if err != nil {
@@ -135,6 +135,9 @@ func TestGradientGauge_ExpeditionAggregate_Linkage(t *testing.T) {
 	if data.Operator != "charge" {
 		t.Errorf("GradientChangedData.Operator: got %q, want %q", data.Operator, "charge")
 	}
+	if data.Delta == nil || *data.Delta != 3 {
+		t.Errorf("GradientChangedData.Delta: got %v, want 3", data.Delta)
+	}
 }
 
 // TestGradientGauge_DischargeEvent verifies discharge produces level=0 event.
@@ -146,7 +149,7 @@ func TestGradientGauge_DischargeEvent(t *testing.T) {
 	gauge.Charge()
 	gauge.Charge()
 	gauge.Discharge()
-	ev, err := agg.RecordGradientChange(gauge.Level(), "discharge", now)
+	ev, err := agg.RecordGradientChange(gauge.Level(), -2, "discharge", now)
 	if err != nil {
 		t.Fatalf("RecordGradientChange: %v", err)
 	}
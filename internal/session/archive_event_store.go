@@ -0,0 +1,62 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/eventsource"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// archiveEventStore replays the archived event segments of stateDir
+// (eventsource.EventArchiveDir) in front of the live store on LoadAll, so
+// full replays see the history retention moved out of events/. The
+// incremental reads (LoadSince, LoadAfterSeqNr) stay live-only: pruning
+// snapshots the projection first, so they never need to reach back past
+// the archive boundary.
+type archiveEventStore struct {
+	port.EventStore
+	stateDir string
+}
+
+// NewEventHistoryStore is NewEventStore for full-history readers
+// (rebuild, projection replay, `events list --archived`): its LoadAll
+// decompresses and replays archived segments before the live events.
+func NewEventHistoryStore(stateDir string, logger domain.Logger) port.EventStore {
+	raw := &archiveEventStore{EventStore: rawEventStore(stateDir, logger), stateDir: stateDir}
+	return NewSpanEventStore(newUpcastEventStore(raw, logger))
+}
+
+// LoadAll returns archived and live events in the stores' replay order
+// (by timestamp, stable). An event stored both in a segment and live —
+// left behind by an interrupted prune — is returned once.
+func (s *archiveEventStore) LoadAll(ctx context.Context) ([]domain.Event, domain.LoadResult, error) {
+	archived, archivedResult, err := eventsource.LoadArchivedEvents(s.stateDir)
+	if err != nil {
+		return nil, archivedResult, fmt.Errorf("load archived events: %w", err)
+	}
+	live, result, err := s.EventStore.LoadAll(ctx)
+	if err != nil || len(archived) == 0 {
+		return live, result, err
+	}
+	seen := make(map[string]bool, len(archived))
+	for _, ev := range archived {
+		if ev.ID != "" {
+			seen[ev.ID] = true
+		}
+	}
+	all := archived
+	for _, ev := range live {
+		if ev.ID == "" || !seen[ev.ID] {
+			all = append(all, ev)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Timestamp.Before(all[j].Timestamp)
+	})
+	result.FileCount += archivedResult.FileCount
+	result.CorruptLineCount += archivedResult.CorruptLineCount
+	return all, result, nil
+}
@@ -3,6 +3,7 @@ package session
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -296,3 +297,29 @@ func (w *IndexWriter) Rebuild(indexPath, stateDir, tool string) (int, error) { /
 
 	return len(entries), nil
 }
+
+// ReadArchiveIndex returns the entries recorded in the index file at
+// indexPath. Returns an empty slice (not error) when the index does not
+// exist yet; malformed lines are skipped.
+func ReadArchiveIndex(indexPath string) ([]domain.IndexEntry, error) {
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	var entries []domain.IndexEntry
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var entry domain.IndexEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			continue // skip malformed lines
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -28,3 +28,7 @@ func (*archiveOps) PruneEventFiles(ctx context.Context, stateDir string, files [
 func (*archiveOps) PruneFlushedOutbox(ctx context.Context, repoPath string) (int, error) {
 	return PruneFlushedOutbox(ctx, repoPath)
 }
+
+func (*archiveOps) PruneArtifacts(ctx context.Context, repoPath string, dropped []string, execute bool) ([]string, error) {
+	return PruneArtifacts(ctx, repoPath, dropped, execute)
+}
@@ -14,7 +14,8 @@ import (
 
 // ArchivePrune scans .expedition/archive/ for .md files older than the given
 // number of days. When execute is false (dry-run), it only lists candidates.
-// When execute is true, it deletes them and reports how many were removed.
+// When execute is true, it deletes them (with their attachments directory)
+// and reports how many were removed.
 func ArchivePrune(continent string, days int, execute bool) (domain.PruneResult, error) {
 	if days <= 0 {
 		return domain.PruneResult{}, fmt.Errorf("days must be positive, got %d", days)
@@ -47,6 +48,8 @@ func ArchivePrune(continent string, days int, execute bool) (domain.PruneResult,
 			if execute {
 				if err := os.Remove(filepath.Join(dir, e.Name())); err == nil {
 					result.Deleted++
+					result.Removed = append(result.Removed, e.Name())
+					_ = os.RemoveAll(filepath.Join(dir, domain.AttachmentsDirName(strings.TrimSuffix(e.Name(), ".md"))))
 				}
 			}
 		}
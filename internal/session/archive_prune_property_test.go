@@ -0,0 +1,161 @@
+package session_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+// Property-based test for snapshot-safe retention: pruning expired daily
+// event files (snapshot, then archive) must not change any projection.
+
+// seedHistory appends one event per op, spread over past days, and ages
+// each daily file's mtime to its date. ops pick the event type and payload
+// and when to move on to the next day.
+func seedHistory(t *testing.T, stateDir string, ops []byte, sequenced bool) int {
+	t.Helper()
+	ctx := context.Background()
+	days := 1
+	for _, op := range ops {
+		if op%5 == 0 {
+			days++
+		}
+	}
+	start := time.Now().AddDate(0, 0, -days-1)
+	start = time.Date(start.Year(), start.Month(), start.Day(), 12, 0, 0, 0, time.Local)
+	var counter interface {
+		AllocSeqNr(ctx context.Context) (uint64, error)
+	}
+	if sequenced {
+		sc, err := session.NewSeqCounter(stateDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sc.Close()
+		counter = sc
+	}
+	store := session.NewEventStore(stateDir, &domain.NopLogger{})
+	day := 0
+	for i, op := range ops {
+		if op%5 == 0 {
+			day++
+		}
+		at := start.AddDate(0, 0, day).Add(time.Duration(i) * time.Second)
+		var ev domain.Event
+		var err error
+		switch op % 3 {
+		case 0:
+			ev, err = domain.NewEvent(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: i + 1, Status: []string{"success", "failed", "skipped"}[int(op/3)%3]}, at)
+		case 1:
+			ev, err = domain.NewEvent(domain.EventGradientChanged, domain.GradientChangedData{Level: int(op) % 6, Operator: "charge"}, at)
+			ev.AggregateType = domain.AggregateTypeExpedition
+		default:
+			ev, err = domain.NewEvent(domain.EventDMailStaged, domain.DMailStagedData{Name: "report"}, at)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if counter != nil {
+			if ev.SeqNr, err = counter.AllocSeqNr(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := store.Append(ctx, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Join(stateDir, "events"))
+	for _, e := range entries {
+		if date, err := time.ParseInLocation("2006-01-02", strings.TrimSuffix(e.Name(), ".jsonl"), time.Local); err == nil {
+			mtime := date.Add(12 * time.Hour)
+			_ = os.Chtimes(filepath.Join(stateDir, "events", e.Name()), mtime, mtime)
+		}
+	}
+	return days
+}
+
+// projections returns the cached and the fully replayed ExpeditionState,
+// as JSON so restored and replayed timestamps compare by value.
+func projections(t *testing.T, stateDir string) (string, string) {
+	t.Helper()
+	ctx := context.Background()
+	cached, _, err := session.NewProjectionCache(stateDir, nil).State(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, _, err := session.NewEventHistoryStore(stateDir, nil).LoadAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := json.Marshal(cached)
+	b, _ := json.Marshal(session.ProjectState(history))
+	return string(a), string(b)
+}
+
+func TestPruneEventFiles_Property_ProjectionsUnchanged(t *testing.T) {
+	f := func(ops []byte, keep uint8, sequenced bool) bool {
+		if len(ops) > 40 {
+			ops = ops[:40]
+		}
+		continent := t.TempDir()
+		stateDir := filepath.Join(continent, domain.StateDir)
+		days := seedHistory(t, stateDir, ops, sequenced)
+		_, before := projections(t, stateDir)
+
+		ctx := context.Background()
+		expired, err := session.ListExpiredEventFiles(ctx, stateDir, int(keep)%(days+2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := session.PruneEventFiles(ctx, stateDir, expired); err != nil {
+			t.Fatal(err)
+		}
+		cached, replayed := projections(t, stateDir)
+		if cached != before || replayed != before {
+			t.Logf("pruned %v: before %s, cached %s, replayed %s", expired, before, cached, replayed)
+			return false
+		}
+		report, err := session.VerifyEventStore(ctx, continent)
+		if err != nil || !report.OK() {
+			t.Logf("pruned %v: verify %+v (%v)", expired, report.Problems, err)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 40}); err != nil {
+		t.Errorf("ProjectionsUnchanged property violated: %v", err)
+	}
+}
+
+func TestPruneEventFiles_SnapshotsBeforeArchiving(t *testing.T) {
+	// given: a sequenced history whose older days have expired
+	continent := t.TempDir()
+	stateDir := filepath.Join(continent, domain.StateDir)
+	seedHistory(t, stateDir, []byte{3, 5, 4, 10, 1, 2}, true)
+	ctx := context.Background()
+	expired, _ := session.ListExpiredEventFiles(ctx, stateDir, 1)
+
+	// when
+	archived, err := session.PruneEventFiles(ctx, stateDir, expired)
+
+	// then
+	if err != nil || len(archived) == 0 || len(archived) != len(expired) {
+		t.Fatalf("archived = %v (%v), want %v", archived, err, expired)
+	}
+	_, load, err := session.NewProjectionCache(stateDir, nil).State(ctx)
+	if err != nil || !load.FromSnapshot || load.SnapshotSeqNr != 6 {
+		t.Errorf("load = %+v (%v), want the prune snapshot at SeqNr 6", load, err)
+	}
+	list, err := session.ListEvents(ctx, continent, domain.EventFilter{}, true, nil)
+	if err != nil || len(list) != 6 {
+		t.Errorf("events list --archived = %d events (%v), want all 6", len(list), err)
+	}
+}
@@ -70,6 +70,9 @@ func TestArchivePrune_Execute_DeletesOldFiles(t *testing.T) {
 	if len(result.Candidates) != 2 {
 		t.Errorf("candidates = %d, want 2", len(result.Candidates))
 	}
+	if len(result.Removed) != 2 {
+		t.Errorf("removed = %v, want both old files", result.Removed)
+	}
 
 	// old files should be gone
 	if _, err := os.Stat(old1); !errors.Is(err, fs.ErrNotExist) {
@@ -180,6 +183,9 @@ func TestArchivePrune_Execute_ReportsPartialFailure(t *testing.T) {
 	if result.Deleted != 0 {
 		t.Errorf("deleted = %d, want 0 (all removals should fail)", result.Deleted)
 	}
+	if len(result.Removed) != 0 {
+		t.Errorf("removed = %v, want none", result.Removed)
+	}
 }
 
 func TestArchivePrune_NegativeDays_ReturnsError(t *testing.T) {
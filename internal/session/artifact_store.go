@@ -0,0 +1,312 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// artifactGCGrace protects freshly stored blobs from PruneArtifacts: the
+// dmail tool stores a blob before it stages the D-Mail referencing it.
+const artifactGCGrace = time.Hour
+
+// ArtifactStore is the content-addressed blob store behind D-Mail
+// attachments: each blob is stored once as <dir>/<sha256>.
+type ArtifactStore struct {
+	dir string
+}
+
+// NewArtifactStore returns a store rooted at dir.
+func NewArtifactStore(dir string) *ArtifactStore {
+	return &ArtifactStore{dir: dir}
+}
+
+// NewArtifactStoreForDir returns the artifacts store of continent.
+func NewArtifactStoreForDir(continent string) *ArtifactStore {
+	return NewArtifactStore(domain.ArtifactsDir(continent))
+}
+
+// Path returns where the blob with digest is stored.
+func (s *ArtifactStore) Path(digest string) string {
+	return filepath.Join(s.dir, digest)
+}
+
+// Put stores the file at path and returns it as an attachment named
+// after the file. A blob already stored under the same digest is kept
+// and its modification time refreshed.
+func (s *ArtifactStore) Put(path string) (domain.DMailAttachment, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return domain.DMailAttachment{}, fmt.Errorf("artifacts: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+	if info, err := src.Stat(); err != nil {
+		return domain.DMailAttachment{}, fmt.Errorf("artifacts: %w", err)
+	} else if !info.Mode().IsRegular() {
+		return domain.DMailAttachment{}, fmt.Errorf("artifacts: %s is not a regular file", path)
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return domain.DMailAttachment{}, fmt.Errorf("artifacts: create dir: %w", err)
+	}
+
+	var head [512]byte
+	n, err := io.ReadFull(src, head[:])
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return domain.DMailAttachment{}, fmt.Errorf("artifacts: read %s: %w", path, err)
+	}
+	digest, size, tmpPath, err := writeHashed(s.dir, io.MultiReader(bytes.NewReader(head[:n]), src))
+	if err != nil {
+		return domain.DMailAttachment{}, fmt.Errorf("artifacts: store %s: %w", path, err)
+	}
+	if _, err := os.Stat(s.Path(digest)); err == nil {
+		_ = os.Remove(tmpPath)
+		// Refresh the mtime so the PruneArtifacts grace period covers a
+		// blob re-attached just before a prune.
+		now := time.Now()
+		if err := os.Chtimes(s.Path(digest), now, now); err != nil {
+			return domain.DMailAttachment{}, fmt.Errorf("artifacts: touch %s: %w", digest, err)
+		}
+	} else if err := os.Rename(tmpPath, s.Path(digest)); err != nil {
+		_ = os.Remove(tmpPath)
+		return domain.DMailAttachment{}, fmt.Errorf("artifacts: store %s: %w", path, err)
+	}
+	return domain.DMailAttachment{
+		Filename:  filepath.Base(path),
+		MediaType: attachmentMediaType(path, head[:n]),
+		SHA256:    digest,
+		Size:      size,
+	}, nil
+}
+
+// CopyTo copies the blob of a to target through a temp file, verifying
+// its checksum and size on the way.
+func (s *ArtifactStore) CopyTo(a domain.DMailAttachment, target string) error {
+	src, err := os.Open(s.Path(a.SHA256))
+	if err != nil {
+		return fmt.Errorf("attachment %s: %w", a.Filename, err)
+	}
+	defer func() { _ = src.Close() }()
+	digest, size, tmpPath, err := writeHashed(filepath.Dir(target), src)
+	if err != nil {
+		return fmt.Errorf("attachment %s: %w", a.Filename, err)
+	}
+	if digest != a.SHA256 || size != a.Size {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("attachment %s: stored blob does not match (sha256 %s, %d bytes)", a.Filename, digest, size)
+	}
+	if err := os.Rename(tmpPath, target); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("attachment %s: %w", a.Filename, err)
+	}
+	return nil
+}
+
+// writeHashed copies r into a temp file in dir and returns its SHA256,
+// size and path.
+func writeHashed(dir string, r io.Reader) (digest string, size int64, tmpPath string, err error) {
+	tmp, err := os.CreateTemp(dir, ".paintress-tmp-*")
+	if err != nil {
+		return "", 0, "", err
+	}
+	h := sha256.New()
+	size, err = io.Copy(io.MultiWriter(tmp, h), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", 0, "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, tmp.Name(), nil
+}
+
+// attachmentMediaType guesses the media type of path from its extension,
+// else from its first bytes.
+func attachmentMediaType(path string, head []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+	return http.DetectContentType(head)
+}
+
+// writeAttachments places the attachments of the D-Mail in data into
+// dir/<stem>.attachments/. The files are assembled in a temp directory
+// renamed into place, so a reader never sees a partial set; callers
+// write the D-Mail itself afterwards. Data that does not parse as a
+// D-Mail carries no attachments.
+func (s *ArtifactStore) writeAttachments(dir, filename string, data []byte) error {
+	dm, err := domain.ParseDMail(data)
+	if err != nil || len(dm.Attachments) == 0 {
+		return nil
+	}
+	if err := domain.ValidateAttachments(dm.Attachments); err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp(dir, ".paintress-tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	for _, a := range dm.Attachments {
+		if err := s.CopyTo(a, filepath.Join(tmpDir, a.Filename)); err != nil {
+			return err
+		}
+	}
+	if err := os.Chmod(tmpDir, 0o755); err != nil {
+		return err
+	}
+	final := filepath.Join(dir, domain.AttachmentsDirName(strings.TrimSuffix(filename, ".md")))
+	if err := os.RemoveAll(final); err != nil {
+		return err
+	}
+	return os.Rename(tmpDir, final)
+}
+
+// AttachmentCheck is the verification result of one received attachment.
+type AttachmentCheck struct { // nosemgrep: structure.multiple-exported-structs-go -- artifact store family; AttachmentCheck is the receiver-side verification result [permanent]
+	domain.DMailAttachment
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// VerifyAttachments checks the attachments dm declares against the files
+// in dir/<name>.attachments/: each must exist with the declared size and
+// SHA256.
+func VerifyAttachments(dir string, dm domain.DMail) []AttachmentCheck {
+	checks := make([]AttachmentCheck, 0, len(dm.Attachments))
+	for _, a := range dm.Attachments {
+		check := AttachmentCheck{DMailAttachment: a}
+		if err := verifyAttachment(filepath.Join(dir, domain.AttachmentsDirName(dm.Name)), a); err != nil {
+			check.Error = err.Error()
+		} else {
+			check.Verified = true
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func verifyAttachment(dir string, a domain.DMailAttachment) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	f, err := os.Open(filepath.Join(dir, a.Filename))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if digest := hex.EncodeToString(h.Sum(nil)); digest != a.SHA256 || size != a.Size {
+		return fmt.Errorf("checksum mismatch: got sha256 %s (%d bytes), want %s (%d bytes)", digest, size, a.SHA256, a.Size)
+	}
+	return nil
+}
+
+// moveAttachments moves the attachments directory of name from one
+// D-Mail directory to another. A D-Mail without attachments is a no-op.
+func moveAttachments(fromDir, toDir, name string) error {
+	dirName := domain.AttachmentsDirName(name)
+	err := os.Rename(filepath.Join(fromDir, dirName), filepath.Join(toDir, dirName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// PruneArtifacts lists the blobs in the artifacts store of continent that
+// no D-Mail references any more and deletes them when execute is set.
+// References are collected from inbox/, outbox/, archive/ (minus the
+// archive files in dropped: about to be pruned in a dry run, actually
+// removed when executing) and D-Mails still staged
+// in the outbox. Blobs younger than an hour are kept: they may belong to
+// a D-Mail being sent.
+func PruneArtifacts(ctx context.Context, continent string, dropped []string, execute bool) ([]string, error) {
+	entries, err := os.ReadDir(domain.ArtifactsDir(continent))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	referenced, err := referencedArtifacts(ctx, continent, dropped)
+	if err != nil {
+		return nil, err
+	}
+	store := NewArtifactStoreForDir(continent)
+	threshold := time.Now().Add(-artifactGCGrace)
+	var unreferenced []string
+	for _, e := range entries {
+		if e.IsDir() || !domain.IsArtifactDigest(e.Name()) || referenced[e.Name()] {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.ModTime().After(threshold) {
+			continue
+		}
+		if execute {
+			if err := os.Remove(store.Path(e.Name())); err != nil {
+				return unreferenced, fmt.Errorf("artifacts: remove %s: %w", e.Name(), err)
+			}
+		}
+		unreferenced = append(unreferenced, e.Name())
+	}
+	return unreferenced, nil
+}
+
+func referencedArtifacts(ctx context.Context, continent string, dropped []string) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	add := func(dm domain.DMail) {
+		for _, a := range dm.Attachments {
+			referenced[a.SHA256] = true
+		}
+	}
+	for _, dir := range []string{domain.InboxDir(continent), domain.OutboxDir(continent), domain.ArchiveDir(continent)} {
+		for _, m := range readThreadDMails(dir, false) {
+			if dir == domain.ArchiveDir(continent) && slices.Contains(dropped, m.DMail.Name+".md") {
+				continue
+			}
+			add(m.DMail)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(domain.RunDir(continent), "outbox.db")); errors.Is(err, fs.ErrNotExist) {
+		return referenced, nil
+	}
+	store, err := NewOutboxStoreForDir(continent)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = store.Close() }()
+	rows, err := store.db.QueryContext(ctx, `SELECT data FROM staged WHERE flushed = 0`)
+	if err != nil {
+		return nil, fmt.Errorf("artifacts: query staged: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("artifacts: scan staged: %w", err)
+		}
+		if dm, err := domain.ParseDMail(data); err == nil {
+			add(dm)
+		}
+	}
+	return referenced, rows.Err()
+}
@@ -0,0 +1,278 @@
+package session_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+func TestMCPServer_DMail_FlushesAttachmentsAlongsideDMail(t *testing.T) {
+	// given: a failing test log inside the continent
+	continent := t.TempDir()
+	content := []byte("--- FAIL: TestLogin (0.01s)\n")
+	if err := os.WriteFile(filepath.Join(continent, "test.log"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+
+	// when
+	body := callTool(t, continent, nil, "dmail", `{"kind":"report","name":"pt-report-my-1","description":"Expedition 1 failed MY-1","body":"# Report","issues":["MY-1"],"attachments":["test.log"]}`)
+
+	// then
+	if body["sent"] != true {
+		t.Fatalf("dmail response = %v", body)
+	}
+	if _, err := os.Stat(filepath.Join(domain.ArtifactsDir(continent), digest)); err != nil {
+		t.Errorf("blob not stored: %v", err)
+	}
+	for _, dir := range []string{domain.OutboxDir(continent), domain.ArchiveDir(continent)} {
+		data, err := os.ReadFile(filepath.Join(dir, "pt-report-my-1.md"))
+		if err != nil {
+			t.Fatalf("flushed D-Mail missing: %v", err)
+		}
+		dm, err := domain.ParseDMail(data)
+		if err != nil {
+			t.Fatalf("ParseDMail: %v", err)
+		}
+		if len(dm.Attachments) != 1 || dm.Attachments[0].SHA256 != digest || dm.Attachments[0].Size != int64(len(content)) {
+			t.Fatalf("attachments = %+v", dm.Attachments)
+		}
+		copied, err := os.ReadFile(filepath.Join(dir, "pt-report-my-1.attachments", "test.log"))
+		if err != nil || string(copied) != string(content) {
+			t.Errorf("attachment copy in %s = %q, %v", dir, copied, err)
+		}
+		if checks := session.VerifyAttachments(dir, dm); !checks[0].Verified {
+			t.Errorf("verify in %s: %+v", dir, checks[0])
+		}
+	}
+}
+
+func TestMCPServer_DMail_RejectsMissingAttachment(t *testing.T) {
+	// given
+	continent := t.TempDir()
+
+	// when
+	body := callTool(t, continent, nil, "dmail", `{"kind":"report","name":"pt-report-my-1","description":"d","body":"b","attachments":["missing.png"]}`)
+
+	// then
+	if body["error_code"] == nil || body["sent"] == true {
+		t.Fatalf("dmail response = %v, want an error", body)
+	}
+	if _, err := os.Stat(filepath.Join(domain.OutboxDir(continent), "pt-report-my-1.md")); err == nil {
+		t.Error("D-Mail sent without its attachment")
+	}
+}
+
+func TestMCPServer_DMail_RejectsAttachmentsOutsideProject(t *testing.T) {
+	// given: a secret outside the continent, reachable by ../ and by a
+	// symlink inside the continent
+	parent := t.TempDir()
+	continent := filepath.Join(parent, "repo")
+	if err := os.MkdirAll(continent, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(parent, "id_rsa")
+	if err := os.WriteFile(secret, []byte("PRIVATE KEY"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(continent, "innocent.log")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"../id_rsa", "innocent.log", secret} {
+		t.Run(path, func(t *testing.T) {
+			// when
+			body := callTool(t, continent, nil, "dmail", `{"kind":"report","name":"pt-report-my-1","description":"d","body":"b","attachments":["`+path+`"]}`)
+
+			// then
+			if body["sent"] == true {
+				t.Fatalf("dmail response = %v, want a rejection", body)
+			}
+			if reason, _ := body["reason"].(string); !strings.Contains(reason, "outside the project") {
+				t.Errorf("reason = %v, want outside the project", body["reason"])
+			}
+		})
+	}
+	if entries, _ := os.ReadDir(domain.ArtifactsDir(continent)); len(entries) != 0 {
+		t.Errorf("artifacts stored for rejected paths: %v", entries)
+	}
+}
+
+func TestVerifyAttachments_DetectsTampering(t *testing.T) {
+	// given: an inbox D-Mail whose attachment changed after it was sent
+	continent := t.TempDir()
+	store := session.NewArtifactStoreForDir(continent)
+	src := filepath.Join(t.TempDir(), "shot.png")
+	if err := os.WriteFile(src, []byte("\x89PNG original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := store.Put(src)
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	dm := domain.DMail{Name: "fb-my-1", Kind: domain.KindImplFeedback, Description: "d", Attachments: []domain.DMailAttachment{a}}
+	writeInboxDMail(t, continent, dm)
+	attDir := filepath.Join(domain.InboxDir(continent), "fb-my-1.attachments")
+	if err := os.MkdirAll(attDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(attDir, "shot.png"), []byte("\x89PNG tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	checks := session.VerifyAttachments(domain.InboxDir(continent), dm)
+	body := callTool(t, continent, nil, "read_inbox", `{}`)
+
+	// then
+	if a.MediaType != "image/png" {
+		t.Errorf("media type = %q, want image/png", a.MediaType)
+	}
+	if len(checks) != 1 || checks[0].Verified || !strings.Contains(checks[0].Error, "checksum mismatch") {
+		t.Errorf("checks = %+v, want a checksum mismatch", checks)
+	}
+	entry := body["dmails"].([]any)[0].(map[string]any)
+	att := entry["attachments"].([]any)[0].(map[string]any)
+	if att["verified"] != false || att["filename"] != "shot.png" {
+		t.Errorf("read_inbox attachments = %v", entry["attachments"])
+	}
+}
+
+func TestArchiveInboxDMail_MovesAttachments(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-my-1", Kind: domain.KindImplFeedback, Description: "d"})
+	attDir := filepath.Join(domain.InboxDir(continent), "fb-my-1.attachments")
+	if err := os.MkdirAll(attDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(attDir, "test.log"), []byte("log"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	err := session.ArchiveInboxDMail(context.Background(), continent, "fb-my-1", nil)
+
+	// then
+	if err != nil {
+		t.Fatalf("ArchiveInboxDMail: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(domain.ArchiveDir(continent), "fb-my-1.attachments", "test.log")); err != nil {
+		t.Errorf("attachments not archived: %v", err)
+	}
+	if _, err := os.Stat(attDir); !os.IsNotExist(err) {
+		t.Errorf("attachments left in inbox: %v", err)
+	}
+}
+
+func TestPruneArtifacts_RemovesOnlyUnreferencedOldBlobs(t *testing.T) {
+	// given: a referenced blob, an unreferenced old blob, an unreferenced
+	// fresh blob and a blob referenced only by an archive file being pruned
+	continent := t.TempDir()
+	ensureExpeditionDirs(t, continent)
+	store := session.NewArtifactStoreForDir(continent)
+	put := func(name, content string) domain.DMailAttachment {
+		t.Helper()
+		src := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(src, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		a, err := store.Put(src)
+		if err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		return a
+	}
+	kept := put("kept.log", "kept")
+	orphan := put("orphan.log", "orphan")
+	fresh := put("fresh.log", "fresh")
+	dropped := put("dropped.log", "dropped")
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-kept", Kind: domain.KindImplFeedback, Description: "d", Attachments: []domain.DMailAttachment{kept}})
+	old := domain.DMail{Name: "pt-report-old", Kind: domain.KindReport, Description: "d", Attachments: []domain.DMailAttachment{dropped}}
+	data, err := old.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(domain.ArchiveDir(continent), "pt-report-old.md"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	for _, a := range []domain.DMailAttachment{kept, orphan, dropped} {
+		if err := os.Chtimes(store.Path(a.SHA256), past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// when
+	listed, err := session.PruneArtifacts(context.Background(), continent, []string{"pt-report-old.md"}, false)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	removed, err := session.PruneArtifacts(context.Background(), continent, []string{"pt-report-old.md"}, true)
+
+	// then
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	want := map[string]bool{orphan.SHA256: true, dropped.SHA256: true}
+	if len(listed) != 2 || !want[listed[0]] || !want[listed[1]] {
+		t.Errorf("listed = %v, want the orphan and dropped blobs", listed)
+	}
+	if len(removed) != 2 {
+		t.Errorf("removed = %v, want 2 blobs", removed)
+	}
+	for _, a := range []domain.DMailAttachment{kept, fresh} {
+		if _, err := os.Stat(store.Path(a.SHA256)); err != nil {
+			t.Errorf("%s should be kept: %v", a.Filename, err)
+		}
+	}
+	for _, a := range []domain.DMailAttachment{orphan, dropped} {
+		if _, err := os.Stat(store.Path(a.SHA256)); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed: %v", a.Filename, err)
+		}
+	}
+}
+
+func TestArtifactStore_PutRefreshesDeduplicatedBlob(t *testing.T) {
+	// given: a blob stored two hours ago
+	continent := t.TempDir()
+	store := session.NewArtifactStoreForDir(continent)
+	src := filepath.Join(t.TempDir(), "test.log")
+	if err := os.WriteFile(src, []byte("log"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := store.Put(src)
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(store.Path(a.SHA256), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	// when: the same content is attached again
+	if _, err := store.Put(src); err != nil {
+		t.Fatalf("Put again: %v", err)
+	}
+	unreferenced, err := session.PruneArtifacts(context.Background(), continent, nil, true)
+
+	// then: the grace period protects it
+	if err != nil {
+		t.Fatalf("PruneArtifacts: %v", err)
+	}
+	if len(unreferenced) != 0 {
+		t.Errorf("pruned %v, want the re-attached blob kept", unreferenced)
+	}
+	if _, err := os.Stat(store.Path(a.SHA256)); err != nil {
+		t.Errorf("blob removed: %v", err)
+	}
+}
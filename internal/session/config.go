@@ -16,9 +16,14 @@ var expeditionGitignoreEntries = []string{
 	"inbox/",
 	"outbox/",
 	"archive/",
+	"artifacts/",
 	"insights/",
 	".otel.env",
 	"events/",
+	"events.*",
+	"events-archive/",
+	"snapshots/",
+	"seq.db*",
 	".mcp.json",
 	".claude/",
 }
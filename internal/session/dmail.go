@@ -8,9 +8,11 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/hironow/paintress/internal/domain"
 	"github.com/hironow/paintress/internal/harness"
@@ -58,7 +60,7 @@ func SendDMail(ctx context.Context, store port.OutboxStore, d domain.DMail, emit
 		return fmt.Errorf("dmail: stage: %w", err)
 	}
 	if emitter != nil {
-		if emitErr := emitter.EmitDMailStaged(d.Name, time.Now()); emitErr != nil {
+		if emitErr := emitter.EmitDMailStaged(d.Name, d.Issues, time.Now()); emitErr != nil {
 			span.RecordError(emitErr)
 			span.SetAttributes(attribute.String("error.stage", "paintress.dmail"))
 			return fmt.Errorf("dmail: event staged: %w", emitErr)
@@ -88,11 +90,41 @@ func SendDMail(ctx context.Context, store port.OutboxStore, d domain.DMail, emit
 	return nil
 }
 
-// ScanInbox reads all .md files in inbox/, parses each as DMail.
-// Returns parsed d-mails sorted by filename. Returns empty slice for empty
+// outboxDMailSender implements port.DMailSender with SendDMail.
+type outboxDMailSender struct {
+	store   port.OutboxStore
+	emitter port.ExpeditionEventEmitter
+}
+
+// NewDMailSender returns a port.DMailSender that sends through store via
+// SendDMail, emitting through emitter (nil for no events).
+func NewDMailSender(store port.OutboxStore, emitter port.ExpeditionEventEmitter) port.DMailSender {
+	return &outboxDMailSender{store: store, emitter: emitter}
+}
+
+func (s *outboxDMailSender) SendDMail(ctx context.Context, d domain.DMail) error {
+	return SendDMail(ctx, s.store, d, s.emitter)
+}
+
+// ScanInbox reads the D-Mails in inbox/, sorted by file name: legacy .md
+// files parsed as DMail, and envelopes (<message_id>.yaml plus the body
+// file they reference) mapped by DMailEnvelope.ToDMail. An envelope seen
+// for the first time is stamped with SeenAt. Envelopes already acked, or
+// whose IdempotencyKey was acked before or appears earlier in the
+// listing, are re-deliveries and skipped. Returns empty slice for empty
 // or non-existent directory.
 func ScanInbox(ctx context.Context, continent string) ([]domain.DMail, error) {
-	_, span := platform.Tracer.Start(ctx, "paintress.dmail.scan")
+	return scanInbox(ctx, continent, true)
+}
+
+// peekInbox lists inbox/ like ScanInbox without stamping SeenAt, for
+// headless readers (paintress watch) that no human or session sees.
+func peekInbox(ctx context.Context, continent string) ([]domain.DMail, error) {
+	return scanInbox(ctx, continent, false)
+}
+
+func scanInbox(ctx context.Context, continent string, stampSeen bool) ([]domain.DMail, error) {
+	ctx, span := platform.Tracer.Start(ctx, "paintress.dmail.scan")
 	defer span.End()
 
 	dir := domain.InboxDir(continent)
@@ -111,34 +143,84 @@ func ScanInbox(ctx context.Context, continent string) ([]domain.DMail, error) {
 		return entries[i].Name() < entries[j].Name()
 	})
 
+	var ledger *SQLiteEnvelopeLedger // opened at the first envelope
+	ledgerOpened := false
+	defer func() { _ = ledger.Close() }()
+	seenKeys := make(map[string]bool)
+	now := time.Now()
+	duplicates := 0
+
 	var dmails []domain.DMail
 	for _, e := range entries {
-		if e.IsDir() || filepath.Ext(e.Name()) != ".md" {
+		name := e.Name()
+		if e.IsDir() || strings.HasSuffix(name, domain.EnvelopeBodyExt) {
 			continue
 		}
-		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
-		if err != nil {
-			span.RecordError(err)
-			span.SetAttributes(attribute.String("error.stage", "paintress.dmail.scan"))
-			return nil, fmt.Errorf("dmail: read %s: %w", e.Name(), err)
-		}
-		dm, err := domain.ParseDMail(data)
-		if err != nil {
-			span.RecordError(err)
-			span.SetAttributes(attribute.String("error.stage", "paintress.dmail.scan"))
-			return nil, fmt.Errorf("dmail: parse %s: %w", e.Name(), err)
+		switch filepath.Ext(name) {
+		case ".md":
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				span.RecordError(err)
+				span.SetAttributes(attribute.String("error.stage", "paintress.dmail.scan"))
+				return nil, fmt.Errorf("dmail: read %s: %w", name, err)
+			}
+			dm, err := domain.ParseDMail(data)
+			if err != nil {
+				span.RecordError(err)
+				span.SetAttributes(attribute.String("error.stage", "paintress.dmail.scan"))
+				return nil, fmt.Errorf("dmail: parse %s: %w", name, err)
+			}
+			dmails = append(dmails, dm)
+		case domain.EnvelopeFileExt:
+			stem := strings.TrimSuffix(name, domain.EnvelopeFileExt)
+			env, dm, err := readInboxEnvelope(dir, stem)
+			if err != nil {
+				span.RecordError(err)
+				span.SetAttributes(attribute.String("error.stage", "paintress.dmail.scan"))
+				return nil, fmt.Errorf("dmail: envelope %s: %w", name, err)
+			}
+			if !ledgerOpened {
+				ledgerOpened = true
+				if ledger, err = openEnvelopeLedgerIfExists(continent); err != nil {
+					span.RecordError(err)
+					span.SetAttributes(attribute.String("error.stage", "paintress.dmail.scan"))
+					return nil, fmt.Errorf("dmail: %w", err)
+				}
+			}
+			acked, err := ledger.Acked(ctx, env.IdempotencyKey)
+			if err != nil {
+				span.RecordError(err)
+				span.SetAttributes(attribute.String("error.stage", "paintress.dmail.scan"))
+				return nil, fmt.Errorf("dmail: %w", err)
+			}
+			if env.IsConsumed() || acked || seenKeys[env.IdempotencyKey] {
+				duplicates++
+				continue
+			}
+			seenKeys[env.IdempotencyKey] = true
+			if !stampSeen {
+				dmails = append(dmails, dm)
+				continue
+			}
+			if err := stampEnvelopeSeen(dir, stem, &env, now); err != nil {
+				// Listing still works from a read-only inbox.
+				span.RecordError(err)
+				span.SetAttributes(attribute.String("error.stage", "paintress.dmail.seen"))
+			}
+			dmails = append(dmails, dm)
 		}
-		dmails = append(dmails, dm)
 	}
 
 	span.SetAttributes(attribute.Int("dmail.scan.count", len(dmails)))
+	span.SetAttributes(attribute.Int("dmail.scan.duplicate.count", duplicates))
 	return dmails, nil
 }
 
 // ArchiveInboxDMail moves a d-mail from inbox/ to archive/.
-// Uses os.Rename for atomic move.
+// Uses os.Rename for atomic move. An envelope (name.yaml) moves with its
+// body, stamped with AckAt (see archiveInboxEnvelope).
 func ArchiveInboxDMail(ctx context.Context, continent, name string, emitter port.ExpeditionEventEmitter) error {
-	_, span := platform.Tracer.Start(ctx, "paintress.dmail.archive")
+	ctx, span := platform.Tracer.Start(ctx, "paintress.dmail.archive")
 	defer span.End()
 
 	filename := name + ".md"
@@ -152,9 +234,32 @@ func ArchiveInboxDMail(ctx context.Context, continent, name string, emitter port
 		return fmt.Errorf("dmail: mkdir archive: %w", err)
 	}
 
+	envelope := filepath.Join(domain.InboxDir(continent), name+domain.EnvelopeFileExt)
+	if _, err := os.Stat(src); errors.Is(err, fs.ErrNotExist) {
+		if _, envErr := os.Stat(envelope); envErr == nil {
+			if err := archiveInboxEnvelope(ctx, continent, name, time.Now()); err != nil {
+				span.RecordError(err)
+				span.SetAttributes(attribute.String("error.stage", "paintress.dmail.archive"))
+				return err
+			}
+			return emitDMailArchived(span, emitter, name)
+		}
+	}
+
+	// Attachments move first: the D-Mail file is what marks it archived.
+	if err := moveAttachments(domain.InboxDir(continent), arcDir, name); err != nil {
+		span.RecordError(err)
+		span.SetAttributes(attribute.String("error.stage", "paintress.dmail.archive"))
+		return fmt.Errorf("dmail: archive %s attachments: %w", name, err)
+	}
 	if err := os.Rename(src, dst); err != nil {
+		_ = moveAttachments(arcDir, domain.InboxDir(continent), name)
 		if errors.Is(err, fs.ErrNotExist) {
-			if _, statErr := os.Stat(dst); statErr == nil {
+			_, statErr := os.Stat(dst)
+			if errors.Is(statErr, fs.ErrNotExist) {
+				_, statErr = os.Stat(filepath.Join(arcDir, name+domain.EnvelopeFileExt))
+			}
+			if statErr == nil {
 				span.SetAttributes(attribute.Int("dmail.archive.count", 0))
 				return nil // already archived by another worker
 			} else if errors.Is(statErr, fs.ErrNotExist) {
@@ -172,7 +277,10 @@ func ArchiveInboxDMail(ctx context.Context, continent, name string, emitter port
 		span.SetAttributes(attribute.String("error.stage", "paintress.dmail.archive"))
 		return fmt.Errorf("dmail: archive %s: %w", name, err)
 	}
+	return emitDMailArchived(span, emitter, name)
+}
 
+func emitDMailArchived(span trace.Span, emitter port.ExpeditionEventEmitter, name string) error {
 	if emitter != nil {
 		if emitErr := emitter.EmitDMailArchived(name, time.Now()); emitErr != nil {
 			span.RecordError(emitErr)
@@ -1792,15 +1792,21 @@ func (f *failingEmitter) EmitSpecRegistered(_ string, _ []domain.WaveStepDef, _
 }
 func (f *failingEmitter) EmitInboxReceived(_, _ string, _ time.Time) error { return f.err }
 func (f *failingEmitter) EmitGommage(_ int, _ time.Time) error             { return f.err }
-func (f *failingEmitter) EmitGradientChange(_ int, _ string, _ time.Time) error {
+func (f *failingEmitter) GradientVersion() (domain.ExpectedVersion, error) {
+	return domain.AnyVersion, f.err
+}
+func (f *failingEmitter) EmitGradientChange(_ domain.ExpectedVersion, _, _ int, _ string, _ time.Time) error {
+	return f.err
+}
+func (f *failingEmitter) EmitRetryAttempted(_ string, _ int, _ time.Time) error   { return f.err }
+func (f *failingEmitter) EmitEscalated(_ string, _ []string, _ time.Time) error   { return f.err }
+func (f *failingEmitter) EmitResolved(_ string, _ []string, _ time.Time) error    { return f.err }
+func (f *failingEmitter) EmitDMailStaged(_ string, _ []string, _ time.Time) error { return f.err }
+func (f *failingEmitter) EmitDMailFlushed(_ int, _ time.Time) error               { return f.err }
+func (f *failingEmitter) EmitDMailArchived(_ string, _ time.Time) error           { return f.err }
+func (f *failingEmitter) EmitInboxConsumed(_ domain.InboxConsumption, _ time.Time) error {
 	return f.err
 }
-func (f *failingEmitter) EmitRetryAttempted(_ string, _ int, _ time.Time) error { return f.err }
-func (f *failingEmitter) EmitEscalated(_ string, _ []string, _ time.Time) error { return f.err }
-func (f *failingEmitter) EmitResolved(_ string, _ []string, _ time.Time) error  { return f.err }
-func (f *failingEmitter) EmitDMailStaged(_ string, _ time.Time) error           { return f.err }
-func (f *failingEmitter) EmitDMailFlushed(_ int, _ time.Time) error             { return f.err }
-func (f *failingEmitter) EmitDMailArchived(_ string, _ time.Time) error         { return f.err }
 func (f *failingEmitter) EmitGommageRecovery(_ int, _, _ string, _ int, _ string, _ time.Time) error {
 	return f.err
 }
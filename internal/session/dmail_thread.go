@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// LoadDMailThread renders the conversation selector names (a D-Mail name
+// or an issue ID, see domain.BuildDMailThread) from the D-Mails in
+// archive/ and inbox/ and the full event history of continent.
+// Read-only: inbox envelopes are not stamped seen.
+func LoadDMailThread(ctx context.Context, continent, selector string, logger domain.Logger) (domain.DMailThread, error) {
+	mails := readThreadDMails(domain.ArchiveDir(continent), false)
+	mails = append(mails, readThreadDMails(domain.InboxDir(continent), true)...)
+	events, err := loadStatusHistory(ctx, continent, logger)
+	if err != nil {
+		return domain.DMailThread{}, err
+	}
+	return domain.BuildDMailThread(strings.TrimSuffix(selector, domain.EnvelopeFileExt), mails, events)
+}
+
+// readThreadDMails reads the D-Mails (.md files and envelopes) in dir,
+// oldest file first. Unreadable entries are skipped: a thread view
+// shows what it can.
+func readThreadDMails(dir string, inInbox bool) []domain.ThreadDMail {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var mails []domain.ThreadDMail
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasSuffix(name, domain.EnvelopeBodyExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		var dm domain.DMail
+		switch filepath.Ext(name) {
+		case ".md":
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			if dm, err = domain.ParseDMail(data); err != nil {
+				continue
+			}
+		case domain.EnvelopeFileExt:
+			if _, dm, err = readInboxEnvelope(dir, strings.TrimSuffix(name, domain.EnvelopeFileExt)); err != nil {
+				continue
+			}
+		default:
+			continue
+		}
+		mails = append(mails, domain.ThreadDMail{DMail: dm, InInbox: inInbox, ModTime: info.ModTime()})
+	}
+	sort.SliceStable(mails, func(i, j int) bool { return mails[i].ModTime.Before(mails[j].ModTime) })
+	return mails
+}
+
+// repliedDMail finds the D-Mail a report answers. An explicit name wins
+// (a parent missing from archive/ and inbox/ still threads under that
+// name); otherwise the latest received D-Mail sharing one of issues,
+// preferring those still in inbox/.
+func repliedDMail(continent, explicit string, issues []string) (domain.DMail, bool) {
+	mails := readThreadDMails(domain.ArchiveDir(continent), false)
+	mails = append(mails, readThreadDMails(domain.InboxDir(continent), true)...)
+	if explicit != "" {
+		explicit = strings.TrimSuffix(explicit, ".md")
+		for _, m := range mails {
+			if m.DMail.Name == explicit {
+				return m.DMail, true
+			}
+		}
+		return domain.DMail{Name: explicit}, true
+	}
+	candidates := make([]domain.DMail, len(mails))
+	for i, m := range mails {
+		candidates[i] = m.DMail
+	}
+	return domain.RepliedDMail(candidates, issues)
+}
@@ -0,0 +1,77 @@
+package session_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+func readArchivedDMail(t *testing.T, continent, name string) domain.DMail {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(domain.ArchiveDir(continent), name+".md"))
+	if err != nil {
+		t.Fatalf("read archived %s: %v", name, err)
+	}
+	dm, err := domain.ParseDMail(data)
+	if err != nil {
+		t.Fatalf("parse archived %s: %v", name, err)
+	}
+	return dm
+}
+
+func TestMCPServer_DMail_ThreadsReportUnderInboxDMail(t *testing.T) {
+	// given: a specification and unrelated feedback waiting in inbox/
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{Name: "spec-my-1", Kind: domain.KindSpecification, Description: "Auth", Issues: []string{"MY-1"}})
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-my-2", Kind: domain.KindImplFeedback, Description: "Other", Issues: []string{"MY-2"}})
+
+	// when: a report on MY-1, then one answering the feedback explicitly
+	inferred := callTool(t, continent, nil, "dmail", `{"kind":"report","name":"pt-report-my-1","description":"Expedition 1 completed MY-1","body":"# Report\n","issues":["MY-1"]}`)
+	explicit := callTool(t, continent, nil, "dmail", `{"kind":"report","name":"pt-report-my-2","description":"Expedition 2 completed","body":"# Report\n","in_reply_to":"fb-my-2"}`)
+
+	// then
+	if inferred["in_reply_to"] != "spec-my-1" || inferred["thread_id"] != "spec-my-1" {
+		t.Errorf("inferred reply = %v", inferred)
+	}
+	report := readArchivedDMail(t, continent, "pt-report-my-1")
+	if report.Metadata[domain.MetadataInReplyTo] != "spec-my-1" || report.Metadata[domain.MetadataThreadID] != "spec-my-1" {
+		t.Errorf("report metadata = %v", report.Metadata)
+	}
+	if explicit["in_reply_to"] != "fb-my-2" {
+		t.Errorf("explicit reply = %v", explicit)
+	}
+
+	// when: the thread of the report is loaded
+	thread, err := session.LoadDMailThread(context.Background(), continent, "pt-report-my-1", &domain.NopLogger{})
+
+	// then: the specification and its report, not the other conversation
+	if err != nil {
+		t.Fatalf("LoadDMailThread: %v", err)
+	}
+	if len(thread.Entries) != 2 || thread.Entries[0].Name != "spec-my-1" || thread.Entries[1].Name != "pt-report-my-1" {
+		t.Errorf("thread entries = %+v", thread.Entries)
+	}
+	if thread.Entries[0].Outcome != "pending" {
+		t.Errorf("spec outcome = %q, want pending", thread.Entries[0].Outcome)
+	}
+}
+
+func TestMCPServer_DMail_UnrelatedReportStartsNoThread(t *testing.T) {
+	// given
+	continent := t.TempDir()
+
+	// when
+	body := callTool(t, continent, nil, "dmail", `{"kind":"report","name":"pt-report-x","description":"d","body":"b","issues":["X-1"]}`)
+
+	// then
+	if _, ok := body["in_reply_to"]; ok {
+		t.Errorf("unthreaded report got in_reply_to: %v", body)
+	}
+	if md := readArchivedDMail(t, continent, "pt-report-x").Metadata; md[domain.MetadataInReplyTo] != "" || md[domain.MetadataThreadID] != "" {
+		t.Errorf("metadata = %v, want no thread keys", md)
+	}
+}
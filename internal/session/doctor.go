@@ -185,7 +185,11 @@ func RunDoctor(ctx context.Context, claudeCmd string, continent string, repair b
 		} else {
 			checks = append(checks, skillResult)
 		}
-		checks = append(checks, checkEventStore(continent))
+		if EventStoreBackend(filepath.Join(continent, domain.StateDir)) == domain.EventStoreSQLite {
+			checks = append(checks, checkSQLiteEventStore(ctx, continent))
+		} else {
+			checks = append(checks, checkEventStore(ctx, continent))
+		}
 		checks = append(checks, checkDeadLetters(ctx, continent))
 		checks = append(checks, CheckFsnotify())
 
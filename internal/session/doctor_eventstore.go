@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/eventsource"
 )
 
 // checkDeadLetters reports outbox items that have exceeded max retry count.
@@ -45,7 +46,7 @@ func checkDeadLetters(ctx context.Context, continent string) domain.DoctorCheck
 			Name:    "dead-letters",
 			Status:  domain.CheckWarn,
 			Message: fmt.Sprintf("%d dead-lettered outbox item(s)", count),
-			Hint:    "run 'paintress dead-letters purge --execute' to remove",
+			Hint:    "inspect with 'paintress dead-letters list'; retry with 'dead-letters requeue --all' or remove with 'dead-letters purge --execute'",
 		}
 	}
 	return domain.DoctorCheck{
@@ -60,8 +61,10 @@ func checkDeadLetters(ctx context.Context, continent string) domain.DoctorCheck
 // syntactic corruption (invalid JSON) and structural corruption (valid JSON but
 // incompatible with domain.Event, e.g. bad timestamp format).
 // Scans .expedition/events/*.jsonl files. // nosemgrep: layer-session-no-event-persistence [permanent]
-// Returns a Warning-level check.
-func checkEventStore(continent string) domain.DoctorCheck {
+// A store that parses is then verified like `events verify` (hash chain,
+// duplicate IDs, SeqNr order); integrity problems fail the check.
+// Returns a Warning-level check otherwise.
+func checkEventStore(ctx context.Context, continent string) domain.DoctorCheck {
 	eventsDir := filepath.Join(continent, domain.StateDir, "events")
 	entries, err := os.ReadDir(eventsDir)
 	if err != nil {
@@ -122,12 +125,84 @@ func checkEventStore(continent string) domain.DoctorCheck {
 			Name:    "events",
 			Status:  domain.CheckWarn,
 			Message: fmt.Sprintf("%d corrupt line(s) in event store (%d file(s), %d valid events)", corruptLines, files, lines),
-			Hint:    "corrupt lines are skipped during replay — review JSONL files in " + eventsDir,
+			Hint:    `corrupt lines are skipped during replay — run "paintress events fsck --repair" to quarantine them`,
 		}
 	}
+	if check, broken := checkEventChain(ctx, continent); broken {
+		return check
+	}
 	return domain.DoctorCheck{
 		Name:    "events",
 		Status:  domain.CheckOK,
 		Message: fmt.Sprintf("%s (%d files, %d events OK)", eventsDir, files, lines),
 	}
 }
+
+// checkSQLiteEventStore is checkEventStore for continents migrated to the
+// SQLite backend: it replays events.db and reports undecodable rows.
+func checkSQLiteEventStore(ctx context.Context, continent string) domain.DoctorCheck {
+	dbPath := eventsource.EventsDBPath(filepath.Join(continent, domain.StateDir))
+	if _, err := os.Stat(dbPath); err != nil {
+		return domain.DoctorCheck{
+			Name:    "events",
+			Status:  domain.CheckWarn,
+			Message: "events.db not found (event_store: sqlite)",
+			Hint:    `events are created on the next run; or "paintress events migrate --to jsonl" to switch back`,
+		}
+	}
+	events, result, err := eventsource.NewSQLiteEventStore(dbPath, nil).LoadAll(ctx)
+	if err != nil {
+		return domain.DoctorCheck{
+			Name:    "events",
+			Status:  domain.CheckWarn,
+			Message: "read error: " + err.Error(),
+			Hint:    "check file permissions on " + dbPath,
+		}
+	}
+	if result.CorruptLineCount > 0 {
+		return domain.DoctorCheck{
+			Name:    "events",
+			Status:  domain.CheckWarn,
+			Message: fmt.Sprintf("%d corrupt row(s) in event store (%d valid events)", result.CorruptLineCount, len(events)),
+			Hint:    `corrupt rows are skipped during replay — run "paintress events fsck --repair" to quarantine them`,
+		}
+	}
+	if check, broken := checkEventChain(ctx, continent); broken {
+		return check
+	}
+	return domain.DoctorCheck{
+		Name:    "events",
+		Status:  domain.CheckOK,
+		Message: fmt.Sprintf("%s (%d events OK)", dbPath, len(events)),
+	}
+}
+
+// checkEventChain runs the `events verify` integrity check. broken is
+// true when it found problems; check then reports the first of them.
+func checkEventChain(ctx context.Context, continent string) (check domain.DoctorCheck, broken bool) {
+	report, err := VerifyEventStore(ctx, continent)
+	if err != nil {
+		return domain.DoctorCheck{
+			Name:    "events",
+			Status:  domain.CheckWarn,
+			Message: "verify error: " + err.Error(),
+		}, true
+	}
+	if report.OK() {
+		return domain.DoctorCheck{}, false
+	}
+	first := report.Problems[0]
+	hint := `run "paintress events verify" for the full report`
+	for _, p := range report.Problems {
+		if p.Kind == eventsource.ProblemCorrupt || p.Kind == eventsource.ProblemDuplicateID {
+			hint += `; "paintress events fsck --repair" quarantines corrupt lines and drops duplicates`
+			break
+		}
+	}
+	return domain.DoctorCheck{
+		Name:    "events",
+		Status:  domain.CheckFail,
+		Message: fmt.Sprintf("integrity: %d problem(s), first: %s at %s: %s", len(report.Problems), first.Kind, first.Where, first.Detail),
+		Hint:    hint,
+	}, true
+}
@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hironow/paintress/internal/domain"
 	"github.com/hironow/paintress/internal/session"
@@ -393,6 +394,33 @@ func TestCheckEventStore_Valid(t *testing.T) {
 	}
 }
 
+func TestCheckEventStore_BrokenChainFails(t *testing.T) {
+	// given — a stream spanning two days whose first daily file was replaced
+	dir := t.TempDir()
+	stateDir := filepath.Join(dir, ".expedition")
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		ev, _ := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: i + 1}, at.AddDate(0, 0, i))
+		ev.AggregateType, ev.AggregateID = "expedition", "run-1"
+		if _, err := session.NewEventStore(stateDir, &domain.NopLogger{}).Append(context.Background(), ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(stateDir, "events", "2026-03-01.jsonl"),
+		[]byte("{\"type\":\"expedition.started\",\"timestamp\":\"2026-03-01T09:00:00Z\"}\n"), 0644)
+
+	// when
+	check := session.ExportCheckEventStore(dir)
+
+	// then
+	if check.Status != domain.CheckFail {
+		t.Errorf("expected FAIL, got %s: %s", check.Status.StatusLabel(), check.Message)
+	}
+	if !strings.Contains(check.Message, "missing_segment") || !strings.Contains(check.Hint, "events verify") {
+		t.Errorf("message = %q hint = %q, want missing_segment and the verify hint", check.Message, check.Hint)
+	}
+}
+
 func TestCheckEventStore_Corrupt(t *testing.T) {
 	// given — corrupt JSONL mixed with valid events
 	dir := t.TempDir()
@@ -411,6 +439,9 @@ func TestCheckEventStore_Corrupt(t *testing.T) {
 	if !strings.Contains(check.Message, "1 corrupt line") {
 		t.Errorf("expected '1 corrupt line' in message: %q", check.Message)
 	}
+	if !strings.Contains(check.Hint, "events fsck --repair") {
+		t.Errorf("hint = %q, want a pointer to events fsck --repair", check.Hint)
+	}
 }
 
 func TestCheckEventStore_StructuralCorrupt(t *testing.T) {
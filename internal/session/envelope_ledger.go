@@ -0,0 +1,187 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteEnvelopeLedger records the IdempotencyKeys of acknowledged
+// D-Mail envelopes in .expedition/.run/inbox.db, so an envelope
+// re-delivered after its first copy was archived is not consumed twice.
+// It also records the names of consumed D-Mails, so consuming a D-Mail
+// again (a retried archive_inbox, a re-delivered copy) records no second
+// set of events, and which D-Mails paintress watch already notified
+// about, so a cron-driven `watch --once` notifies each of them once.
+type SQLiteEnvelopeLedger struct {
+	db *sql.DB
+}
+
+// NewSQLiteEnvelopeLedger opens (or creates) a ledger at dbPath.
+func NewSQLiteEnvelopeLedger(dbPath string) (*SQLiteEnvelopeLedger, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return nil, fmt.Errorf("envelope ledger: create dir: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath) // nosemgrep: d4-sql-open-without-defer-close -- stored in struct, closed via Close() [permanent]
+	if err != nil {
+		return nil, fmt.Errorf("envelope ledger: open db: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{
+		`PRAGMA journal_mode=WAL`,
+		`PRAGMA busy_timeout=5000`,
+		`CREATE TABLE IF NOT EXISTS acked_envelopes (
+		idempotency_key TEXT PRIMARY KEY,
+		message_id      TEXT NOT NULL,
+		acked_at        TEXT NOT NULL
+	)`,
+		`CREATE TABLE IF NOT EXISTS consumed_dmails (
+		name        TEXT PRIMARY KEY,
+		consumed_at TEXT NOT NULL
+	)`,
+		`CREATE TABLE IF NOT EXISTS notified_dmails (
+		name        TEXT PRIMARY KEY,
+		notified_at TEXT NOT NULL
+	)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("envelope ledger: init: %w", err)
+		}
+	}
+	return &SQLiteEnvelopeLedger{db: db}, nil
+}
+
+func envelopeLedgerPath(continent string) string {
+	return filepath.Join(domain.RunDir(continent), "inbox.db")
+}
+
+// NewEnvelopeLedgerForDir opens the envelope ledger of continent.
+func NewEnvelopeLedgerForDir(continent string) (*SQLiteEnvelopeLedger, error) {
+	return NewSQLiteEnvelopeLedger(envelopeLedgerPath(continent))
+}
+
+// openEnvelopeLedgerIfExists opens the ledger of continent for reading.
+// It returns nil when no envelope was ever acknowledged, so read paths
+// do not create inbox.db as a side effect.
+func openEnvelopeLedgerIfExists(continent string) (*SQLiteEnvelopeLedger, error) {
+	if _, err := os.Stat(envelopeLedgerPath(continent)); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return NewEnvelopeLedgerForDir(continent)
+}
+
+// Acked reports whether an envelope with key was acknowledged. A nil
+// ledger has acknowledged nothing.
+func (l *SQLiteEnvelopeLedger) Acked(ctx context.Context, key string) (bool, error) {
+	if l == nil {
+		return false, nil
+	}
+	var one int
+	err := l.db.QueryRowContext(ctx,
+		`SELECT 1 FROM acked_envelopes WHERE idempotency_key = ?`, key).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("envelope ledger: lookup %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// RecordAck records env as acknowledged at its AckAt (now when unset).
+// The first acknowledgement of a key wins.
+func (l *SQLiteEnvelopeLedger) RecordAck(ctx context.Context, env domain.DMailEnvelope) error {
+	at := time.Now()
+	if env.AckAt != nil {
+		at = *env.AckAt
+	}
+	_, err := l.db.ExecContext(ctx,
+		`INSERT INTO acked_envelopes (idempotency_key, message_id, acked_at) VALUES (?, ?, ?)
+		ON CONFLICT(idempotency_key) DO NOTHING`,
+		env.IdempotencyKey, env.MessageID, at.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("envelope ledger: record %s: %w", env.MessageID, err)
+	}
+	return nil
+}
+
+// ForgetAck removes the acknowledgement env recorded, so a rolled-back
+// archive leaves the envelope consumable again.
+func (l *SQLiteEnvelopeLedger) ForgetAck(ctx context.Context, env domain.DMailEnvelope) error {
+	_, err := l.db.ExecContext(ctx,
+		`DELETE FROM acked_envelopes WHERE idempotency_key = ? AND message_id = ?`,
+		env.IdempotencyKey, env.MessageID)
+	if err != nil {
+		return fmt.Errorf("envelope ledger: forget %s: %w", env.MessageID, err)
+	}
+	return nil
+}
+
+// Consumed reports whether the consumption of the D-Mail name was
+// recorded. A nil ledger has recorded nothing.
+func (l *SQLiteEnvelopeLedger) Consumed(ctx context.Context, name string) (bool, error) {
+	if l == nil {
+		return false, nil
+	}
+	var one int
+	err := l.db.QueryRowContext(ctx,
+		`SELECT 1 FROM consumed_dmails WHERE name = ?`, name).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("envelope ledger: lookup consumed %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// RecordConsumed records that the events of consuming the D-Mail name
+// were stored. The first record of a name wins.
+func (l *SQLiteEnvelopeLedger) RecordConsumed(ctx context.Context, name string, at time.Time) error {
+	_, err := l.db.ExecContext(ctx,
+		`INSERT INTO consumed_dmails (name, consumed_at) VALUES (?, ?)
+		ON CONFLICT(name) DO NOTHING`,
+		name, at.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("envelope ledger: record consumed %s: %w", name, err)
+	}
+	return nil
+}
+
+// MarkNotified records that a notification about the D-Mail name was
+// sent and reports whether this is the first time.
+func (l *SQLiteEnvelopeLedger) MarkNotified(ctx context.Context, name string, at time.Time) (bool, error) {
+	res, err := l.db.ExecContext(ctx,
+		`INSERT INTO notified_dmails (name, notified_at) VALUES (?, ?)
+		ON CONFLICT(name) DO NOTHING`,
+		name, at.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return false, fmt.Errorf("envelope ledger: mark notified %s: %w", name, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("envelope ledger: mark notified %s: %w", name, err)
+	}
+	return n == 1, nil
+}
+
+// Close closes the underlying database connection. Closing a nil
+// ledger is a no-op.
+func (l *SQLiteEnvelopeLedger) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.db.Close()
+}
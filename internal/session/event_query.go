@@ -0,0 +1,152 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/eventsource"
+)
+
+// ListEvents returns the continent's events matching filter in store
+// order, upcast like every other read. With archived the archived
+// segments are decompressed and included (NewEventHistoryStore).
+func ListEvents(ctx context.Context, continent string, filter domain.EventFilter, archived bool, logger domain.Logger) ([]domain.Event, error) {
+	stateDir := filepath.Join(continent, domain.StateDir)
+	store := NewEventStore(stateDir, logger)
+	if archived {
+		store = NewEventHistoryStore(stateDir, logger)
+	}
+	var events []domain.Event
+	var err error
+	if filter.Since.IsZero() || archived {
+		events, _, err = store.LoadAll(ctx)
+	} else {
+		// LoadSince is exclusive; the filter's Since is not.
+		events, _, err = store.LoadSince(ctx, filter.Since.Add(-time.Nanosecond))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load events: %w", err)
+	}
+	return domain.FilterEvents(events, filter), nil
+}
+
+// FindEvent returns the event whose ID is id, or the only one starting
+// with it, together with its causation chain (domain.CausationChain).
+// Archived segments are searched too.
+func FindEvent(ctx context.Context, continent, id string, logger domain.Logger) (domain.Event, []domain.Event, error) { // nosemgrep: domain-primitives.multiple-string-params-go -- continent/id are semantically distinct [permanent]
+	events, err := ListEvents(ctx, continent, domain.EventFilter{}, true, logger)
+	if err != nil {
+		return domain.Event{}, nil, err
+	}
+	var matches []domain.Event
+	for _, ev := range events {
+		if ev.ID == id {
+			matches = []domain.Event{ev}
+			break
+		}
+		if strings.HasPrefix(ev.ID, id) {
+			matches = append(matches, ev)
+		}
+	}
+	switch {
+	case id == "" || len(matches) == 0:
+		return domain.Event{}, nil, fmt.Errorf("no event %q", id)
+	case len(matches) > 1:
+		return domain.Event{}, nil, fmt.Errorf("event ID prefix %q is ambiguous (%d events)", id, len(matches))
+	}
+	return matches[0], domain.CausationChain(events, matches[0]), nil
+}
+
+// FollowEvents streams the events matching filter that are appended to
+// the continent's store from now on, as the MCP server or a run writes
+// them. Like MonitorInbox it watches with fsnotify; the channel closes
+// when ctx is cancelled.
+func FollowEvents(ctx context.Context, continent string, filter domain.EventFilter, logger domain.Logger) (<-chan domain.Event, error) {
+	stateDir := filepath.Join(continent, domain.StateDir)
+	eventsDir := eventsource.EventsDir(stateDir)
+
+	watcher, err := fsnotify.NewWatcher() // nosemgrep: adr0005-fsnotify-watcher-without-close -- watcher is closed in the goroutine owning the event loop [permanent]
+	if err != nil {
+		return nil, err
+	}
+	// The state dir catches events.db* writes and the creation of events/.
+	if err := watcher.Add(stateDir); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	if _, statErr := os.Stat(eventsDir); statErr == nil {
+		if err := watcher.Add(eventsDir); err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+	// Positioned after the watches, so no append falls between the two.
+	tail, err := eventsource.NewEventTail(ctx, stateDir, EventStoreBackend(stateDir))
+	if err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	ch := make(chan domain.Event, 16)
+	go func() {
+		defer close(ch)
+		defer func() { _ = watcher.Close() }()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Name == eventsDir && event.Op&fsnotify.Create != 0 {
+					if addErr := watcher.Add(eventsDir); addErr != nil {
+						logger.Warn("events tail: watch %s: %v", eventsDir, addErr)
+					}
+				}
+				if !isEventStorePath(stateDir, event.Name) || event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+					continue
+				}
+				appended, nextErr := tail.Next(ctx)
+				if nextErr != nil {
+					logger.Warn("events tail: %v", nextErr)
+				}
+				for _, ev := range appended {
+					if up, upErr := domain.UpcastEvent(ev); upErr == nil {
+						ev = up
+					}
+					if !filter.Match(ev) {
+						continue
+					}
+					select {
+					case ch <- ev:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// isEventStorePath reports whether path is a JSONL daily file or part of
+// the SQLite event database.
+func isEventStorePath(stateDir, path string) bool {
+	if filepath.Dir(path) == eventsource.EventsDir(stateDir) {
+		return strings.HasSuffix(path, ".jsonl")
+	}
+	return strings.HasPrefix(path, eventsource.EventsDBPath(stateDir))
+}
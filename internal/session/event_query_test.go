@@ -0,0 +1,56 @@
+package session_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+func TestFollowEvents_StreamsMatchingAppends(t *testing.T) {
+	for _, backend := range []string{domain.EventStoreJSONL, domain.EventStoreSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			// given: an existing event, then a follower filtered to gradient changes
+			dir := t.TempDir()
+			stateDir := filepath.Join(dir, domain.StateDir)
+			if err := os.MkdirAll(stateDir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if backend == domain.EventStoreSQLite {
+				os.WriteFile(filepath.Join(stateDir, "config.yaml"), []byte("event_store: sqlite\n"), 0o644)
+			}
+			store := session.NewEventStore(stateDir, &domain.NopLogger{})
+			old, _ := domain.NewEvent(domain.EventGradientChanged, domain.GradientChangedData{Level: 1, Operator: "charge"}, time.Now())
+			if _, err := store.Append(context.Background(), old); err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			ch, err := session.FollowEvents(ctx, dir, domain.EventFilter{Types: []domain.EventType{domain.EventGradientChanged}}, &domain.NopLogger{})
+			if err != nil {
+				t.Fatalf("FollowEvents: %v", err)
+			}
+
+			// when
+			started, _ := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 1}, time.Now())
+			changed, _ := domain.NewEvent(domain.EventGradientChanged, domain.GradientChangedData{Level: 2, Operator: "charge"}, time.Now())
+			if _, err := store.Append(context.Background(), started, changed); err != nil {
+				t.Fatal(err)
+			}
+
+			// then
+			select {
+			case got := <-ch:
+				if got.ID != changed.ID {
+					t.Errorf("followed %s %s, want the new gradient change", got.Type, got.ID)
+				}
+			case <-ctx.Done():
+				t.Fatal("timeout waiting for the appended event")
+			}
+		})
+	}
+}
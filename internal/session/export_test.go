@@ -37,7 +37,6 @@ func ExportCheckGitRemote(continent string) domain.DoctorCheck {
 
 var ExportCheckWritability = checkWritability
 var ExportCheckSkills = checkSkills
-var ExportCheckEventStore = checkEventStore
 var ExportCheckClaudeInference = checkClaudeInference
 var ExportCheckGHScopes = checkGHScopes
 var ExportCheckContextBudget = CheckContextBudget
@@ -80,3 +79,38 @@ func ExportNewLocalGitExecutor() port.GitExecutor {
 func ExportInitGitRepoForWorktreeWithCommit(t *testing.T) string {
 	return initGitRepoForWorktreeWithCommit(t)
 }
+
+// ExportNewProjectionCacheWithInterval returns a ProjectionCache that
+// snapshots every interval events instead of projectionSnapshotInterval.
+func ExportNewProjectionCacheWithInterval(stateDir string, interval int) *ProjectionCache {
+	c := NewProjectionCache(stateDir, &domain.NopLogger{})
+	c.interval = interval // nosemgrep: immutability.no-pointer-field-mutation-go -- test bridge overriding the snapshot cadence [permanent]
+	return c
+}
+
+// ExportCheckEventStore wraps checkEventStore with a background context for tests.
+func ExportCheckEventStore(continent string) domain.DoctorCheck {
+	return checkEventStore(context.Background(), continent)
+}
+
+// ExportSetOutboxClock replaces the clock the outbox store stamps
+// attempts and measures retry backoff with.
+func ExportSetOutboxClock(s *SQLiteOutboxStore, now func() time.Time) {
+	s.now = now // nosemgrep: immutability.no-pointer-field-mutation-go -- test bridge overriding the outbox clock [permanent]
+}
+
+// ExportFlushRetryBackoff is the wait after the first failed flush attempt.
+const ExportFlushRetryBackoff = flushRetryBackoff
+
+// ExportOccupyMCPSlots takes every request slot of s, as that many
+// running requests would, and returns the func that frees them.
+func ExportOccupyMCPSlots(s *MCPServer) func() {
+	for range maxConcurrentMCPRequests {
+		s.slots <- struct{}{}
+	}
+	return func() {
+		for range maxConcurrentMCPRequests {
+			<-s.slots
+		}
+	}
+}
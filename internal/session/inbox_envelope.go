@@ -0,0 +1,149 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// readInboxEnvelope reads the envelope dir/<stem>.yaml and the body file
+// it references. The returned D-Mail is named stem, the name
+// archive_inbox addresses it by.
+func readInboxEnvelope(dir, stem string) (domain.DMailEnvelope, domain.DMail, error) {
+	data, err := os.ReadFile(filepath.Join(dir, stem+domain.EnvelopeFileExt))
+	if err != nil {
+		return domain.DMailEnvelope{}, domain.DMail{}, err
+	}
+	env, err := domain.ParseDMailEnvelope(data)
+	if err != nil {
+		return domain.DMailEnvelope{}, domain.DMail{}, err
+	}
+	bodyFile, err := env.BodyFile()
+	if err != nil {
+		return domain.DMailEnvelope{}, domain.DMail{}, err
+	}
+	body, err := os.ReadFile(filepath.Join(dir, bodyFile))
+	if err != nil {
+		return domain.DMailEnvelope{}, domain.DMail{}, fmt.Errorf("read body: %w", err)
+	}
+	dm := env.ToDMail(body)
+	dm.Name = stem
+	return env, dm, nil
+}
+
+// readInboxDMail reads the inbox D-Mail called name: a legacy
+// <name>.md file, or else the envelope <name>.yaml with its body (env
+// is then non-nil). Neither existing is fs.ErrNotExist.
+func readInboxDMail(continent, name string) (dm domain.DMail, env *domain.DMailEnvelope, err error) {
+	dir := domain.InboxDir(continent)
+	data, err := os.ReadFile(filepath.Join(dir, name+".md"))
+	if err == nil {
+		dm, err = domain.ParseDMail(data)
+		return dm, nil, err
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return domain.DMail{}, nil, err
+	}
+	e, dm, err := readInboxEnvelope(dir, name)
+	if err != nil {
+		return domain.DMail{}, nil, err
+	}
+	return dm, &e, nil
+}
+
+// stampEnvelopeSeen sets SeenAt of an envelope observed for the first
+// time and rewrites its file.
+func stampEnvelopeSeen(dir, stem string, env *domain.DMailEnvelope, now time.Time) error {
+	if env.SeenAt != nil {
+		return nil
+	}
+	seen := now.UTC()
+	env.SeenAt = &seen
+	return writeEnvelope(filepath.Join(dir, stem+domain.EnvelopeFileExt), *env)
+}
+
+func writeEnvelope(path string, env domain.DMailEnvelope) error {
+	data, err := env.Marshal()
+	if err != nil {
+		return err
+	}
+	return atomicWrite(path, data)
+}
+
+// archiveInboxEnvelope moves the envelope name and its body from inbox/
+// to archive/, stamping AckAt on the archived envelope and recording its
+// IdempotencyKey in the envelope ledger. The body moves first and the
+// inbox envelope goes last, so an interrupted archive leaves the
+// envelope in inbox/ to be archived again.
+func archiveInboxEnvelope(ctx context.Context, continent, name string, now time.Time) error {
+	inboxDir, arcDir := domain.InboxDir(continent), domain.ArchiveDir(continent)
+	env, _, err := readInboxEnvelope(inboxDir, name)
+	if err != nil {
+		return fmt.Errorf("dmail: read envelope %s: %w", name, err)
+	}
+	bodyFile, _ := env.BodyFile() // validated by readInboxEnvelope
+	if err := os.Rename(filepath.Join(inboxDir, bodyFile), filepath.Join(arcDir, bodyFile)); err != nil {
+		return fmt.Errorf("dmail: archive %s body: %w", name, err)
+	}
+	acked := now.UTC()
+	env.AckAt = &acked
+	if env.SeenAt == nil {
+		env.SeenAt = &acked
+	}
+	if err := writeEnvelope(filepath.Join(arcDir, name+domain.EnvelopeFileExt), env); err != nil {
+		return fmt.Errorf("dmail: archive %s envelope: %w", name, err)
+	}
+	ledger, err := NewEnvelopeLedgerForDir(continent)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = ledger.Close() }()
+	if err := ledger.RecordAck(ctx, env); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(inboxDir, name+domain.EnvelopeFileExt)); err != nil {
+		return fmt.Errorf("dmail: remove inbox envelope %s: %w", name, err)
+	}
+	return nil
+}
+
+// restoreInboxDMail undoes ArchiveInboxDMail for name: the files move
+// back to inbox/ and an envelope loses its AckAt and ledger entry.
+func restoreInboxDMail(ctx context.Context, continent, name string) error {
+	inboxDir, arcDir := domain.InboxDir(continent), domain.ArchiveDir(continent)
+	err := os.Rename(filepath.Join(arcDir, name+".md"), filepath.Join(inboxDir, name+".md"))
+	if err == nil {
+		return moveAttachments(arcDir, inboxDir, name)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	env, _, err := readInboxEnvelope(arcDir, name)
+	if err != nil {
+		return err
+	}
+	bodyFile, _ := env.BodyFile() // validated by readInboxEnvelope
+	acked := env
+	env.AckAt = nil
+	if err := writeEnvelope(filepath.Join(inboxDir, name+domain.EnvelopeFileExt), env); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(arcDir, bodyFile), filepath.Join(inboxDir, bodyFile)); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(arcDir, name+domain.EnvelopeFileExt)); err != nil {
+		return err
+	}
+	ledger, err := NewEnvelopeLedgerForDir(continent)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = ledger.Close() }()
+	return ledger.ForgetAck(ctx, acked)
+}
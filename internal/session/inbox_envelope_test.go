@@ -0,0 +1,152 @@
+package session_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+// writeInboxEnvelope delivers an envelope <id>.yaml with its body
+// <id>.body.md into inbox/.
+func writeInboxEnvelope(t *testing.T, continent, id, key, body string) {
+	t.Helper()
+	dir := domain.InboxDir(continent)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	env := domain.DMailEnvelope{
+		MessageID:      id,
+		SourceTool:     "sightjack",
+		TargetTool:     "paintress",
+		Kind:           "scan_report",
+		BodyPath:       "./" + id + domain.EnvelopeBodyExt,
+		CreatedAt:      time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC),
+		IdempotencyKey: key,
+	}
+	data, err := env.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, id+domain.EnvelopeFileExt), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, id+domain.EnvelopeBodyExt), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readEnvelope(t *testing.T, path string) domain.DMailEnvelope {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read envelope: %v", err)
+	}
+	env, err := domain.ParseDMailEnvelope(data)
+	if err != nil {
+		t.Fatalf("parse envelope: %v", err)
+	}
+	return env
+}
+
+func TestScanInbox_ReadsEnvelopesAlongsideLegacyDMails(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-legacy", Kind: domain.KindImplFeedback, Description: "legacy"})
+	writeInboxEnvelope(t, continent, "dmail-a", "sha256:a", "# Scan report from sightjack\n\nWave 4 next.\n")
+
+	// when
+	dmails, err := session.ScanInbox(context.Background(), continent)
+
+	// then
+	if err != nil {
+		t.Fatalf("ScanInbox: %v", err)
+	}
+	if len(dmails) != 2 {
+		t.Fatalf("dmails = %+v, want the envelope and the legacy d-mail", dmails)
+	}
+	env := dmails[0]
+	if env.Name != "dmail-a" || env.Kind != "scan_report" || env.Description != "Scan report from sightjack" {
+		t.Errorf("envelope d-mail = %+v", env)
+	}
+	if env.Metadata["source_tool"] != "sightjack" || env.Metadata["idempotency_key"] != "sha256:a" {
+		t.Errorf("envelope metadata = %v", env.Metadata)
+	}
+	if dmails[1].Name != "fb-legacy" {
+		t.Errorf("legacy d-mail = %+v", dmails[1])
+	}
+	stamped := readEnvelope(t, filepath.Join(domain.InboxDir(continent), "dmail-a.yaml"))
+	if stamped.SeenAt == nil || stamped.AckAt != nil {
+		t.Errorf("after listing: seen_at = %v, ack_at = %v; want seen, not acked", stamped.SeenAt, stamped.AckAt)
+	}
+}
+
+func TestMCPServer_ArchiveInbox_AcksEnvelopeAndSkipsRedelivery(t *testing.T) {
+	// given: an envelope consumed through archive_inbox
+	continent := t.TempDir()
+	writeInboxEnvelope(t, continent, "dmail-a", "sha256:same", "# Report\n")
+	emitter := &inboxEmitter{}
+	first := callTool(t, continent, emitter, "archive_inbox", `{"name":"dmail-a"}`)
+
+	// when: the same message is delivered again under another id
+	writeInboxEnvelope(t, continent, "dmail-b", "sha256:same", "# Report\n")
+	dmails, err := session.ScanInbox(context.Background(), continent)
+	if err != nil {
+		t.Fatalf("ScanInbox: %v", err)
+	}
+	second := callTool(t, continent, emitter, "archive_inbox", `{"name":"dmail-b"}`)
+
+	// then
+	if first["archived"] != true || first["persistence"] != "event-store+filesystem" {
+		t.Fatalf("first archive = %v", first)
+	}
+	archived := readEnvelope(t, filepath.Join(domain.ArchiveDir(continent), "dmail-a.yaml"))
+	if archived.AckAt == nil {
+		t.Error("archived envelope has no ack_at")
+	}
+	if _, err := os.Stat(filepath.Join(domain.ArchiveDir(continent), "dmail-a.body.md")); err != nil {
+		t.Errorf("archived body missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(domain.InboxDir(continent), "dmail-a.yaml")); !os.IsNotExist(err) {
+		t.Errorf("inbox envelope should be gone, stat err = %v", err)
+	}
+	if len(dmails) != 0 {
+		t.Errorf("re-delivered envelope listed: %+v", dmails)
+	}
+	if second["archived"] != true || second["duplicate"] != true {
+		t.Errorf("second archive = %v, want a duplicate archive", second)
+	}
+	if len(emitter.calls) != 2 {
+		t.Errorf("calls = %v, want events for the first delivery only", emitter.calls)
+	}
+}
+
+func TestMCPServer_ArchiveInbox_EmitFailureRestoresEnvelope(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	writeInboxEnvelope(t, continent, "dmail-keep", "sha256:keep", "# Keep\n")
+	emitter := &inboxEmitter{failOn: "dmail.archived"}
+
+	// when
+	body := callTool(t, continent, emitter, "archive_inbox", `{"name":"dmail-keep"}`)
+	dmails, err := session.ScanInbox(context.Background(), continent)
+
+	// then: back in inbox, un-acked and listed again
+	if body["archived"] != false {
+		t.Fatalf("archived = %v, want false", body["archived"])
+	}
+	if err != nil {
+		t.Fatalf("ScanInbox: %v", err)
+	}
+	if len(dmails) != 1 || dmails[0].Name != "dmail-keep" {
+		t.Errorf("dmails = %+v, want the restored envelope", dmails)
+	}
+	restored := readEnvelope(t, filepath.Join(domain.InboxDir(continent), "dmail-keep.yaml"))
+	if restored.AckAt != nil {
+		t.Errorf("restored envelope keeps ack_at %v", restored.AckAt)
+	}
+}
@@ -14,7 +14,14 @@ import (
 	"github.com/hironow/paintress/internal/domain"
 )
 
+// errJournalIssueMismatch reports a journal entry written for another
+// issue under the same expedition number.
+var errJournalIssueMismatch = errors.New("journal entry belongs to another issue")
+
 // WriteJournal writes an expedition report to the journal directory.
+// The entry is created atomically (temp file + link), and an existing
+// entry is only replaced when it records the same issue (or none);
+// otherwise the error wraps errJournalIssueMismatch.
 func WriteJournal(continent string, report *domain.ExpeditionReport) error {
 	dir := domain.JournalDir(continent)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -53,7 +60,49 @@ func WriteJournal(continent string, report *domain.ExpeditionReport) error {
 		report.HighSeverityDMails,
 	)
 
-	return os.WriteFile(path, []byte(content), 0644)
+	tmp, err := os.CreateTemp(dir, filename+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.WriteString(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Link(tmp.Name(), path); !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	existing, err := journalIssueID(path)
+	if err != nil {
+		return err
+	}
+	if existing != "" && existing != report.IssueID {
+		return fmt.Errorf("journal/%s records issue %s, not %s: %w", filename, existing, report.IssueID, errJournalIssueMismatch)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// journalIssueID reads the issue ID from the Issue line of a journal
+// entry ("- **Issue**: <id> — <title>"), or "" when it has none.
+func journalIssueID(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "- **Issue**:") {
+			id, _, _ := strings.Cut(extractValue(line), "—")
+			return strings.TrimSpace(id), nil
+		}
+	}
+	return "", nil
 }
 
 // WritePRIndex appends a PR URL index entry to the pr-index.jsonl file // nosemgrep: layer-session-no-event-persistence [permanent]
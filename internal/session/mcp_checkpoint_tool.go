@@ -0,0 +1,177 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// checkpointPhaseEnum lists the /expedition-next phases a checkpoint can
+// record, in workflow order.
+var checkpointPhaseEnum = []string{"implement", "verify", "pr", "journal", "report"}
+
+// realRecordCheckpoint emits EventExpeditionCheckpoint so a session that
+// crashes mid-expedition can be resumed by the next one (see
+// list_incomplete_expeditions). The latest checkpoint of an expedition
+// wins; append_journal's expedition.completed event closes it.
+func realRecordCheckpoint(continent string, emitter port.ExpeditionEventEmitter, args json.RawMessage) map[string]any {
+	var payload struct {
+		Expedition  int    `json:"expedition"`
+		Phase       string `json:"phase"`
+		WorkDir     string `json:"work_dir"`
+		CommitCount int    `json:"commit_count"`
+	}
+	if len(args) > 0 {
+		_ = json.Unmarshal(args, &payload)
+	}
+	if continent == "" {
+		return toolError(toolErrNotConfigured, map[string]any{
+			"initialized": false,
+			"recorded":    false,
+			"reason":      "paintress mcp continent root not configured",
+		})
+	}
+	if payload.Expedition <= 0 || payload.CommitCount < 0 {
+		return toolError(toolErrInvalidArguments, map[string]any{
+			"initialized": true,
+			"recorded":    false,
+			"reason":      "expedition must be positive and commit_count non-negative",
+		})
+	}
+	if journaled(continent, payload.Expedition) {
+		return toolError(toolErrConflict, map[string]any{
+			"initialized": true,
+			"recorded":    false,
+			"expedition":  payload.Expedition,
+			"reason":      fmt.Sprintf("expedition %d is already journaled; nothing to resume", payload.Expedition),
+		})
+	}
+	workDir := payload.WorkDir
+	if !filepath.IsAbs(workDir) {
+		workDir = filepath.Join(continent, workDir)
+	}
+
+	result := map[string]any{
+		"initialized":  true,
+		"recorded":     false,
+		"expedition":   payload.Expedition,
+		"phase":        payload.Phase,
+		"work_dir":     workDir,
+		"commit_count": payload.CommitCount,
+		"persistence":  "preview-only",
+	}
+	if emitter == nil {
+		result["note"] = "no event emitter wired; checkpoint not persisted"
+		return jsonResult(result)
+	}
+	if err := emitter.EmitCheckpoint(payload.Expedition, payload.Phase, workDir, payload.CommitCount, time.Now().UTC()); err != nil {
+		result["reason"] = fmt.Sprintf("emit checkpoint: %v", err)
+		return toolError(toolErrStorage, result)
+	}
+	result["recorded"] = true
+	result["persistence"] = "event-store"
+	return jsonResult(result)
+}
+
+// realListIncompleteExpeditions reports expeditions whose latest
+// checkpoint has no expedition.completed event and no journal entry,
+// enriched with the reserved issue id and the work dir's current branch.
+func realListIncompleteExpeditions(ctx context.Context, continent string) map[string]any {
+	if continent == "" {
+		return toolError(toolErrNotConfigured, map[string]any{
+			"initialized": false,
+			"reason":      "paintress mcp continent root not configured",
+		})
+	}
+	incomplete, err := incompleteExpeditions(ctx, continent)
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{"initialized": true, "reason": err.Error()})
+	}
+	instruction := "No interrupted expedition; pick new work with next_issue."
+	if len(incomplete) > 0 {
+		instruction = resumeInstruction(incomplete[0])
+	}
+	return jsonResult(map[string]any{
+		"initialized": true,
+		"continent":   continent,
+		"incomplete":  incomplete,
+		"instruction": instruction,
+	})
+}
+
+// incompleteExpeditions combines CheckpointScanner results with the
+// reservation table and git so a resume needs no further lookups.
+func incompleteExpeditions(ctx context.Context, continent string) ([]map[string]any, error) {
+	reservations, err := loadReservations(ctx, continent)
+	if err != nil {
+		return nil, fmt.Errorf("expedition reservations read failed: %w", err)
+	}
+	issues := make(map[int]string, len(reservations))
+	for _, r := range reservations {
+		issues[r.Expedition] = r.IssueID
+	}
+
+	incomplete := make([]map[string]any, 0)
+	for _, cp := range NewCheckpointScanner(continent).FindIncompleteCheckpoints() {
+		if journaled(continent, cp.Expedition) {
+			continue
+		}
+		_, statErr := os.Stat(cp.WorkDir)
+		incomplete = append(incomplete, map[string]any{
+			"expedition":      cp.Expedition,
+			"issue_id":        issues[cp.Expedition],
+			"phase":           cp.Phase,
+			"work_dir":        cp.WorkDir,
+			"work_dir_exists": statErr == nil,
+			"branch":          currentBranch(ctx, cp.WorkDir),
+			"commit_count":    cp.CommitCount,
+		})
+	}
+	return incomplete, nil
+}
+
+// resumeInstruction renders the sentence next_issue and
+// list_incomplete_expeditions lead with when work was interrupted.
+func resumeInstruction(cp map[string]any) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Resume expedition %d", cp["expedition"])
+	if issue, _ := cp["issue_id"].(string); issue != "" {
+		fmt.Fprintf(&b, " (%s)", issue)
+	}
+	if branch, _ := cp["branch"].(string); branch != "" {
+		fmt.Fprintf(&b, " on branch %s", branch)
+	}
+	fmt.Fprintf(&b, " at phase %s in %s before offering new work.", cp["phase"], cp["work_dir"])
+	return b.String()
+}
+
+// journaled reports whether journal/NNN.md exists for expedition.
+func journaled(continent string, expedition int) bool {
+	path := filepath.Join(domain.JournalDir(continent), fmt.Sprintf("%03d.md", expedition))
+	_, err := os.Stat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// currentBranch returns the checked-out branch of dir (also before its
+// first commit), or "" when dir is gone, not a git work tree, or on a
+// detached HEAD.
+func currentBranch(ctx context.Context, dir string) string {
+	gitCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	cmd := exec.CommandContext(gitCtx, "git", "-C", dir, "symbolic-ref", "--short", "-q", "HEAD")
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
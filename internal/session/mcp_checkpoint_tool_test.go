@@ -0,0 +1,107 @@
+package session_test
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// record_checkpoint / list_incomplete_expeditions let a new session
+// resume an expedition a crashed one left behind; next_issue leads with
+// it before offering new work.
+
+type checkpointEmitter struct {
+	port.NopExpeditionEventEmitter
+	store port.EventStore
+}
+
+func (e *checkpointEmitter) EmitCheckpoint(expedition int, phase, workDir string, commitCount int, now time.Time) error {
+	ev, err := domain.NewEvent(domain.EventExpeditionCheckpoint, domain.ExpeditionCheckpointData{
+		Expedition: expedition, Phase: phase, WorkDir: workDir, CommitCount: commitCount,
+	}, now)
+	if err != nil {
+		return err
+	}
+	_, err = e.store.Append(context.Background(), ev)
+	return err
+}
+
+func gitWorkTree(t *testing.T, branch string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", "-b", branch, dir).CombinedOutput(); err != nil {
+		t.Skipf("git init unavailable: %v (%s)", err, out)
+	}
+	return dir
+}
+
+func TestMCPServer_Checkpoint_NextIssueLeadsWithResume(t *testing.T) {
+	// given: expedition 1 reserved for MY-14 and checkpointed at verify
+	continent := t.TempDir()
+	workDir := gitWorkTree(t, "feat/my-14")
+	emitter := &checkpointEmitter{store: session.NewEventStore(filepath.Join(continent, domain.StateDir), nil)}
+	callTool(t, continent, nil, "start_expedition", `{"issue_id":"MY-14"}`)
+	recorded := callTool(t, continent, emitter, "record_checkpoint", `{"expedition":1,"phase":"verify","work_dir":"`+workDir+`","commit_count":3}`)
+	if recorded["recorded"] != true {
+		t.Fatalf("record_checkpoint = %v", recorded)
+	}
+
+	// when
+	listed := callTool(t, continent, nil, "list_incomplete_expeditions", `{}`)
+	next := callTool(t, continent, nil, "next_issue", `{}`)
+
+	// then
+	incomplete := listed["incomplete"].([]any)
+	if len(incomplete) != 1 {
+		t.Fatalf("incomplete = %v, want 1 entry", incomplete)
+	}
+	cp := incomplete[0].(map[string]any)
+	if cp["issue_id"] != "MY-14" || cp["branch"] != "feat/my-14" || cp["phase"] != "verify" || cp["commit_count"] != float64(3) {
+		t.Errorf("checkpoint = %v", cp)
+	}
+	resume, ok := next["resume"].(map[string]any)
+	if !ok || resume["expedition"] != float64(1) {
+		t.Fatalf("next_issue resume = %v", next["resume"])
+	}
+	want := "Resume expedition 1 (MY-14) on branch feat/my-14 at phase verify"
+	if instruction, _ := next["instruction"].(string); !strings.HasPrefix(instruction, want) {
+		t.Errorf("instruction = %q, want prefix %q", instruction, want)
+	}
+}
+
+func TestMCPServer_Checkpoint_JournaledExpeditionIsClosed(t *testing.T) {
+	// given: a checkpoint for expedition 2, which is then journaled
+	continent := t.TempDir()
+	emitter := &checkpointEmitter{store: session.NewEventStore(filepath.Join(continent, domain.StateDir), nil)}
+	callTool(t, continent, emitter, "record_checkpoint", `{"expedition":2,"phase":"pr","work_dir":"."}`)
+	writeJournal(t, continent, "002.md", "# Expedition #2 — Journal\n")
+
+	// when
+	listed := callTool(t, continent, nil, "list_incomplete_expeditions", `{}`)
+	again := callTool(t, continent, emitter, "record_checkpoint", `{"expedition":2,"phase":"report","work_dir":"."}`)
+
+	// then
+	if n := len(listed["incomplete"].([]any)); n != 0 {
+		t.Errorf("incomplete = %v, want none", listed["incomplete"])
+	}
+	if again["error_code"] != "conflict" {
+		t.Errorf("checkpoint after journal: error_code = %v, want conflict", again["error_code"])
+	}
+}
+
+func TestMCPServer_Checkpoint_RejectsUnknownPhase(t *testing.T) {
+	// when
+	body := callTool(t, t.TempDir(), nil, "record_checkpoint", `{"expedition":1,"phase":"lunch","work_dir":"."}`)
+
+	// then
+	if body["error_code"] != "invalid_arguments" {
+		t.Errorf("error_code = %v, want invalid_arguments", body["error_code"])
+	}
+}
@@ -0,0 +1,289 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// maxConcurrentMCPRequests bounds how many id-bearing requests a stdio
+// session runs at once. When every slot is busy further requests wait
+// for one in their own goroutine; the reader keeps reading, so
+// notifications/cancelled still reaches running and waiting requests.
+const maxConcurrentMCPRequests = 8
+
+// requestRegistry tracks the in-flight requests of one session (stdio or
+// HTTP) so notifications/cancelled can cancel them by id.
+type requestRegistry struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func (r *requestRegistry) track(key string, cancel context.CancelFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancels == nil {
+		r.cancels = make(map[string]context.CancelFunc)
+	}
+	r.cancels[key] = cancel
+}
+
+func (r *requestRegistry) untrack(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cancels, key)
+}
+
+func (r *requestRegistry) cancel(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cancel, ok := r.cancels[key]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// responseSequencer writes the responses of a stdio session in the order
+// their requests arrived, whatever order they complete in. Each request
+// takes a ticket when it is read; a response waits until every earlier
+// ticket has been answered or dropped.
+type responseSequencer struct {
+	mu    sync.Mutex
+	next  uint64 // oldest ticket not yet written
+	tail  uint64 // next ticket to hand out
+	ready map[uint64]*jsonrpcMessage
+}
+
+func (q *responseSequencer) reserve() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ticket := q.tail
+	q.tail++
+	return ticket
+}
+
+// complete records the response of ticket (nil drops it, e.g. for a
+// cancelled request) and writes every response now due. Completing a
+// ticket twice keeps the first outcome.
+func (q *responseSequencer) complete(s *MCPServer, ticket uint64, resp *jsonrpcMessage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ready == nil {
+		q.ready = make(map[uint64]*jsonrpcMessage)
+	}
+	if _, done := q.ready[ticket]; done || ticket < q.next {
+		return
+	}
+	q.ready[ticket] = resp
+	for {
+		due, ok := q.ready[q.next]
+		if !ok {
+			return
+		}
+		delete(q.ready, q.next)
+		q.next++
+		if due == nil {
+			continue
+		}
+		if err := s.writeMessage(*due); err != nil {
+			s.logger.Warn("mcp server: handle: %v", err)
+		}
+	}
+}
+
+// requestKey normalizes a JSON-RPC id so the id of a request and the
+// requestId of its notifications/cancelled compare equal.
+func requestKey(id json.RawMessage) string {
+	return string(bytes.TrimSpace(id))
+}
+
+// accept schedules one line read by Serve. Notifications (including
+// notifications/cancelled) and initialize run inline, so the negotiated
+// revision is in place before any later request is dispatched. Other
+// requests run concurrently, bounded by maxConcurrentMCPRequests; those
+// with side effects (see sequentialRequest) additionally run one at a
+// time in arrival order. Slots are taken by the request goroutines, never
+// by the reader, so a cancellation is read even while every slot is
+// busy. Responses are written in request order through
+// s.responses, so a fast request answered while an earlier slow one
+// runs is held back until the slow one is answered; a cancelled request
+// gets no response and stops holding later ones back.
+func (s *MCPServer) accept(ctx context.Context, line []byte) error {
+	var msg jsonrpcMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return fmt.Errorf("decode request: %w", err)
+	}
+	if len(msg.ID) == 0 {
+		if resp := s.dispatch(ctx, msg); resp != nil {
+			return s.writeMessage(*resp)
+		}
+		return nil
+	}
+	if msg.Method == "initialize" {
+		s.responses.complete(s, s.responses.reserve(), s.dispatch(ctx, msg))
+		return nil
+	}
+
+	reqCtx, cancel := context.WithCancel(ctx)
+	key := requestKey(msg.ID)
+	ticket := s.responses.reserve()
+	s.inFlight.track(key, func() {
+		cancel()
+		s.responses.complete(s, ticket, nil)
+	})
+
+	// Sequential requests wait for the previous one to finish; only the
+	// reader touches lastSequential, so it needs no lock.
+	var prev <-chan struct{}
+	var done chan struct{}
+	if sequentialRequest(msg) {
+		prev = s.lastSequential
+		done = make(chan struct{})
+		s.lastSequential = done
+	}
+
+	s.requests.Add(1)
+	go func() {
+		defer s.requests.Done()
+		defer cancel()
+		defer s.inFlight.untrack(key)
+		if prev != nil {
+			<-prev
+		}
+		if done != nil {
+			defer close(done)
+		}
+		// The slot is taken after prev, so sequential requests queued
+		// behind a running one do not hold slots it may need.
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-reqCtx.Done():
+			s.responses.complete(s, ticket, nil)
+			return
+		}
+		if reqCtx.Err() != nil {
+			s.responses.complete(s, ticket, nil)
+			return
+		}
+		progressCtx, finish := s.withProgress(reqCtx, msg)
+		resp := s.dispatch(progressCtx, msg)
+		finish()
+		if reqCtx.Err() != nil {
+			resp = nil
+		}
+		s.responses.complete(s, ticket, resp)
+	}()
+	return nil
+}
+
+// cancelRequest handles notifications/cancelled. Unknown or already
+// finished request ids are ignored, as the MCP spec requires.
+func (s *MCPServer) cancelRequest(params json.RawMessage) {
+	var p struct {
+		RequestID json.RawMessage `json:"requestId"`
+		Reason    string          `json:"reason"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || len(p.RequestID) == 0 {
+		return
+	}
+	if s.inFlight.cancel(requestKey(p.RequestID)) {
+		s.logger.Info("mcp server: request %s cancelled by client: %s", p.RequestID, p.Reason)
+	}
+}
+
+// toolReadOnly indexes readOnlyHint from toolDescriptors.
+var toolReadOnly = sync.OnceValue(func() map[string]bool {
+	index := make(map[string]bool)
+	for _, d := range toolDescriptors() {
+		annotations, _ := d["annotations"].(map[string]any)
+		readOnly, _ := annotations["readOnlyHint"].(bool)
+		index[d["name"].(string)] = readOnly
+	}
+	return index
+})
+
+// sequentialRequest reports whether msg has side effects whose order
+// matters: tool calls not annotated readOnlyHint (start_expedition before
+// append_journal, successive gradient deltas) and subscription changes.
+// Unknown tools are answered with an error and run concurrently.
+func sequentialRequest(msg jsonrpcMessage) bool {
+	switch msg.Method {
+	case "resources/subscribe", "resources/unsubscribe":
+		return true
+	case "tools/call":
+		var call struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(msg.Params, &call); err != nil {
+			return false
+		}
+		readOnly, known := toolReadOnly()[call.Name]
+		return known && !readOnly
+	default:
+		return false
+	}
+}
+
+type progressKey struct{}
+
+// progressReporter sends notifications/progress for one request that
+// carried params._meta.progressToken. finish stops it before the
+// response is written, so no progress follows the response.
+type progressReporter struct {
+	srv     *MCPServer
+	token   json.RawMessage
+	version string
+
+	mu       sync.Mutex
+	finished bool
+}
+
+// withProgress attaches a progressReporter to ctx when msg asked for
+// progress. The returned finish func must run before the response is
+// written.
+func (s *MCPServer) withProgress(ctx context.Context, msg jsonrpcMessage) (context.Context, func()) {
+	var p struct {
+		Meta struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(msg.Params, &p); err != nil || len(p.Meta.ProgressToken) == 0 {
+		return ctx, func() {}
+	}
+	reporter := &progressReporter{srv: s, token: p.Meta.ProgressToken, version: s.negotiatedVersion()}
+	return context.WithValue(ctx, progressKey{}, reporter), reporter.finish
+}
+
+func (p *progressReporter) finish() {
+	p.mu.Lock()
+	p.finished = true
+	p.mu.Unlock()
+}
+
+func (p *progressReporter) report(progress, total float64, message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.finished {
+		return
+	}
+	params := map[string]any{"progressToken": p.token, "progress": progress, "total": total}
+	// message was added to notifications/progress in 2025-03-26.
+	if message != "" && p.version >= mcpProtocol20250326 {
+		params["message"] = message
+	}
+	if err := p.srv.notify("notifications/progress", params); err != nil {
+		p.srv.logger.Warn("mcp server: progress: %v", err)
+	}
+}
+
+// reportProgress sends a notifications/progress for the request served
+// by ctx. It is a no-op when the client supplied no progressToken.
+func reportProgress(ctx context.Context, progress, total float64, message string) {
+	if reporter, ok := ctx.Value(progressKey{}).(*progressReporter); ok {
+		reporter.report(progress, total, message)
+	}
+}
@@ -0,0 +1,264 @@
+package session_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// Requests are dispatched concurrently: a slow tool must not hold up
+// later requests, responses still come in request order,
+// notifications/cancelled suppresses the cancelled response, and a
+// progressToken yields notifications/progress before the response.
+
+// blockingEmitter parks update_gradient inside EmitGradientChange until
+// release is closed, standing in for a slow tool.
+type blockingEmitter struct {
+	port.NopExpeditionEventEmitter
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newBlockingEmitter() *blockingEmitter {
+	return &blockingEmitter{entered: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (e *blockingEmitter) EmitGradientChange(domain.ExpectedVersion, int, int, string, time.Time) error {
+	e.entered <- struct{}{}
+	<-e.release
+	return nil
+}
+
+type pipeSession struct {
+	t     *testing.T
+	srv   *session.MCPServer
+	in    *io.PipeWriter
+	lines chan map[string]any
+	done  chan error
+}
+
+// startPipeSession runs Serve over io.Pipe so the test writes requests
+// and reads responses one at a time, like a real stdio client.
+func startPipeSession(t *testing.T, continent string, emitter port.ExpeditionEventEmitter) *pipeSession {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	srv := session.NewMCPServer(inR, outW, nil).WithContinent(continent)
+	if emitter != nil {
+		srv = srv.WithEmitter(emitter)
+	}
+	p := &pipeSession{t: t, srv: srv, in: inW, lines: make(chan map[string]any, 64), done: make(chan error, 1)}
+	go func() {
+		p.done <- srv.Serve(context.Background())
+		_ = outW.Close()
+	}()
+	go func() {
+		defer close(p.lines)
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			var msg map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err == nil {
+				p.lines <- msg
+			}
+		}
+	}()
+	return p
+}
+
+func (p *pipeSession) send(line string) {
+	p.t.Helper()
+	if _, err := io.WriteString(p.in, line+"\n"); err != nil {
+		p.t.Fatalf("write %s: %v", line, err)
+	}
+}
+
+func (p *pipeSession) next() map[string]any {
+	p.t.Helper()
+	select {
+	case msg, ok := <-p.lines:
+		if !ok {
+			p.t.Fatal("server closed output")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		p.t.Fatal("timed out waiting for a message")
+		return nil
+	}
+}
+
+// close ends the input stream and returns every message written after
+// it, once Serve has returned.
+func (p *pipeSession) close() []map[string]any {
+	p.t.Helper()
+	_ = p.in.Close()
+	var rest []map[string]any
+	for msg := range p.lines {
+		rest = append(rest, msg)
+	}
+	if err := <-p.done; err != nil {
+		p.t.Fatalf("Serve: %v", err)
+	}
+	return rest
+}
+
+func TestMCPServer_SlowRequestDoesNotBlockDispatch(t *testing.T) {
+	// given: update_gradient parked inside the emitter
+	emitter := newBlockingEmitter()
+	p := startPipeSession(t, t.TempDir(), emitter)
+	p.send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"update_gradient","arguments":{"delta":1}}}`)
+	<-emitter.entered
+
+	// when
+	p.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_insights","arguments":{},"_meta":{"progressToken":"tok-2"}}}`)
+
+	// then: get_insights runs (its progress arrives) while update_gradient
+	// is still running
+	if msg := p.next(); msg["method"] != "notifications/progress" {
+		t.Fatalf("first message = %v, want get_insights progress", msg)
+	}
+	close(emitter.release)
+	var ids []any
+	for _, msg := range p.close() {
+		if id, ok := msg["id"]; ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) != 2 || ids[0] != float64(1) || ids[1] != float64(2) {
+		t.Errorf("response ids = %v, want [1 2]", ids)
+	}
+}
+
+func TestMCPServer_ResponsesFollowRequestOrder(t *testing.T) {
+	// given: update_gradient parked inside the emitter
+	emitter := newBlockingEmitter()
+	p := startPipeSession(t, t.TempDir(), emitter)
+	p.send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"update_gradient","arguments":{"delta":1}}}`)
+	<-emitter.entered
+
+	// when: ping completes first
+	p.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"ping","arguments":{}}}`)
+	select {
+	case msg := <-p.lines:
+		t.Fatalf("answered %v before the earlier request", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(emitter.release)
+
+	// then: responses come in request order
+	if msg := p.next(); msg["id"] != float64(1) {
+		t.Fatalf("first response id = %v, want 1 (update_gradient)", msg["id"])
+	}
+	if msg := p.next(); msg["id"] != float64(2) {
+		t.Errorf("second response id = %v, want 2 (ping)", msg["id"])
+	}
+	p.close()
+}
+
+func TestMCPServer_CancelledRequestGetsNoResponse(t *testing.T) {
+	// given
+	emitter := newBlockingEmitter()
+	p := startPipeSession(t, t.TempDir(), emitter)
+	p.send(`{"jsonrpc":"2.0","id":"slow","method":"tools/call","params":{"name":"update_gradient","arguments":{"delta":1}}}`)
+	<-emitter.entered
+
+	// when: cancel, then use ping as a barrier (notifications run inline)
+	p.send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"slow","reason":"user aborted"}}`)
+	p.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"ping","arguments":{}}}`)
+	if msg := p.next(); msg["id"] != float64(2) {
+		t.Fatalf("response id = %v, want 2 (ping)", msg["id"])
+	}
+	close(emitter.release)
+	rest := p.close()
+
+	// then
+	for _, msg := range rest {
+		if msg["id"] == "slow" {
+			t.Errorf("cancelled request was answered: %v", msg)
+		}
+	}
+}
+
+func TestMCPServer_CancelReadWhileEverySlotIsBusy(t *testing.T) {
+	// given: every slot taken, and a request waiting for one
+	p := startPipeSession(t, t.TempDir(), nil)
+	release := session.ExportOccupyMCPSlots(p.srv)
+	p.send(`{"jsonrpc":"2.0","id":"queued","method":"tools/call","params":{"name":"ping","arguments":{}}}`)
+
+	// when: the client cancels it before a slot frees up; the pipe only
+	// takes each line once the reader is ready for it
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		_, _ = io.WriteString(p.in, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"queued","reason":"user aborted"}}`+"\n")
+		_, _ = io.WriteString(p.in, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"ping","arguments":{}}}`+"\n")
+	}()
+	select {
+	case <-sent:
+		release()
+	case <-time.After(5 * time.Second):
+		release()
+		t.Fatal("reader stopped reading while every slot was busy")
+	}
+
+	// then: the reader took the cancellation; only ping 2 is answered
+	if msg := p.next(); msg["id"] != float64(2) {
+		t.Fatalf("response id = %v, want 2 (the queued request was cancelled)", msg["id"])
+	}
+	for _, msg := range p.close() {
+		if msg["id"] == "queued" {
+			t.Errorf("cancelled request was answered: %v", msg)
+		}
+	}
+}
+
+func TestMCPServer_ProgressNotificationsPrecedeResponse(t *testing.T) {
+	// given
+	p := startPipeSession(t, t.TempDir(), nil)
+	p.send(initializeLine("2025-06-18"))
+	p.next()
+
+	// when
+	p.send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_insights","arguments":{},"_meta":{"progressToken":"tok-1"}}}`)
+
+	// then
+	var progress []map[string]any
+	for {
+		msg := p.next()
+		if msg["method"] == "notifications/progress" {
+			progress = append(progress, msg["params"].(map[string]any))
+			continue
+		}
+		if msg["id"] != float64(1) {
+			t.Fatalf("unexpected message %v", msg)
+		}
+		break
+	}
+	p.close()
+	if len(progress) == 0 {
+		t.Fatal("no notifications/progress before the response")
+	}
+	last := progress[len(progress)-1]
+	if last["progressToken"] != "tok-1" || last["progress"] != last["total"] {
+		t.Errorf("last progress = %v, want token tok-1 with progress == total", last)
+	}
+	if _, ok := last["message"].(string); !ok {
+		t.Errorf("2025-06-18 progress lacks message: %v", last)
+	}
+}
+
+func TestMCPServer_NoProgressWithoutToken(t *testing.T) {
+	// when
+	msgs := serveLines(t, t.TempDir(), `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_insights","arguments":{}}}`)
+
+	// then
+	if len(msgs) != 1 || msgs[0]["id"] != float64(1) {
+		t.Errorf("messages = %v, want only the response", msgs)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/hironow/paintress/internal/domain"
 	"github.com/hironow/paintress/internal/usecase/port"
@@ -15,6 +17,16 @@ import (
 // would bypass the SQLite stage -> atomic flush contract phonewave's
 // watcher depends on. SendDMail also emits dmail.staged /
 // dmail.flushed events when the expedition emitter is wired.
+//
+// The D-Mail is threaded under the one it answers: in_reply_to (the
+// argument, else the metadata key) names it, or else it is the latest
+// received D-Mail sharing an issue. in_reply_to / thread_id metadata the
+// caller set is kept.
+//
+// attachments lists files inside the continent (relative paths resolve
+// against it) to ship with the D-Mail: each is stored in the content-addressed
+// artifacts/ store and described in the attachments frontmatter, and
+// Flush copies the blobs next to the D-Mail.
 func realDMail(ctx context.Context, continent string, emitter port.ExpeditionEventEmitter, args json.RawMessage) map[string]any {
 	var payload struct {
 		Kind        string            `json:"kind"`
@@ -25,17 +37,27 @@ func realDMail(ctx context.Context, continent string, emitter port.ExpeditionEve
 		Severity    string            `json:"severity"`
 		Priority    int               `json:"priority"`
 		Metadata    map[string]string `json:"metadata"`
+		InReplyTo   string            `json:"in_reply_to"`
+		Attachments []string          `json:"attachments"`
 	}
 	if len(args) > 0 {
 		_ = json.Unmarshal(args, &payload)
 	}
 	if continent == "" {
-		return jsonResult(map[string]any{
+		return toolError(toolErrNotConfigured, map[string]any{
 			"initialized": false,
 			"sent":        false,
 			"reason":      "paintress mcp continent not configured (start `paintress mcp` from the project root)",
 		})
 	}
+	metadata := payload.Metadata
+	explicit := payload.InReplyTo
+	if explicit == "" {
+		explicit = metadata[domain.MetadataInReplyTo]
+	}
+	if parent, ok := repliedDMail(continent, explicit, payload.Issues); ok {
+		metadata = domain.ReplyTo(metadata, parent)
+	}
 	mail, err := domain.NewProducedDMail(
 		domain.DMailKind(payload.Kind),
 		payload.Name,
@@ -44,10 +66,17 @@ func realDMail(ctx context.Context, continent string, emitter port.ExpeditionEve
 		payload.Issues,
 		payload.Severity,
 		payload.Priority,
-		payload.Metadata,
+		metadata,
 	)
 	if err != nil {
-		return jsonResult(map[string]any{
+		return toolError(toolErrRejected, map[string]any{
+			"initialized": true,
+			"sent":        false,
+			"reason":      err.Error(),
+		})
+	}
+	if mail.Attachments, err = storeAttachments(continent, payload.Attachments); err != nil {
+		return toolError(toolErrRejected, map[string]any{
 			"initialized": true,
 			"sent":        false,
 			"reason":      err.Error(),
@@ -55,7 +84,7 @@ func realDMail(ctx context.Context, continent string, emitter port.ExpeditionEve
 	}
 	store, err := NewOutboxStoreForDir(continent)
 	if err != nil {
-		return jsonResult(map[string]any{
+		return toolError(toolErrStorage, map[string]any{
 			"initialized": true,
 			"sent":        false,
 			"reason":      fmt.Sprintf("outbox store open failed: %v", err),
@@ -63,18 +92,75 @@ func realDMail(ctx context.Context, continent string, emitter port.ExpeditionEve
 	}
 	defer func() { _ = store.Close() }()
 	if err := SendDMail(ctx, store, mail, emitter); err != nil {
-		return jsonResult(map[string]any{
+		return toolError(toolErrStorage, map[string]any{
 			"initialized": true,
 			"sent":        false,
 			"reason":      fmt.Sprintf("dmail send failed (re-run dmail to retry): %v", err),
 		})
 	}
-	return jsonResult(map[string]any{
+	result := map[string]any{
 		"initialized": true,
 		"sent":        true,
 		"name":        mail.Name,
 		"filename":    mail.Name + ".md",
 		"kind":        string(mail.Kind),
 		"persistence": "transactional-outbox",
-	})
+	}
+	if len(mail.Attachments) > 0 {
+		result["attachments"] = mail.Attachments
+	}
+	if parent := mail.Metadata[domain.MetadataInReplyTo]; parent != "" {
+		result["in_reply_to"] = parent
+		result["thread_id"] = mail.Metadata[domain.MetadataThreadID]
+	}
+	return jsonResult(result)
+}
+
+// storeAttachments puts the files at paths into the artifacts store of
+// continent and returns their attachment descriptions. Every path must
+// resolve, symlinks followed, to a file inside continent: the tool must
+// not ship arbitrary host files to other tools through the outbox.
+func storeAttachments(continent string, paths []string) ([]domain.DMailAttachment, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	root, err := filepath.EvalSymlinks(continent)
+	if err != nil {
+		return nil, fmt.Errorf("resolve continent: %w", err)
+	}
+	store := NewArtifactStoreForDir(continent)
+	attachments := make([]domain.DMailAttachment, 0, len(paths))
+	for _, path := range paths {
+		resolved, err := confinedAttachmentPath(root, path)
+		if err != nil {
+			return nil, err
+		}
+		a, err := store.Put(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("attach %s: %w", path, err)
+		}
+		attachments = append(attachments, a)
+	}
+	if err := domain.ValidateAttachments(attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// confinedAttachmentPath resolves path (relative to root unless absolute)
+// through its symlinks and rejects it unless it stays inside root.
+func confinedAttachmentPath(root, path string) (string, error) {
+	candidate := path
+	if !filepath.IsAbs(candidate) {
+		candidate = filepath.Join(root, candidate)
+	}
+	resolved, err := filepath.EvalSymlinks(candidate)
+	if err != nil {
+		return "", fmt.Errorf("attach %s: %w", path, err)
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("attach %s: outside the project directory", path)
+	}
+	return resolved, nil
 }
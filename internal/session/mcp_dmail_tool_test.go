@@ -78,10 +78,10 @@ func TestMCPServer_DMail_RejectsKindOutsideProducesSet(t *testing.T) {
 
 	// then
 	body := decodeDMailToolJSON(t, out.Bytes())
-	if body["sent"] != false {
-		t.Fatalf("sent = %v, want false for non-produced kind", body["sent"])
+	if body["error_code"] != "invalid_arguments" {
+		t.Fatalf("error_code = %v, want invalid_arguments for non-produced kind", body["error_code"])
 	}
-	if reason, _ := body["reason"].(string); !strings.Contains(reason, "produce") {
+	if reason, _ := body["reason"].(string); !strings.Contains(reason, "must be one of report") {
 		t.Errorf("reason = %v, want produces-set explanation", body["reason"])
 	}
 }
@@ -100,8 +100,8 @@ func TestMCPServer_DMail_RejectsInvalidPayload(t *testing.T) {
 
 	// then
 	body := decodeDMailToolJSON(t, out.Bytes())
-	if body["sent"] != false {
-		t.Errorf("sent = %v, want false for invalid payload", body["sent"])
+	if body["error_code"] != "invalid_arguments" {
+		t.Errorf("error_code = %v, want invalid_arguments for missing description", body["error_code"])
 	}
 }
 
@@ -0,0 +1,175 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// realStartExpedition reserves the next expedition number for an issue
+// and emits EventExpeditionStarted. The number comes from the run lock
+// store's reservation table (one atomic INSERT ... SELECT), so two
+// sessions starting at once never share a number, and numbers of failed
+// or skipped expeditions that never reached pr-index are never reused.
+//
+// Re-invoking for an issue whose reservation has not been journaled yet
+// returns the same number (reused=true) without a second start event,
+// so an aborted /expedition-next run resumes on its original number.
+// The reuse check is part of the allocating statement (ClaimExpedition),
+// and a reservation left unjournaled for domain.ExpeditionReservationTTL
+// is abandoned: the issue then gets a fresh number.
+func realStartExpedition(ctx context.Context, continent string, emitter port.ExpeditionEventEmitter, args json.RawMessage) map[string]any {
+	var payload struct {
+		IssueID string `json:"issue_id"`
+		Worker  int    `json:"worker"`
+		Model   string `json:"model"`
+	}
+	if len(args) > 0 {
+		_ = json.Unmarshal(args, &payload)
+	}
+	if continent == "" {
+		return toolError(toolErrNotConfigured, map[string]any{
+			"initialized": false,
+			"reserved":    false,
+			"reason":      "paintress mcp continent root not configured",
+		})
+	}
+	if payload.IssueID == "" {
+		return toolError(toolErrInvalidArguments, map[string]any{
+			"initialized": true,
+			"reserved":    false,
+			"reason":      "missing required field: issue_id",
+		})
+	}
+
+	store, err := NewRunLockStoreForDir(continent)
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{
+			"initialized": true,
+			"reserved":    false,
+			"reason":      fmt.Sprintf("run lock store open failed: %v", err),
+		})
+	}
+	defer func() { _ = store.Close() }()
+
+	reservations, err := store.ExpeditionReservations(ctx)
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{"initialized": true, "reserved": false, "reason": err.Error()})
+	}
+	// Reservations journaled without closing them (older releases, or a
+	// failed close in append_journal) must not be resumed.
+	for _, r := range reservations {
+		if !r.Closed && journaled(continent, r.Expedition) {
+			if err := store.CloseExpedition(ctx, r.Expedition); err != nil {
+				return toolError(toolErrStorage, map[string]any{"initialized": true, "reserved": false, "reason": err.Error()})
+			}
+		}
+	}
+
+	expedition, reused, err := store.ClaimExpedition(ctx, payload.IssueID, highestUsedExpedition(continent), time.Now())
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{"initialized": true, "reserved": false, "reason": err.Error()})
+	}
+	if reused {
+		return jsonResult(map[string]any{
+			"initialized": true,
+			"reserved":    true,
+			"reused":      true,
+			"expedition":  expedition,
+			"issue_id":    payload.IssueID,
+			"persistence": "reservation",
+			"note":        "issue already holds an unjournaled reservation; resume it",
+		})
+	}
+	result := map[string]any{
+		"initialized": true,
+		"reserved":    true,
+		"reused":      false,
+		"expedition":  expedition,
+		"issue_id":    payload.IssueID,
+		"persistence": "reservation",
+	}
+	if emitter == nil {
+		return jsonResult(result)
+	}
+	if err := emitter.EmitStartExpedition(expedition, payload.Worker, payload.Model, time.Now().UTC()); err != nil {
+		result["reason"] = fmt.Sprintf("emit expedition started: %v", err)
+		return toolError(toolErrPartialPersistence, result)
+	}
+	result["persistence"] = "event-store+reservation"
+	return jsonResult(result)
+}
+
+// highestUsedExpedition returns the largest expedition number already
+// visible on disk, from pr-index entries and journal/NNN.md files. It
+// is the floor for new reservations so pre-reservation history is
+// never reused.
+func highestUsedExpedition(continent string) int {
+	highest := 0
+	if entries, err := ReadPRIndex(continent); err == nil {
+		for _, e := range entries {
+			highest = max(highest, e.Expedition)
+		}
+	}
+	if files, err := ListJournalFiles(continent); err == nil {
+		for _, f := range files {
+			if n, convErr := strconv.Atoi(strings.TrimSuffix(filepath.Base(f), ".md")); convErr == nil {
+				highest = max(highest, n)
+			}
+		}
+	}
+	return highest
+}
+
+// openReservations filters reservations down to those whose journal
+// entry has not been written yet and that have not expired (=
+// expeditions still in flight).
+func openReservations(continent string, reservations []domain.ExpeditionReservation) []domain.ExpeditionReservation {
+	var open []domain.ExpeditionReservation
+	now := time.Now()
+	for _, r := range reservations {
+		if !r.Closed && !r.Expired(now) && !journaled(continent, r.Expedition) {
+			open = append(open, r)
+		}
+	}
+	return open
+}
+
+// loadReservations reads the reservation table without creating the
+// run lock store when it does not exist yet (read paths such as
+// next_issue must not leave a database behind).
+func loadReservations(ctx context.Context, continent string) ([]domain.ExpeditionReservation, error) {
+	if _, err := os.Stat(runLockStorePath(continent)); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	store, err := NewRunLockStoreForDir(continent)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = store.Close() }()
+	return store.ExpeditionReservations(ctx)
+}
+
+// closeReservation closes the reservation of a journaled expedition. A
+// continent without a run lock store has nothing to close.
+func closeReservation(ctx context.Context, continent string, expedition int) error {
+	if _, err := os.Stat(runLockStorePath(continent)); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	store, err := NewRunLockStoreForDir(continent)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+	return store.CloseExpedition(ctx, expedition)
+}
@@ -0,0 +1,135 @@
+package session_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// start_expedition reserves expedition numbers atomically so concurrent
+// sessions never share one and append_journal cannot silently overwrite
+// another issue's journal.
+
+type startRecordingEmitter struct {
+	port.NopExpeditionEventEmitter
+	started []int
+}
+
+func (e *startRecordingEmitter) EmitStartExpedition(expedition, _ int, _ string, _ time.Time) error {
+	e.started = append(e.started, expedition)
+	return nil
+}
+
+func TestMCPServer_StartExpedition_ReservesAboveJournalAndEmits(t *testing.T) {
+	// given: a failed expedition #3 journaled without a PR (invisible to pr-index)
+	continent := t.TempDir()
+	writeJournal(t, continent, "003.md", "# Expedition #3 — Journal\n")
+	emitter := &startRecordingEmitter{}
+
+	// when
+	body := callTool(t, continent, emitter, "start_expedition", `{"issue_id":"MY-10"}`)
+
+	// then
+	if body["reserved"] != true || body["expedition"] != float64(4) {
+		t.Fatalf("body = %v, want expedition 4", body)
+	}
+	if body["persistence"] != "event-store+reservation" {
+		t.Errorf("persistence = %v", body["persistence"])
+	}
+	if len(emitter.started) != 1 || emitter.started[0] != 4 {
+		t.Errorf("started = %v, want [4]", emitter.started)
+	}
+}
+
+func TestMCPServer_StartExpedition_SecondIssueGetsNextNumber_SameIssueReuses(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	emitter := &startRecordingEmitter{}
+	first := callTool(t, continent, emitter, "start_expedition", `{"issue_id":"MY-1"}`)
+
+	// when
+	other := callTool(t, continent, emitter, "start_expedition", `{"issue_id":"MY-2"}`)
+	again := callTool(t, continent, emitter, "start_expedition", `{"issue_id":"MY-1"}`)
+
+	// then
+	if first["expedition"] != float64(1) || other["expedition"] != float64(2) {
+		t.Errorf("first = %v, other = %v; want 1, 2", first["expedition"], other["expedition"])
+	}
+	if again["expedition"] != float64(1) || again["reused"] != true {
+		t.Errorf("again = %v, want reused expedition 1", again)
+	}
+	if len(emitter.started) != 2 {
+		t.Errorf("started = %v, want 2 start events (reuse emits none)", emitter.started)
+	}
+}
+
+func TestMCPServer_NextIssue_AccountsForReservations(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	callTool(t, continent, nil, "start_expedition", `{"issue_id":"MY-7"}`)
+
+	// when
+	body := callTool(t, continent, nil, "next_issue", `{}`)
+
+	// then
+	if body["next_expedition_number"] != float64(2) {
+		t.Errorf("next_expedition_number = %v, want 2", body["next_expedition_number"])
+	}
+	inFlight := body["in_flight"].([]any)
+	if len(inFlight) != 1 || inFlight[0].(map[string]any)["issue_id"] != "MY-7" {
+		t.Errorf("in_flight = %v", inFlight)
+	}
+}
+
+func TestMCPServer_AppendJournal_RejectsNumberReservedForOtherIssue(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	callTool(t, continent, nil, "start_expedition", `{"issue_id":"MY-1"}`)
+
+	// when
+	rejected := callTool(t, continent, nil, "append_journal", `{"expedition":1,"issue_id":"MY-2","status":"success"}`)
+	accepted := callTool(t, continent, nil, "append_journal", `{"expedition":1,"issue_id":"MY-1","status":"success"}`)
+
+	// then
+	if rejected["persisted"] != false || !strings.Contains(rejected["reason"].(string), "reserved for issue MY-1") {
+		t.Errorf("rejected = %v", rejected)
+	}
+	if accepted["persisted"] != true {
+		t.Errorf("accepted = %v", accepted)
+	}
+}
+
+func TestMCPServer_AppendJournal_RejectsJournalOfOtherIssue(t *testing.T) {
+	// given: expedition 5 journaled for MY-5 without a reservation
+	continent := t.TempDir()
+	callTool(t, continent, nil, "append_journal", `{"expedition":5,"issue_id":"MY-5","status":"success"}`)
+
+	// when
+	rejected := callTool(t, continent, nil, "append_journal", `{"expedition":5,"issue_id":"MY-6","status":"failed"}`)
+	rewritten := callTool(t, continent, nil, "append_journal", `{"expedition":5,"issue_id":"MY-5","status":"failed"}`)
+
+	// then
+	if rejected["persisted"] != false || rejected["error_code"] != "conflict" || !strings.Contains(rejected["reason"].(string), "records issue MY-5") {
+		t.Errorf("rejected = %v", rejected)
+	}
+	if rewritten["persisted"] != true {
+		t.Errorf("rewritten = %v, want the same issue to replace its entry", rewritten)
+	}
+}
+
+func TestMCPServer_StartExpedition_JournaledReservationIsNotResumed(t *testing.T) {
+	// given: MY-1 finished expedition 1
+	continent := t.TempDir()
+	callTool(t, continent, nil, "start_expedition", `{"issue_id":"MY-1"}`)
+	callTool(t, continent, nil, "append_journal", `{"expedition":1,"issue_id":"MY-1","status":"failed"}`)
+
+	// when
+	again := callTool(t, continent, nil, "start_expedition", `{"issue_id":"MY-1"}`)
+
+	// then
+	if again["expedition"] != float64(2) || again["reused"] != false {
+		t.Errorf("again = %v, want a fresh expedition 2", again)
+	}
+}
@@ -0,0 +1,99 @@
+package session_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// racingEmitter persists gradient changes like the real emitter, but
+// before its first races appends it lets a competing session append a
+// change of +10 to the same stream, between update_gradient's read and
+// its write.
+type racingEmitter struct {
+	port.NopExpeditionEventEmitter
+	store port.EventStore
+	races int
+	emits int
+}
+
+func (e *racingEmitter) gradientEvent(level, delta int, operator string) domain.Event {
+	ev, _ := domain.NewEvent(domain.EventGradientChanged, domain.GradientChangedData{Level: level, Delta: &delta, Operator: operator}, time.Now())
+	ev.AggregateType = domain.AggregateTypeGradient
+	ev.AggregateID = domain.GradientAggregateID
+	return ev
+}
+
+func (e *racingEmitter) GradientVersion() (domain.ExpectedVersion, error) {
+	v, err := e.store.StreamVersion(context.Background(), domain.GradientStreamID)
+	return domain.ExpectedVersion(v), err
+}
+
+func (e *racingEmitter) EmitGradientChange(expected domain.ExpectedVersion, level, delta int, operator string, _ time.Time) error {
+	e.emits++
+	if e.emits <= e.races {
+		competing := e.gradientEvent(int(expected)*10+10, 10, "other-session")
+		if _, err := e.store.Append(context.Background(), competing); err != nil {
+			return err
+		}
+	}
+	_, err := e.store.AppendExpecting(context.Background(), expected, e.gradientEvent(level, delta, operator))
+	return err
+}
+
+func callUpdateGradient(t *testing.T, continent string, emitter port.ExpeditionEventEmitter, args string) map[string]any {
+	t.Helper()
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"update_gradient","arguments":` + args + `}}` + "\n")
+	var out bytes.Buffer
+	if err := session.NewMCPServer(in, &out, nil).WithContinent(continent).WithEmitter(emitter).Serve(context.Background()); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	return decodeFirstText(t, &out)
+}
+
+func TestMCPServer_UpdateGradient_RetriesOnConcurrentChange(t *testing.T) {
+	// given: another session persists +10 while the first attempt is in flight
+	continent := t.TempDir()
+	store := session.NewEventStore(filepath.Join(continent, domain.StateDir), nil)
+	emitter := &racingEmitter{store: store, races: 1}
+
+	// when
+	body := callUpdateGradient(t, continent, emitter, `{"delta":3,"operator":"session-a"}`)
+
+	// then: the retry builds on the competing change instead of overwriting it
+	if got, _ := body["new_level"].(float64); got != 13 {
+		t.Errorf("new_level = %v, want 13 (10 from the other session + 3): %v", body["new_level"], body)
+	}
+	if body["attempts"] != float64(2) || body["operator"] != "session-a" {
+		t.Errorf("attempts = %v operator = %v, want 2 / session-a", body["attempts"], body["operator"])
+	}
+	events, _, _ := store.LoadAll(context.Background())
+	var last domain.GradientChangedData
+	_ = json.Unmarshal(events[len(events)-1].Data, &last)
+	if len(events) != 2 || last.Level != 13 || last.Delta == nil || *last.Delta != 3 || last.Operator != "session-a" {
+		t.Errorf("stored %d events, last = %+v, want level 13 with delta 3 by session-a", len(events), last)
+	}
+}
+
+func TestMCPServer_UpdateGradient_ReportsPersistentConflict(t *testing.T) {
+	// given: every attempt loses to a competing session
+	continent := t.TempDir()
+	store := session.NewEventStore(filepath.Join(continent, domain.StateDir), nil)
+	emitter := &racingEmitter{store: store, races: 99}
+
+	// when
+	body := callUpdateGradient(t, continent, emitter, `{"delta":1}`)
+
+	// then
+	if body["error_code"] != "conflict" || body["attempts"] != float64(3) {
+		t.Errorf("body = %v, want a conflict after 3 attempts", body)
+	}
+}
@@ -0,0 +1,485 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// mcpHTTPPath is the single Streamable HTTP endpoint (MCP transport spec:
+// one path serves POST, GET and DELETE).
+const mcpHTTPPath = "/mcp"
+
+// mcpSessionHeader carries the session id assigned on initialize.
+const mcpSessionHeader = "Mcp-Session-Id"
+
+// mcpProtocolHeader carries the negotiated revision on requests after
+// initialize (2025-06-18 Streamable HTTP).
+const mcpProtocolHeader = "MCP-Protocol-Version"
+
+// mcpHTTPMaxBody bounds a POST body, matching the stdio line buffer.
+const mcpHTTPMaxBody = 4 * 1024 * 1024
+
+// mcpSessionBacklog bounds server-initiated messages queued for a
+// session whose SSE stream is not connected; further messages are
+// dropped rather than blocking the watcher.
+const mcpSessionBacklog = 64
+
+// mcpSessionIdleTTL is how long a session may go without requests or an
+// open SSE stream before it is ended, unless MCPHTTPOptions.IdleTTL
+// says otherwise.
+const mcpSessionIdleTTL = 30 * time.Minute
+
+// MCPHTTPOptions configures the Streamable HTTP transport.
+//
+// Token, when set, must be presented as "Authorization: Bearer <token>".
+// Browser-originated requests are accepted only from loopback origins
+// plus AllowedOrigins (DNS rebinding protection); requests without an
+// Origin header (CLI clients) are unaffected.
+//
+// IdleTTL ends sessions that had no request and no open SSE stream for
+// that long (default 30 minutes); their id then answers 404, which
+// tells the client to re-initialize.
+type MCPHTTPOptions struct {
+	Token          string
+	AllowedOrigins []string
+	IdleTTL        time.Duration
+}
+
+// MCPHTTPHandler serves the MCP Streamable HTTP transport: POST for
+// client requests (answered as application/json), GET for the per-session
+// SSE stream of server-initiated messages, DELETE to end a session. Each
+// session gets its own MCPServer sharing the base server's continent and
+// emitter, so one long-lived process serves several claude-code windows
+// through the same dispatch as stdio. The shared emitter serializes its
+// own appends; read-only requests run concurrently, and requests with
+// side effects (sequentialRequest) run one at a time across all
+// sessions, so sessions never race on the journal and other
+// .expedition/ files. Idle sessions are reaped (see MCPHTTPOptions).
+type MCPHTTPHandler struct {
+	base *MCPServer
+	opts MCPHTTPOptions
+	ctx  context.Context
+
+	sequential sync.Mutex
+
+	mu       sync.Mutex
+	sessions map[string]*mcpHTTPSession
+
+	closeOnce sync.Once
+	closed    chan struct{} // stops the reaper
+}
+
+// mcpHTTPSession is one initialized client. busy and lastActive are
+// guarded by the handler's mu.
+type mcpHTTPSession struct {
+	id         string
+	srv        *MCPServer
+	queue      chan []byte
+	ctx        context.Context
+	stop       func()
+	stream     sync.Mutex // one SSE stream per session at a time
+	busy       int        // requests and streams using the session
+	lastActive time.Time  // when the last of them ended
+}
+
+// sessionSink adapts the session queue to the io.Writer MCPServer writes
+// notifications to. Each Write is one newline-terminated message.
+type sessionSink struct {
+	queue  chan []byte
+	logger domain.Logger
+}
+
+func (w sessionSink) Write(p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\n")
+	select {
+	case w.queue <- bytes.Clone(msg):
+	default:
+		w.logger.Warn("mcp http: session backlog full, dropping message")
+	}
+	return len(p), nil
+}
+
+// HTTPHandler returns the Streamable HTTP handler for s. Sessions live
+// until DELETE, their idle TTL, ctx cancellation, or Close.
+func (s *MCPServer) HTTPHandler(ctx context.Context, opts MCPHTTPOptions) *MCPHTTPHandler {
+	if opts.IdleTTL <= 0 {
+		opts.IdleTTL = mcpSessionIdleTTL
+	}
+	h := &MCPHTTPHandler{base: s, opts: opts, ctx: ctx, sessions: make(map[string]*mcpHTTPSession), closed: make(chan struct{})}
+	go h.reapIdle()
+	return h
+}
+
+// ListenHTTP serves the Streamable HTTP transport on addr until ctx is
+// cancelled, then shuts the listener down and ends every session.
+func (s *MCPServer) ListenHTTP(ctx context.Context, addr string, opts MCPHTTPOptions) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("mcp http: listen %s: %w", addr, err)
+	}
+	if opts.Token == "" && !isLoopbackAddr(ln.Addr()) {
+		s.logger.Warn("mcp http: %s is not a loopback address and no bearer token is set", ln.Addr())
+	}
+	handler := s.HTTPHandler(ctx, opts)
+	defer handler.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle(mcpHTTPPath, handler)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+	s.logger.Info("mcp http: listening on http://%s%s", ln.Addr(), mcpHTTPPath)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("mcp http: serve: %w", err)
+	case <-ctx.Done():
+	}
+	// Open SSE streams end with their sessions; close them first so
+	// Shutdown does not wait on them.
+	handler.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("mcp http: shutdown: %w", err)
+	}
+	return nil
+}
+
+// Close ends every session and stops their resource watchers.
+func (h *MCPHTTPHandler) Close() {
+	h.closeOnce.Do(func() { close(h.closed) })
+	h.mu.Lock()
+	sessions := h.sessions
+	h.sessions = make(map[string]*mcpHTTPSession)
+	h.mu.Unlock()
+	for _, sess := range sessions {
+		sess.stop()
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *MCPHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.originAllowed(r.Header.Get("Origin")) {
+		http.Error(w, "forbidden origin", http.StatusForbidden)
+		return
+	}
+	if !h.authorized(r.Header.Get("Authorization")) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="paintress"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if v := r.Header.Get(mcpProtocolHeader); v != "" && !slices.Contains(mcpProtocolVersions, v) {
+		http.Error(w, "unsupported "+mcpProtocolHeader+": "+v, http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleStream(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *MCPHTTPHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, mcpHTTPMaxBody+1))
+	if err != nil {
+		http.Error(w, "read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > mcpHTTPMaxBody {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	msgs, batch, err := decodeHTTPMessages(body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, replyError(nil, -32700, fmt.Sprintf("parse error: %v", err)))
+		return
+	}
+
+	var sess *mcpHTTPSession
+	if slices.ContainsFunc(msgs, func(m jsonrpcMessage) bool { return m.Method == "initialize" }) {
+		sess, err = h.newSession()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		var status int
+		sess, status = h.lookupSession(r.Header.Get(mcpSessionHeader))
+		if sess == nil {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+	}
+	defer h.releaseSession(sess)
+
+	var responses []*jsonrpcMessage
+	for _, msg := range msgs {
+		if resp := h.dispatch(r.Context(), sess, msg); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+
+	w.Header().Set(mcpSessionHeader, sess.id)
+	switch {
+	case len(responses) == 0:
+		// Only notifications / responses / cancelled requests:
+		// acknowledged without a body.
+		w.WriteHeader(http.StatusAccepted)
+	case batch:
+		writeJSON(w, http.StatusOK, responses)
+	default:
+		writeJSON(w, http.StatusOK, responses[0])
+	}
+}
+
+// dispatch runs one POSTed message for sess. A request is registered in
+// the session's in-flight map while it runs, so a notifications/cancelled
+// posted meanwhile cancels it; a cancelled request gets no response.
+// initialize and requests with side effects hold h.sequential.
+func (h *MCPHTTPHandler) dispatch(ctx context.Context, sess *mcpHTTPSession, msg jsonrpcMessage) *jsonrpcMessage {
+	if len(msg.ID) == 0 {
+		return sess.srv.dispatch(ctx, msg)
+	}
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	key := requestKey(msg.ID)
+	sess.srv.inFlight.track(key, cancel)
+	defer sess.srv.inFlight.untrack(key)
+
+	if msg.Method == "initialize" || sequentialRequest(msg) {
+		h.sequential.Lock()
+		defer h.sequential.Unlock()
+	}
+	if reqCtx.Err() != nil {
+		return nil
+	}
+	progressCtx, finish := sess.srv.withProgress(reqCtx, msg)
+	resp := sess.srv.dispatch(progressCtx, msg)
+	finish()
+	if reqCtx.Err() != nil {
+		return nil
+	}
+	return resp
+}
+
+// handleStream opens the SSE stream carrying server-initiated messages
+// (resource notifications) for the session.
+func (h *MCPHTTPHandler) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "GET requires Accept: text/event-stream", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, status := h.lookupSession(r.Header.Get(mcpSessionHeader))
+	if sess == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	defer h.releaseSession(sess)
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	if !sess.stream.TryLock() {
+		http.Error(w, "session already has an open stream", http.StatusConflict)
+		return
+	}
+	defer sess.stream.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set(mcpSessionHeader, sess.id)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sess.ctx.Done():
+			return
+		case msg := <-sess.queue:
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (h *MCPHTTPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(mcpSessionHeader)
+	sess, status := h.lookupSession(id)
+	if sess == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	h.releaseSession(sess)
+	h.mu.Lock()
+	delete(h.sessions, id)
+	h.mu.Unlock()
+	sess.stop()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MCPHTTPHandler) newSession() (*mcpHTTPSession, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("mcp http: session id: %w", err)
+	}
+	id := hex.EncodeToString(raw)
+	queue := make(chan []byte, mcpSessionBacklog)
+	srv := NewMCPServer(nil, sessionSink{queue: queue, logger: h.base.logger}, h.base.logger).
+		WithContinent(h.base.continent).
+		WithEmitter(h.base.emitter)
+	ctx, stop := srv.start(h.ctx)
+	sess := &mcpHTTPSession{id: id, srv: srv, queue: queue, ctx: ctx, stop: stop, busy: 1}
+
+	h.mu.Lock()
+	h.sessions[id] = sess
+	h.mu.Unlock()
+	return sess, nil
+}
+
+// lookupSession resolves the Mcp-Session-Id header. A missing header is
+// 400 and an unknown (expired or deleted) id is 404, which tells the
+// client to re-initialize. The session is busy, and so not reaped, until
+// releaseSession; newSession returns it busy as well.
+func (h *MCPHTTPHandler) lookupSession(id string) (*mcpHTTPSession, int) {
+	if id == "" {
+		return nil, http.StatusBadRequest
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sess, ok := h.sessions[id]
+	if !ok {
+		return nil, http.StatusNotFound
+	}
+	sess.busy++
+	return sess, http.StatusOK
+}
+
+// releaseSession ends a use of sess begun by lookupSession or newSession.
+func (h *MCPHTTPHandler) releaseSession(sess *mcpHTTPSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sess.busy--
+	sess.lastActive = time.Now()
+}
+
+// reapIdle ends sessions that have been idle for longer than the idle
+// TTL, until ctx is done or Close.
+func (h *MCPHTTPHandler) reapIdle() {
+	ticker := time.NewTicker(h.opts.IdleTTL / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-h.closed:
+			return
+		case now := <-ticker.C:
+			var idle []*mcpHTTPSession
+			h.mu.Lock()
+			for id, sess := range h.sessions {
+				if sess.busy == 0 && now.Sub(sess.lastActive) > h.opts.IdleTTL {
+					delete(h.sessions, id)
+					idle = append(idle, sess)
+				}
+			}
+			h.mu.Unlock()
+			for _, sess := range idle {
+				h.base.logger.Info("mcp http: session %s idle for %s, ended", sess.id, h.opts.IdleTTL)
+				sess.stop()
+			}
+		}
+	}
+}
+
+func (h *MCPHTTPHandler) authorized(header string) bool {
+	if h.opts.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.opts.Token)) == 1
+}
+
+func (h *MCPHTTPHandler) originAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	if slices.Contains(h.opts.AllowedOrigins, origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// decodeHTTPMessages accepts a single JSON-RPC message or a batch array.
+func decodeHTTPMessages(body []byte) ([]jsonrpcMessage, bool, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, false, errors.New("empty body")
+	}
+	if trimmed[0] == '[' {
+		var msgs []jsonrpcMessage
+		if err := json.Unmarshal(trimmed, &msgs); err != nil {
+			return nil, true, err
+		}
+		if len(msgs) == 0 {
+			return nil, true, errors.New("empty batch")
+		}
+		return msgs, true, nil
+	}
+	var msg jsonrpcMessage
+	if err := json.Unmarshal(trimmed, &msg); err != nil {
+		return nil, false, err
+	}
+	return []jsonrpcMessage{msg}, false, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func isLoopbackAddr(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
@@ -0,0 +1,358 @@
+package session_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+// Streamable HTTP transport: one long-lived `paintress mcp --listen`
+// process serves several sessions through the stdio dispatch.
+
+func newMCPHTTPServer(t *testing.T, continent string, opts session.MCPHTTPOptions) *httptest.Server {
+	t.Helper()
+	srv := session.NewMCPServer(nil, io.Discard, nil).WithContinent(continent)
+	handler := srv.HTTPHandler(context.Background(), opts)
+	ts := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		handler.Close()
+		ts.Close()
+	})
+	return ts
+}
+
+func postMCP(t *testing.T, ts *httptest.Server, sessionID, body string, header map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func initializeMCP(t *testing.T, ts *httptest.Server, header map[string]string) string {
+	t.Helper()
+	resp := postMCP(t, ts, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`, header)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("initialize status = %d", resp.StatusCode)
+	}
+	id := resp.Header.Get("Mcp-Session-Id")
+	if id == "" {
+		t.Fatal("initialize did not assign Mcp-Session-Id")
+	}
+	return id
+}
+
+func TestMCPHTTP_InitializeThenToolsCall(t *testing.T) {
+	// given
+	ts := newMCPHTTPServer(t, t.TempDir(), session.MCPHTTPOptions{})
+	sessionID := initializeMCP(t, ts, nil)
+
+	// when
+	resp := postMCP(t, ts, sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"ping","arguments":{}}}`, nil)
+
+	// then
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var msg map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	text := msg["result"].(map[string]any)["content"].([]any)[0].(map[string]any)["text"]
+	if text != "pong" {
+		t.Errorf("ping text = %v", text)
+	}
+}
+
+func TestMCPHTTP_NotificationIsAccepted(t *testing.T) {
+	// given
+	ts := newMCPHTTPServer(t, t.TempDir(), session.MCPHTTPOptions{})
+	sessionID := initializeMCP(t, ts, nil)
+
+	// when
+	resp := postMCP(t, ts, sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, nil)
+
+	// then
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", resp.StatusCode)
+	}
+}
+
+func TestMCPHTTP_SessionRequired(t *testing.T) {
+	// given
+	ts := newMCPHTTPServer(t, t.TempDir(), session.MCPHTTPOptions{})
+	ping := `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"ping"}}`
+
+	// when
+	missing := postMCP(t, ts, "", ping, nil)
+	unknown := postMCP(t, ts, "deadbeef", ping, nil)
+
+	// then
+	if missing.StatusCode != http.StatusBadRequest {
+		t.Errorf("missing session status = %d, want 400", missing.StatusCode)
+	}
+	if unknown.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown session status = %d, want 404", unknown.StatusCode)
+	}
+}
+
+func TestMCPHTTP_DeleteEndsSession(t *testing.T) {
+	// given
+	ts := newMCPHTTPServer(t, t.TempDir(), session.MCPHTTPOptions{})
+	sessionID := initializeMCP(t, ts, nil)
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL, nil)
+	req.Header.Set("Mcp-Session-Id", sessionID)
+
+	// when
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	// then
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("DELETE status = %d", resp.StatusCode)
+	}
+	after := postMCP(t, ts, sessionID, `{"jsonrpc":"2.0","id":4,"method":"tools/list"}`, nil)
+	if after.StatusCode != http.StatusNotFound {
+		t.Errorf("post-delete status = %d, want 404", after.StatusCode)
+	}
+}
+
+func TestMCPHTTP_IdleSessionExpires(t *testing.T) {
+	// given: one session left idle, one holding an SSE stream open
+	ts := newMCPHTTPServer(t, t.TempDir(), session.MCPHTTPOptions{IdleTTL: 50 * time.Millisecond})
+	idleID := initializeMCP(t, ts, nil)
+	streamingID := initializeMCP(t, ts, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Mcp-Session-Id", streamingID)
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = stream.Body.Close() }()
+
+	// when
+	time.Sleep(300 * time.Millisecond)
+
+	// then: the idle id is gone; the streaming session is kept
+	idle := postMCP(t, ts, idleID, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, nil)
+	if idle.StatusCode != http.StatusNotFound {
+		t.Errorf("idle session status = %d, want 404", idle.StatusCode)
+	}
+	streaming := postMCP(t, ts, streamingID, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, nil)
+	if streaming.StatusCode != http.StatusOK {
+		t.Errorf("streaming session status = %d, want 200", streaming.StatusCode)
+	}
+}
+
+func TestMCPHTTP_RejectsForeignOrigin(t *testing.T) {
+	// given
+	ts := newMCPHTTPServer(t, t.TempDir(), session.MCPHTTPOptions{AllowedOrigins: []string{"https://trusted.example"}})
+	init := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+
+	// when
+	foreign := postMCP(t, ts, "", init, map[string]string{"Origin": "https://evil.example"})
+	loopback := postMCP(t, ts, "", init, map[string]string{"Origin": "http://localhost:3000"})
+	trusted := postMCP(t, ts, "", init, map[string]string{"Origin": "https://trusted.example"})
+
+	// then
+	if foreign.StatusCode != http.StatusForbidden {
+		t.Errorf("foreign origin status = %d, want 403", foreign.StatusCode)
+	}
+	if loopback.StatusCode != http.StatusOK || trusted.StatusCode != http.StatusOK {
+		t.Errorf("loopback = %d, trusted = %d, want 200", loopback.StatusCode, trusted.StatusCode)
+	}
+}
+
+func TestMCPHTTP_BearerToken(t *testing.T) {
+	// given
+	ts := newMCPHTTPServer(t, t.TempDir(), session.MCPHTTPOptions{Token: "s3cret"})
+	init := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+
+	// when
+	anonymous := postMCP(t, ts, "", init, nil)
+	wrong := postMCP(t, ts, "", init, map[string]string{"Authorization": "Bearer nope"})
+	right := postMCP(t, ts, "", init, map[string]string{"Authorization": "Bearer s3cret"})
+
+	// then
+	if anonymous.StatusCode != http.StatusUnauthorized || wrong.StatusCode != http.StatusUnauthorized {
+		t.Errorf("anonymous = %d, wrong = %d, want 401", anonymous.StatusCode, wrong.StatusCode)
+	}
+	if right.StatusCode != http.StatusOK {
+		t.Errorf("authorized status = %d, want 200", right.StatusCode)
+	}
+}
+
+func TestMCPHTTP_BatchReturnsArray(t *testing.T) {
+	// given
+	ts := newMCPHTTPServer(t, t.TempDir(), session.MCPHTTPOptions{})
+	sessionID := initializeMCP(t, ts, nil)
+
+	// when
+	resp := postMCP(t, ts, sessionID, `[{"jsonrpc":"2.0","id":5,"method":"tools/list"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":6,"method":"resources/list"}]`, nil)
+
+	// then
+	var msgs []map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&msgs); err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs[0]["id"] != float64(5) || msgs[1]["id"] != float64(6) {
+		t.Errorf("batch responses = %v", msgs)
+	}
+}
+
+func TestMCPHTTP_SSEStreamDeliversResourceNotifications(t *testing.T) {
+	// given: a session subscribed to an inbox resource with an open stream
+	continent := t.TempDir()
+	inboxDir := domain.InboxDir(continent)
+	for _, dir := range []string{inboxDir, domain.JournalDir(continent)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := newMCPHTTPServer(t, continent, session.MCPHTTPOptions{})
+	sessionID := initializeMCP(t, ts, nil)
+	sub := postMCP(t, ts, sessionID, `{"jsonrpc":"2.0","id":7,"method":"resources/subscribe","params":{"uri":"paintress://inbox/late"}}`, nil)
+	if sub.StatusCode != http.StatusOK {
+		t.Fatalf("subscribe status = %d", sub.StatusCode)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = stream.Body.Close() }()
+	if ct := stream.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	// when
+	dm := domain.DMail{Name: "late", Kind: domain.KindReport, Description: "late arrival"}
+	data, _ := dm.Marshal()
+	if err := os.WriteFile(filepath.Join(inboxDir, "late.md"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// then
+	scanner := bufio.NewScanner(stream.Body)
+	for scanner.Scan() {
+		payload, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var msg map[string]any
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			t.Fatalf("decode SSE data: %v", err)
+		}
+		if msg["method"] == "notifications/resources/updated" {
+			return
+		}
+	}
+	t.Fatalf("stream ended without resources/updated notification: %v", scanner.Err())
+}
+
+func TestMCPHTTP_RejectsUnsupportedProtocolHeader(t *testing.T) {
+	// given
+	ts := newMCPHTTPServer(t, t.TempDir(), session.MCPHTTPOptions{})
+	sessionID := initializeMCP(t, ts, nil)
+	list := `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`
+
+	// when
+	unsupported := postMCP(t, ts, sessionID, list, map[string]string{"MCP-Protocol-Version": "1999-01-01"})
+	supported := postMCP(t, ts, sessionID, list, map[string]string{"MCP-Protocol-Version": "2025-06-18"})
+
+	// then
+	if unsupported.StatusCode != http.StatusBadRequest {
+		t.Errorf("unsupported version status = %d, want 400", unsupported.StatusCode)
+	}
+	if supported.StatusCode != http.StatusOK {
+		t.Errorf("supported version status = %d, want 200", supported.StatusCode)
+	}
+}
+
+func TestMCPHTTP_CancelledRequestGetsNoResponse(t *testing.T) {
+	// given: a slow side-effecting call in one session
+	emitter := newBlockingEmitter()
+	srv := session.NewMCPServer(nil, io.Discard, nil).WithContinent(t.TempDir()).WithEmitter(emitter)
+	handler := srv.HTTPHandler(context.Background(), session.MCPHTTPOptions{})
+	ts := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		handler.Close()
+		ts.Close()
+	})
+	slowSession := initializeMCP(t, ts, nil)
+	otherSession := initializeMCP(t, ts, nil)
+	slow := make(chan *http.Response, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"jsonrpc":"2.0","id":"slow","method":"tools/call","params":{"name":"update_gradient","arguments":{"delta":1}}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Mcp-Session-Id", slowSession)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("POST: %v", err)
+			close(slow)
+			return
+		}
+		slow <- resp
+	}()
+	<-emitter.entered
+
+	// when: another session is served meanwhile, then the call is cancelled
+	ping := postMCP(t, ts, otherSession, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"ping","arguments":{}}}`, nil)
+	cancelled := postMCP(t, ts, slowSession, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"slow","reason":"user aborted"}}`, nil)
+	close(emitter.release)
+	resp, ok := <-slow
+	if !ok {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	// then
+	if ping.StatusCode != http.StatusOK {
+		t.Errorf("ping status = %d, want 200 while the other session is busy", ping.StatusCode)
+	}
+	if cancelled.StatusCode != http.StatusAccepted {
+		t.Errorf("cancel status = %d, want 202", cancelled.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusAccepted || len(body) != 0 {
+		t.Errorf("cancelled request answered: %d %s", resp.StatusCode, body)
+	}
+}
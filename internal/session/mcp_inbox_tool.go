@@ -0,0 +1,319 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/harness"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// realReadInbox surfaces .expedition/inbox/ to the session so the
+// /expedition-next skill can pick the next specification without
+// parsing D-Mail frontmatter by hand. Each D-Mail comes back with its
+// wave reference, Rival Contract sections (when the body is a contract),
+// the checksum verification of its attachments and the deterministic
+// pre-flight triage decision. Nothing is archived
+// or emitted (ScanInbox only stamps seen_at on new envelopes); consume a
+// D-Mail via archive_inbox.
+func realReadInbox(ctx context.Context, continent string, args json.RawMessage, logger domain.Logger) map[string]any {
+	var payload struct {
+		Kind string `json:"kind"`
+	}
+	if len(args) > 0 {
+		_ = json.Unmarshal(args, &payload)
+	}
+	if continent == "" {
+		return toolError(toolErrNotConfigured, map[string]any{
+			"initialized": false,
+			"reason":      "paintress mcp continent not configured (start `paintress mcp` from the project root)",
+			"dmails":      []any{},
+		})
+	}
+	dmails, err := ScanInbox(ctx, continent)
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{
+			"initialized": true,
+			"reason":      fmt.Sprintf("scan inbox: %v", err),
+			"dmails":      []any{},
+		})
+	}
+	maxRetries := inboxMaxRetries(continent)
+	retries := retryCounts(ctx, continent, logger)
+
+	entries := make([]map[string]any, 0, len(dmails))
+	for _, dm := range dmails {
+		if payload.Kind != "" && string(dm.Kind) != payload.Kind {
+			continue
+		}
+		entry := inboxEntry(dm, retries[harness.RetryKey(dm.Issues)], maxRetries)
+		if len(dm.Attachments) > 0 {
+			entry["attachments"] = VerifyAttachments(domain.InboxDir(continent), dm)
+		}
+		entries = append(entries, entry)
+	}
+	return jsonResult(map[string]any{
+		"initialized": true,
+		"continent":   continent,
+		"count":       len(entries),
+		"dmails":      entries,
+		"instruction": "Pick the highest-priority specification whose triage.pass_through is true. Call archive_inbox with its name once consumed.",
+	})
+}
+
+// inboxEntry renders one D-Mail for read_inbox.
+func inboxEntry(dm domain.DMail, retryCount, maxRetries int) map[string]any {
+	entry := map[string]any{
+		"name":        dm.Name,
+		"kind":        string(dm.Kind),
+		"description": dm.Description,
+		"issues":      nonNilStrings(dm.Issues),
+		"severity":    dm.Severity,
+		"action":      dm.Action,
+		"priority":    dm.Priority,
+		"metadata":    dm.Metadata,
+		"body":        dm.Body,
+		"wave":        dm.Wave,
+	}
+
+	contract, ok, err := harness.ParseRivalContractBody(dm.Body)
+	switch {
+	case err != nil:
+		entry["rival_contract"] = map[string]any{"present": false, "error": err.Error()}
+	case ok:
+		entry["rival_contract"] = map[string]any{
+			"present":    true,
+			"title":      contract.Title,
+			"intent":     contract.Intent,
+			"domain":     contract.Domain,
+			"decisions":  contract.Decisions,
+			"steps":      contract.Steps,
+			"boundaries": contract.Boundaries,
+			"evidence":   contract.Evidence,
+		}
+	default:
+		entry["rival_contract"] = map[string]any{"present": false}
+	}
+
+	decision := harness.DeterminePreFlightDecision(dm, retryCount, maxRetries)
+	entry["triage"] = map[string]any{
+		"pass_through": decision.PassThrough,
+		"escalate":     decision.Escalate,
+		"resolve":      decision.Resolve,
+		"track_retry":  decision.TrackRetry,
+		"retry_count":  retryCount,
+		"max_retries":  maxRetries,
+	}
+	return entry
+}
+
+// realArchiveInbox consumes one inbox D-Mail: the file (an envelope
+// with its body) moves to archive/ and the triage outcome is recorded in
+// the event store in a single append (inbox.received, then
+// issue.escalated / issue.resolved / retry.attempted as decided, then
+// dmail.archived). If recording fails the files are moved back to inbox/
+// so the D-Mail is never consumed without a trace. An envelope whose
+// IdempotencyKey was already acked, or a D-Mail whose consumption was
+// already recorded under its name, is a re-delivery: it is archived
+// without events.
+func realArchiveInbox(ctx context.Context, continent string, emitter port.ExpeditionEventEmitter, args json.RawMessage, logger domain.Logger) map[string]any {
+	var payload struct {
+		Name string `json:"name"`
+	}
+	if len(args) > 0 {
+		_ = json.Unmarshal(args, &payload)
+	}
+	if continent == "" {
+		return toolError(toolErrNotConfigured, map[string]any{
+			"initialized": false,
+			"archived":    false,
+			"reason":      "paintress mcp continent not configured (start `paintress mcp` from the project root)",
+		})
+	}
+	name := strings.TrimSuffix(strings.TrimSuffix(payload.Name, ".md"), domain.EnvelopeFileExt)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return toolError(toolErrInvalidArguments, map[string]any{
+			"initialized": true,
+			"archived":    false,
+			"reason":      fmt.Sprintf("invalid d-mail name %q", payload.Name),
+		})
+	}
+	dm, envelope, err := readInboxDMail(continent, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return toolError(toolErrNotFound, map[string]any{
+			"initialized": true,
+			"archived":    false,
+			"reason":      fmt.Sprintf("d-mail %q not found in inbox", name),
+		})
+	}
+	if err != nil {
+		return toolError(toolErrRejected, map[string]any{
+			"initialized": true,
+			"archived":    false,
+			"reason":      fmt.Sprintf("parse %s: %v", name, err),
+		})
+	}
+	if duplicate, err := inboxDuplicate(ctx, continent, name, envelope); err != nil {
+		return toolError(toolErrStorage, map[string]any{"initialized": true, "archived": false, "reason": err.Error()})
+	} else if duplicate {
+		return archiveDuplicateDMail(ctx, continent, name, dm)
+	}
+
+	maxRetries := inboxMaxRetries(continent)
+	retryKey := harness.RetryKey(dm.Issues)
+	retryCount := retryCounts(ctx, continent, logger)[retryKey]
+	decision := harness.DeterminePreFlightDecision(dm, retryCount, maxRetries)
+
+	if err := ArchiveInboxDMail(ctx, continent, name, nil); err != nil {
+		return toolError(toolErrStorage, map[string]any{
+			"initialized": true,
+			"archived":    false,
+			"reason":      err.Error(),
+		})
+	}
+
+	persistence := "filesystem-only"
+	if emitter != nil {
+		if err := recordInboxConsumed(ctx, continent, emitter, inboxConsumption(dm, name, decision, retryKey, retryCount), logger); err != nil {
+			if restoreErr := restoreInboxDMail(ctx, continent, name); restoreErr != nil {
+				logger.Warn("archive_inbox: restore %s after emit failure: %v", name, restoreErr)
+			}
+			return toolError(toolErrStorage, map[string]any{
+				"initialized": true,
+				"archived":    false,
+				"reason":      fmt.Sprintf("record inbox consumption (d-mail left in inbox): %v", err),
+			})
+		}
+		persistence = "event-store+filesystem"
+	}
+
+	return jsonResult(map[string]any{
+		"initialized": true,
+		"archived":    true,
+		"name":        name,
+		"kind":        string(dm.Kind),
+		"triage": map[string]any{
+			"pass_through": decision.PassThrough,
+			"escalate":     decision.Escalate,
+			"resolve":      decision.Resolve,
+			"track_retry":  decision.TrackRetry,
+		},
+		"persistence": persistence,
+	})
+}
+
+// inboxDuplicate reports whether the inbox D-Mail name was consumed
+// before: its consumption is recorded under its name, or (for an
+// envelope) its IdempotencyKey was acked.
+func inboxDuplicate(ctx context.Context, continent, name string, env *domain.DMailEnvelope) (bool, error) {
+	ledger, err := openEnvelopeLedgerIfExists(continent)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = ledger.Close() }()
+	if consumed, err := ledger.Consumed(ctx, name); err != nil || consumed {
+		return consumed, err
+	}
+	if env == nil {
+		return false, nil
+	}
+	return ledger.Acked(ctx, env.IdempotencyKey)
+}
+
+// archiveDuplicateDMail clears a re-delivered D-Mail out of inbox/
+// without triage or events: its first delivery was already consumed.
+func archiveDuplicateDMail(ctx context.Context, continent, name string, dm domain.DMail) map[string]any {
+	if err := ArchiveInboxDMail(ctx, continent, name, nil); err != nil {
+		return toolError(toolErrStorage, map[string]any{
+			"initialized": true,
+			"archived":    false,
+			"reason":      err.Error(),
+		})
+	}
+	return jsonResult(map[string]any{
+		"initialized": true,
+		"archived":    true,
+		"name":        name,
+		"kind":        string(dm.Kind),
+		"duplicate":   true,
+		"persistence": "filesystem-only",
+	})
+}
+
+// inboxConsumption describes the consumption of dm under name with the
+// pre-flight decision; a tracked retry records attempt retryCount+1.
+func inboxConsumption(dm domain.DMail, name string, decision harness.PreFlightDecision, retryKey string, retryCount int) domain.InboxConsumption {
+	consumed := domain.InboxConsumption{
+		Name:     name,
+		Severity: dm.Severity,
+		Issues:   dm.Issues,
+		Escalate: decision.Escalate,
+		Resolve:  decision.Resolve,
+	}
+	if decision.TrackRetry {
+		consumed.RetryKey = retryKey
+		consumed.RetryAttempt = retryCount + 1
+	}
+	return consumed
+}
+
+// recordInboxConsumed appends the events of consumed in one append and
+// then records the consumption in the inbox ledger, so a later attempt
+// to consume the same name is treated as a re-delivery. Once the events
+// are stored the consumption stands: a ledger failure is only logged.
+func recordInboxConsumed(ctx context.Context, continent string, emitter port.ExpeditionEventEmitter, consumed domain.InboxConsumption, logger domain.Logger) error {
+	now := time.Now().UTC()
+	if err := emitter.EmitInboxConsumed(consumed, now); err != nil {
+		return err
+	}
+	ledger, err := NewEnvelopeLedgerForDir(continent)
+	if err == nil {
+		err = ledger.RecordConsumed(ctx, consumed.Name, now)
+		_ = ledger.Close()
+	}
+	if err != nil {
+		logger.Warn("inbox: record consumption of %s: %v", consumed.Name, err)
+	}
+	return nil
+}
+
+// inboxMaxRetries returns the configured max_retries, falling back to
+// the project default when the config cannot be read.
+func inboxMaxRetries(continent string) int {
+	cfg, err := LoadProjectConfig(continent)
+	if err != nil {
+		return domain.DefaultProjectConfig().MaxRetries
+	}
+	return cfg.MaxRetries
+}
+
+// retryCounts returns the highest recorded retry attempt per retry key
+// (canonical issue set) from the ExpeditionState projection, so attempts
+// in archived segments still count. Load failures degrade to zero counts
+// so triage still answers.
+func retryCounts(ctx context.Context, continent string, logger domain.Logger) map[string]int {
+	counts := make(map[string]int)
+	state, _, err := NewProjectionCache(filepath.Join(continent, domain.StateDir), logger).State(ctx)
+	if err != nil {
+		logger.Warn("inbox triage: load events: %v", err)
+		return counts
+	}
+	for key, attempt := range state.RetryAttempts {
+		counts[key] = attempt
+	}
+	return counts
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,348 @@
+package session_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/harness"
+	"github.com/hironow/paintress/internal/session"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// read_inbox / archive_inbox let the /expedition-next skill pick and
+// consume inbound D-Mails without parsing frontmatter by hand.
+
+const inboxContractBody = `# Contract: Add session expiry enforcement
+
+## Intent
+Expired sessions must be rejected.
+
+## Domain
+Session, Expiry.
+
+## Decisions
+Enforce at middleware.
+
+## Steps
+1. Add check.
+
+## Boundaries
+No schema change.
+
+## Evidence
+- test: go test ./...
+`
+
+// inboxEmitter records the inbox-consumption events archive_inbox emits.
+type inboxEmitter struct {
+	port.NopExpeditionEventEmitter
+	calls   []string
+	failOn  string
+	retries []int
+}
+
+func (e *inboxEmitter) record(call string) error {
+	if call == e.failOn {
+		return errors.New("store unavailable")
+	}
+	e.calls = append(e.calls, call)
+	return nil
+}
+
+func (e *inboxEmitter) EmitInboxReceived(_, _ string, _ time.Time) error {
+	return e.record("inbox.received")
+}
+
+func (e *inboxEmitter) EmitEscalated(_ string, _ []string, _ time.Time) error {
+	return e.record("issue.escalated")
+}
+
+func (e *inboxEmitter) EmitResolved(_ string, _ []string, _ time.Time) error {
+	return e.record("issue.resolved")
+}
+
+func (e *inboxEmitter) EmitRetryAttempted(_ string, attempt int, _ time.Time) error {
+	e.retries = append(e.retries, attempt)
+	return e.record("retry.attempted")
+}
+
+func (e *inboxEmitter) EmitDMailArchived(_ string, _ time.Time) error {
+	return e.record("dmail.archived")
+}
+
+// EmitInboxConsumed records the batch like the store appends it: every
+// event or, when one of them is failOn, none.
+func (e *inboxEmitter) EmitInboxConsumed(c domain.InboxConsumption, _ time.Time) error {
+	batch := []string{"inbox.received"}
+	if c.Escalate {
+		batch = append(batch, "issue.escalated")
+	}
+	if c.Resolve {
+		batch = append(batch, "issue.resolved")
+	}
+	if c.RetryKey != "" {
+		batch = append(batch, "retry.attempted")
+	}
+	batch = append(batch, "dmail.archived")
+	for _, call := range batch {
+		if call == e.failOn {
+			return errors.New("store unavailable")
+		}
+	}
+	if c.RetryKey != "" {
+		e.retries = append(e.retries, c.RetryAttempt)
+	}
+	e.calls = append(e.calls, batch...)
+	return nil
+}
+
+func writeInboxDMail(t *testing.T, continent string, dm domain.DMail) {
+	t.Helper()
+	dir := domain.InboxDir(continent)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := dm.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, dm.Name+".md"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// callTool runs one tools/call through a fresh server and decodes the
+// JSON tool body.
+func callTool(t *testing.T, continent string, emitter port.ExpeditionEventEmitter, tool, args string) map[string]any {
+	t.Helper()
+	req := `{"jsonrpc":"2.0","id":80,"method":"tools/call","params":{"name":"` + tool + `","arguments":` + args + `}}` + "\n"
+	var out bytes.Buffer
+	srv := session.NewMCPServer(strings.NewReader(req), &out, nil).WithContinent(continent)
+	if emitter != nil {
+		srv = srv.WithEmitter(emitter)
+	}
+	if err := srv.Serve(context.Background()); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	return decodeDMailToolJSON(t, out.Bytes())
+}
+
+func TestMCPServer_ReadInbox_ParsesContractWaveAndTriage(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{
+		Name:        "spec-auth-w1",
+		Kind:        domain.KindSpecification,
+		Description: "Auth wave",
+		Issues:      []string{"MY-1"},
+		Wave:        &domain.WaveReference{ID: "auth-w1", Steps: []domain.WaveStepDef{{ID: "s1", Title: "Expiry"}}},
+		Body:        inboxContractBody,
+	})
+	writeInboxDMail(t, continent, domain.DMail{
+		Name:        "fb-escalate",
+		Kind:        domain.KindImplFeedback,
+		Description: "Needs a human",
+		Issues:      []string{"MY-2"},
+		Action:      "escalate",
+		Body:        "# Feedback\n",
+	})
+
+	// when
+	body := callTool(t, continent, nil, "read_inbox", `{}`)
+
+	// then
+	if body["count"] != float64(2) {
+		t.Fatalf("count = %v, want 2 (body=%v)", body["count"], body)
+	}
+	byName := make(map[string]map[string]any)
+	for _, d := range body["dmails"].([]any) {
+		entry := d.(map[string]any)
+		byName[entry["name"].(string)] = entry
+	}
+	spec := byName["spec-auth-w1"]
+	contract := spec["rival_contract"].(map[string]any)
+	if contract["present"] != true || contract["title"] != "Add session expiry enforcement" {
+		t.Errorf("rival_contract = %v", contract)
+	}
+	if wave := spec["wave"].(map[string]any); wave["id"] != "auth-w1" {
+		t.Errorf("wave = %v", wave)
+	}
+	if triage := spec["triage"].(map[string]any); triage["pass_through"] != true {
+		t.Errorf("spec triage = %v, want pass_through", triage)
+	}
+	if triage := byName["fb-escalate"]["triage"].(map[string]any); triage["escalate"] != true {
+		t.Errorf("feedback triage = %v, want escalate", triage)
+	}
+}
+
+func TestMCPServer_ReadInbox_KindFilter(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{Name: "spec-a", Kind: domain.KindSpecification, Description: "a"})
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-b", Kind: domain.KindImplFeedback, Description: "b"})
+
+	// when
+	body := callTool(t, continent, nil, "read_inbox", `{"kind":"specification"}`)
+
+	// then
+	dmails := body["dmails"].([]any)
+	if len(dmails) != 1 || dmails[0].(map[string]any)["name"] != "spec-a" {
+		t.Errorf("dmails = %v, want only spec-a", dmails)
+	}
+}
+
+func TestMCPServer_ArchiveInbox_MovesAndEmitsTriageEvents(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{
+		Name: "fb-resolve", Kind: domain.KindImplFeedback, Description: "done",
+		Issues: []string{"MY-3"}, Severity: "high", Action: "resolve",
+	})
+	emitter := &inboxEmitter{}
+
+	// when
+	body := callTool(t, continent, emitter, "archive_inbox", `{"name":"fb-resolve"}`)
+
+	// then
+	if body["archived"] != true || body["persistence"] != "event-store+filesystem" {
+		t.Fatalf("body = %v", body)
+	}
+	if _, err := os.Stat(filepath.Join(domain.ArchiveDir(continent), "fb-resolve.md")); err != nil {
+		t.Errorf("archive file missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(domain.InboxDir(continent), "fb-resolve.md")); !os.IsNotExist(err) {
+		t.Errorf("inbox file should be gone, stat err = %v", err)
+	}
+	want := []string{"inbox.received", "issue.resolved", "dmail.archived"}
+	if strings.Join(emitter.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", emitter.calls, want)
+	}
+}
+
+func TestMCPServer_ArchiveInbox_RetryRecordsAttempt(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{
+		Name: "fb-retry", Kind: domain.KindImplFeedback, Description: "retry",
+		Issues: []string{"MY-4"}, Action: "retry",
+	})
+	emitter := &inboxEmitter{}
+
+	// when
+	callTool(t, continent, emitter, "archive_inbox", `{"name":"fb-retry"}`)
+
+	// then
+	if len(emitter.retries) != 1 || emitter.retries[0] != 1 {
+		t.Errorf("retries = %v, want [1]", emitter.retries)
+	}
+}
+
+func TestMCPServer_ArchiveInbox_RetryCountsArchivedAttempts(t *testing.T) {
+	// given: attempt 2 for MY-5 was recorded and then archived
+	continent := t.TempDir()
+	store := session.NewEventStore(filepath.Join(continent, domain.StateDir), nil)
+	ev, err := domain.NewEvent(domain.EventRetryAttempted, domain.RetryAttemptedData{DMail: harness.RetryKey([]string{"MY-5"}), Attempt: 2}, time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Append(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	archiveEventFiles(t, continent)
+	writeInboxDMail(t, continent, domain.DMail{
+		Name: "fb-retry-again", Kind: domain.KindImplFeedback, Description: "retry",
+		Issues: []string{"MY-5"}, Action: "retry",
+	})
+	emitter := &inboxEmitter{}
+
+	// when
+	callTool(t, continent, emitter, "archive_inbox", `{"name":"fb-retry-again"}`)
+
+	// then
+	if len(emitter.retries) != 1 || emitter.retries[0] != 3 {
+		t.Errorf("retries = %v, want [3]", emitter.retries)
+	}
+}
+
+func TestMCPServer_ArchiveInbox_EmitFailureKeepsDMailInInbox(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-keep", Kind: domain.KindImplFeedback, Description: "keep"})
+	emitter := &inboxEmitter{failOn: "dmail.archived"}
+
+	// when
+	body := callTool(t, continent, emitter, "archive_inbox", `{"name":"fb-keep"}`)
+
+	// then
+	if body["archived"] != false {
+		t.Fatalf("archived = %v, want false", body["archived"])
+	}
+	if _, err := os.Stat(filepath.Join(domain.InboxDir(continent), "fb-keep.md")); err != nil {
+		t.Errorf("d-mail should be restored to inbox: %v", err)
+	}
+}
+
+func TestMCPServer_ArchiveInbox_RetryAfterEmitFailureRecordsEventsOnce(t *testing.T) {
+	// given: a first archive whose append failed
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-once", Kind: domain.KindImplFeedback, Description: "once"})
+	emitter := &inboxEmitter{failOn: "dmail.archived"}
+	callTool(t, continent, emitter, "archive_inbox", `{"name":"fb-once"}`)
+	emitter.failOn = ""
+
+	// when
+	body := callTool(t, continent, emitter, "archive_inbox", `{"name":"fb-once"}`)
+
+	// then
+	if body["archived"] != true {
+		t.Fatalf("archived = %v, want true", body["archived"])
+	}
+	want := []string{"inbox.received", "dmail.archived"}
+	if strings.Join(emitter.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", emitter.calls, want)
+	}
+}
+
+func TestMCPServer_ArchiveInbox_RedeliveredNameIsDuplicate(t *testing.T) {
+	// given: fb-again was consumed, then delivered again
+	continent := t.TempDir()
+	dm := domain.DMail{Name: "fb-again", Kind: domain.KindImplFeedback, Description: "again"}
+	writeInboxDMail(t, continent, dm)
+	emitter := &inboxEmitter{}
+	callTool(t, continent, emitter, "archive_inbox", `{"name":"fb-again"}`)
+	writeInboxDMail(t, continent, dm)
+
+	// when
+	body := callTool(t, continent, emitter, "archive_inbox", `{"name":"fb-again"}`)
+
+	// then
+	if body["archived"] != true || body["duplicate"] != true {
+		t.Fatalf("body = %v, want an archived duplicate", body)
+	}
+	if len(emitter.calls) != 2 {
+		t.Errorf("calls = %v, want the first consumption only", emitter.calls)
+	}
+	if _, err := os.Stat(filepath.Join(domain.InboxDir(continent), "fb-again.md")); !os.IsNotExist(err) {
+		t.Errorf("duplicate left in inbox: %v", err)
+	}
+}
+
+func TestMCPServer_ArchiveInbox_RejectsPathNames(t *testing.T) {
+	// given
+	continent := t.TempDir()
+
+	// when
+	body := callTool(t, continent, nil, "archive_inbox", `{"name":"../config"}`)
+
+	// then
+	if body["archived"] != false || !strings.Contains(body["reason"].(string), "invalid d-mail name") {
+		t.Errorf("body = %v", body)
+	}
+}
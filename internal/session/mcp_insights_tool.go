@@ -1,6 +1,7 @@
 package session
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -16,8 +17,9 @@ import (
 // revives the dormant ScanJournalsForLumina path — recomputed per call
 // from the journal read models, so it is always fresh, write-free and
 // idempotent. Missing files / empty journals are an empty result, not
-// an error.
-func realGetInsights(continent string, args json.RawMessage) map[string]any {
+// an error. The journal scan is the slow part on long histories, so
+// progress is reported per ledger file and once the scan completes.
+func realGetInsights(ctx context.Context, continent string, args json.RawMessage) map[string]any {
 	var payload struct {
 		Kind string `json:"kind"`
 	}
@@ -25,7 +27,7 @@ func realGetInsights(continent string, args json.RawMessage) map[string]any {
 		_ = json.Unmarshal(args, &payload)
 	}
 	if continent == "" {
-		return jsonResult(map[string]any{
+		return toolError(toolErrNotConfigured, map[string]any{
 			"initialized": false,
 			"reason":      "paintress mcp continent not configured (start `paintress mcp` from the project root)",
 		})
@@ -35,7 +37,7 @@ func realGetInsights(continent string, args json.RawMessage) map[string]any {
 	runDir := filepath.Join(continent, domain.StateDir, ".run")
 	writer := NewInsightWriter(insightsDir, runDir)
 
-	files := []map[string]any{}
+	var ledger []string
 	if entries, err := os.ReadDir(insightsDir); err == nil {
 		for _, e := range entries {
 			if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
@@ -44,32 +46,43 @@ func realGetInsights(continent string, args json.RawMessage) map[string]any {
 			if payload.Kind != "" && !strings.HasPrefix(e.Name(), payload.Kind) {
 				continue
 			}
-			file, readErr := writer.Read(e.Name())
-			if readErr != nil {
-				continue
-			}
-			entryMaps := make([]map[string]any, 0, len(file.Entries))
-			for _, ie := range file.Entries {
-				entryMaps = append(entryMaps, map[string]any{
-					"title":       ie.Title,
-					"what":        ie.What,
-					"why":         ie.Why,
-					"how":         ie.How,
-					"when":        ie.When,
-					"who":         ie.Who,
-					"constraints": ie.Constraints,
-					"extra":       ie.Extra,
-				})
-			}
-			files = append(files, map[string]any{
-				"file":       e.Name(),
-				"kind":       file.Kind,
-				"updated_at": file.UpdatedAt,
-				"entries":    entryMaps,
+			ledger = append(ledger, e.Name())
+		}
+	}
+	total := float64(len(ledger) + 1)
+
+	files := []map[string]any{}
+	for i, name := range ledger {
+		reportProgress(ctx, float64(i), total, "reading insight ledger "+name)
+		file, readErr := writer.Read(name)
+		if readErr != nil {
+			continue
+		}
+		entryMaps := make([]map[string]any, 0, len(file.Entries))
+		for _, ie := range file.Entries {
+			entryMaps = append(entryMaps, map[string]any{
+				"title":       ie.Title,
+				"what":        ie.What,
+				"why":         ie.Why,
+				"how":         ie.How,
+				"when":        ie.When,
+				"who":         ie.Who,
+				"constraints": ie.Constraints,
+				"extra":       ie.Extra,
 			})
 		}
+		files = append(files, map[string]any{
+			"file":       name,
+			"kind":       file.Kind,
+			"updated_at": file.UpdatedAt,
+			"entries":    entryMaps,
+		})
 	}
 
+	if err := ctx.Err(); err != nil {
+		return toolError(toolErrCancelled, map[string]any{"initialized": true, "reason": err.Error()})
+	}
+	reportProgress(ctx, float64(len(ledger)), total, "scanning journals for lumina patterns")
 	luminas := ScanJournalsForLumina(continent)
 	liveLumina := make([]map[string]any, 0, len(luminas))
 	for _, l := range luminas {
@@ -79,6 +92,7 @@ func realGetInsights(continent string, args json.RawMessage) map[string]any {
 			"uses":    l.Uses,
 		})
 	}
+	reportProgress(ctx, total, total, fmt.Sprintf("%d live lumina(s)", len(liveLumina)))
 
 	return jsonResult(map[string]any{
 		"initialized": true,
@@ -0,0 +1,453 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// realNextIssue surfaces paintress's local journal state (= completed
+// issue ids + next expedition number + last PR) so the Claude Code
+// session can decide which configured issue source item to handle next.
+//
+// paintress does NOT call external issue MCP servers itself. The
+// session reads completed_issue_ids from this tool and excludes them
+// while selecting work from the configured issue source.
+//
+// next_expedition_number accounts for journal entries and
+// start_expedition reservations as well as pr-index, so failed or
+// skipped expeditions and in-flight reservations are never handed out
+// again. It is advisory: start_expedition makes the binding allocation.
+//
+// continent is the project root resolved via WithContinent (typically
+// os.Getwd() in the cobra subcommand). When empty or the journal
+// directory is missing, the response indicates an uninitialized
+// project so the session surfaces a clear error.
+//
+// With as_of, the answer is the historical one projected from events up
+// to that cut-off instead (see nextIssueAsOf).
+func realNextIssue(ctx context.Context, continent string, args json.RawMessage, logger domain.Logger) map[string]any {
+	var payload struct {
+		AsOf string `json:"as_of"`
+	}
+	if len(args) > 0 {
+		_ = json.Unmarshal(args, &payload)
+	}
+	if continent == "" {
+		return toolError(toolErrNotConfigured, map[string]any{
+			"initialized":            false,
+			"reason":                 "paintress mcp continent root not configured (start `paintress mcp` from the project root or pass via WithContinent)",
+			"next_expedition_number": 1,
+			"completed_issue_ids":    []string{},
+		})
+	}
+	if payload.AsOf != "" {
+		return nextIssueAsOf(ctx, continent, payload.AsOf, logger)
+	}
+	entries, err := ReadPRIndex(continent)
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{
+			"initialized": false,
+			"reason":      fmt.Sprintf("pr-index read failed: %v", err),
+			"continent":   continent,
+		})
+	}
+	reservations, err := loadReservations(ctx, continent)
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{
+			"initialized": false,
+			"reason":      fmt.Sprintf("expedition reservations read failed: %v", err),
+			"continent":   continent,
+		})
+	}
+
+	completedIDs := make([]string, 0, len(entries))
+	maxExp := highestUsedExpedition(continent)
+	var lastPR map[string]any
+	for _, e := range entries {
+		completedIDs = append(completedIDs, e.IssueID)
+	}
+	for _, r := range reservations {
+		maxExp = max(maxExp, r.Expedition)
+	}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		lastPR = map[string]any{
+			"expedition": last.Expedition,
+			"issue_id":   last.IssueID,
+			"pr_url":     last.PRUrl,
+		}
+	}
+	incomplete, err := incompleteExpeditions(ctx, continent)
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{
+			"initialized": false,
+			"reason":      err.Error(),
+			"continent":   continent,
+		})
+	}
+	inFlight := make([]map[string]any, 0)
+	for _, r := range openReservations(continent, reservations) {
+		inFlight = append(inFlight, map[string]any{
+			"expedition":  r.Expedition,
+			"issue_id":    r.IssueID,
+			"reserved_at": r.ReservedAt,
+		})
+	}
+
+	instruction := "Read the configured issue source, exclude completed_issue_ids and in_flight issues held by other sessions, pick the highest-priority unstarted item. Reserve its number with start_expedition, then persist completion via append_journal after the expedition."
+	var resume map[string]any
+	if len(incomplete) > 0 {
+		// An interrupted expedition is offered before any new work.
+		resume = incomplete[0]
+		instruction = resumeInstruction(resume) + " " + instruction
+	}
+
+	return jsonResult(map[string]any{
+		"initialized":            true,
+		"continent":              continent,
+		"next_expedition_number": maxExp + 1,
+		"completed_issue_ids":    completedIDs,
+		"in_flight":              inFlight,
+		"resume":                 resume,
+		"incomplete_expeditions": incomplete,
+		"last_pr":                lastPR,
+		"journal_dir":            domain.JournalDir(continent),
+		"instruction":            instruction,
+	})
+}
+
+// nextIssueAsOf answers next_issue as it stood at asOf, from the events
+// recorded up to the cut-off (archived segments included):
+// completed_issue_ids are the issues of successful completions,
+// next_expedition_number follows the highest started or completed
+// expedition, and the ExpeditionState, pending wave steps and windowed
+// success rate come from the same events. Reservations, checkpoints and
+// the pr-index have no history, so in_flight and resume stay empty. The
+// result is read-only context, never a basis for start_expedition.
+func nextIssueAsOf(ctx context.Context, continent, value string, logger domain.Logger) map[string]any {
+	asOf, err := domain.ParseAsOf(value)
+	if err != nil {
+		return toolError(toolErrInvalidArguments, map[string]any{
+			"initialized": true,
+			"reason":      fmt.Sprintf("as_of: %v", err),
+			"continent":   continent,
+		})
+	}
+	all, err := loadStatusHistory(ctx, continent, logger)
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{
+			"initialized": true,
+			"reason":      err.Error(),
+			"continent":   continent,
+		})
+	}
+	snapshot, err := projectStatusAsOf(all, asOf, defaultStatusTrendWindow)
+	if err != nil {
+		return toolError(toolErrNotFound, map[string]any{
+			"initialized": true,
+			"reason":      fmt.Sprintf("as_of: %v", err),
+			"continent":   continent,
+		})
+	}
+
+	completedIDs := make([]string, 0)
+	maxExp := 0
+	for _, ev := range domain.EventsAsOf(all, snapshot.Cutoff) {
+		switch ev.Type {
+		case domain.EventExpeditionStarted:
+			var data domain.ExpeditionStartedData
+			if json.Unmarshal(ev.Data, &data) == nil {
+				maxExp = max(maxExp, data.Expedition)
+			}
+		case domain.EventExpeditionCompleted:
+			var data domain.ExpeditionCompletedData
+			if json.Unmarshal(ev.Data, &data) != nil {
+				continue
+			}
+			maxExp = max(maxExp, data.Expedition)
+			if data.Status == "success" && data.IssueID != "" {
+				completedIDs = append(completedIDs, data.IssueID)
+			}
+		}
+	}
+
+	return jsonResult(map[string]any{
+		"initialized":            true,
+		"continent":              continent,
+		"next_expedition_number": maxExp + 1,
+		"completed_issue_ids":    completedIDs,
+		"in_flight":              []map[string]any{},
+		"incomplete_expeditions": []map[string]any{},
+		"journal_dir":            domain.JournalDir(continent),
+		"as_of": map[string]any{
+			"as_of":                 snapshot.AsOf,
+			"cutoff":                snapshot.Cutoff.Format(time.RFC3339),
+			"events":                snapshot.Events,
+			"state":                 snapshot.State,
+			"pending_steps":         snapshot.PendingSteps,
+			"windowed_success_rate": snapshot.WindowedSuccessRate,
+		},
+		"instruction": fmt.Sprintf("Historical view as of %s (read-only): use it to reconstruct what was done and pending then. Do not reserve work from it; call next_issue without as_of for the current state.", snapshot.AsOf),
+	})
+}
+
+// updateGradientAttempts bounds update_gradient's read-modify-write
+// cycles when concurrent writers keep moving the gradient stream.
+const updateGradientAttempts = 3
+
+// realUpdateGradient reads the current GradientLevel via the
+// ProjectionCache (snapshot + tail), applies the delta, and emits an
+// EventGradientChanged via the injected emitter (Phase 4 follow-up #4,
+// persistence='event-store'). The append expects the gradient stream's
+// version read before the level, so a gradient change another session
+// persisted in between is not overwritten: the cycle is retried, and after
+// updateGradientAttempts conflicts the tool reports the conflict. When
+// no emitter is wired (tests / opt-out), it returns a preview of
+// (current + delta) without persisting. The session can re-read the
+// new level via the next projection.
+//
+// continent is the project root from MCPServer.WithContinent. When
+// empty the response signals uninitialized so the session aborts.
+func realUpdateGradient(ctx context.Context, continent string, emitter port.ExpeditionEventEmitter, args json.RawMessage, logger domain.Logger) map[string]any {
+	var payload struct {
+		Delta    int    `json:"delta"`
+		Operator string `json:"operator"`
+	}
+	if len(args) > 0 {
+		_ = json.Unmarshal(args, &payload)
+	}
+	if payload.Operator == "" {
+		payload.Operator = "mcp.update_gradient"
+	}
+	if continent == "" {
+		return toolError(toolErrNotConfigured, map[string]any{
+			"initialized":   false,
+			"reason":        "paintress mcp continent root not configured",
+			"delta":         payload.Delta,
+			"current_level": 0,
+			"preview_level": payload.Delta,
+		})
+	}
+	cache := NewProjectionCache(filepath.Join(continent, domain.StateDir), logger)
+	for attempt := 1; ; attempt++ {
+		// Version first: an append landing between the two reads then
+		// fails the expectation instead of being lost.
+		expected := domain.AnyVersion
+		if emitter != nil {
+			version, err := emitter.GradientVersion()
+			if err != nil {
+				return toolError(toolErrStorage, map[string]any{
+					"initialized":   false,
+					"reason":        fmt.Sprintf("event store load failed: %v", err),
+					"delta":         payload.Delta,
+					"current_level": 0,
+					"preview_level": payload.Delta,
+				})
+			}
+			expected = version
+		}
+		state, _, err := cache.State(ctx)
+		if err != nil {
+			return toolError(toolErrStorage, map[string]any{
+				"initialized":   false,
+				"reason":        fmt.Sprintf("event store load failed: %v", err),
+				"delta":         payload.Delta,
+				"current_level": 0,
+				"preview_level": payload.Delta,
+			})
+		}
+		newLevel := state.GradientLevel + payload.Delta
+		if emitter == nil {
+			return jsonResult(map[string]any{
+				"initialized":   true,
+				"continent":     continent,
+				"current_level": state.GradientLevel,
+				"delta":         payload.Delta,
+				"preview_level": newLevel,
+				"persistence":   "preview-only",
+				"note":          "Preview only. Emitter not wired; cmd composition root injects one via MCPServer.WithEmitter to persist EventGradientChanged.",
+			})
+		}
+		err = emitter.EmitGradientChange(expected, newLevel, payload.Delta, payload.Operator, time.Now().UTC())
+		if domain.IsConcurrencyConflict(err) && attempt < updateGradientAttempts {
+			logger.Info("update_gradient: %v; retrying (%d/%d)", err, attempt, updateGradientAttempts)
+			continue
+		}
+		if err != nil {
+			code := toolErrStorage
+			if domain.IsConcurrencyConflict(err) {
+				code = toolErrConflict
+			}
+			return toolError(code, map[string]any{
+				"initialized":   true,
+				"continent":     continent,
+				"current_level": state.GradientLevel,
+				"delta":         payload.Delta,
+				"preview_level": newLevel,
+				"persistence":   "preview-only",
+				"attempts":      attempt,
+				"reason":        fmt.Sprintf("emit gradient change: %v", err),
+			})
+		}
+		return jsonResult(map[string]any{
+			"initialized":   true,
+			"continent":     continent,
+			"current_level": state.GradientLevel,
+			"delta":         payload.Delta,
+			"new_level":     newLevel,
+			"operator":      payload.Operator,
+			"attempts":      attempt,
+			"persistence":   "event-store",
+		})
+	}
+}
+
+// realAppendJournal writes the expedition report to the journal
+// directory and pr-index file via the existing WriteJournal /
+// WritePRIndex helpers, then emits an EventExpeditionCompleted via the
+// injected emitter (Phase 4 follow-up #4, persistence=
+// 'event-store+filesystem'). When no emitter is wired (tests /
+// opt-out), it persists filesystem-only. The session can re-read the
+// new state via next_issue.
+//
+// continent is the project root from MCPServer.WithContinent. When
+// empty the response signals uninitialized so the session aborts.
+//
+//nolint:staticcheck // intentional: documents the existing journal/pr-index files maintained by session/journal.go
+func realAppendJournal(ctx context.Context, continent string, emitter port.ExpeditionEventEmitter, args json.RawMessage) map[string]any {
+	var payload struct {
+		Expedition         int    `json:"expedition"`
+		IssueID            string `json:"issue_id"`
+		IssueTitle         string `json:"issue_title"`
+		MissionType        string `json:"mission_type"`
+		Branch             string `json:"branch"`
+		PRUrl              string `json:"pr_url"`
+		Status             string `json:"status"`
+		Reason             string `json:"reason"`
+		Remaining          string `json:"remaining"`
+		BugsFound          int    `json:"bugs_found"`
+		BugIssues          string `json:"bug_issues"`
+		Insight            string `json:"insight"`
+		FailureType        string `json:"failure_type"`
+		HighSeverityDMails string `json:"high_severity_dmails"`
+		WaveID             string `json:"wave_id"`
+		StepID             string `json:"step_id"`
+	}
+	if len(args) > 0 {
+		_ = json.Unmarshal(args, &payload)
+	}
+	if continent == "" {
+		return toolError(toolErrNotConfigured, map[string]any{
+			"initialized": false,
+			"reason":      "paintress mcp continent root not configured",
+		})
+	}
+	if payload.Expedition <= 0 || payload.IssueID == "" || payload.Status == "" {
+		return toolError(toolErrInvalidArguments, map[string]any{
+			"initialized": true,
+			"persisted":   false,
+			"reason":      "missing required fields: expedition (>0), issue_id, status",
+			"received":    payload,
+		})
+	}
+	reservations, err := loadReservations(ctx, continent)
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{
+			"initialized": true,
+			"persisted":   false,
+			"reason":      fmt.Sprintf("expedition reservations read failed: %v", err),
+		})
+	}
+	for _, r := range reservations {
+		if r.Expedition == payload.Expedition && r.IssueID != payload.IssueID {
+			return toolError(toolErrConflict, map[string]any{
+				"initialized": true,
+				"persisted":   false,
+				"reason":      fmt.Sprintf("expedition %d is reserved for issue %s, not %s; call start_expedition for a new number", r.Expedition, r.IssueID, payload.IssueID),
+			})
+		}
+	}
+	report := &domain.ExpeditionReport{
+		Expedition:         payload.Expedition,
+		IssueID:            payload.IssueID,
+		IssueTitle:         payload.IssueTitle,
+		MissionType:        payload.MissionType,
+		Branch:             payload.Branch,
+		PRUrl:              payload.PRUrl,
+		Status:             payload.Status,
+		Reason:             payload.Reason,
+		Remaining:          payload.Remaining,
+		BugsFound:          payload.BugsFound,
+		BugIssues:          payload.BugIssues,
+		Insight:            payload.Insight,
+		FailureType:        payload.FailureType,
+		HighSeverityDMails: payload.HighSeverityDMails,
+		WaveID:             payload.WaveID,
+		StepID:             payload.StepID,
+	}
+	if err := WriteJournal(continent, report); err != nil {
+		code := toolErrStorage
+		if errors.Is(err, errJournalIssueMismatch) {
+			code = toolErrConflict
+		}
+		return toolError(code, map[string]any{
+			"initialized": true,
+			"persisted":   false,
+			"reason":      fmt.Sprintf("write journal: %v", err),
+		})
+	}
+	// A reservation left open here is closed by the next start_expedition,
+	// which checks the journal itself.
+	_ = closeReservation(ctx, continent, report.Expedition)
+	if err := WritePRIndex(continent, report); err != nil {
+		return toolError(toolErrPartialPersistence, map[string]any{
+			"initialized":  true,
+			"persisted":    true,
+			"journal_file": filepath.Join(domain.JournalDir(continent), fmt.Sprintf("%03d.md", report.Expedition)),
+			"pr_index":     false,
+			"reason":       fmt.Sprintf("journal written but pr-index append failed: %v", err),
+		})
+	}
+	if emitter == nil {
+		return jsonResult(map[string]any{
+			"initialized":      true,
+			"persisted":        true,
+			"expedition":       report.Expedition,
+			"issue_id":         report.IssueID,
+			"journal_file":     filepath.Join(domain.JournalDir(continent), fmt.Sprintf("%03d.md", report.Expedition)),
+			"pr_index_updated": report.PRUrl != "" && report.PRUrl != "none",
+			"persistence":      "filesystem-only",
+			"note":             "Filesystem persistence complete (journal/<NNN>.md + pr index). Emitter not wired; cmd composition root injects one via MCPServer.WithEmitter to also emit EventExpeditionCompleted.", // nosemgrep: layer-session-no-event-persistence -- comment text only, persistence is via session/journal.go::WriteJournal+WritePRIndex helpers that the rule allows [permanent]
+		})
+	}
+	bugsFoundStr := strconv.Itoa(report.BugsFound)
+	if err := emitter.EmitCompleteExpedition(report.Expedition, report.Status, report.IssueID, bugsFoundStr, report.WaveID, report.StepID, time.Now().UTC()); err != nil {
+		return toolError(toolErrPartialPersistence, map[string]any{
+			"initialized":      true,
+			"persisted":        true,
+			"expedition":       report.Expedition,
+			"issue_id":         report.IssueID,
+			"journal_file":     filepath.Join(domain.JournalDir(continent), fmt.Sprintf("%03d.md", report.Expedition)),
+			"pr_index_updated": report.PRUrl != "" && report.PRUrl != "none",
+			"persistence":      "filesystem-only",
+			"reason":           fmt.Sprintf("emit expedition completed: %v", err),
+		})
+	}
+	return jsonResult(map[string]any{
+		"initialized":      true,
+		"persisted":        true,
+		"expedition":       report.Expedition,
+		"issue_id":         report.IssueID,
+		"journal_file":     filepath.Join(domain.JournalDir(continent), fmt.Sprintf("%03d.md", report.Expedition)),
+		"pr_index_updated": report.PRUrl != "" && report.PRUrl != "none",
+		"persistence":      "event-store+filesystem",
+	})
+}
@@ -0,0 +1,308 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/harness"
+)
+
+// promptGradientMax is the Gradient Gauge capacity used when rendering
+// the expedition briefing. It matches the five-level scale documented on
+// GradientGauge (0 empty … 5 Gradient Attack).
+const promptGradientMax = 5
+
+// errPromptArgument marks prompts/get failures caused by the caller
+// (unknown prompt, missing or invalid argument) so they map to -32602.
+var errPromptArgument = errors.New("invalid prompt arguments")
+
+// promptDescriptors lists the MCP prompts backed by the filter
+// PromptRegistry templates. Claude Code surfaces each one as a slash
+// command (/mcp__paintress__<name>).
+func promptDescriptors() []map[string]any {
+	langArg := map[string]any{
+		"name":        "lang",
+		"description": "Prompt language: en, ja or fr. Defaults to the lang in .expedition/config.yaml.",
+		"required":    false,
+	}
+	return []map[string]any{
+		{
+			"name":        "expedition",
+			"title":       "Expedition briefing",
+			"description": "Full expedition briefing rendered from live project state: Gradient Gauge level, Lumina and capability violations from past journals, inbox D-Mails (Rival Contracts included), injected context and the mission rules of engagement.",
+			"arguments": []map[string]any{
+				langArg,
+				{
+					"name":        "number",
+					"description": "Expedition number. Defaults to the next free number (journal, pr-index and start_expedition reservations).",
+					"required":    false,
+				},
+			},
+		},
+		{
+			"name":        "mission",
+			"title":       "Mission rules of engagement",
+			"description": "Mission rules of engagement for linear (issue tracker) or wave (specification D-Mail) mode.",
+			"arguments": []map[string]any{
+				langArg,
+				{
+					"name":        "mode",
+					"description": "linear (default) or wave.",
+					"required":    false,
+				},
+			},
+		},
+		{
+			"name":        "review_fix",
+			"title":       "Review fix",
+			"description": "Focused prompt for fixing review comments on an open PR, optionally with a review-fix strategy hint.",
+			"arguments": []map[string]any{
+				{"name": "branch", "description": "Branch with the open PR.", "required": true},
+				{"name": "comments", "description": "Review comments to address.", "required": true},
+				{"name": "strategy", "description": "direct (default), decompose or rewrite.", "required": false},
+			},
+		},
+	}
+}
+
+func (s *MCPServer) handlePromptsGet(ctx context.Context, msg jsonrpcMessage) *jsonrpcMessage {
+	var params struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil || params.Name == "" {
+		return replyError(msg.ID, -32602, "invalid prompts/get params: name is required")
+	}
+	description, text, err := renderPrompt(ctx, s.continent, params.Name, params.Arguments, s.logger)
+	if err != nil {
+		if errors.Is(err, errPromptArgument) {
+			return replyError(msg.ID, -32602, err.Error())
+		}
+		return replyError(msg.ID, -32603, err.Error())
+	}
+	return reply(msg.ID, map[string]any{
+		"description": description,
+		"messages": []map[string]any{{
+			"role":    "user",
+			"content": map[string]any{"type": "text", "text": text},
+		}},
+	})
+}
+
+// renderPrompt expands the named prompt and returns its description and
+// rendered text.
+func renderPrompt(ctx context.Context, continent, name string, args map[string]string, logger domain.Logger) (string, string, error) {
+	switch name {
+	case "expedition":
+		lang, err := promptLang(continent, args["lang"])
+		if err != nil {
+			return "", "", err
+		}
+		number := 0
+		if raw := args["number"]; raw != "" {
+			n, convErr := strconv.Atoi(raw)
+			if convErr != nil || n < 1 {
+				return "", "", fmt.Errorf("%w: number must be a positive integer, got %q", errPromptArgument, raw)
+			}
+			number = n
+		}
+		if continent == "" {
+			return "", "", fmt.Errorf("paintress mcp continent not configured (start `paintress mcp` from the project root)")
+		}
+		data := expeditionPromptData(ctx, continent, lang, number, logger)
+		text := harness.RenderExpeditionPrompt(harness.MustDefaultPromptRegistry(), lang, data)
+		return fmt.Sprintf("Expedition #%d briefing", data.Number), text, nil
+
+	case "mission":
+		lang, err := promptLang(continent, args["lang"])
+		if err != nil {
+			return "", "", err
+		}
+		var wave bool
+		switch args["mode"] {
+		case "", "linear":
+		case "wave":
+			wave = true
+		default:
+			return "", "", fmt.Errorf("%w: mode must be linear or wave, got %q", errPromptArgument, args["mode"])
+		}
+		return "Mission rules of engagement", harness.MissionText(harness.MustDefaultPromptRegistry(), lang, wave), nil
+
+	case "review_fix":
+		if args["branch"] == "" || args["comments"] == "" {
+			return "", "", fmt.Errorf("%w: branch and comments are required", errPromptArgument)
+		}
+		strategy := harness.FixStrategy(args["strategy"])
+		switch strategy {
+		case "", harness.StrategyDirect:
+			return "Review fix", harness.BuildReviewFixPrompt(args["branch"], args["comments"]), nil
+		case harness.StrategyDecompose, harness.StrategyRewrite:
+			return "Review fix (" + string(strategy) + ")", harness.BuildReviewFixPromptWithStrategy(args["branch"], args["comments"], strategy), nil
+		default:
+			return "", "", fmt.Errorf("%w: strategy must be direct, decompose or rewrite, got %q", errPromptArgument, args["strategy"])
+		}
+
+	default:
+		return "", "", fmt.Errorf("%w: unknown prompt %q", errPromptArgument, name)
+	}
+}
+
+// promptLang resolves the prompt language: the explicit argument wins,
+// otherwise the configured project lang. The expedition templates also
+// ship a French variant, so fr is accepted on top of domain.ValidLang.
+func promptLang(continent, requested string) (string, error) {
+	if requested != "" {
+		if !validPromptLang(requested) {
+			return "", fmt.Errorf("%w: unsupported lang %q", errPromptArgument, requested)
+		}
+		return requested, nil
+	}
+	if continent != "" {
+		if cfg, err := LoadProjectConfig(continent); err == nil && validPromptLang(cfg.Lang) {
+			return cfg.Lang, nil
+		}
+	}
+	return domain.DefaultProjectConfig().Lang, nil
+}
+
+func validPromptLang(lang string) bool {
+	return domain.ValidLang(lang) || lang == "fr"
+}
+
+// expeditionPromptData assembles domain.PromptData from live project
+// state, the same inputs the headless expedition loop used to inject.
+// Each source degrades to an empty section (with a warning) so a broken
+// event store or inbox never blocks the briefing.
+func expeditionPromptData(ctx context.Context, continent, lang string, number int, logger domain.Logger) domain.PromptData {
+	cfg, err := LoadProjectConfig(continent)
+	if err != nil {
+		logger.Warn("prompts/get: load config: %v", err)
+		def := domain.DefaultProjectConfig()
+		cfg = &def
+	}
+	if number == 0 {
+		number = highestUsedExpedition(continent) + 1
+		reservations, resErr := loadReservations(ctx, continent)
+		if resErr != nil {
+			logger.Warn("prompts/get: load reservations: %v", resErr)
+		}
+		for _, r := range reservations {
+			number = max(number, r.Expedition+1)
+		}
+	}
+
+	gauge := harness.NewGradientGauge(promptGradientMax)
+	state, _, err := NewProjectionCache(filepath.Join(continent, domain.StateDir), logger).State(ctx)
+	if err != nil {
+		logger.Warn("prompts/get: load events: %v", err)
+		state = &ExpeditionState{}
+	}
+	for range state.GradientLevel {
+		gauge.Charge()
+	}
+
+	lumina := harness.FormatLuminaForPrompt(ScanJournalsForLumina(continent))
+	violations := domain.ScanJournalsForCapabilityViolations(journalOutcomes(continent))
+	if section := domain.FormatCapabilityViolationsSection(violations); section != "" {
+		lumina += "\n\n" + section
+	}
+
+	dmails, err := ScanInbox(ctx, continent)
+	if err != nil {
+		logger.Warn("prompts/get: scan inbox: %v", err)
+	}
+
+	reserve := "Model: `" + cfg.Model + "`"
+	if primary, reserves, parseErr := domain.ParseModelConfig(cfg.Model); parseErr == nil {
+		reserve = "Model: `" + primary + "`"
+		if len(reserves) > 0 {
+			reserve += " (reserve: " + strings.Join(reserves, ", ") + ")"
+		}
+	}
+
+	return domain.PromptData{
+		Number:                  number,
+		Timestamp:               time.Now().Format("2006-01-02 15:04:05"),
+		Bt:                      "`",
+		Cb:                      "```",
+		LuminaSection:           lumina,
+		GradientSection:         gauge.FormatForPrompt(),
+		ReserveSection:          reserve,
+		BaseBranch:              cfg.BaseBranch,
+		DevURL:                  devURL(cfg),
+		ContextSection:          readContextFiles(continent, logger),
+		InboxSection:            harness.FormatDMailForPrompt(dmails),
+		LinearTeam:              cfg.TrackerTeam(),
+		LinearProject:           cfg.TrackerProject(),
+		MissionSection:          harness.MissionText(harness.MustDefaultPromptRegistry(), lang, false),
+		HasEventSourcedContract: harness.HasEventSourcedContract(dmails),
+	}
+}
+
+func devURL(cfg *domain.ProjectConfig) string {
+	if cfg.NoDev {
+		return ""
+	}
+	return cfg.DevURL
+}
+
+// journalOutcomes reads the status and reason of every journal entry,
+// the inputs ScanJournalsForCapabilityViolations needs.
+func journalOutcomes(continent string) []domain.JournalEntry {
+	files, err := ListJournalFiles(continent)
+	if err != nil {
+		return nil
+	}
+	entries := make([]domain.JournalEntry, 0, len(files))
+	for _, f := range files {
+		content, readErr := os.ReadFile(f)
+		if readErr != nil {
+			continue
+		}
+		var entry domain.JournalEntry
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "- **Status**:"):
+				entry.Status = extractValue(line)
+			case strings.HasPrefix(line, "- **Reason**:"):
+				entry.Reason = extractValue(line)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// readContextFiles concatenates the Markdown files under
+// .expedition/context/ in name order.
+func readContextFiles(continent string, logger domain.Logger) string {
+	dir := domain.ContextDir(continent)
+	matches, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil || len(matches) == 0 {
+		return ""
+	}
+	slices.Sort(matches)
+	var b strings.Builder
+	for _, path := range matches {
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			logger.Warn("prompts/get: read context %s: %v", filepath.Base(path), readErr)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.Write(data)
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package session_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+// prompts/* render the filter PromptRegistry templates with live
+// project state so /mcp__paintress__expedition reproduces the briefing
+// the headless loop used to build.
+
+func promptText(t *testing.T, msg map[string]any) string {
+	t.Helper()
+	result, ok := msg["result"].(map[string]any)
+	if !ok {
+		t.Fatalf("no result in %v", msg)
+	}
+	messages := result["messages"].([]any)
+	return messages[0].(map[string]any)["content"].(map[string]any)["text"].(string)
+}
+
+func TestMCPServer_PromptsList(t *testing.T) {
+	// when
+	msgs := serveLines(t, t.TempDir(),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"prompts/list"}`,
+	)
+
+	// then
+	caps := msgs[0]["result"].(map[string]any)["capabilities"].(map[string]any)
+	if _, ok := caps["prompts"]; !ok {
+		t.Errorf("initialize capabilities lack prompts: %v", caps)
+	}
+	var names []string
+	for _, p := range msgs[1]["result"].(map[string]any)["prompts"].([]any) {
+		names = append(names, p.(map[string]any)["name"].(string))
+	}
+	if strings.Join(names, ",") != "expedition,mission,review_fix" {
+		t.Errorf("prompts = %v", names)
+	}
+}
+
+func TestMCPServer_PromptsGet_ExpeditionUsesLiveState(t *testing.T) {
+	// given: fr config, gradient level 3, a capability failure, a spec in the inbox
+	continent := t.TempDir()
+	stateDir := filepath.Join(continent, domain.StateDir)
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(domain.ProjectConfigPath(continent), []byte("lang: fr\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	emitter := &recordingEmitter{store: session.NewEventStore(stateDir, nil)}
+	if err := emitter.EmitGradientChange(domain.AnyVersion, 3, 3, "test", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	writeJournal(t, continent, "001.md", "# Expedition #1 — Journal\n\n- **Status**: failed\n- **Reason**: dial tcp: connection refused\n")
+	writeInboxDMail(t, continent, domain.DMail{Name: "spec-login", Kind: domain.KindSpecification, Description: "Login form", Body: "Implement the login form.\n"})
+
+	// when
+	msgs := serveLines(t, continent, `{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"expedition"}}`)
+
+	// then
+	text := promptText(t, msgs[0])
+	for _, want := range []string{
+		"#2 —",                           // next free number after journal 001
+		"3/5",                            // gradient level from the event store
+		"Capability Boundary Violations", // failed journal with a network signal
+		"spec-login",                     // inbox D-Mail
+		"Boîte de réception D-Mail",      // configured lang: fr
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("briefing missing %q", want)
+		}
+	}
+}
+
+func TestMCPServer_PromptsGet_ReviewFixWithStrategy(t *testing.T) {
+	// when
+	msgs := serveLines(t, t.TempDir(),
+		`{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"review_fix","arguments":{"branch":"feat/x","comments":"nit: rename","strategy":"decompose"}}}`,
+	)
+
+	// then
+	text := promptText(t, msgs[0])
+	if !strings.Contains(text, "feat/x") || !strings.Contains(text, "decompose the review comments") {
+		t.Errorf("review_fix text = %q", text)
+	}
+}
+
+func TestMCPServer_PromptsGet_InvalidArguments(t *testing.T) {
+	// when
+	msgs := serveLines(t, t.TempDir(),
+		`{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"nope"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"review_fix","arguments":{"branch":"b"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"prompts/get","params":{"name":"mission","arguments":{"lang":"de"}}}`,
+	)
+
+	// then
+	for _, msg := range msgs {
+		errObj, ok := msg["error"].(map[string]any)
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("id %v: want -32602 error, got %v", msg["id"], msg)
+		}
+	}
+}
+
+func TestMCPServer_PromptsGet_MissionModes(t *testing.T) {
+	// when
+	msgs := serveLines(t, t.TempDir(),
+		`{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"mission","arguments":{"lang":"en"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"mission","arguments":{"lang":"en","mode":"wave"}}}`,
+	)
+
+	// then
+	linear, wave := promptText(t, msgs[0]), promptText(t, msgs[1])
+	if linear == wave {
+		t.Error("linear and wave mission texts are identical")
+	}
+	if !strings.Contains(linear, "Linear") {
+		t.Errorf("linear mission text lacks Linear instructions")
+	}
+}
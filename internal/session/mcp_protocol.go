@@ -0,0 +1,92 @@
+package session
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+)
+
+// MCP protocol revisions this server implements. Revision strings are
+// dates, so later revisions compare greater as plain strings.
+const (
+	mcpProtocol20241105 = "2024-11-05"
+	mcpProtocol20250326 = "2025-03-26" // adds tool annotations
+	mcpProtocol20250618 = "2025-06-18" // adds outputSchema + structuredContent
+)
+
+// mcpProtocolVersions lists the supported revisions, newest first.
+var mcpProtocolVersions = []string{mcpProtocol20250618, mcpProtocol20250326, mcpProtocol20241105}
+
+// negotiateProtocolVersion picks the revision for a session. Per the MCP
+// lifecycle spec the server answers with the client's requested version
+// when it supports it, and otherwise with the latest version it does
+// support (never an echo of an unsupported one); the client decides
+// whether it can proceed. A client that sends no version is treated as
+// the oldest revision.
+func negotiateProtocolVersion(requested string) string {
+	if requested == "" {
+		return mcpProtocol20241105
+	}
+	if slices.Contains(mcpProtocolVersions, requested) {
+		return requested
+	}
+	return mcpProtocolVersions[0]
+}
+
+func toolAnnotationsSupported(version string) bool { return version >= mcpProtocol20250326 }
+
+func structuredOutputSupported(version string) bool { return version >= mcpProtocol20250618 }
+
+// negotiate records the revision requested in initialize params and
+// returns the one the session will speak.
+func (s *MCPServer) negotiate(params json.RawMessage) string {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	_ = json.Unmarshal(params, &p) // malformed params negotiate as a client without a version
+	version := negotiateProtocolVersion(p.ProtocolVersion)
+	s.protoMu.Lock()
+	s.protocolVersion = version
+	s.protoMu.Unlock()
+	return version
+}
+
+// negotiatedVersion returns the session's revision; before initialize it
+// is the oldest revision so pre-handshake callers see legacy results.
+func (s *MCPServer) negotiatedVersion() string {
+	s.protoMu.Lock()
+	defer s.protoMu.Unlock()
+	if s.protocolVersion == "" {
+		return mcpProtocol20241105
+	}
+	return s.protocolVersion
+}
+
+// toolsForProtocol returns the tool descriptors as seen by a client of
+// the given revision: annotations from 2025-03-26, outputSchema from
+// 2025-06-18.
+func toolsForProtocol(version string) []map[string]any {
+	descriptors := toolDescriptors()
+	tools := make([]map[string]any, 0, len(descriptors))
+	for _, d := range descriptors {
+		tool := maps.Clone(d)
+		if !toolAnnotationsSupported(version) {
+			delete(tool, "annotations")
+		}
+		if !structuredOutputSupported(version) {
+			delete(tool, "outputSchema")
+		}
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+// resultForProtocol adapts a tool result to the session's revision:
+// structuredContent is only sent to 2025-06-18 clients. The JSON text
+// block is always present so older clients keep working.
+func resultForProtocol(version string, result map[string]any) map[string]any {
+	if !structuredOutputSupported(version) {
+		delete(result, "structuredContent")
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+package session_test
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Protocol negotiation: 2024-11-05, 2025-03-26 and 2025-06-18 clients
+// each get the features of their revision.
+
+func initializeLine(version string) string {
+	if version == "" {
+		return `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{}}`
+	}
+	return `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"` + version + `"}}`
+}
+
+func TestMCPServer_NegotiatesProtocolVersion(t *testing.T) {
+	tests := []struct {
+		requested, want string
+	}{
+		{"2024-11-05", "2024-11-05"},
+		{"2025-03-26", "2025-03-26"},
+		{"2025-06-18", "2025-06-18"},
+		{"2099-01-01", "2025-06-18"},
+		{"", "2024-11-05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			// when
+			msgs := serveLines(t, t.TempDir(), initializeLine(tt.requested))
+
+			// then
+			if got := msgs[0]["result"].(map[string]any)["protocolVersion"]; got != tt.want {
+				t.Errorf("protocolVersion = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMCPServer_ToolsListFeaturesFollowRevision(t *testing.T) {
+	tests := []struct {
+		version                     string
+		wantAnnotations, wantOutput bool
+	}{
+		{"2024-11-05", false, false},
+		{"2025-03-26", true, false},
+		{"2025-06-18", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			// when
+			msgs := serveLines(t, t.TempDir(), initializeLine(tt.version), `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+
+			// then
+			for _, raw := range msgs[1]["result"].(map[string]any)["tools"].([]any) {
+				tool := raw.(map[string]any)
+				annotations, hasAnnotations := tool["annotations"].(map[string]any)
+				if hasAnnotations != tt.wantAnnotations {
+					t.Errorf("%s: annotations present = %v", tool["name"], hasAnnotations)
+				}
+				if hasAnnotations {
+					for _, hint := range []string{"readOnlyHint", "idempotentHint", "destructiveHint"} {
+						if _, ok := annotations[hint].(bool); !ok {
+							t.Errorf("%s: %s missing", tool["name"], hint)
+						}
+					}
+				}
+				if tool["name"] == "next_issue" {
+					if _, ok := tool["outputSchema"]; ok != tt.wantOutput {
+						t.Errorf("next_issue outputSchema present = %v", ok)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMCPServer_StructuredContentOnlyFor20250618(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"next_issue","arguments":{}}}`
+
+	// when
+	legacy := serveLines(t, continent, initializeLine("2024-11-05"), call)
+	current := serveLines(t, continent, initializeLine("2025-06-18"), call)
+
+	// then
+	if _, ok := legacy[1]["result"].(map[string]any)["structuredContent"]; ok {
+		t.Error("2024-11-05 client received structuredContent")
+	}
+	result := current[1]["result"].(map[string]any)
+	structured, ok := result["structuredContent"].(map[string]any)
+	if !ok {
+		t.Fatalf("2025-06-18 client got no structuredContent: %v", result)
+	}
+	var fromText map[string]any
+	text := result["content"].([]any)[0].(map[string]any)["text"].(string)
+	if err := json.Unmarshal([]byte(text), &fromText); err != nil {
+		t.Fatal(err)
+	}
+	if structured["next_expedition_number"] != fromText["next_expedition_number"] || structured["next_expedition_number"] != float64(1) {
+		t.Errorf("structured = %v, text = %v", structured, fromText)
+	}
+}
+
+func TestMCPServer_IsErrorResultHasNoStructuredContent(t *testing.T) {
+	// when
+	msgs := serveLines(t, t.TempDir(), initializeLine("2025-06-18"),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"update_gradient","arguments":{"delta":"x"}}}`)
+
+	// then
+	result := msgs[1]["result"].(map[string]any)
+	if result["isError"] != true {
+		t.Fatalf("isError = %v", result["isError"])
+	}
+	if _, ok := result["structuredContent"]; ok {
+		t.Error("isError result carries structuredContent")
+	}
+}
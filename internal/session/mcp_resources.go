@@ -0,0 +1,419 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// mcpResourceScheme prefixes every resource URI the MCP server exposes.
+// URIs address .expedition/ documents by collection and name, e.g.
+// paintress://journal/012 or paintress://inbox/<d-mail name>.
+const mcpResourceScheme = "paintress://"
+
+// Resource collections. Each maps onto one .expedition/ subdirectory.
+const (
+	resourceJournal  = "journal"
+	resourceInsights = "insights"
+	resourceInbox    = "inbox"
+	resourceArchive  = "archive"
+)
+
+// MCP resource error codes (MCP spec: resources/read on an unknown URI
+// returns -32002 "Resource not found").
+const mcpErrResourceNotFound = -32002
+
+// errResourceNotFound marks a well-formed URI whose document is missing.
+var errResourceNotFound = errors.New("resource not found")
+
+// resourceDescriptor builds one resources/list entry.
+func resourceDescriptor(collection, name, description string) map[string]any {
+	return map[string]any{
+		"uri":         mcpResourceScheme + collection + "/" + name,
+		"name":        collection + "/" + name,
+		"description": description,
+		"mimeType":    "text/markdown",
+	}
+}
+
+// listResources enumerates the addressable .expedition/ documents: journal
+// entries (ListJournalFiles), insight ledger files (InsightWriter.Read),
+// inbox D-Mails (ScanInbox) and archived D-Mails (archive index, falling
+// back to a directory listing when the index has not been built yet).
+// Missing directories contribute nothing rather than failing the listing.
+func listResources(ctx context.Context, continent string) ([]map[string]any, error) {
+	resources := []map[string]any{}
+
+	if files, err := ListJournalFiles(continent); err == nil {
+		for _, f := range files {
+			name := strings.TrimSuffix(filepath.Base(f), ".md")
+			resources = append(resources, resourceDescriptor(resourceJournal, name, "Expedition journal: "+ExtractSummary(f)))
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("list journal: %w", err)
+	}
+
+	insightsDir := domain.InsightsDir(continent)
+	writer := NewInsightWriter(insightsDir, domain.RunDir(continent))
+	if entries, err := os.ReadDir(insightsDir); err == nil {
+		for _, e := range entries {
+			if e.IsDir() || filepath.Ext(e.Name()) != ".md" {
+				continue
+			}
+			file, readErr := writer.Read(e.Name())
+			if readErr != nil {
+				continue
+			}
+			desc := fmt.Sprintf("Insight ledger (%s): %d entr(ies)", file.Kind, len(file.Entries))
+			resources = append(resources, resourceDescriptor(resourceInsights, e.Name(), desc))
+		}
+	}
+
+	dmails, err := ScanInbox(ctx, continent)
+	if err != nil {
+		return nil, fmt.Errorf("list inbox: %w", err)
+	}
+	for _, dm := range dmails {
+		desc := fmt.Sprintf("Inbox D-Mail (%s): %s", dm.Kind, dm.Description)
+		resources = append(resources, resourceDescriptor(resourceInbox, dm.Name, desc))
+	}
+
+	archived, err := listArchiveResources(continent)
+	if err != nil {
+		return nil, fmt.Errorf("list archive: %w", err)
+	}
+	return append(resources, archived...), nil
+}
+
+// listArchiveResources lists archived D-Mails from the archive index so
+// summaries come from the same metadata archive-prune maintains. When the
+// index is absent the archive directory is listed directly.
+func listArchiveResources(continent string) ([]map[string]any, error) {
+	index, err := ReadArchiveIndex(domain.ArchiveIndexPath(continent))
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var resources []map[string]any
+	for _, entry := range index {
+		dir, file := filepath.Split(filepath.ToSlash(entry.Path))
+		if strings.Trim(dir, "/") != resourceArchive || filepath.Ext(file) != ".md" {
+			continue
+		}
+		name := strings.TrimSuffix(file, ".md")
+		if seen[name] {
+			continue
+		}
+		if _, statErr := os.Stat(filepath.Join(domain.ArchiveDir(continent), file)); statErr != nil {
+			continue // pruned since the index was built
+		}
+		seen[name] = true
+		resources = append(resources, resourceDescriptor(resourceArchive, name, "Archived D-Mail: "+entry.Summary))
+	}
+
+	entries, err := os.ReadDir(domain.ArchiveDir(continent))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return resources, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".md")
+		if e.IsDir() || filepath.Ext(e.Name()) != ".md" || seen[name] {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		summary := ExtractSummary(filepath.Join(domain.ArchiveDir(continent), name+".md"))
+		resources = append(resources, resourceDescriptor(resourceArchive, name, "Archived D-Mail: "+summary))
+	}
+	return resources, nil
+}
+
+// resolveResourcePath maps a paintress:// URI onto the file it addresses.
+// Names must be a single path element so a URI can never escape its
+// collection directory.
+func resolveResourcePath(continent, uri string) (string, error) {
+	rest, ok := strings.CutPrefix(uri, mcpResourceScheme)
+	if !ok {
+		return "", fmt.Errorf("unsupported resource uri %q (want %s<collection>/<name>)", uri, mcpResourceScheme)
+	}
+	collection, name, ok := strings.Cut(rest, "/")
+	if !ok || name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid resource uri %q", uri)
+	}
+	switch collection {
+	case resourceJournal:
+		return filepath.Join(domain.JournalDir(continent), name+".md"), nil
+	case resourceInsights:
+		return filepath.Join(domain.InsightsDir(continent), name), nil
+	case resourceInbox:
+		return inboxResourcePath(continent, name), nil
+	case resourceArchive:
+		return filepath.Join(domain.ArchiveDir(continent), name+".md"), nil
+	default:
+		return "", fmt.Errorf("unknown resource collection %q in %q", collection, uri)
+	}
+}
+
+// inboxResourcePath is the file behind an inbox resource: the legacy
+// <name>.md, or else the body of the envelope <name>.yaml.
+func inboxResourcePath(continent, name string) string {
+	dir := domain.InboxDir(continent)
+	legacy := filepath.Join(dir, name+".md")
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy
+	}
+	env, _, err := readInboxEnvelope(dir, name)
+	if err != nil {
+		return legacy
+	}
+	bodyFile, _ := env.BodyFile() // validated by readInboxEnvelope
+	return filepath.Join(dir, bodyFile)
+}
+
+// resourceURIForPath is the inverse of resolveResourcePath for the
+// directories the subscription watcher observes. Returns "" for files
+// that are not addressable resources.
+func resourceURIForPath(continent, path string) string {
+	if filepath.Ext(path) != ".md" {
+		return ""
+	}
+	dir, file := filepath.Split(path)
+	dir = filepath.Clean(dir)
+	switch dir {
+	case filepath.Clean(domain.JournalDir(continent)):
+		if file == "000.md" {
+			return ""
+		}
+		return mcpResourceScheme + resourceJournal + "/" + strings.TrimSuffix(file, ".md")
+	case filepath.Clean(domain.InboxDir(continent)):
+		if name, ok := strings.CutSuffix(file, domain.EnvelopeBodyExt); ok {
+			return mcpResourceScheme + resourceInbox + "/" + name
+		}
+		return mcpResourceScheme + resourceInbox + "/" + strings.TrimSuffix(file, ".md")
+	}
+	return ""
+}
+
+// readResource returns the resources/read contents for uri.
+func readResource(continent, uri string) (map[string]any, error) {
+	path, err := resolveResourcePath(continent, uri)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", errResourceNotFound, uri)
+		}
+		return nil, fmt.Errorf("read %s: %w", uri, err)
+	}
+	return map[string]any{
+		"contents": []map[string]any{{
+			"uri":      uri,
+			"mimeType": "text/markdown",
+			"text":     string(data),
+		}},
+	}, nil
+}
+
+// handleResourcesList answers resources/list.
+func (s *MCPServer) handleResourcesList(ctx context.Context, msg jsonrpcMessage) *jsonrpcMessage {
+	if s.continent == "" {
+		return reply(msg.ID, map[string]any{"resources": []map[string]any{}})
+	}
+	resources, err := listResources(ctx, s.continent)
+	if err != nil {
+		return replyError(msg.ID, -32603, fmt.Sprintf("resources/list: %v", err))
+	}
+	return reply(msg.ID, map[string]any{"resources": resources})
+}
+
+// handleResourcesRead answers resources/read.
+func (s *MCPServer) handleResourcesRead(msg jsonrpcMessage) *jsonrpcMessage {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil || params.URI == "" {
+		return replyError(msg.ID, -32602, "invalid resources/read params: uri is required")
+	}
+	if s.continent == "" {
+		return replyError(msg.ID, mcpErrResourceNotFound, "paintress mcp continent not configured")
+	}
+	result, err := readResource(s.continent, params.URI)
+	if err != nil {
+		if errors.Is(err, errResourceNotFound) {
+			return replyError(msg.ID, mcpErrResourceNotFound, err.Error())
+		}
+		return replyError(msg.ID, -32602, err.Error())
+	}
+	return reply(msg.ID, result)
+}
+
+// handleResourcesSubscribe records a subscription and lazily starts the
+// journal/inbox watcher. Subscribing to a document that does not exist
+// yet is allowed: the client is notified once it appears.
+func (s *MCPServer) handleResourcesSubscribe(msg jsonrpcMessage, subscribe bool) *jsonrpcMessage {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil || params.URI == "" {
+		return replyError(msg.ID, -32602, "invalid subscription params: uri is required")
+	}
+	if s.continent == "" {
+		return replyError(msg.ID, mcpErrResourceNotFound, "paintress mcp continent not configured")
+	}
+	if _, err := resolveResourcePath(s.continent, params.URI); err != nil {
+		return replyError(msg.ID, -32602, err.Error())
+	}
+
+	s.resMu.Lock()
+	if subscribe {
+		s.subscriptions[params.URI] = true
+	} else {
+		delete(s.subscriptions, params.URI)
+	}
+	startWatch := subscribe && !s.watching && s.lifetime != nil
+	if startWatch {
+		s.watching = true
+	}
+	lifetime := s.lifetime
+	s.resMu.Unlock()
+
+	if startWatch {
+		s.startResourceWatch(lifetime)
+	}
+	return reply(msg.ID, map[string]any{})
+}
+
+// watchResourceDirs adds journal/ and inbox/ to watcher once they exist,
+// else their nearest existing ancestor inside the continent so their
+// creation is seen. It returns the resource directories added by this
+// call after the first one (watched is empty on the first call), whose
+// contents may have appeared before the watch.
+func (s *MCPServer) watchResourceDirs(watcher *fsnotify.Watcher, watched map[string]bool) []string {
+	first := len(watched) == 0
+	root := filepath.Clean(s.continent)
+	var appeared []string
+	for _, target := range []string{domain.JournalDir(s.continent), domain.InboxDir(s.continent)} {
+		dir := filepath.Clean(target)
+		for dir != root {
+			if _, err := os.Stat(dir); err == nil {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+		if watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			s.logger.Warn("mcp resources: watch %s: %v", dir, err)
+			continue
+		}
+		watched[dir] = true
+		if dir == filepath.Clean(target) && !first {
+			appeared = append(appeared, dir)
+		}
+	}
+	return appeared
+}
+
+// notifyDirAppeared announces a resource directory created after the
+// watch started: documents written into it before it was watched raised
+// no event of their own.
+func (s *MCPServer) notifyDirAppeared(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) == 0 {
+		return
+	}
+	if notifyErr := s.notify("notifications/resources/list_changed", nil); notifyErr != nil {
+		s.logger.Warn("mcp resources: %v", notifyErr)
+	}
+	for _, e := range entries {
+		uri := resourceURIForPath(s.continent, filepath.Join(dir, e.Name()))
+		if uri == "" || !s.isSubscribed(uri) {
+			continue
+		}
+		if notifyErr := s.notify("notifications/resources/updated", map[string]any{"uri": uri}); notifyErr != nil {
+			s.logger.Warn("mcp resources: %v", notifyErr)
+		}
+	}
+}
+
+func (s *MCPServer) isSubscribed(uri string) bool {
+	s.resMu.Lock()
+	defer s.resMu.Unlock()
+	return s.subscriptions[uri]
+}
+
+// startResourceWatch watches journal/ and inbox/ and turns file changes
+// into notifications/resources/list_changed (entries added or removed)
+// and notifications/resources/updated (subscribed document changed).
+// A directory that does not exist yet is watched through its nearest
+// existing ancestor and added once it is created. The goroutine exits
+// when ctx (the server lifetime bound by start) is cancelled; the stop
+// func waits for it so no notification is written after the transport
+// shuts down.
+func (s *MCPServer) startResourceWatch(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher() // nosemgrep: adr0005-fsnotify-watcher-without-close -- watcher is closed in the goroutine owning the event loop [permanent]
+	if err != nil {
+		s.logger.Warn("mcp resources: watcher: %v", err)
+		return
+	}
+	watched := make(map[string]bool)
+	s.watchResourceDirs(watcher, watched)
+
+	s.watchWG.Add(1)
+	go func() {
+		defer s.watchWG.Done()
+		defer func() { _ = watcher.Close() }()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&fsnotify.Create != 0 {
+					for _, dir := range s.watchResourceDirs(watcher, watched) {
+						s.notifyDirAppeared(dir)
+					}
+				}
+				uri := resourceURIForPath(s.continent, event.Name)
+				if uri == "" {
+					continue
+				}
+				if event.Op&(fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+					if notifyErr := s.notify("notifications/resources/list_changed", nil); notifyErr != nil {
+						s.logger.Warn("mcp resources: %v", notifyErr)
+					}
+				}
+				if s.isSubscribed(uri) {
+					if notifyErr := s.notify("notifications/resources/updated", map[string]any{"uri": uri}); notifyErr != nil {
+						s.logger.Warn("mcp resources: %v", notifyErr)
+					}
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+}
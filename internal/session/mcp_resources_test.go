@@ -0,0 +1,316 @@
+package session_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+// resources/* expose .expedition/ documents (journal, insights, inbox,
+// archive) as paintress:// URIs so the session can pull them without
+// extra tool calls.
+
+func serveLines(t *testing.T, continent string, lines ...string) []map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	srv := session.NewMCPServer(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out, nil).WithContinent(continent)
+	if err := srv.Serve(context.Background()); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	var msgs []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		var msg map[string]any
+		if err := json.Unmarshal(line, &msg); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	// Requests are dispatched concurrently, so responses arrive in
+	// completion order; JSON-RPC correlates them by id. Sort by id so
+	// callers can index them in request order (notifications go last).
+	sort.SliceStable(msgs, func(i, j int) bool {
+		idI, okI := msgs[i]["id"].(float64)
+		idJ, okJ := msgs[j]["id"].(float64)
+		if okI != okJ {
+			return okI
+		}
+		return idI < idJ
+	})
+	return msgs
+}
+
+func seedResourceFixtures(t *testing.T, continent string) {
+	t.Helper()
+	writeJournal(t, continent, "012.md", "# Expedition #12 — Journal\n\n- **Issue**: MY-12\n")
+
+	inboxDir := domain.InboxDir(continent)
+	if err := os.MkdirAll(inboxDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	dm := domain.DMail{Name: "fb-auth", Kind: "implementation-feedback", Description: "Auth feedback", Body: "# Feedback\n"}
+	data, err := dm.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inboxDir, "fb-auth.md"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	archiveDir := domain.ArchiveDir(continent)
+	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := domain.DMail{Name: "old-report", Kind: "report", Description: "Old report"}
+	oldData, err := old.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(archiveDir, "old-report.md"), oldData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{domain.RunDir(continent), domain.InsightsDir(continent)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writer := session.NewInsightWriter(domain.InsightsDir(continent), domain.RunDir(continent))
+	if err := writer.Append("lumina.md", "lumina", "paintress", domain.InsightEntry{Title: "retry auth", What: "flaky"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMCPServer_Initialize_AdvertisesResources(t *testing.T) {
+	// given
+	continent := t.TempDir()
+
+	// when
+	msgs := serveLines(t, continent, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+
+	// then
+	caps := msgs[0]["result"].(map[string]any)["capabilities"].(map[string]any)
+	res, ok := caps["resources"].(map[string]any)
+	if !ok {
+		t.Fatalf("capabilities.resources missing: %v", caps)
+	}
+	if res["subscribe"] != true || res["listChanged"] != true {
+		t.Errorf("resources capability = %v, want subscribe+listChanged", res)
+	}
+}
+
+func TestMCPServer_ResourcesList_AllCollections(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	seedResourceFixtures(t, continent)
+
+	// when
+	msgs := serveLines(t, continent, `{"jsonrpc":"2.0","id":2,"method":"resources/list"}`)
+
+	// then
+	resources := msgs[0]["result"].(map[string]any)["resources"].([]any)
+	got := make(map[string]bool)
+	for _, r := range resources {
+		entry := r.(map[string]any)
+		got[entry["uri"].(string)] = true
+		if entry["mimeType"] != "text/markdown" {
+			t.Errorf("%v mimeType = %v", entry["uri"], entry["mimeType"])
+		}
+	}
+	for _, want := range []string{
+		"paintress://journal/012",
+		"paintress://insights/lumina.md",
+		"paintress://inbox/fb-auth",
+		"paintress://archive/old-report",
+	} {
+		if !got[want] {
+			t.Errorf("missing %s in %v", want, got)
+		}
+	}
+}
+
+func TestMCPServer_ResourcesList_EmptyContinent(t *testing.T) {
+	// given: no .expedition/ at all
+	continent := t.TempDir()
+
+	// when
+	msgs := serveLines(t, continent, `{"jsonrpc":"2.0","id":3,"method":"resources/list"}`)
+
+	// then
+	if msgs[0]["error"] != nil {
+		t.Fatalf("unexpected error: %v", msgs[0]["error"])
+	}
+	resources := msgs[0]["result"].(map[string]any)["resources"].([]any)
+	if len(resources) != 0 {
+		t.Errorf("resources = %v, want empty", resources)
+	}
+}
+
+func TestMCPServer_ResourcesRead_ReturnsMarkdown(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	seedResourceFixtures(t, continent)
+
+	// when
+	msgs := serveLines(t, continent,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"paintress://journal/012"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":"paintress://inbox/fb-auth"}}`,
+	)
+
+	// then
+	journal := msgs[0]["result"].(map[string]any)["contents"].([]any)[0].(map[string]any)
+	if !strings.Contains(journal["text"].(string), "MY-12") {
+		t.Errorf("journal text = %q", journal["text"])
+	}
+	inbox := msgs[1]["result"].(map[string]any)["contents"].([]any)[0].(map[string]any)
+	if inbox["uri"] != "paintress://inbox/fb-auth" || !strings.Contains(inbox["text"].(string), "Auth feedback") {
+		t.Errorf("inbox content = %v", inbox)
+	}
+}
+
+func TestMCPServer_ResourcesRead_Errors(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	seedResourceFixtures(t, continent)
+
+	// when
+	msgs := serveLines(t, continent,
+		`{"jsonrpc":"2.0","id":6,"method":"resources/read","params":{"uri":"paintress://journal/999"}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"resources/read","params":{"uri":"paintress://inbox/..%2F..%2Fetc"}}`,
+		`{"jsonrpc":"2.0","id":8,"method":"resources/read","params":{"uri":"paintress://journal/../../go.mod"}}`,
+		`{"jsonrpc":"2.0","id":9,"method":"resources/read","params":{"uri":"file:///etc/passwd"}}`,
+	)
+
+	// then
+	wantCodes := []float64{-32002, -32002, -32602, -32602}
+	for i, want := range wantCodes {
+		errObj, ok := msgs[i]["error"].(map[string]any)
+		if !ok {
+			t.Errorf("msg %d: expected error, got %v", i, msgs[i])
+			continue
+		}
+		if errObj["code"] != want {
+			t.Errorf("msg %d: code = %v, want %v", i, errObj["code"], want)
+		}
+	}
+}
+
+func TestMCPServer_ResourcesSubscribe_NotifiesOnInboxArrival(t *testing.T) {
+	// given: a subscribed server reading from a pipe
+	continent := t.TempDir()
+	inboxDir := domain.InboxDir(continent)
+	if err := os.MkdirAll(inboxDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(domain.JournalDir(continent), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	srv := session.NewMCPServer(inR, outW, nil).WithContinent(continent)
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(context.Background())
+		_ = outW.Close()
+	}()
+	lines := make(chan map[string]any, 16)
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			var msg map[string]any
+			if json.Unmarshal(scanner.Bytes(), &msg) == nil {
+				lines <- msg
+			}
+		}
+		close(lines)
+	}()
+	if _, err := io.WriteString(inW, `{"jsonrpc":"2.0","id":10,"method":"resources/subscribe","params":{"uri":"paintress://inbox/new-mail"}}`+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	if ack := <-lines; ack["error"] != nil {
+		t.Fatalf("subscribe error: %v", ack["error"])
+	}
+
+	// when
+	dm := domain.DMail{Name: "new-mail", Kind: "report", Description: "arrived"}
+	data, _ := dm.Marshal()
+	if err := os.WriteFile(filepath.Join(inboxDir, "new-mail.md"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// then
+	var sawUpdated, sawListChanged bool
+	timeout := time.After(5 * time.Second)
+	for !(sawUpdated && sawListChanged) {
+		select {
+		case msg := <-lines:
+			switch msg["method"] {
+			case "notifications/resources/updated":
+				if params, _ := msg["params"].(map[string]any); params["uri"] == "paintress://inbox/new-mail" {
+					sawUpdated = true
+				}
+			case "notifications/resources/list_changed":
+				sawListChanged = true
+			}
+		case <-timeout:
+			t.Fatalf("updated=%v list_changed=%v before timeout", sawUpdated, sawListChanged)
+		}
+	}
+	go func() {
+		for range lines { // drain so late notifications never block Serve
+		}
+	}()
+	_ = inW.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+}
+
+func TestMCPServer_ResourcesSubscribe_NotifiesWhenInboxCreatedLater(t *testing.T) {
+	// given: subscribed before .expedition/ exists at all
+	continent := t.TempDir()
+	p := startPipeSession(t, continent, nil)
+	p.send(`{"jsonrpc":"2.0","id":10,"method":"resources/subscribe","params":{"uri":"paintress://inbox/new-mail"}}`)
+	if ack := p.next(); ack["error"] != nil {
+		t.Fatalf("subscribe error: %v", ack["error"])
+	}
+
+	// when
+	if err := os.MkdirAll(domain.InboxDir(continent), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	dm := domain.DMail{Name: "new-mail", Kind: "report", Description: "arrived"}
+	data, _ := dm.Marshal()
+	if err := os.WriteFile(filepath.Join(domain.InboxDir(continent), "new-mail.md"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// then
+	for {
+		msg := p.next()
+		if msg["method"] != "notifications/resources/updated" {
+			continue
+		}
+		if params, _ := msg["params"].(map[string]any); params["uri"] == "paintress://inbox/new-mail" {
+			break
+		}
+	}
+	go func() {
+		for range p.lines { // drain so late notifications never block Serve
+		}
+	}()
+	_ = p.in.Close()
+	if err := <-p.done; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+}
@@ -7,8 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"path/filepath"
-	"strconv"
+	"sync"
 	"time"
 
 	"github.com/hironow/paintress/internal/domain"
@@ -16,14 +15,19 @@ import (
 	"github.com/hironow/paintress/internal/usecase/port"
 )
 
-// MCPServer is a stdio-based Model Context Protocol server for the
-// refs/issues/0027 jun15 MCP pivot.
+// MCPServer is a Model Context Protocol server for the refs/issues/0027
+// jun15 MCP pivot, served over stdio or Streamable HTTP (mcp_http.go).
 //
-// All four tools are real implementations: ping (health
-// check), next_issue (reads journal + pr-index state), and
-// update_gradient + append_journal (persist
-// EventGradientChanged / EventExpeditionCompleted via the event store
-// when an emitter is wired; cmd wires one by default).
+// Its tools (toolDescriptors) cover the expedition loop: choosing and
+// starting work (next_issue, start_expedition), recording it
+// (update_gradient, append_journal, record_checkpoint), D-Mail in and
+// out (dmail, read_inbox, archive_inbox) and read-only views (ping,
+// get_status, get_insights, list_incomplete_expeditions). Tools with
+// side effects persist events through the emitter when one is wired;
+// cmd wires one by default. Resources (mcp_resources.go) expose the
+// journal, insights, inbox and archive documents, with subscriptions;
+// prompts (mcp_prompts.go) expose the expedition, mission and
+// review_fix templates.
 //
 // Wire it into a Claude Code interactive session via --mcp-config so
 // inference stays on the human-initiated session's subscription quota
@@ -32,7 +36,10 @@ import (
 //
 // Protocol: JSON-RPC 2.0 over stdio, one envelope per line. Stderr
 // carries human-readable diagnostics (per the project stdout/stderr
-// separation invariant).
+// separation invariant). Requests are dispatched concurrently (see
+// mcp_dispatch.go) and server-initiated notifications (resource
+// subscriptions, progress) share out with responses, so writes are
+// serialized.
 //
 // continent is the project root directory (= paintress's "continent"
 // abstraction) used to resolve journal / pr-index paths for the
@@ -45,6 +52,30 @@ type MCPServer struct {
 	logger    domain.Logger
 	continent string
 	emitter   port.ExpeditionEventEmitter
+
+	writeMu sync.Mutex
+
+	// concurrent stdio dispatch (see mcp_dispatch.go): slots bounds the
+	// running requests, inFlight maps ids to cancel funcs, requests lets
+	// Serve wait for them, lastSequential chains side-effecting requests
+	// in arrival order and responses writes replies in that order too.
+	slots          chan struct{}
+	inFlight       requestRegistry
+	requests       sync.WaitGroup
+	lastSequential chan struct{}
+	responses      responseSequencer
+
+	// MCP revision negotiated by initialize (see mcp_protocol.go).
+	protoMu         sync.Mutex
+	protocolVersion string
+
+	// resource subscriptions (resources/subscribe); the watcher starts
+	// on the first subscription and stops with Serve.
+	resMu         sync.Mutex
+	subscriptions map[string]bool
+	watching      bool
+	watchWG       sync.WaitGroup
+	lifetime      context.Context
 }
 
 // NewMCPServer wires explicit I/O so tests can drive the server
@@ -55,7 +86,13 @@ func NewMCPServer(in io.Reader, out io.Writer, logger domain.Logger) *MCPServer
 	if logger == nil {
 		logger = &domain.NopLogger{}
 	}
-	return &MCPServer{in: in, out: out, logger: logger}
+	return &MCPServer{
+		in:            in,
+		out:           out,
+		logger:        logger,
+		slots:         make(chan struct{}, maxConcurrentMCPRequests),
+		subscriptions: make(map[string]bool),
+	}
 }
 
 // WithContinent sets the project root used by real-impl MCP tools to
@@ -101,8 +138,13 @@ type jsonrpcError struct {
 // Serve reads messages from in line-by-line and writes responses to
 // out until ctx cancels or stdin closes. Per-message decode errors
 // surface as JSON-RPC error responses; only stream-level read errors
-// abort Serve.
+// abort Serve. In-flight requests are answered and background resource
+// watchers stopped before Serve returns.
 func (s *MCPServer) Serve(ctx context.Context) error {
+	ctx, stop := s.start(ctx)
+	defer stop()
+	defer s.requests.Wait()
+
 	scanner := bufio.NewScanner(s.in)
 	// 4 MiB buffer to comfortably cover D-Mail bodies in later commits.
 	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
@@ -116,7 +158,7 @@ func (s *MCPServer) Serve(ctx context.Context) error {
 		if len(line) == 0 {
 			continue
 		}
-		if err := s.handle(ctx, line); err != nil {
+		if err := s.accept(ctx, line); err != nil {
 			s.logger.Warn("mcp server: handle: %v", err)
 		}
 	}
@@ -126,61 +168,87 @@ func (s *MCPServer) Serve(ctx context.Context) error {
 	return nil
 }
 
-func (s *MCPServer) handle(ctx context.Context, line []byte) error {
-	var msg jsonrpcMessage
-	if err := json.Unmarshal(line, &msg); err != nil {
-		return fmt.Errorf("decode request: %w", err)
+// start binds the server lifetime to ctx: background resource watchers
+// run until the returned stop func cancels it, and stop waits for them
+// so nothing is written after the transport shuts down.
+func (s *MCPServer) start(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	s.resMu.Lock()
+	s.lifetime = ctx
+	s.resMu.Unlock()
+	return ctx, func() {
+		cancel()
+		s.watchWG.Wait()
 	}
+}
+
+// dispatch routes one decoded message and returns the response to send,
+// or nil for notifications. Transports (stdio Serve, Streamable HTTP)
+// share it so every session sees the same method and tool surface.
+func (s *MCPServer) dispatch(ctx context.Context, msg jsonrpcMessage) *jsonrpcMessage {
 	switch msg.Method {
 	case "initialize":
-		return s.respond(msg.ID, initializeResult())
+		return reply(msg.ID, initializeResult(s.negotiate(msg.Params)))
 	case "notifications/initialized":
 		// JSON-RPC notification (no id): the client signals it finished
 		// the handshake. No response is sent.
 		return nil
+	case "notifications/cancelled":
+		s.cancelRequest(msg.Params)
+		return nil
 	case "tools/list":
-		return s.respond(msg.ID, map[string]any{"tools": toolDescriptors()})
+		return reply(msg.ID, map[string]any{"tools": toolsForProtocol(s.negotiatedVersion())})
 	case "tools/call":
 		return s.handleToolsCall(ctx, msg)
+	case "prompts/list":
+		return reply(msg.ID, map[string]any{"prompts": promptDescriptors()})
+	case "prompts/get":
+		return s.handlePromptsGet(ctx, msg)
+	case "resources/list":
+		return s.handleResourcesList(ctx, msg)
+	case "resources/read":
+		return s.handleResourcesRead(msg)
+	case "resources/subscribe":
+		return s.handleResourcesSubscribe(msg, true)
+	case "resources/unsubscribe":
+		return s.handleResourcesSubscribe(msg, false)
 	default:
 		// Unknown notifications (no id) are ignored per JSON-RPC; only
 		// id-bearing requests get a method-not-found error.
 		if len(msg.ID) == 0 {
 			return nil
 		}
-		return s.respondError(msg.ID, -32601, fmt.Sprintf("method not implemented: %s", msg.Method))
+		return replyError(msg.ID, -32601, fmt.Sprintf("method not implemented: %s", msg.Method))
 	}
 }
 
-// mcpProtocolVersion is the single MCP protocol version this server
-// implements. Per the MCP lifecycle spec, the server returns the
-// version it actually supports (not an echo of the client's request):
-// echoing an unsupported client version would falsely claim support
-// and break future / draft clients. The client decides compatibility
-// from this value.
-const mcpProtocolVersion = "2024-11-05"
-
 // initializeResult builds the MCP initialize handshake response. The
 // Claude Code session sends `initialize` first; without a valid reply
-// it never proceeds to tools/list. The server advertises its supported
-// protocol version + the tools capability.
-func initializeResult() map[string]any {
+// it never proceeds to tools/list. The server advertises the negotiated
+// protocol version + the tools, resources and prompts capabilities.
+func initializeResult(version string) map[string]any {
 	return map[string]any{
-		"protocolVersion": mcpProtocolVersion,
-		"capabilities":    map[string]any{"tools": map[string]any{"listChanged": false}},
-		"serverInfo":      map[string]any{"name": "paintress", "version": "0.1.0"},
+		"protocolVersion": version,
+		"capabilities": map[string]any{
+			"tools":     map[string]any{"listChanged": false},
+			"resources": map[string]any{"subscribe": true, "listChanged": true},
+			"prompts":   map[string]any{"listChanged": false},
+		},
+		"serverInfo": map[string]any{"name": "paintress", "version": "0.1.0"},
 		// instructions feed Claude Code's deferred tool loading (Tool
 		// Search): only tool names + this summary are in context at
 		// startup, so it must say what the server is FOR.
-		"instructions": "paintress is the implementer data plane of the tap 5-tool ecosystem: read the expedition journal state (next_issue), consult learned patterns (get_insights — live Lumina scan + insight ledger), persist progress (update_gradient, append_journal), and emit report d-mails through the transactional outbox (dmail). Drive it from the /expedition-next skill in a human-initiated session.",
+		"instructions": "paintress is the implementer data plane of the tap 5-tool ecosystem: read the expedition journal state (next_issue), reserve an expedition number (start_expedition), check operational health (get_status), checkpoint and resume interrupted expeditions (record_checkpoint, list_incomplete_expeditions), consult learned patterns (get_insights — live Lumina scan + insight ledger), read and consume inbound d-mails (read_inbox, archive_inbox), persist progress (update_gradient, append_journal), and emit report d-mails through the transactional outbox (dmail). Journal entries, insight ledgers, inbox and archived d-mails are also readable as paintress:// resources, and the expedition / mission / review_fix prompts render the full briefing from live state. Drive it from the /expedition-next skill in a human-initiated session.",
 	}
 }
 
-// handleToolsCall dispatches a single tools/call request and records
-// MCP invocation metrics (mcp.tool.invocations counter +
+// handleToolsCall validates the arguments of a single tools/call request
+// against the tool's inputSchema, dispatches it and records MCP
+// invocation metrics (mcp.tool.invocations counter +
 // mcp.tool.duration histogram) for cost-monitoring verification post
-// 2026-06-15 (refs/issues/0027 Phase 3 cost monitoring (a)).
-func (s *MCPServer) handleToolsCall(ctx context.Context, msg jsonrpcMessage) error {
+// 2026-06-15 (refs/issues/0027 Phase 3 cost monitoring (a)). Invalid
+// arguments and isError results are recorded with status "error".
+func (s *MCPServer) handleToolsCall(ctx context.Context, msg jsonrpcMessage) *jsonrpcMessage {
 	start := time.Now()
 	var call struct {
 		Name      string          `json:"name"`
@@ -188,113 +256,56 @@ func (s *MCPServer) handleToolsCall(ctx context.Context, msg jsonrpcMessage) err
 	}
 	if err := json.Unmarshal(msg.Params, &call); err != nil {
 		platform.RecordMCPInvocation(ctx, "", "error", time.Since(start))
-		return s.respondError(msg.ID, -32602, "invalid tools/call params")
+		return replyError(msg.ID, -32602, "invalid tools/call params")
+	}
+
+	schema, known := toolSchemas()[call.Name]
+	if !known {
+		platform.RecordMCPInvocation(ctx, call.Name, "error", time.Since(start))
+		return replyError(msg.ID, -32601, fmt.Sprintf("unknown tool: %s", call.Name))
+	}
+	if violations := validateToolArgs(schema, call.Arguments); len(violations) > 0 {
+		platform.RecordMCPInvocation(ctx, call.Name, "error", time.Since(start))
+		return reply(msg.ID, invalidArgsResult(call.Name, violations))
 	}
 
-	status := "ok"
 	var result map[string]any
 	switch call.Name {
 	case "ping":
 		result = textResult("pong")
 	case "next_issue":
-		result = realNextIssue(s.continent)
+		result = realNextIssue(ctx, s.continent, call.Arguments, s.logger)
 	case "update_gradient":
 		result = realUpdateGradient(ctx, s.continent, s.emitter, call.Arguments, s.logger)
 	case "append_journal":
-		result = realAppendJournal(s.continent, s.emitter, call.Arguments)
+		result = realAppendJournal(ctx, s.continent, s.emitter, call.Arguments)
+	case "start_expedition":
+		result = realStartExpedition(ctx, s.continent, s.emitter, call.Arguments)
 	case "dmail":
 		result = realDMail(ctx, s.continent, s.emitter, call.Arguments)
 	case "get_insights":
-		result = realGetInsights(s.continent, call.Arguments)
+		result = realGetInsights(ctx, s.continent, call.Arguments)
+	case "record_checkpoint":
+		result = realRecordCheckpoint(s.continent, s.emitter, call.Arguments)
+	case "list_incomplete_expeditions":
+		result = realListIncompleteExpeditions(ctx, s.continent)
+	case "get_status":
+		result = realGetStatus(ctx, s.continent, call.Arguments, s.logger)
+	case "read_inbox":
+		result = realReadInbox(ctx, s.continent, call.Arguments, s.logger)
+	case "archive_inbox":
+		result = realArchiveInbox(ctx, s.continent, s.emitter, call.Arguments, s.logger)
 	default:
 		platform.RecordMCPInvocation(ctx, call.Name, "error", time.Since(start))
-		return s.respondError(msg.ID, -32601, fmt.Sprintf("unknown tool: %s", call.Name))
+		return replyError(msg.ID, -32601, fmt.Sprintf("unknown tool: %s", call.Name))
 	}
 
-	err := s.respond(msg.ID, result)
-	if err != nil {
+	status := "ok"
+	if isErrorResult(result) {
 		status = "error"
 	}
 	platform.RecordMCPInvocation(ctx, call.Name, status, time.Since(start))
-	return err
-}
-
-// toolDescriptors returns the tool set. Each entry pins the interface
-// (name, description, inputSchema) so Claude Code clients see a stable
-// contract. next_issue / update_gradient / append_journal are real
-// impl: they read pr-index / event store and write journal/ + pr-index;
-// update_gradient / append_journal also emit EventGradientChanged /
-// EventExpeditionCompleted when an emitter is wired (cmd wires one).
-func toolDescriptors() []map[string]any {
-	return []map[string]any{
-		{
-			"name":        "ping",
-			"description": "Health check. Returns 'pong'.",
-			"inputSchema": map[string]any{"type": "object", "properties": map[string]any{}},
-		},
-		{
-			"name":        "next_issue",
-			"description": "Return paintress's local journal state (completed_issue_ids + next_expedition_number + last_pr). The Claude Code session uses completed_issue_ids to exclude already-done work from the configured issue source.",
-			"inputSchema": map[string]any{"type": "object", "properties": map[string]any{}},
-		},
-		{
-			"name":        "update_gradient",
-			"description": "Read current gradient_level from the event store, apply delta, and persist an EventGradientChanged event (persistence='event-store'). Returns current_level + new_level. Falls back to a preview without persisting when no emitter is wired.",
-			"inputSchema": map[string]any{
-				"type": "object",
-				"properties": map[string]any{
-					"delta": map[string]any{"type": "integer", "description": "signed level change"},
-				},
-				"required": []any{"delta"},
-			},
-		},
-		{
-			"name":        "append_journal",
-			"description": "Persist an ExpeditionReport to journal/<NNN>.md + pr-index and emit an EventExpeditionCompleted event (persistence='event-store+filesystem'). Falls back to filesystem-only when no emitter is wired.",
-			"inputSchema": map[string]any{
-				"type": "object",
-				"properties": map[string]any{
-					"expedition":   map[string]any{"type": "integer"},
-					"date":         map[string]any{"type": "string"},
-					"issue_id":     map[string]any{"type": "string"},
-					"issue_title":  map[string]any{"type": "string"},
-					"mission_type": map[string]any{"type": "string"},
-					"status":       map[string]any{"type": "string"},
-					"reason":       map[string]any{"type": "string"},
-					"pr_url":       map[string]any{"type": "string"},
-				},
-				"required": []any{"expedition", "issue_id", "status"},
-			},
-		},
-		{
-			"name":        "dmail",
-			"description": "Emit a D-Mail through the transactional outbox (refs issue 0031). Arguments map onto the D-Mail v1 schema; paintress may emit kind: report. Never write outbox/ directly — this tool is the canonical atomic path (SQLite stage -> flush) that phonewave delivery depends on. Re-sending the same name is an idempotent upsert.",
-			"inputSchema": map[string]any{
-				"type": "object",
-				"properties": map[string]any{
-					"kind":        map[string]any{"type": "string", "description": "report"},
-					"name":        map[string]any{"type": "string", "description": "unique d-mail name (becomes <name>.md; e.g. pt-report-<issue>-<expedition>)"},
-					"description": map[string]any{"type": "string", "description": "one-line summary (required by schema v1)"},
-					"body":        map[string]any{"type": "string", "description": "markdown body (expedition report)"},
-					"issues":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "related issue ids"},
-					"severity":    map[string]any{"type": "string", "description": "low / medium / high (optional)"},
-					"priority":    map[string]any{"type": "integer", "description": "priority (optional)"},
-					"metadata":    map[string]any{"type": "object", "description": "string map; project_id / actor_type injected automatically"},
-				},
-				"required": []any{"kind", "name", "description", "body"},
-			},
-		},
-		{
-			"name":        "get_insights",
-			"description": "Read the learning loop (refs issue 0034): persisted insight-ledger files from .expedition/insights/ plus a live Lumina pattern scan recomputed from the journals (failure / success / high-severity patterns). Consult before implementing to avoid repeating past failures. Read-only and idempotent; empty state returns empty arrays.",
-			"inputSchema": map[string]any{
-				"type": "object",
-				"properties": map[string]any{
-					"kind": map[string]any{"type": "string", "description": "optional filename-prefix filter (e.g. lumina / gommage)"},
-				},
-			},
-		},
-	}
+	return reply(msg.ID, resultForProtocol(s.negotiatedVersion(), result))
 }
 
 // textResult wraps a plain string into the MCP content envelope.
@@ -303,269 +314,38 @@ func textResult(text string) map[string]any {
 }
 
 // jsonResult marshals data as JSON and returns an MCP content envelope.
-func jsonResult(data any) map[string]any {
+// The same object rides along as structuredContent; resultForProtocol
+// drops it for clients older than 2025-06-18.
+func jsonResult(data map[string]any) map[string]any {
 	body, err := json.Marshal(data)
 	if err != nil {
 		return textResult(fmt.Sprintf(`{"error":"marshal failed: %v"}`, err))
 	}
-	return map[string]any{"content": []map[string]any{{"type": "text", "text": string(body)}}}
-}
-
-// realNextIssue surfaces paintress's local journal state (= completed
-// issue ids + next expedition number + last PR) so the Claude Code
-// session can decide which configured issue source item to handle next.
-//
-// paintress does NOT call external issue MCP servers itself. The
-// session reads completed_issue_ids from this tool and excludes them
-// while selecting work from the configured issue source.
-//
-// continent is the project root resolved via WithContinent (typically
-// os.Getwd() in the cobra subcommand). When empty or the journal
-// directory is missing, the response indicates an uninitialized
-// project so the session surfaces a clear error.
-func realNextIssue(continent string) map[string]any {
-	if continent == "" {
-		return jsonResult(map[string]any{
-			"initialized":            false,
-			"reason":                 "paintress mcp continent root not configured (start `paintress mcp` from the project root or pass via WithContinent)",
-			"next_expedition_number": 1,
-			"completed_issue_ids":    []string{},
-		})
-	}
-	entries, err := ReadPRIndex(continent)
-	if err != nil {
-		return jsonResult(map[string]any{
-			"initialized": false,
-			"reason":      fmt.Sprintf("pr-index read failed: %v", err),
-			"continent":   continent,
-		})
-	}
-
-	completedIDs := make([]string, 0, len(entries))
-	maxExp := 0
-	var lastPR map[string]any
-	for _, e := range entries {
-		completedIDs = append(completedIDs, e.IssueID)
-		if e.Expedition > maxExp {
-			maxExp = e.Expedition
-		}
-	}
-	if len(entries) > 0 {
-		last := entries[len(entries)-1]
-		lastPR = map[string]any{
-			"expedition": last.Expedition,
-			"issue_id":   last.IssueID,
-			"pr_url":     last.PRUrl,
-		}
-	}
-
-	return jsonResult(map[string]any{
-		"initialized":            true,
-		"continent":              continent,
-		"next_expedition_number": maxExp + 1,
-		"completed_issue_ids":    completedIDs,
-		"last_pr":                lastPR,
-		"journal_dir":            domain.JournalDir(continent),
-		"instruction":            "Read the configured issue source, exclude completed_issue_ids, pick the highest-priority unstarted item. Persist completion via append_journal after the expedition.",
-	})
-}
-
-// realUpdateGradient reads the current GradientLevel via the event
-// store + projection, applies the delta, and emits an
-// EventGradientChanged via the injected emitter (Phase 4 follow-up #4,
-// persistence='event-store'). When no emitter is wired (tests /
-// opt-out), it returns a preview of (current + delta) without
-// persisting. The session can re-read the new level via the next
-// projection.
-//
-// continent is the project root from MCPServer.WithContinent. When
-// empty the response signals uninitialized so the session aborts.
-func realUpdateGradient(ctx context.Context, continent string, emitter port.ExpeditionEventEmitter, args json.RawMessage, logger domain.Logger) map[string]any {
-	var payload struct {
-		Delta int `json:"delta"`
-	}
-	if len(args) > 0 {
-		_ = json.Unmarshal(args, &payload)
-	}
-	if continent == "" {
-		return jsonResult(map[string]any{
-			"initialized":   false,
-			"reason":        "paintress mcp continent root not configured",
-			"delta":         payload.Delta,
-			"current_level": 0,
-			"preview_level": payload.Delta,
-		})
-	}
-	stateDir := filepath.Join(continent, domain.StateDir)
-	store := NewEventStore(stateDir, logger)
-	events, _, err := store.LoadAll(ctx)
-	if err != nil {
-		return jsonResult(map[string]any{
-			"initialized":   false,
-			"reason":        fmt.Sprintf("event store load failed: %v", err),
-			"delta":         payload.Delta,
-			"current_level": 0,
-			"preview_level": payload.Delta,
-		})
-	}
-	state := ProjectState(events)
-	newLevel := state.GradientLevel + payload.Delta
-	if emitter == nil {
-		return jsonResult(map[string]any{
-			"initialized":   true,
-			"continent":     continent,
-			"current_level": state.GradientLevel,
-			"delta":         payload.Delta,
-			"preview_level": newLevel,
-			"persistence":   "preview-only",
-			"note":          "Preview only. Emitter not wired; cmd composition root injects one via MCPServer.WithEmitter to persist EventGradientChanged.",
-		})
-	}
-	if err := emitter.EmitGradientChange(newLevel, "mcp.update_gradient", time.Now().UTC()); err != nil {
-		return jsonResult(map[string]any{
-			"initialized":   true,
-			"continent":     continent,
-			"current_level": state.GradientLevel,
-			"delta":         payload.Delta,
-			"preview_level": newLevel,
-			"persistence":   "preview-only",
-			"reason":        fmt.Sprintf("emit gradient change: %v", err),
-		})
+	return map[string]any{
+		"content":           []map[string]any{{"type": "text", "text": string(body)}},
+		"structuredContent": data,
 	}
-	return jsonResult(map[string]any{
-		"initialized":   true,
-		"continent":     continent,
-		"current_level": state.GradientLevel,
-		"delta":         payload.Delta,
-		"new_level":     newLevel,
-		"persistence":   "event-store",
-	})
 }
 
-// realAppendJournal writes the expedition report to the journal
-// directory and pr-index file via the existing WriteJournal /
-// WritePRIndex helpers, then emits an EventExpeditionCompleted via the
-// injected emitter (Phase 4 follow-up #4, persistence=
-// 'event-store+filesystem'). When no emitter is wired (tests /
-// opt-out), it persists filesystem-only. The session can re-read the
-// new state via next_issue.
-//
-// continent is the project root from MCPServer.WithContinent. When
-// empty the response signals uninitialized so the session aborts.
-//
-//nolint:staticcheck // intentional: documents the existing journal/pr-index files maintained by session/journal.go
-func realAppendJournal(continent string, emitter port.ExpeditionEventEmitter, args json.RawMessage) map[string]any {
-	var payload struct {
-		Expedition         int    `json:"expedition"`
-		IssueID            string `json:"issue_id"`
-		IssueTitle         string `json:"issue_title"`
-		MissionType        string `json:"mission_type"`
-		Branch             string `json:"branch"`
-		PRUrl              string `json:"pr_url"`
-		Status             string `json:"status"`
-		Reason             string `json:"reason"`
-		Remaining          string `json:"remaining"`
-		BugsFound          int    `json:"bugs_found"`
-		BugIssues          string `json:"bug_issues"`
-		Insight            string `json:"insight"`
-		FailureType        string `json:"failure_type"`
-		HighSeverityDMails string `json:"high_severity_dmails"`
-		WaveID             string `json:"wave_id"`
-		StepID             string `json:"step_id"`
-	}
-	if len(args) > 0 {
-		_ = json.Unmarshal(args, &payload)
-	}
-	if continent == "" {
-		return jsonResult(map[string]any{
-			"initialized": false,
-			"reason":      "paintress mcp continent root not configured",
-		})
-	}
-	if payload.Expedition <= 0 || payload.IssueID == "" || payload.Status == "" {
-		return jsonResult(map[string]any{
-			"initialized": true,
-			"persisted":   false,
-			"reason":      "missing required fields: expedition (>0), issue_id, status",
-			"received":    payload,
-		})
-	}
-	report := &domain.ExpeditionReport{
-		Expedition:         payload.Expedition,
-		IssueID:            payload.IssueID,
-		IssueTitle:         payload.IssueTitle,
-		MissionType:        payload.MissionType,
-		Branch:             payload.Branch,
-		PRUrl:              payload.PRUrl,
-		Status:             payload.Status,
-		Reason:             payload.Reason,
-		Remaining:          payload.Remaining,
-		BugsFound:          payload.BugsFound,
-		BugIssues:          payload.BugIssues,
-		Insight:            payload.Insight,
-		FailureType:        payload.FailureType,
-		HighSeverityDMails: payload.HighSeverityDMails,
-		WaveID:             payload.WaveID,
-		StepID:             payload.StepID,
-	}
-	if err := WriteJournal(continent, report); err != nil {
-		return jsonResult(map[string]any{
-			"initialized": true,
-			"persisted":   false,
-			"reason":      fmt.Sprintf("write journal: %v", err),
-		})
-	}
-	if err := WritePRIndex(continent, report); err != nil {
-		return jsonResult(map[string]any{
-			"initialized":  true,
-			"persisted":    true,
-			"journal_file": filepath.Join(domain.JournalDir(continent), fmt.Sprintf("%03d.md", report.Expedition)),
-			"pr_index":     false,
-			"reason":       fmt.Sprintf("journal written but pr-index append failed: %v", err),
-		})
-	}
-	if emitter == nil {
-		return jsonResult(map[string]any{
-			"initialized":      true,
-			"persisted":        true,
-			"expedition":       report.Expedition,
-			"issue_id":         report.IssueID,
-			"journal_file":     filepath.Join(domain.JournalDir(continent), fmt.Sprintf("%03d.md", report.Expedition)),
-			"pr_index_updated": report.PRUrl != "" && report.PRUrl != "none",
-			"persistence":      "filesystem-only",
-			"note":             "Filesystem persistence complete (journal/<NNN>.md + pr index). Emitter not wired; cmd composition root injects one via MCPServer.WithEmitter to also emit EventExpeditionCompleted.", // nosemgrep: layer-session-no-event-persistence -- comment text only, persistence is via session/journal.go::WriteJournal+WritePRIndex helpers that the rule allows [permanent]
-		})
-	}
-	bugsFoundStr := strconv.Itoa(report.BugsFound)
-	if err := emitter.EmitCompleteExpedition(report.Expedition, report.Status, report.IssueID, bugsFoundStr, report.WaveID, report.StepID, time.Now().UTC()); err != nil {
-		return jsonResult(map[string]any{
-			"initialized":      true,
-			"persisted":        true,
-			"expedition":       report.Expedition,
-			"issue_id":         report.IssueID,
-			"journal_file":     filepath.Join(domain.JournalDir(continent), fmt.Sprintf("%03d.md", report.Expedition)),
-			"pr_index_updated": report.PRUrl != "" && report.PRUrl != "none",
-			"persistence":      "filesystem-only",
-			"reason":           fmt.Sprintf("emit expedition completed: %v", err),
-		})
-	}
-	return jsonResult(map[string]any{
-		"initialized":      true,
-		"persisted":        true,
-		"expedition":       report.Expedition,
-		"issue_id":         report.IssueID,
-		"journal_file":     filepath.Join(domain.JournalDir(continent), fmt.Sprintf("%03d.md", report.Expedition)),
-		"pr_index_updated": report.PRUrl != "" && report.PRUrl != "none",
-		"persistence":      "event-store+filesystem",
-	})
+func reply(id json.RawMessage, result any) *jsonrpcMessage {
+	return &jsonrpcMessage{JSONRPC: "2.0", ID: id, Result: result}
 }
 
-func (s *MCPServer) respond(id json.RawMessage, result any) error {
-	return s.writeMessage(jsonrpcMessage{JSONRPC: "2.0", ID: id, Result: result})
+func replyError(id json.RawMessage, code int, message string) *jsonrpcMessage {
+	return &jsonrpcMessage{JSONRPC: "2.0", ID: id, Error: &jsonrpcError{Code: code, Message: message}}
 }
 
-func (s *MCPServer) respondError(id json.RawMessage, code int, message string) error {
-	return s.writeMessage(jsonrpcMessage{JSONRPC: "2.0", ID: id, Error: &jsonrpcError{Code: code, Message: message}})
+// notify sends a server-initiated JSON-RPC notification (no id).
+func (s *MCPServer) notify(method string, params any) error {
+	msg := jsonrpcMessage{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("encode %s params: %w", method, err)
+		}
+		msg.Params = raw
+	}
+	return s.writeMessage(msg)
 }
 
 func (s *MCPServer) writeMessage(msg jsonrpcMessage) error {
@@ -573,6 +353,8 @@ func (s *MCPServer) writeMessage(msg jsonrpcMessage) error {
 	if err != nil {
 		return fmt.Errorf("encode response: %w", err)
 	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 	if _, err := s.out.Write(append(out, '\n')); err != nil {
 		return fmt.Errorf("write response: %w", err)
 	}
@@ -26,9 +26,13 @@ type recordingEmitter struct {
 	completes []domain.ExpeditionCompletedData
 }
 
-func (r *recordingEmitter) EmitGradientChange(level int, operator string, now time.Time) error {
-	r.gradients = append(r.gradients, domain.GradientChangedData{Level: level, Operator: operator})
-	ev, err := domain.NewEvent(domain.EventGradientChanged, domain.GradientChangedData{Level: level, Operator: operator}, now)
+func (r *recordingEmitter) GradientVersion() (domain.ExpectedVersion, error) {
+	return domain.AnyVersion, nil
+}
+
+func (r *recordingEmitter) EmitGradientChange(_ domain.ExpectedVersion, level, delta int, operator string, now time.Time) error {
+	r.gradients = append(r.gradients, domain.GradientChangedData{Level: level, Delta: &delta, Operator: operator})
+	ev, err := domain.NewEvent(domain.EventGradientChanged, domain.GradientChangedData{Level: level, Delta: &delta, Operator: operator}, now)
 	if err != nil {
 		return err
 	}
@@ -67,14 +71,17 @@ func (r *recordingEmitter) EmitStartExpedition(_, _ int, _ string, _ time.Time)
 func (r *recordingEmitter) EmitSpecRegistered(_ string, _ []domain.WaveStepDef, _ string, _ time.Time) error {
 	return nil
 }
-func (r *recordingEmitter) EmitInboxReceived(_, _ string, _ time.Time) error      { return nil }
-func (r *recordingEmitter) EmitGommage(_ int, _ time.Time) error                  { return nil }
-func (r *recordingEmitter) EmitRetryAttempted(_ string, _ int, _ time.Time) error { return nil }
-func (r *recordingEmitter) EmitEscalated(_ string, _ []string, _ time.Time) error { return nil }
-func (r *recordingEmitter) EmitResolved(_ string, _ []string, _ time.Time) error  { return nil }
-func (r *recordingEmitter) EmitDMailStaged(_ string, _ time.Time) error           { return nil }
-func (r *recordingEmitter) EmitDMailFlushed(_ int, _ time.Time) error             { return nil }
-func (r *recordingEmitter) EmitDMailArchived(_ string, _ time.Time) error         { return nil }
+func (r *recordingEmitter) EmitInboxReceived(_, _ string, _ time.Time) error        { return nil }
+func (r *recordingEmitter) EmitGommage(_ int, _ time.Time) error                    { return nil }
+func (r *recordingEmitter) EmitRetryAttempted(_ string, _ int, _ time.Time) error   { return nil }
+func (r *recordingEmitter) EmitEscalated(_ string, _ []string, _ time.Time) error   { return nil }
+func (r *recordingEmitter) EmitResolved(_ string, _ []string, _ time.Time) error    { return nil }
+func (r *recordingEmitter) EmitDMailStaged(_ string, _ []string, _ time.Time) error { return nil }
+func (r *recordingEmitter) EmitDMailFlushed(_ int, _ time.Time) error               { return nil }
+func (r *recordingEmitter) EmitDMailArchived(_ string, _ time.Time) error           { return nil }
+func (r *recordingEmitter) EmitInboxConsumed(_ domain.InboxConsumption, _ time.Time) error {
+	return nil
+}
 func (r *recordingEmitter) EmitGommageRecovery(_ int, _, _ string, _ int, _ string, _ time.Time) error {
 	return nil
 }
@@ -338,7 +345,7 @@ func TestMCPServer_UpdateGradient_RealImpl_EmptyEventStore(t *testing.T) {
 
 func TestMCPServer_AppendJournal_UninitializedContinent(t *testing.T) {
 	// given: empty continent → uninitialized response.
-	in := strings.NewReader(`{"jsonrpc":"2.0","id":8,"method":"tools/call","params":{"name":"append_journal","arguments":{"expedition":42,"issue_id":"PAI-1","status":"completed"}}}` + "\n")
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":8,"method":"tools/call","params":{"name":"append_journal","arguments":{"expedition":42,"issue_id":"PAI-1","status":"success"}}}` + "\n")
 	var out bytes.Buffer
 	srv := session.NewMCPServer(in, &out, nil)
 
@@ -357,7 +364,7 @@ func TestMCPServer_AppendJournal_UninitializedContinent(t *testing.T) {
 func TestMCPServer_AppendJournal_RealImpl_PersistsToFilesystem(t *testing.T) {
 	// given: temp continent + minimal valid input.
 	continent := t.TempDir()
-	in := strings.NewReader(`{"jsonrpc":"2.0","id":9,"method":"tools/call","params":{"name":"append_journal","arguments":{"expedition":42,"issue_id":"PAI-1","issue_title":"Fix login","status":"completed","pr_url":"https://github.com/example/repo/pull/7","reason":"validated"}}}` + "\n")
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":9,"method":"tools/call","params":{"name":"append_journal","arguments":{"expedition":42,"issue_id":"PAI-1","issue_title":"Fix login","status":"success","pr_url":"https://github.com/example/repo/pull/7","reason":"validated"}}}` + "\n")
 	var out bytes.Buffer
 	srv := session.NewMCPServer(in, &out, nil).WithContinent(continent)
 
@@ -515,7 +522,7 @@ func TestMCPServer_AppendJournal_Phase4_EmitsExpeditionCompletedEvent(t *testing
 func TestMCPServer_AppendJournal_RealImpl_RejectsMissingRequiredFields(t *testing.T) {
 	// given: empty issue_id is invalid.
 	continent := t.TempDir()
-	in := strings.NewReader(`{"jsonrpc":"2.0","id":10,"method":"tools/call","params":{"name":"append_journal","arguments":{"expedition":1,"status":"completed"}}}` + "\n")
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":10,"method":"tools/call","params":{"name":"append_journal","arguments":{"expedition":1,"status":"success"}}}` + "\n")
 	var out bytes.Buffer
 	srv := session.NewMCPServer(in, &out, nil).WithContinent(continent)
 
@@ -526,11 +533,11 @@ func TestMCPServer_AppendJournal_RealImpl_RejectsMissingRequiredFields(t *testin
 
 	// then
 	body := decodeFirstText(t, &out)
-	if body["persisted"] != false {
-		t.Errorf("persisted = %v, want false (missing issue_id)", body["persisted"])
+	if body["error_code"] != "invalid_arguments" {
+		t.Errorf("error_code = %v, want invalid_arguments (missing issue_id)", body["error_code"])
 	}
-	if _, ok := body["reason"]; !ok {
-		t.Errorf("reason missing: %v", body)
+	if reason, _ := body["reason"].(string); !strings.Contains(reason, `"issue_id"`) {
+		t.Errorf("reason = %v, want missing issue_id", body["reason"])
 	}
 }
 
@@ -586,8 +593,8 @@ func TestMCPServer_RejectsUnknownMethod(t *testing.T) {
 }
 
 func TestMCPServer_Initialize_Handshake(t *testing.T) {
-	// given: client sends initialize with a different protocol version
-	in := strings.NewReader(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"claude-code","version":"1.0"}}}` + "\n")
+	// given: client sends initialize with a protocol version the server does not know
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2099-01-01","capabilities":{},"clientInfo":{"name":"claude-code","version":"1.0"}}}` + "\n")
 	var out bytes.Buffer
 	srv := session.NewMCPServer(in, &out, nil)
 
@@ -610,8 +617,8 @@ func TestMCPServer_Initialize_Handshake(t *testing.T) {
 	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &resp); err != nil {
 		t.Fatalf("decode initialize response: %v (raw=%q)", err, out.String())
 	}
-	if resp.Result.ProtocolVersion != "2024-11-05" {
-		t.Errorf("protocolVersion = %q, want 2024-11-05 (server supported, not echo of client 2025-06-18)", resp.Result.ProtocolVersion)
+	if resp.Result.ProtocolVersion != "2025-06-18" {
+		t.Errorf("protocolVersion = %q, want 2025-06-18 (latest server supported, not echo of client 2099-01-01)", resp.Result.ProtocolVersion)
 	}
 	if _, ok := resp.Result.Capabilities["tools"]; !ok {
 		t.Errorf("capabilities.tools missing: %v", resp.Result.Capabilities)
@@ -0,0 +1,185 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// defaultStatusTrendWindow is the number of completed (non-skipped)
+// expeditions per window when get_status compares the recent success
+// rate against the one before it.
+const defaultStatusTrendWindow = 5
+
+// realGetStatus exposes the operational read model behind `paintress
+// status` to the session: the StatusReport (including provider pause
+// state), the ExpeditionState projection, the windowed success-rate
+// trend, expedition duration percentiles and the dead-letter count. It
+// is read-only: a missing event store or outbox DB is reported as zero,
+// never created. With as_of, the report, state, trend and durations are
+// projected from the events up to that cut-off (see StatusAsOf); provider
+// state, dead letters and inbox/archive counts have no history and stay
+// current.
+func realGetStatus(ctx context.Context, continent string, args json.RawMessage, logger domain.Logger) map[string]any {
+	var payload struct {
+		Window int    `json:"window"`
+		AsOf   string `json:"as_of"`
+	}
+	if len(args) > 0 {
+		_ = json.Unmarshal(args, &payload)
+	}
+	if continent == "" {
+		return toolError(toolErrNotConfigured, map[string]any{
+			"initialized": false,
+			"reason":      "paintress mcp continent not configured (start `paintress mcp` from the project root)",
+		})
+	}
+	window := payload.Window
+	if window <= 0 {
+		window = defaultStatusTrendWindow
+	}
+	var asOf domain.AsOf
+	if payload.AsOf != "" {
+		parsed, err := domain.ParseAsOf(payload.AsOf)
+		if err != nil {
+			return toolError(toolErrInvalidArguments, map[string]any{
+				"initialized": true,
+				"reason":      fmt.Sprintf("as_of: %v", err),
+			})
+		}
+		asOf = parsed
+	}
+
+	reportProgress(ctx, 0, 3, "replaying the event store")
+	report, state := statusWithState(ctx, continent, logger)
+	// Trend and durations need per-event timestamps, which the projection
+	// does not keep: both views read the full history, archived segments
+	// included.
+	events, err := loadStatusHistory(ctx, continent, logger)
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{
+			"initialized": true,
+			"reason":      err.Error(),
+		})
+	}
+	var snapshot *StatusSnapshot
+	if payload.AsOf != "" {
+		all := events
+		snap, err := projectStatusAsOf(all, asOf, window)
+		if err != nil {
+			return toolError(toolErrNotFound, map[string]any{
+				"initialized": true,
+				"reason":      fmt.Sprintf("as_of: %v", err),
+			})
+		}
+		snapshot = &snap
+		events = domain.EventsAsOf(all, snap.Cutoff)
+		state = snap.State
+		applyStateToReport(&report, state)
+	}
+	reportProgress(ctx, 1, 3, "counting dead-lettered outbox items")
+	deadLetters, err := deadLetterCount(ctx, continent)
+	if err != nil {
+		return toolError(toolErrStorage, map[string]any{
+			"initialized": true,
+			"reason":      fmt.Sprintf("dead letter count: %v", err),
+		})
+	}
+
+	reportProgress(ctx, 2, 3, "computing trend and duration percentiles")
+	durations := domain.ExpeditionDurations(events)
+	p50, p90, p99 := domain.DurationPercentiles(durations)
+	trend := domain.DetectSuccessRateTrend(events, window)
+	paused := domain.NormalizeProviderState(domain.ProviderState(report.ProviderState)) == domain.ProviderStatePaused
+
+	provider := map[string]any{
+		"state":        report.ProviderState,
+		"reason":       report.ProviderReason,
+		"retry_budget": report.ProviderRetryBudget,
+		"resume_when":  report.ProviderResumeWhen,
+		"paused":       paused,
+	}
+	if !report.ProviderResumeAt.IsZero() {
+		provider["resume_at"] = report.ProviderResumeAt.Format(time.RFC3339)
+	}
+
+	instruction := statusInstruction(paused, trend, deadLetters, report.InboxCount)
+	if snapshot != nil {
+		instruction = fmt.Sprintf("Historical view as of %s (%s); provider, dead_letters and inbox/archive counts are current. %s",
+			snapshot.AsOf, snapshot.Cutoff.Format(time.RFC3339), instruction)
+	}
+	result := map[string]any{
+		"initialized": true,
+		"continent":   continent,
+		"report":      report,
+		"state":       state,
+		"provider":    provider,
+		"success_rate_trend": map[string]any{
+			"window":        window,
+			"windowed_rate": domain.WindowedSuccessRate(events, window),
+			"trend":         string(trend),
+		},
+		"durations": map[string]any{
+			"count":       len(durations),
+			"p50_seconds": p50.Seconds(),
+			"p90_seconds": p90.Seconds(),
+			"p99_seconds": p99.Seconds(),
+		},
+		"dead_letters":  deadLetters,
+		"inbox_count":   report.InboxCount,
+		"archive_count": report.ArchiveCount,
+		"instruction":   instruction,
+	}
+	if snapshot != nil {
+		result["as_of"] = map[string]any{
+			"as_of":         snapshot.AsOf,
+			"cutoff":        snapshot.Cutoff.Format(time.RFC3339),
+			"events":        snapshot.Events,
+			"pending_steps": snapshot.PendingSteps,
+		}
+	}
+	return jsonResult(result)
+}
+
+// deadLetterCount returns the number of dead-lettered outbox items, or
+// zero when no outbox DB exists yet (opening the store would create it).
+func deadLetterCount(ctx context.Context, continent string) (int, error) {
+	dbPath := filepath.Join(continent, domain.StateDir, ".run", "outbox.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return 0, nil
+	}
+	store, err := NewOutboxStoreForDir(continent)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = store.Close() }()
+	return store.DeadLetterCount(ctx)
+}
+
+// statusInstruction turns the read model into a short hint the session
+// can act on when picking the next issue.
+func statusInstruction(paused bool, trend domain.SuccessRateTrendType, deadLetters, inbox int) string {
+	var hints []string
+	if paused {
+		hints = append(hints, "The provider is paused: do not start a new expedition until resume_when / resume_at is satisfied.")
+	}
+	if trend == domain.TrendDeclining {
+		hints = append(hints, "The success rate is declining: prefer a small, well-scoped issue next.")
+	}
+	if deadLetters > 0 {
+		hints = append(hints, fmt.Sprintf("%d outbox D-Mail(s) are dead-lettered and were never delivered.", deadLetters))
+	}
+	if inbox > 0 {
+		hints = append(hints, fmt.Sprintf("%d D-Mail(s) wait in the inbox; review them with read_inbox first.", inbox))
+	}
+	if len(hints) == 0 {
+		return "No operational concerns; continue with next_issue."
+	}
+	return strings.Join(hints, " ")
+}
@@ -0,0 +1,161 @@
+package session_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+// get_status exposes the `paintress status` read model plus trend,
+// duration and dead-letter metrics so the session can adapt.
+
+func appendExpeditions(t *testing.T, continent string, statuses ...string) {
+	t.Helper()
+	store := session.NewEventStore(filepath.Join(continent, domain.StateDir), nil)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var events []domain.Event
+	for i, status := range statuses {
+		start := base.Add(time.Duration(i) * time.Hour)
+		started, err := domain.NewEvent(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: i + 1}, start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		completed, err := domain.NewEvent(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: i + 1, Status: status}, start.Add(time.Duration(i+1)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, started, completed)
+	}
+	if _, err := store.Append(context.Background(), events...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMCPServer_GetStatus_TrendDurationsAndCounts(t *testing.T) {
+	// given: 4 successes then 4 failures, one inbox D-Mail
+	continent := t.TempDir()
+	appendExpeditions(t, continent, "success", "success", "success", "success", "failed", "failed", "failed", "failed")
+	writeInboxDMail(t, continent, domain.DMail{Name: "spec-a", Kind: domain.KindSpecification, Description: "A", Body: "a\n"})
+
+	// when
+	body := callTool(t, continent, nil, "get_status", `{"window":4}`)
+
+	// then
+	trend := body["success_rate_trend"].(map[string]any)
+	if trend["trend"] != "declining" || trend["windowed_rate"] != float64(0) {
+		t.Errorf("success_rate_trend = %v, want declining with rate 0", trend)
+	}
+	durations := body["durations"].(map[string]any)
+	if durations["count"] != float64(8) || durations["p50_seconds"] != float64(240) {
+		t.Errorf("durations = %v, want 8 samples with p50 240s", durations)
+	}
+	state := body["state"].(map[string]any)
+	if state["total_expeditions"] != float64(8) || state["consecutive_failures"] != float64(4) {
+		t.Errorf("state = %v", state)
+	}
+	report := body["report"].(map[string]any)
+	if report["expeditions"] != float64(8) || body["inbox_count"] != float64(1) {
+		t.Errorf("report = %v, inbox_count = %v", report, body["inbox_count"])
+	}
+	if body["dead_letters"] != float64(0) {
+		t.Errorf("dead_letters = %v, want 0", body["dead_letters"])
+	}
+}
+
+// archiveEventFiles moves every daily event file into an archive segment,
+// as `paintress archive-prune --events` does once they expire.
+func archiveEventFiles(t *testing.T, continent string) {
+	t.Helper()
+	stateDir := filepath.Join(continent, domain.StateDir)
+	entries, err := os.ReadDir(filepath.Join(stateDir, "events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().AddDate(0, 0, -30)
+	for _, e := range entries {
+		if err := os.Chtimes(filepath.Join(stateDir, "events", e.Name()), past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	expired, err := session.ListExpiredEventFiles(ctx, stateDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived, err := session.PruneEventFiles(ctx, stateDir, expired); err != nil || len(archived) == 0 {
+		t.Fatalf("PruneEventFiles = %v, %v", archived, err)
+	}
+}
+
+func TestMCPServer_GetStatus_IncludesArchivedEvents(t *testing.T) {
+	// given: the expedition history lives only in an archive segment
+	continent := t.TempDir()
+	appendExpeditions(t, continent, "success", "success", "failed", "failed")
+	archiveEventFiles(t, continent)
+
+	// when
+	body := callTool(t, continent, nil, "get_status", `{"window":2}`)
+
+	// then
+	durations := body["durations"].(map[string]any)
+	if durations["count"] != float64(4) {
+		t.Errorf("durations = %v, want 4 samples", durations)
+	}
+	if trend := body["success_rate_trend"].(map[string]any); trend["trend"] != "declining" {
+		t.Errorf("success_rate_trend = %v, want declining", trend)
+	}
+}
+
+func TestMCPServer_GetStatus_ProviderPaused(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	store, err := session.NewSQLiteCodingSessionStore(filepath.Join(continent, domain.StateDir, ".run", "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumeAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	record := domain.NewCodingSessionRecord(domain.ProviderClaudeCode, "model", continent)
+	record.Metadata = domain.ProviderStateSnapshot{
+		State:           domain.ProviderStatePaused,
+		Reason:          domain.ProviderReasonRateLimit,
+		ResumeAt:        resumeAt,
+		ResumeCondition: domain.ResumeConditionProviderReset,
+	}.ApplyMetadata(nil)
+	if err := store.Save(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Close()
+
+	// when
+	body := callTool(t, continent, nil, "get_status", `{}`)
+
+	// then
+	provider := body["provider"].(map[string]any)
+	if provider["paused"] != true || provider["state"] != "paused" {
+		t.Errorf("provider = %v, want paused", provider)
+	}
+	if provider["resume_at"] != resumeAt.Format(time.RFC3339) {
+		t.Errorf("resume_at = %v", provider["resume_at"])
+	}
+}
+
+func TestMCPServer_GetStatus_EmptyContinentCreatesNothing(t *testing.T) {
+	// given
+	continent := t.TempDir()
+
+	// when
+	body := callTool(t, continent, nil, "get_status", `{}`)
+
+	// then
+	if body["initialized"] != true || body["dead_letters"] != float64(0) {
+		t.Errorf("body = %v", body)
+	}
+	if _, err := os.Stat(filepath.Join(continent, domain.StateDir, ".run")); !os.IsNotExist(err) {
+		t.Errorf("get_status created .run/ as a side effect (err=%v)", err)
+	}
+}
@@ -0,0 +1,224 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Stable error codes carried as error_code in isError tool results.
+// Clients branch on these; the human-readable reason may change.
+const (
+	toolErrInvalidArguments   = "invalid_arguments"
+	toolErrNotConfigured      = "continent_not_configured"
+	toolErrNotFound           = "not_found"
+	toolErrConflict           = "conflict"
+	toolErrRejected           = "rejected"
+	toolErrStorage            = "storage_failure"
+	toolErrPartialPersistence = "partial_persistence"
+	toolErrCancelled          = "cancelled"
+)
+
+// toolArgViolation is one reason a tools/call argument object failed its
+// tool's inputSchema. Rule is one of required, type, enum, unknown_field.
+type toolArgViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// toolSchemas indexes the inputSchema of every tool in toolDescriptors.
+var toolSchemas = sync.OnceValue(func() map[string]map[string]any {
+	schemas := make(map[string]map[string]any)
+	for _, d := range toolDescriptors() {
+		schemas[d["name"].(string)] = d["inputSchema"].(map[string]any)
+	}
+	return schemas
+})
+
+// validateToolArgs checks raw tools/call arguments against a tool's
+// inputSchema: the arguments must be an object, every required property
+// present, every property of its declared type (and in its enum when one
+// is declared), and no property the schema does not declare. Missing or
+// null arguments are treated as an empty object. Violations come back
+// sorted by field so the result is deterministic.
+func validateToolArgs(schema map[string]any, args json.RawMessage) []toolArgViolation {
+	trimmed := bytes.TrimSpace(args)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		trimmed = []byte("{}")
+	}
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return []toolArgViolation{{Rule: "type", Message: fmt.Sprintf("arguments are not valid JSON: %v", err)}}
+	}
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return []toolArgViolation{{Rule: "type", Message: "arguments must be a JSON object"}}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	var violations []toolArgViolation
+	for field, v := range obj {
+		prop, declared := properties[field].(map[string]any)
+		if !declared {
+			violations = append(violations, toolArgViolation{
+				Field:   field,
+				Rule:    "unknown_field",
+				Message: fmt.Sprintf("unknown field %q (accepted: %s)", field, strings.Join(sortedKeys(properties), ", ")),
+			})
+			continue
+		}
+		violations = append(violations, checkSchemaValue(field, prop, v)...)
+	}
+	required, _ := schema["required"].([]any)
+	for _, r := range required {
+		field, _ := r.(string)
+		if _, present := obj[field]; !present {
+			violations = append(violations, toolArgViolation{
+				Field:   field,
+				Rule:    "required",
+				Message: fmt.Sprintf("missing required field %q", field),
+			})
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Field < violations[j].Field })
+	return violations
+}
+
+// checkSchemaValue validates one value against a property schema. Only
+// the JSON Schema subset used by toolDescriptors is supported: type
+// (string, integer, number, boolean, array, object), enum, items and
+// additionalProperties.
+func checkSchemaValue(field string, prop map[string]any, v any) []toolArgViolation {
+	want, _ := prop["type"].(string)
+	if !matchesSchemaType(want, v) {
+		return []toolArgViolation{{
+			Field:   field,
+			Rule:    "type",
+			Message: fmt.Sprintf("field %q must be %s, got %s", field, want, jsonTypeName(v)),
+		}}
+	}
+	if enum, ok := prop["enum"].([]string); ok && !slices.Contains(enum, v.(string)) {
+		return []toolArgViolation{{
+			Field:   field,
+			Rule:    "enum",
+			Message: fmt.Sprintf("field %q must be one of %s, got %q", field, strings.Join(enum, ", "), v),
+		}}
+	}
+	var violations []toolArgViolation
+	switch want {
+	case "array":
+		if items, ok := prop["items"].(map[string]any); ok {
+			for i, item := range v.([]any) {
+				violations = append(violations, checkSchemaValue(fmt.Sprintf("%s[%d]", field, i), items, item)...)
+			}
+		}
+	case "object":
+		if extra, ok := prop["additionalProperties"].(map[string]any); ok {
+			obj := v.(map[string]any)
+			for _, k := range sortedKeys(obj) {
+				violations = append(violations, checkSchemaValue(field+"."+k, extra, obj[k])...)
+			}
+		}
+	}
+	return violations
+}
+
+func matchesSchemaType(want string, v any) bool {
+	switch want {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	default:
+		return true
+	}
+}
+
+func jsonTypeName(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// toolError builds an MCP isError tool result. body keeps the tool's
+// usual fields (initialized, persisted, reason, ...) so existing clients
+// still find them; error_code is the stable machine-readable code. The
+// body is an error report rather than the tool's outputSchema, so it is
+// sent as text only, never as structuredContent.
+func toolError(code string, body map[string]any) map[string]any {
+	body["error_code"] = code
+	result := jsonResult(body)
+	delete(result, "structuredContent")
+	result["isError"] = true
+	return result
+}
+
+// invalidArgsResult renders schema violations as an invalid_arguments
+// isError result.
+func invalidArgsResult(tool string, violations []toolArgViolation) map[string]any {
+	messages := make([]string, 0, len(violations))
+	for _, v := range violations {
+		messages = append(messages, v.Message)
+	}
+	return toolError(toolErrInvalidArguments, map[string]any{
+		"tool":       tool,
+		"reason":     strings.Join(messages, "; "),
+		"violations": violations,
+	})
+}
+
+// isErrorResult reports whether a tool result is an MCP isError result.
+func isErrorResult(result map[string]any) bool {
+	isErr, _ := result["isError"].(bool)
+	return isErr
+}
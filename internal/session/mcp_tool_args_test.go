@@ -0,0 +1,110 @@
+package session_test
+
+import (
+	"strings"
+	"testing"
+)
+
+// tools/call arguments are validated against each tool's inputSchema;
+// failures come back as isError results carrying a stable error_code.
+
+func callToolResult(t *testing.T, continent, tool, args string) map[string]any {
+	t.Helper()
+	msgs := serveLines(t, continent, `{"jsonrpc":"2.0","id":90,"method":"tools/call","params":{"name":"`+tool+`","arguments":`+args+`}}`)
+	result, ok := msgs[0]["result"].(map[string]any)
+	if !ok {
+		t.Fatalf("no result: %v", msgs[0])
+	}
+	return result
+}
+
+func violationRules(t *testing.T, body map[string]any) map[string]string {
+	t.Helper()
+	rules := make(map[string]string)
+	for _, v := range body["violations"].([]any) {
+		m := v.(map[string]any)
+		rules[m["field"].(string)] = m["rule"].(string)
+	}
+	return rules
+}
+
+func TestMCPServer_ToolArgs_TypoIsUnknownFieldAndMissingRequired(t *testing.T) {
+	// given
+	continent := t.TempDir()
+
+	// when
+	result := callToolResult(t, continent, "start_expedition", `{"issueId":"MY-1"}`)
+
+	// then
+	if result["isError"] != true {
+		t.Fatalf("isError = %v, want true", result["isError"])
+	}
+	body := callTool(t, continent, nil, "start_expedition", `{"issueId":"MY-1"}`)
+	if body["error_code"] != "invalid_arguments" {
+		t.Errorf("error_code = %v", body["error_code"])
+	}
+	rules := violationRules(t, body)
+	if rules["issueId"] != "unknown_field" || rules["issue_id"] != "required" {
+		t.Errorf("violations = %v", rules)
+	}
+}
+
+func TestMCPServer_ToolArgs_TypeAndEnumViolations(t *testing.T) {
+	tests := []struct {
+		name  string
+		tool  string
+		args  string
+		field string
+		rule  string
+	}{
+		{"string delta", "update_gradient", `{"delta":"3"}`, "delta", "type"},
+		{"fractional integer", "update_gradient", `{"delta":1.5}`, "delta", "type"},
+		{"status enum", "append_journal", `{"expedition":1,"issue_id":"MY-1","status":"done"}`, "status", "enum"},
+		{"kind enum", "read_inbox", `{"kind":"memo"}`, "kind", "enum"},
+		{"severity enum", "dmail", `{"kind":"report","name":"n","description":"d","body":"b","severity":"urgent"}`, "severity", "enum"},
+		{"issues item type", "dmail", `{"kind":"report","name":"n","description":"d","body":"b","issues":[1]}`, "issues[0]", "type"},
+		{"metadata value type", "dmail", `{"kind":"report","name":"n","description":"d","body":"b","metadata":{"k":1}}`, "metadata.k", "type"},
+		{"arguments not an object", "next_issue", `[]`, "", "type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			body := callTool(t, t.TempDir(), nil, tt.tool, tt.args)
+
+			// then
+			if body["error_code"] != "invalid_arguments" {
+				t.Fatalf("error_code = %v (body=%v)", body["error_code"], body)
+			}
+			if got := violationRules(t, body)[tt.field]; got != tt.rule {
+				t.Errorf("violation on %q = %q, want %q", tt.field, got, tt.rule)
+			}
+		})
+	}
+}
+
+func TestMCPServer_ToolArgs_ValidCallIsNotError(t *testing.T) {
+	// when
+	result := callToolResult(t, t.TempDir(), "read_inbox", `{"kind":"specification"}`)
+
+	// then
+	if _, ok := result["isError"]; ok {
+		t.Errorf("isError set on a valid call: %v", result)
+	}
+}
+
+func TestMCPServer_ToolFailure_IsErrorWithStableCode(t *testing.T) {
+	// given
+	continent := t.TempDir()
+
+	// when
+	result := callToolResult(t, continent, "archive_inbox", `{"name":"missing"}`)
+	body := callTool(t, continent, nil, "archive_inbox", `{"name":"missing"}`)
+
+	// then
+	if result["isError"] != true || body["error_code"] != "not_found" {
+		t.Errorf("isError = %v, error_code = %v", result["isError"], body["error_code"])
+	}
+	if reason, _ := body["reason"].(string); !strings.Contains(reason, "not found") {
+		t.Errorf("reason = %v", body["reason"])
+	}
+}
@@ -0,0 +1,222 @@
+package session
+
+import (
+	"slices"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// Enum values enforced by validateToolArgs. expeditionStatusEnum mirrors
+// domain.ValidExpeditionStatus.
+var (
+	expeditionStatusEnum = []string{"success", "failed", "parse_error", "skipped"}
+	severityEnum         = []string{string(domain.SeverityLow), string(domain.SeverityMedium), string(domain.SeverityHigh)}
+)
+
+// dmailKindEnum lists a D-Mail kind set as a sorted enum.
+func dmailKindEnum(kinds map[domain.DMailKind]bool) []string {
+	enum := make([]string, 0, len(kinds))
+	for k := range kinds {
+		enum = append(enum, string(k))
+	}
+	slices.Sort(enum)
+	return enum
+}
+
+// toolDescriptors returns the tool set. Each entry pins the interface
+// (name, description, inputSchema) so Claude Code clients see a stable
+// contract; handleToolsCall enforces each inputSchema before dispatch.
+// annotations and outputSchema are filtered per negotiated revision by
+// toolsForProtocol. next_issue / update_gradient / append_journal are real
+// impl: they read pr-index / event store and write journal/ + pr-index;
+// update_gradient / append_journal also emit EventGradientChanged /
+// EventExpeditionCompleted when an emitter is wired (cmd wires one).
+func toolDescriptors() []map[string]any {
+	return []map[string]any{
+		{
+			"name":        "ping",
+			"annotations": toolAnnotations(true, true, false),
+			"description": "Health check. Returns 'pong'.",
+			"inputSchema": map[string]any{"type": "object", "properties": map[string]any{}},
+		},
+		{
+			"name":         "next_issue",
+			"annotations":  toolAnnotations(true, true, false),
+			"outputSchema": nextIssueOutputSchema(),
+			"description":  "Return paintress's local journal state (completed_issue_ids + next_expedition_number + in_flight reservations + last_pr). The Claude Code session uses completed_issue_ids to exclude already-done work from the configured issue source; next_expedition_number is advisory, reserve it with start_expedition. With as_of, returns the read-only historical view projected from events up to that cut-off (completed issues, next number, ExpeditionState, pending wave steps, windowed success rate).",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"as_of": asOfArgSchema(),
+				},
+			},
+		},
+		{
+			"name":        "start_expedition",
+			"annotations": toolAnnotations(false, true, false),
+			"description": "Atomically reserve the next expedition number for an issue and emit EventExpeditionStarted. Concurrent sessions never share a number; re-invoking for an issue whose reservation is not journaled yet returns the same number (reused=true). Call before implementing; append_journal rejects a number reserved for another issue.",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"issue_id": map[string]any{"type": "string", "description": "issue the expedition works on"},
+					"worker":   map[string]any{"type": "integer", "description": "worker slot (optional, default 0)"},
+					"model":    map[string]any{"type": "string", "description": "model name recorded on the start event (optional)"},
+				},
+				"required": []any{"issue_id"},
+			},
+		},
+		{
+			"name":         "update_gradient",
+			"annotations":  toolAnnotations(false, false, false),
+			"outputSchema": updateGradientOutputSchema(),
+			"description":  "Read current gradient_level from the event store, apply delta, and persist an EventGradientChanged event recording level, delta and operator (persistence='event-store'). A change persisted concurrently by another session is never overwritten: the read-modify-write is retried, and reported as a conflict if it keeps losing. Returns current_level + new_level. Falls back to a preview without persisting when no emitter is wired.",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"delta":    map[string]any{"type": "integer", "description": "signed level change"},
+					"operator": map[string]any{"type": "string", "description": "who applies the change, recorded on the event (optional, default mcp.update_gradient)"},
+				},
+				"required": []any{"delta"},
+			},
+		},
+		{
+			"name":         "append_journal",
+			"annotations":  toolAnnotations(false, false, true),
+			"outputSchema": appendJournalOutputSchema(),
+			"description":  "Persist an ExpeditionReport to journal/<NNN>.md + pr-index and emit an EventExpeditionCompleted event (persistence='event-store+filesystem'). Rejects an expedition number reserved by start_expedition for a different issue. Falls back to filesystem-only when no emitter is wired.",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"expedition":           map[string]any{"type": "integer"},
+					"date":                 map[string]any{"type": "string"},
+					"issue_id":             map[string]any{"type": "string"},
+					"issue_title":          map[string]any{"type": "string"},
+					"mission_type":         map[string]any{"type": "string"},
+					"branch":               map[string]any{"type": "string"},
+					"pr_url":               map[string]any{"type": "string"},
+					"status":               map[string]any{"type": "string", "enum": expeditionStatusEnum},
+					"reason":               map[string]any{"type": "string"},
+					"remaining":            map[string]any{"type": "string"},
+					"bugs_found":           map[string]any{"type": "integer"},
+					"bug_issues":           map[string]any{"type": "string"},
+					"insight":              map[string]any{"type": "string"},
+					"failure_type":         map[string]any{"type": "string"},
+					"high_severity_dmails": map[string]any{"type": "string"},
+					"wave_id":              map[string]any{"type": "string"},
+					"step_id":              map[string]any{"type": "string"},
+				},
+				"required": []any{"expedition", "issue_id", "status"},
+			},
+		},
+		{
+			"name":         "dmail",
+			"annotations":  toolAnnotations(false, true, false),
+			"outputSchema": dmailOutputSchema(),
+			"description":  "Emit a D-Mail through the transactional outbox (refs issue 0031). Arguments map onto the D-Mail v1 schema; paintress may emit kind: report. Never write outbox/ directly — this tool is the canonical atomic path (SQLite stage -> flush) that phonewave delivery depends on. Re-sending the same name is an idempotent upsert. The D-Mail is threaded automatically: in_reply_to / thread_id metadata name the D-Mail it answers (the latest received D-Mail sharing an issue unless in_reply_to is given). Files listed in attachments are stored content-addressed and delivered next to the D-Mail with their sha256 in the frontmatter.",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"kind":        map[string]any{"type": "string", "enum": dmailKindEnum(domain.ProducesKinds), "description": "report"},
+					"name":        map[string]any{"type": "string", "description": "unique d-mail name (becomes <name>.md; e.g. pt-report-<issue>-<expedition>)"},
+					"description": map[string]any{"type": "string", "description": "one-line summary (required by schema v1)"},
+					"body":        map[string]any{"type": "string", "description": "markdown body (expedition report)"},
+					"issues":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "related issue ids"},
+					"severity":    map[string]any{"type": "string", "enum": severityEnum, "description": "low / medium / high (optional)"},
+					"priority":    map[string]any{"type": "integer", "description": "priority (optional)"},
+					"metadata":    map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}, "description": "string map; project_id / actor_type / in_reply_to / thread_id injected automatically"},
+					"in_reply_to": map[string]any{"type": "string", "description": "name of the inbox d-mail this answers (optional; inferred from issues when omitted)"},
+					"attachments": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "files inside the project to attach (paths relative to the project root): screenshots, test logs, coverage diffs"},
+				},
+				"required": []any{"kind", "name", "description", "body"},
+			},
+		},
+		{
+			"name":         "get_insights",
+			"annotations":  toolAnnotations(true, true, false),
+			"outputSchema": getInsightsOutputSchema(),
+			"description":  "Read the learning loop (refs issue 0034): persisted insight-ledger files from .expedition/insights/ plus a live Lumina pattern scan recomputed from the journals (failure / success / high-severity patterns). Consult before implementing to avoid repeating past failures. Read-only and idempotent; empty state returns empty arrays.",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"kind": map[string]any{"type": "string", "description": "optional filename-prefix filter (e.g. lumina / gommage)"},
+				},
+			},
+		},
+		{
+			"name":        "record_checkpoint",
+			"annotations": toolAnnotations(false, true, false),
+			"description": "Record how far an expedition got (EventExpeditionCheckpoint) so a crashed session can be resumed: call after each phase with the work dir and commit count. The latest checkpoint wins; append_journal closes the expedition.",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"expedition":   map[string]any{"type": "integer", "description": "expedition number from start_expedition"},
+					"phase":        map[string]any{"type": "string", "enum": checkpointPhaseEnum, "description": "phase just reached"},
+					"work_dir":     map[string]any{"type": "string", "description": "work tree of the expedition branch (absolute, or relative to the continent)"},
+					"commit_count": map[string]any{"type": "integer", "description": "commits on the expedition branch so far (optional, default 0)"},
+				},
+				"required": []any{"expedition", "phase", "work_dir"},
+			},
+		},
+		{
+			"name":         "list_incomplete_expeditions",
+			"annotations":  toolAnnotations(true, true, false),
+			"outputSchema": listIncompleteExpeditionsOutputSchema(),
+			"description":  "List expeditions with a checkpoint but no journal entry (interrupted sessions), with issue id, phase, work dir, current branch and commit count. next_issue leads with the first one as `resume`.",
+			"inputSchema":  map[string]any{"type": "object", "properties": map[string]any{}},
+		},
+		{
+			"name":         "get_status",
+			"annotations":  toolAnnotations(true, true, false),
+			"outputSchema": getStatusOutputSchema(),
+			"description":  "Return the operational read model behind `paintress status`: the StatusReport (counts, success rate, gradient, provider state incl. paused + resume time), the ExpeditionState projection, the windowed success-rate trend, expedition duration p50/p90/p99, dead-letter count and inbox/archive counts. Use it to adapt, e.g. pick a small issue after a declining trend or wait while the provider is paused. With as_of, report/state/trend/durations are projected from events up to that cut-off.",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"window": map[string]any{"type": "integer", "description": "expeditions per success-rate window (optional, default 5)"},
+					"as_of":  asOfArgSchema(),
+				},
+			},
+		},
+		{
+			"name":        "read_inbox",
+			"annotations": toolAnnotations(false, true, false),
+			"description": "List the D-Mails waiting in .expedition/inbox/ with parsed frontmatter, wave reference, Rival Contract sections (when the body is a contract) and the deterministic pre-flight triage decision (pass_through / escalate / resolve / track_retry). Understands legacy .md D-Mails and envelope pairs (<message_id>.yaml + body); an envelope is stamped seen_at when first listed, and re-delivered idempotency keys are skipped. Consume a D-Mail with archive_inbox.",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"kind": map[string]any{"type": "string", "enum": dmailKindEnum(domain.ValidDMailKinds), "description": "optional kind filter (e.g. specification / implementation-feedback)"},
+				},
+			},
+		},
+		{
+			"name":        "archive_inbox",
+			"annotations": toolAnnotations(false, false, false),
+			"description": "Consume one inbox D-Mail: move it to archive/ and record inbox.received plus the triage outcome (issue.escalated / issue.resolved / retry.attempted) and dmail.archived in the event store. An envelope moves with its body and is stamped ack_at; a re-delivered envelope (idempotency key already acked) is archived without events and reported as duplicate. If recording fails the D-Mail stays in the inbox.",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name": map[string]any{"type": "string", "description": "d-mail name as returned by read_inbox"},
+				},
+				"required": []any{"name"},
+			},
+		},
+	}
+}
+
+// toolAnnotations builds the behaviour hints introduced in MCP
+// 2025-03-26. Every paintress tool works on the local continent only,
+// so openWorldHint is always false.
+func toolAnnotations(readOnly, idempotent, destructive bool) map[string]any {
+	return map[string]any{
+		"readOnlyHint":    readOnly,
+		"idempotentHint":  idempotent,
+		"destructiveHint": destructive,
+		"openWorldHint":   false,
+	}
+}
+
+// asOfArgSchema is the optional as_of argument of next_issue and
+// get_status (see domain.ParseAsOf).
+func asOfArgSchema() map[string]any {
+	return map[string]any{"type": "string", "description": "optional cut-off: an RFC 3339 time or \"expedition N\" (the completion of expedition N); answers as of that point from the event history"}
+}
@@ -0,0 +1,244 @@
+package session
+
+// Output schemas (MCP 2025-06-18) for the tools whose results clients
+// consume as typed data. They describe the successful result body sent
+// as structuredContent; isError results carry an error report instead
+// (error_code + reason) and are sent as text only.
+
+func nextIssueOutputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"initialized":            map[string]any{"type": "boolean"},
+			"continent":              map[string]any{"type": "string"},
+			"next_expedition_number": map[string]any{"type": "integer"},
+			"completed_issue_ids":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"in_flight": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"expedition":  map[string]any{"type": "integer"},
+						"issue_id":    map[string]any{"type": "string"},
+						"reserved_at": map[string]any{"type": "string", "format": "date-time"},
+					},
+				},
+			},
+			"resume": map[string]any{
+				"type":        []any{"object", "null"},
+				"description": "first interrupted expedition (see list_incomplete_expeditions); resume it before new work",
+				"properties":  incompleteExpeditionProperties(),
+			},
+			"incomplete_expeditions": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "object", "properties": incompleteExpeditionProperties()},
+			},
+			"last_pr": map[string]any{
+				"type": []any{"object", "null"},
+				"properties": map[string]any{
+					"expedition": map[string]any{"type": "integer"},
+					"issue_id":   map[string]any{"type": "string"},
+					"pr_url":     map[string]any{"type": "string"},
+				},
+			},
+			"journal_dir": map[string]any{"type": "string"},
+			"as_of": map[string]any{
+				"type":        "object",
+				"description": "present when called with as_of: the historical projection the answer was built from",
+				"properties": map[string]any{
+					"as_of":                 map[string]any{"type": "string"},
+					"cutoff":                map[string]any{"type": "string", "format": "date-time"},
+					"events":                map[string]any{"type": "integer"},
+					"state":                 map[string]any{"type": "object"},
+					"pending_steps":         map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+					"windowed_success_rate": map[string]any{"type": "number"},
+				},
+			},
+			"instruction": map[string]any{"type": "string"},
+		},
+		"required": []any{"initialized", "next_expedition_number", "completed_issue_ids"},
+	}
+}
+
+func updateGradientOutputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"initialized":   map[string]any{"type": "boolean"},
+			"continent":     map[string]any{"type": "string"},
+			"current_level": map[string]any{"type": "integer"},
+			"delta":         map[string]any{"type": "integer"},
+			"new_level":     map[string]any{"type": "integer", "description": "set when persisted"},
+			"preview_level": map[string]any{"type": "integer", "description": "set when no emitter is wired"},
+			"operator":      map[string]any{"type": "string", "description": "recorded on the gradient.changed event"},
+			"attempts":      map[string]any{"type": "integer", "description": "read-modify-write cycles used; more than 1 after concurrent changes"},
+			"persistence":   map[string]any{"type": "string", "enum": []string{"event-store", "preview-only"}},
+			"note":          map[string]any{"type": "string"},
+		},
+		"required": []any{"initialized", "current_level", "delta", "persistence"},
+	}
+}
+
+func appendJournalOutputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"initialized":      map[string]any{"type": "boolean"},
+			"persisted":        map[string]any{"type": "boolean"},
+			"expedition":       map[string]any{"type": "integer"},
+			"issue_id":         map[string]any{"type": "string"},
+			"journal_file":     map[string]any{"type": "string"},
+			"pr_index_updated": map[string]any{"type": "boolean"},
+			"persistence":      map[string]any{"type": "string", "enum": []string{"event-store+filesystem", "filesystem-only"}},
+			"note":             map[string]any{"type": "string"},
+		},
+		"required": []any{"initialized", "persisted", "expedition", "issue_id", "journal_file", "persistence"},
+	}
+}
+
+func dmailOutputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"initialized": map[string]any{"type": "boolean"},
+			"sent":        map[string]any{"type": "boolean"},
+			"name":        map[string]any{"type": "string"},
+			"filename":    map[string]any{"type": "string"},
+			"kind":        map[string]any{"type": "string"},
+			"persistence": map[string]any{"type": "string", "enum": []string{"transactional-outbox"}},
+			"in_reply_to": map[string]any{"type": "string"},
+			"thread_id":   map[string]any{"type": "string"},
+			"attachments": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"filename":   map[string]any{"type": "string"},
+						"media_type": map[string]any{"type": "string"},
+						"sha256":     map[string]any{"type": "string"},
+						"size":       map[string]any{"type": "integer"},
+					},
+				},
+			},
+		},
+		"required": []any{"initialized", "sent", "name", "filename", "kind", "persistence"},
+	}
+}
+
+func getInsightsOutputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"initialized": map[string]any{"type": "boolean"},
+			"continent":   map[string]any{"type": "string"},
+			"insights": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"file":       map[string]any{"type": "string"},
+						"kind":       map[string]any{"type": "string"},
+						"updated_at": map[string]any{"type": "string"},
+						"entries":    map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+					},
+				},
+			},
+			"live_lumina": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"pattern": map[string]any{"type": "string"},
+						"source":  map[string]any{"type": "string"},
+						"uses":    map[string]any{"type": "integer"},
+					},
+				},
+			},
+			"instruction": map[string]any{"type": "string"},
+		},
+		"required": []any{"initialized", "insights", "live_lumina"},
+	}
+}
+
+func getStatusOutputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"initialized": map[string]any{"type": "boolean"},
+			"continent":   map[string]any{"type": "string"},
+			"report":      map[string]any{"type": "object", "description": "domain.StatusReport as printed by `paintress status -o json`"},
+			"state":       map[string]any{"type": "object", "description": "ExpeditionState projection replayed from the event store"},
+			"provider": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"state":        map[string]any{"type": "string"},
+					"reason":       map[string]any{"type": "string"},
+					"retry_budget": map[string]any{"type": "integer"},
+					"resume_when":  map[string]any{"type": "string"},
+					"resume_at":    map[string]any{"type": "string", "format": "date-time"},
+					"paused":       map[string]any{"type": "boolean"},
+				},
+			},
+			"success_rate_trend": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"window":        map[string]any{"type": "integer"},
+					"windowed_rate": map[string]any{"type": "number"},
+					"trend":         map[string]any{"type": "string", "enum": []string{"improving", "declining", "stable"}},
+				},
+			},
+			"durations": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"count":       map[string]any{"type": "integer"},
+					"p50_seconds": map[string]any{"type": "number"},
+					"p90_seconds": map[string]any{"type": "number"},
+					"p99_seconds": map[string]any{"type": "number"},
+				},
+			},
+			"dead_letters":  map[string]any{"type": "integer"},
+			"inbox_count":   map[string]any{"type": "integer"},
+			"archive_count": map[string]any{"type": "integer"},
+			"as_of": map[string]any{
+				"type":        "object",
+				"description": "present when called with as_of; provider, dead_letters and inbox/archive counts stay current",
+				"properties": map[string]any{
+					"as_of":         map[string]any{"type": "string"},
+					"cutoff":        map[string]any{"type": "string", "format": "date-time"},
+					"events":        map[string]any{"type": "integer"},
+					"pending_steps": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+			},
+			"instruction": map[string]any{"type": "string"},
+		},
+		"required": []any{"initialized", "report", "state", "provider", "success_rate_trend", "durations", "dead_letters"},
+	}
+}
+
+func listIncompleteExpeditionsOutputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"initialized": map[string]any{"type": "boolean"},
+			"continent":   map[string]any{"type": "string"},
+			"incomplete": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "object", "properties": incompleteExpeditionProperties()},
+			},
+			"instruction": map[string]any{"type": "string"},
+		},
+		"required": []any{"initialized", "incomplete"},
+	}
+}
+
+func incompleteExpeditionProperties() map[string]any {
+	return map[string]any{
+		"expedition":      map[string]any{"type": "integer"},
+		"issue_id":        map[string]any{"type": "string"},
+		"phase":           map[string]any{"type": "string"},
+		"work_dir":        map[string]any{"type": "string"},
+		"work_dir_exists": map[string]any{"type": "boolean"},
+		"branch":          map[string]any{"type": "string"},
+		"commit_count":    map[string]any{"type": "integer"},
+	}
+}
@@ -0,0 +1,192 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// ErrDeadLetterNotFound is returned for a name that is not a dead-lettered
+// outbox item.
+var ErrDeadLetterNotFound = errors.New("no dead-lettered outbox item")
+
+// DeadLetter is an outbox item that exhausted its flush attempts.
+type DeadLetter struct {
+	Name          string    `json:"name"`
+	RetryCount    int       `json:"retry_count"`
+	LastError     string    `json:"last_error"`
+	LastAttemptAt time.Time `json:"last_attempt_at,omitzero"`
+	FirstStagedAt time.Time `json:"first_staged_at,omitzero"`
+	Size          int       `json:"size"`
+}
+
+// FlushFailure is one failed flush attempt of an outbox item.
+type FlushFailure struct { // nosemgrep: structure.multiple-exported-structs-go -- error history row of DeadLetterDetail; one read model [permanent]
+	Attempt int       `json:"attempt"`
+	At      time.Time `json:"at"`
+	Error   string    `json:"error"`
+}
+
+// DeadLetterDetail is a dead letter with its decoded D-Mail frontmatter,
+// body and error history (oldest first). DecodeError is set instead of
+// Frontmatter when the staged data is not a parseable D-Mail.
+type DeadLetterDetail struct { // nosemgrep: structure.multiple-exported-structs-go -- detail view of DeadLetter; one read model [permanent]
+	DeadLetter
+	Frontmatter map[string]any `json:"frontmatter,omitempty"`
+	Body        string         `json:"body"`
+	DecodeError string         `json:"decode_error,omitempty"`
+	Errors      []FlushFailure `json:"errors"`
+}
+
+// ListDeadLetters returns the dead-lettered items, oldest staged first.
+func (s *SQLiteOutboxStore) ListDeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT name, retry_count, last_error, last_attempt_at, first_staged_at, length(data)
+		FROM staged WHERE flushed = 0 AND retry_count >= ?
+		ORDER BY first_staged_at, name`, maxRetryCount)
+	if err != nil {
+		return nil, fmt.Errorf("outbox store: list dead letters: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	letters := make([]DeadLetter, 0)
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("outbox store: list dead letters: %w", err)
+		}
+		letters = append(letters, dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox store: list dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+// ShowDeadLetter returns the dead letter staged as name. The ".md"
+// extension may be omitted.
+func (s *SQLiteOutboxStore) ShowDeadLetter(ctx context.Context, name string) (DeadLetterDetail, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT name, retry_count, last_error, last_attempt_at, first_staged_at, length(data), data
+		FROM staged WHERE flushed = 0 AND retry_count >= ? AND name IN (?, ?)`,
+		maxRetryCount, name, name+".md")
+	var detail DeadLetterDetail
+	var data []byte
+	dl, err := scanDeadLetter(row, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DeadLetterDetail{}, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, name)
+	}
+	if err != nil {
+		return DeadLetterDetail{}, fmt.Errorf("outbox store: show dead letter %s: %w", name, err)
+	}
+	detail.DeadLetter = dl
+	detail.Frontmatter, detail.Body, err = decodeDMailFrontmatter(data)
+	if err != nil {
+		detail.DecodeError = err.Error()
+		detail.Body = string(data)
+	}
+	detail.Errors, err = s.flushFailures(ctx, dl.Name)
+	if err != nil {
+		return DeadLetterDetail{}, err
+	}
+	return detail, nil
+}
+
+// RequeueDeadLetter resets the retry count of the dead letter staged as
+// name (the ".md" extension may be omitted), so the next Flush attempts
+// it again right away. Its error history is kept.
+func (s *SQLiteOutboxStore) RequeueDeadLetter(ctx context.Context, name string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE staged SET retry_count = 0, last_attempt_at = NULL
+		WHERE flushed = 0 AND retry_count >= ? AND name IN (?, ?)`,
+		maxRetryCount, name, name+".md")
+	if err != nil {
+		return fmt.Errorf("outbox store: requeue %s: %w", name, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("outbox store: rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrDeadLetterNotFound, name)
+	}
+	return nil
+}
+
+// RequeueDeadLetters requeues every dead letter (see RequeueDeadLetter)
+// and returns how many there were.
+func (s *SQLiteOutboxStore) RequeueDeadLetters(ctx context.Context) (int, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE staged SET retry_count = 0, last_attempt_at = NULL
+		WHERE flushed = 0 AND retry_count >= ?`, maxRetryCount)
+	if err != nil {
+		return 0, fmt.Errorf("outbox store: requeue dead letters: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("outbox store: rows affected: %w", err)
+	}
+	return int(n), nil
+}
+
+func (s *SQLiteOutboxStore) flushFailures(ctx context.Context, name string) ([]FlushFailure, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT attempt, error, at FROM staged_errors WHERE name = ? ORDER BY rowid`, name)
+	if err != nil {
+		return nil, fmt.Errorf("outbox store: error history %s: %w", name, err)
+	}
+	defer func() { _ = rows.Close() }()
+	failures := make([]FlushFailure, 0)
+	for rows.Next() {
+		var f FlushFailure
+		var at string
+		if err := rows.Scan(&f.Attempt, &f.Error, &at); err != nil {
+			return nil, fmt.Errorf("outbox store: error history %s: %w", name, err)
+		}
+		f.At = parseOutboxTime(sql.NullString{String: at, Valid: true})
+		failures = append(failures, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox store: error history %s: %w", name, err)
+	}
+	return failures, nil
+}
+
+// scanDeadLetter scans the columns selected by ListDeadLetters, followed
+// by extra destinations.
+func scanDeadLetter(row interface{ Scan(...any) error }, extra ...any) (DeadLetter, error) {
+	var dl DeadLetter
+	var lastAttempt, firstStaged sql.NullString
+	dest := append([]any{&dl.Name, &dl.RetryCount, &dl.LastError, &lastAttempt, &firstStaged, &dl.Size}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return DeadLetter{}, err
+	}
+	dl.LastAttemptAt = parseOutboxTime(lastAttempt)
+	dl.FirstStagedAt = parseOutboxTime(firstStaged)
+	return dl, nil
+}
+
+// decodeDMailFrontmatter splits staged D-Mail data into its frontmatter,
+// keyed as written, and its body.
+func decodeDMailFrontmatter(data []byte) (map[string]any, string, error) {
+	dm, err := domain.ParseDMail(data)
+	if err != nil {
+		return nil, "", err
+	}
+	rest := strings.TrimPrefix(string(data), "---\n")
+	end := strings.Index(rest, "\n---\n")
+	if end < 0 {
+		end = len(rest) - len("\n---")
+	}
+	var frontmatter map[string]any
+	if err := yaml.Unmarshal([]byte(rest[:end]), &frontmatter); err != nil {
+		return nil, "", err
+	}
+	return frontmatter, dm.Body, nil
+}
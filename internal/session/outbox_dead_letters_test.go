@@ -0,0 +1,183 @@
+package session_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+// blockArchiveWrite makes flushing name fail, even as root: a non-empty
+// directory in the way of the rename into archive/.
+func blockArchiveWrite(t *testing.T, continent, name string) (unblock func()) {
+	t.Helper()
+	dir := filepath.Join(domain.ArchiveDir(continent), name)
+	if err := os.MkdirAll(filepath.Join(dir, "keep"), 0o755); err != nil {
+		t.Fatalf("block archive write: %v", err)
+	}
+	return func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatalf("unblock archive write: %v", err)
+		}
+	}
+}
+
+func stageReportDMail(t *testing.T, store *session.SQLiteOutboxStore, name string) {
+	t.Helper()
+	data, err := domain.DMail{Name: name, Kind: "report", Description: "expedition report", Issues: []string{"MY-1"}, Body: "# Report\n"}.Marshal()
+	if err != nil {
+		t.Fatalf("marshal dmail: %v", err)
+	}
+	if err := store.Stage(context.Background(), name+".md", data); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+}
+
+func TestSQLiteOutboxStore_Flush_BacksOffAfterFailure(t *testing.T) {
+	// given: an item whose first flush failed
+	continent := t.TempDir()
+	ensureExpeditionDirs(t, continent)
+	store := testOutboxStore(t, continent)
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	session.ExportSetOutboxClock(store, func() time.Time { return now })
+	stageReportDMail(t, store, "report-a")
+	unblock := blockArchiveWrite(t, continent, "report-a.md")
+	if n, err := store.Flush(context.Background()); err != nil || n != 0 {
+		t.Fatalf("first flush: n=%d err=%v, want 0 and nil", n, err)
+	}
+	unblock()
+
+	// when: flushed again before and after the backoff
+	now = now.Add(session.ExportFlushRetryBackoff - time.Second)
+	early, err := store.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("early flush: %v", err)
+	}
+	now = now.Add(time.Second)
+	due, err := store.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("due flush: %v", err)
+	}
+
+	// then
+	if early != 0 {
+		t.Errorf("flush inside the backoff: got %d, want 0", early)
+	}
+	if due != 1 {
+		t.Errorf("flush after the backoff: got %d, want 1", due)
+	}
+}
+
+func TestSQLiteOutboxStore_DeadLetters_ListShowRequeue(t *testing.T) {
+	// given: an item that failed maxRetryCount attempts
+	continent := t.TempDir()
+	ensureExpeditionDirs(t, continent)
+	store := testOutboxStore(t, continent)
+	staged := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	now := staged
+	session.ExportSetOutboxClock(store, func() time.Time { return now })
+	stageReportDMail(t, store, "report-b")
+	unblock := blockArchiveWrite(t, continent, "report-b.md")
+	for range 3 {
+		now = now.Add(time.Hour)
+		if _, err := store.Flush(context.Background()); err != nil {
+			t.Fatalf("flush: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	// when
+	list, err := store.ListDeadLetters(ctx)
+	if err != nil {
+		t.Fatalf("ListDeadLetters: %v", err)
+	}
+	detail, err := store.ShowDeadLetter(ctx, "report-b")
+	if err != nil {
+		t.Fatalf("ShowDeadLetter: %v", err)
+	}
+
+	// then
+	if len(list) != 1 || list[0].Name != "report-b.md" || list[0].RetryCount != 3 {
+		t.Fatalf("dead letters: got %+v, want report-b.md with 3 retries", list)
+	}
+	if !strings.Contains(list[0].LastError, "write archive") {
+		t.Errorf("last error: got %q, want a write archive error", list[0].LastError)
+	}
+	if !list[0].FirstStagedAt.Equal(staged) || !list[0].LastAttemptAt.Equal(now) {
+		t.Errorf("times: got staged %v / attempted %v, want %v / %v", list[0].FirstStagedAt, list[0].LastAttemptAt, staged, now)
+	}
+	if detail.Frontmatter["kind"] != "report" || detail.Frontmatter["name"] != "report-b" {
+		t.Errorf("frontmatter: got %v", detail.Frontmatter)
+	}
+	if detail.Body != "# Report\n" {
+		t.Errorf("body: got %q", detail.Body)
+	}
+	if len(detail.Errors) != 3 || detail.Errors[0].Attempt != 1 || detail.Errors[2].Attempt != 3 {
+		t.Fatalf("error history: got %+v, want attempts 1..3", detail.Errors)
+	}
+
+	// when: requeued and no longer blocked
+	if err := store.RequeueDeadLetter(ctx, "report-b.md"); err != nil {
+		t.Fatalf("RequeueDeadLetter: %v", err)
+	}
+	unblock()
+	n, err := store.Flush(ctx)
+
+	// then: flushed at once, and no longer a dead letter
+	if err != nil || n != 1 {
+		t.Errorf("flush after requeue: n=%d err=%v, want 1 and nil", n, err)
+	}
+	if err := store.RequeueDeadLetter(ctx, "report-b"); !errors.Is(err, session.ErrDeadLetterNotFound) {
+		t.Errorf("requeue of a delivered item: got %v, want ErrDeadLetterNotFound", err)
+	}
+}
+
+func TestSQLiteOutboxStore_MigratesLegacySchema(t *testing.T) {
+	// given: an outbox.db from before the error columns, with a dead letter
+	continent := t.TempDir()
+	ensureExpeditionDirs(t, continent)
+	dbPath := filepath.Join(continent, ".expedition", ".run", "outbox.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open legacy db: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE staged (
+		name        TEXT PRIMARY KEY,
+		data        BLOB    NOT NULL,
+		flushed     INTEGER NOT NULL DEFAULT 0,
+		retry_count INTEGER NOT NULL DEFAULT 0
+	)`)
+	if err == nil {
+		_, err = db.Exec(`INSERT INTO staged (name, data, retry_count) VALUES ('old.md', 'not a dmail', 3)`)
+	}
+	db.Close()
+	if err != nil {
+		t.Fatalf("seed legacy db: %v", err)
+	}
+
+	// when
+	store := testOutboxStore(t, continent)
+	list, err := store.ListDeadLetters(context.Background())
+	if err != nil {
+		t.Fatalf("ListDeadLetters: %v", err)
+	}
+	detail, err := store.ShowDeadLetter(context.Background(), "old")
+
+	// then
+	if err != nil {
+		t.Fatalf("ShowDeadLetter: %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "old.md" || !list[0].FirstStagedAt.IsZero() {
+		t.Errorf("dead letters: got %+v, want old.md without a staging time", list)
+	}
+	if detail.DecodeError == "" || detail.Body != "not a dmail" || len(detail.Errors) != 0 {
+		t.Errorf("detail: got %+v, want a decode error, the raw body and no history", detail)
+	}
+}
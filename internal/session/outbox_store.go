@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -24,16 +25,20 @@ var _ port.OutboxStore = (*SQLiteOutboxStore)(nil)
 
 // SQLiteOutboxStore implements OutboxStore using a SQLite database as the
 // transactional write-ahead log. Staged D-Mails are flushed to archive/ and
-// outbox/ using atomic file writes (temp file + rename).
+// outbox/ using atomic file writes (temp file + rename); their attachments
+// are copied from the artifacts store first.
 type SQLiteOutboxStore struct {
 	db         *sql.DB
 	archiveDir string
 	outboxDir  string
+	artifacts  *ArtifactStore
+	now        func() time.Time
 }
 
 // NewSQLiteOutboxStore opens (or creates) a SQLite database at dbPath and
 // initialises the schema. archiveDir and outboxDir are the target directories
-// for flushed D-Mail files.
+// for flushed D-Mail files; attachment blobs are read from the artifacts/
+// directory next to archiveDir.
 func NewSQLiteOutboxStore(dbPath, archiveDir, outboxDir string) (*SQLiteOutboxStore, error) { // nosemgrep: domain-primitives.multiple-string-params-go -- dbPath/archiveDir/outboxDir are semantically distinct path params [permanent]
 	for _, dir := range []string{filepath.Dir(dbPath), archiveDir, outboxDir} {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -74,6 +79,8 @@ func NewSQLiteOutboxStore(dbPath, archiveDir, outboxDir string) (*SQLiteOutboxSt
 		db:         db,
 		archiveDir: archiveDir,
 		outboxDir:  outboxDir,
+		artifacts:  NewArtifactStore(filepath.Join(filepath.Dir(archiveDir), "artifacts")),
+		now:        time.Now,
 	}, nil
 }
 
@@ -81,19 +88,111 @@ func NewSQLiteOutboxStore(dbPath, archiveDir, outboxDir string) (*SQLiteOutboxSt
 // that exceed this limit are treated as dead-letter and skipped.
 const maxRetryCount = 3
 
+// flushRetryBackoff is the wait after the first failed flush attempt of
+// an item; it doubles with every further failure.
+const flushRetryBackoff = 30 * time.Second
+
+// stagedColumnMigrations adds the columns introduced after the first
+// release of the staged table. Databases created before then are
+// migrated when opened.
+var stagedColumnMigrations = []struct {
+	column string
+	ddl    string
+}{
+	{"last_error", `ALTER TABLE staged ADD COLUMN last_error TEXT NOT NULL DEFAULT ''`},
+	{"last_attempt_at", `ALTER TABLE staged ADD COLUMN last_attempt_at TEXT`},
+	{"first_staged_at", `ALTER TABLE staged ADD COLUMN first_staged_at TEXT`},
+}
+
 func createOutboxSchema(db *sql.DB) error {
 	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS staged (
-		name        TEXT PRIMARY KEY,
-		data        BLOB    NOT NULL,
-		flushed     INTEGER NOT NULL DEFAULT 0,
-		retry_count INTEGER NOT NULL DEFAULT 0
+		name            TEXT PRIMARY KEY,
+		data            BLOB    NOT NULL,
+		flushed         INTEGER NOT NULL DEFAULT 0,
+		retry_count     INTEGER NOT NULL DEFAULT 0,
+		last_error      TEXT    NOT NULL DEFAULT '',
+		last_attempt_at TEXT,
+		first_staged_at TEXT
 	)`)
 	if err != nil {
 		return fmt.Errorf("outbox store: create schema: %w", err)
 	}
+	if err := migrateStagedColumns(db); err != nil {
+		return err
+	}
+	// staged_errors keeps every failed flush attempt, for dead-letters show.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS staged_errors (
+		name    TEXT    NOT NULL,
+		attempt INTEGER NOT NULL,
+		error   TEXT    NOT NULL,
+		at      TEXT    NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("outbox store: create schema: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS staged_errors_name ON staged_errors (name)`); err != nil {
+		return fmt.Errorf("outbox store: create schema: %w", err)
+	}
+	return nil
+}
+
+func migrateStagedColumns(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('staged')`)
+	if err != nil {
+		return fmt.Errorf("outbox store: read schema: %w", err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("outbox store: read schema: %w", err)
+		}
+		existing[column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("outbox store: read schema: %w", err)
+	}
+	for _, m := range stagedColumnMigrations {
+		if existing[m.column] {
+			continue
+		}
+		// Another process opening the same database may have added the
+		// column in the meantime.
+		if _, err := db.Exec(m.ddl); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("outbox store: migrate %s: %w", m.column, err)
+		}
+	}
 	return nil
 }
 
+// retryDue reports whether an item that failed retryCount flush attempts,
+// the last one at lastAttempt, may be attempted again at now.
+func retryDue(retryCount int, lastAttempt sql.NullString, now time.Time) bool {
+	at := parseOutboxTime(lastAttempt)
+	if retryCount == 0 || at.IsZero() {
+		return true
+	}
+	return !now.Before(at.Add(flushRetryBackoff << (retryCount - 1)))
+}
+
+func formatOutboxTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseOutboxTime reads a time written by formatOutboxTime; NULL (rows
+// from before the column existed) and malformed values are the zero time.
+func parseOutboxTime(value sql.NullString) time.Time {
+	if !value.Valid {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, value.String)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 // Stage inserts a D-Mail into the staging table. Idempotent: re-staging the
 // same name updates the data and resets flushed/retry state, enabling
 // re-delivery of D-Mails that have already been flushed (e.g. recurring
@@ -103,8 +202,15 @@ func (s *SQLiteOutboxStore) Stage(ctx context.Context, name string, data []byte)
 	defer span.End()
 
 	span.SetAttributes(attribute.String("db.operation", "stage"))
-	_, err := s.db.Exec(`INSERT INTO staged (name, data) VALUES (?, ?)
-		ON CONFLICT(name) DO UPDATE SET data = excluded.data, flushed = 0, retry_count = 0`, name, data)
+	_, err := s.db.Exec(`INSERT INTO staged (name, data, first_staged_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET data = excluded.data, flushed = 0, retry_count = 0,
+			last_error = '', last_attempt_at = NULL,
+			first_staged_at = COALESCE(staged.first_staged_at, excluded.first_staged_at)`,
+		name, data, formatOutboxTime(s.now()))
+	if err == nil {
+		// New content starts a new delivery: forget earlier failures.
+		_, err = s.db.Exec(`DELETE FROM staged_errors WHERE name = ?`, name)
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetAttributes(attribute.String("error.stage", "outbox.stage"))
@@ -116,8 +222,10 @@ func (s *SQLiteOutboxStore) Stage(ctx context.Context, name string, data []byte)
 // Flush writes all unflushed D-Mails to archive/ and outbox/ using atomic
 // file writes, then marks them as flushed in the database. The entire flush
 // is wrapped in a BEGIN IMMEDIATE transaction so that concurrent CLI
-// processes wait (up to busy_timeout) instead of deadlocking. A partial
-// failure leaves items eligible for retry on the next Flush call.
+// processes wait (up to busy_timeout) instead of deadlocking. A failed
+// item records its error and is retried with exponential backoff: after
+// the n-th failure it is skipped for flushRetryBackoff * 2^(n-1), until
+// it becomes a dead letter at maxRetryCount failures.
 func (s *SQLiteOutboxStore) Flush(ctx context.Context) (int, error) {
 	ctx, span := platform.Tracer.Start(ctx, "outbox.flush")
 	defer span.End()
@@ -150,7 +258,7 @@ func (s *SQLiteOutboxStore) Flush(ctx context.Context) (int, error) {
 	}()
 
 	rows, err := conn.QueryContext(ctx,
-		`SELECT name, data FROM staged WHERE flushed = 0 AND retry_count < ?`, maxRetryCount)
+		`SELECT name, data, retry_count, last_attempt_at FROM staged WHERE flushed = 0 AND retry_count < ?`, maxRetryCount)
 	if err != nil {
 		span.RecordError(err)
 		span.SetAttributes(attribute.String("error.stage", "outbox.flush"))
@@ -158,18 +266,26 @@ func (s *SQLiteOutboxStore) Flush(ctx context.Context) (int, error) {
 	}
 
 	type item struct {
-		name string
-		data []byte
+		name       string
+		data       []byte
+		retryCount int
 	}
+	now := s.now()
 	var items []item
+	backoff := 0
 	for rows.Next() {
 		var it item
-		if err := rows.Scan(&it.name, &it.data); err != nil {
+		var lastAttempt sql.NullString
+		if err := rows.Scan(&it.name, &it.data, &it.retryCount, &lastAttempt); err != nil {
 			_ = rows.Close()
 			span.RecordError(err)
 			span.SetAttributes(attribute.String("error.stage", "outbox.flush"))
 			return 0, fmt.Errorf("outbox store: scan row: %w", err)
 		}
+		if !retryDue(it.retryCount, lastAttempt, now) {
+			backoff++
+			continue
+		}
 		items = append(items, it)
 	}
 	if err := rows.Err(); err != nil {
@@ -183,28 +299,32 @@ func (s *SQLiteOutboxStore) Flush(ctx context.Context) (int, error) {
 		conn.ExecContext(ctx, "ROLLBACK") //nolint:errcheck
 		committed = true                  // suppress deferred rollback
 		span.SetAttributes(attribute.Int("flush.success.count", 0))
+		span.SetAttributes(attribute.Int("flush.backoff.count", backoff))
 		return 0, nil
 	}
 
+	attemptAt := formatOutboxTime(now)
 	flushed := 0
 	retryCount := 0
 	for _, it := range items {
-		archivePath := filepath.Join(s.archiveDir, it.name)
-		if writeErr := atomicWrite(archivePath, it.data); writeErr != nil {
-			// Per-item failure: increment retry_count and continue.
-			conn.ExecContext(ctx, //nolint:errcheck
-				`UPDATE staged SET retry_count = retry_count + 1 WHERE name = ?`, it.name)
-			retryCount++
-			continue
+		writeErr := s.deliver(s.archiveDir, it.name, it.data)
+		if writeErr != nil {
+			writeErr = fmt.Errorf("write archive: %w", writeErr)
+		} else if writeErr = s.deliver(s.outboxDir, it.name, it.data); writeErr != nil {
+			writeErr = fmt.Errorf("write outbox: %w", writeErr)
 		}
-		outboxPath := filepath.Join(s.outboxDir, it.name)
-		if writeErr := atomicWrite(outboxPath, it.data); writeErr != nil {
+		if writeErr != nil {
+			// Per-item failure: record it, increment retry_count and continue.
 			conn.ExecContext(ctx, //nolint:errcheck
-				`UPDATE staged SET retry_count = retry_count + 1 WHERE name = ?`, it.name)
+				`UPDATE staged SET retry_count = retry_count + 1, last_error = ?, last_attempt_at = ? WHERE name = ?`,
+				writeErr.Error(), attemptAt, it.name)
+			conn.ExecContext(ctx, //nolint:errcheck
+				`INSERT INTO staged_errors (name, attempt, error, at) VALUES (?, ?, ?, ?)`,
+				it.name, it.retryCount+1, writeErr.Error(), attemptAt)
 			retryCount++
 			continue
 		}
-		if _, err := conn.ExecContext(ctx, `UPDATE staged SET flushed = 1 WHERE name = ?`, it.name); err != nil {
+		if _, err := conn.ExecContext(ctx, `UPDATE staged SET flushed = 1, last_attempt_at = ? WHERE name = ?`, attemptAt, it.name); err != nil {
 			span.RecordError(err)
 			span.SetAttributes(attribute.String("error.stage", "outbox.flush"))
 			return 0, fmt.Errorf("outbox store: mark flushed %s: %w", it.name, err)
@@ -227,6 +347,7 @@ func (s *SQLiteOutboxStore) Flush(ctx context.Context) (int, error) {
 	}
 	committed = true
 	span.SetAttributes(attribute.Int("flush.retry.count", retryCount))
+	span.SetAttributes(attribute.Int("flush.backoff.count", backoff))
 	span.SetAttributes(attribute.Int("flush.success.count", flushed))
 	if deadCount > 0 {
 		span.SetAttributes(attribute.Int("flush.dead_letter.count", deadCount))
@@ -235,6 +356,15 @@ func (s *SQLiteOutboxStore) Flush(ctx context.Context) (int, error) {
 	return flushed, nil
 }
 
+// deliver writes a staged D-Mail into dir: its attachments first, then
+// the D-Mail file, so a reader that sees the D-Mail sees its attachments.
+func (s *SQLiteOutboxStore) deliver(dir, name string, data []byte) error {
+	if err := s.artifacts.writeAttachments(dir, name, data); err != nil {
+		return err
+	}
+	return atomicWrite(filepath.Join(dir, name), data)
+}
+
 // PruneFlushed deletes all flushed rows from the staging table and runs
 // incremental vacuum to reclaim disk space. Returns the number of deleted rows.
 func (s *SQLiteOutboxStore) PruneFlushed(ctx context.Context) (int, error) {
@@ -242,6 +372,11 @@ func (s *SQLiteOutboxStore) PruneFlushed(ctx context.Context) (int, error) {
 	defer span.End()
 
 	span.SetAttributes(attribute.String("db.operation", "prune"))
+	if _, err := s.db.Exec(`DELETE FROM staged_errors WHERE name IN (SELECT name FROM staged WHERE flushed = 1)`); err != nil {
+		span.RecordError(err)
+		span.SetAttributes(attribute.String("error.stage", "outbox.prune"))
+		return 0, fmt.Errorf("outbox store: prune error history: %w", err)
+	}
 	result, err := s.db.Exec(`DELETE FROM staged WHERE flushed = 1`)
 	if err != nil {
 		span.RecordError(err)
@@ -287,6 +422,10 @@ func (s *SQLiteOutboxStore) DeadLetterCount(ctx context.Context) (int, error) {
 // PurgeDeadLetters deletes items that have exceeded maxRetryCount.
 // Returns the number of purged items.
 func (s *SQLiteOutboxStore) PurgeDeadLetters(ctx context.Context) (int, error) {
+	if _, err := s.db.ExecContext(ctx,
+		`DELETE FROM staged_errors WHERE name IN (SELECT name FROM staged WHERE flushed = 0 AND retry_count >= ?)`, maxRetryCount); err != nil {
+		return 0, fmt.Errorf("outbox store: purge dead letter history: %w", err)
+	}
 	result, err := s.db.ExecContext(ctx,
 		`DELETE FROM staged WHERE flushed = 0 AND retry_count >= ?`, maxRetryCount)
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/hironow/paintress/internal/domain"
 	"github.com/hironow/paintress/internal/session"
@@ -340,6 +341,8 @@ func TestSQLiteOutboxStore_RetryCount_DeadLetterAfterMaxRetries(t *testing.T) {
 		t.Fatalf("create store: %v", err)
 	}
 	defer store.Close()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	session.ExportSetOutboxClock(store, func() time.Time { return now })
 
 	store.Stage(context.Background(), "fail.md", []byte("data"))
 
@@ -347,8 +350,9 @@ func TestSQLiteOutboxStore_RetryCount_DeadLetterAfterMaxRetries(t *testing.T) {
 	os.Chmod(archiveDir, 0o444)
 	defer os.Chmod(archiveDir, 0o755)
 
-	// when: flush 3 times (each fails, incrementing retry_count to 3)
+	// when: flush 3 times, each past the backoff (each fails, incrementing retry_count to 3)
 	for i := range 3 {
+		now = now.Add(time.Hour)
 		n, _ := store.Flush(context.Background())
 		if n != 0 {
 			t.Errorf("flush %d: expected 0 flushed, got %d", i+1, n)
@@ -381,6 +385,8 @@ func TestSQLiteOutboxStore_RetryCount_SuccessBeforeMaxRetries(t *testing.T) {
 		t.Fatalf("create store: %v", err)
 	}
 	defer store.Close()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	session.ExportSetOutboxClock(store, func() time.Time { return now })
 
 	store.Stage(context.Background(), "retry.md", []byte("retry-data"))
 
@@ -391,8 +397,9 @@ func TestSQLiteOutboxStore_RetryCount_SuccessBeforeMaxRetries(t *testing.T) {
 		t.Errorf("first flush: expected 0, got %d", n)
 	}
 
-	// Restore — second flush succeeds
+	// Restore — second flush after the backoff succeeds
 	os.Chmod(archiveDir, 0o755)
+	now = now.Add(session.ExportFlushRetryBackoff)
 	n, err = store.Flush(context.Background())
 	if err != nil {
 		t.Fatalf("second Flush: %v", err)
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/usecase/port"
+
+	_ "modernc.org/sqlite"
+)
+
+// Compile-time check that SQLitePolicyCursorStore implements port.PolicyCursorStore.
+var _ port.PolicyCursorStore = (*SQLitePolicyCursorStore)(nil)
+
+// SQLitePolicyCursorStore keeps the SeqNr cursors of the durable
+// PolicyEngine subscriptions in .expedition/.run/policy_cursors.db.
+type SQLitePolicyCursorStore struct {
+	db *sql.DB
+}
+
+// NewSQLitePolicyCursorStore opens (or creates) a cursor store at dbPath.
+func NewSQLitePolicyCursorStore(dbPath string) (*SQLitePolicyCursorStore, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return nil, fmt.Errorf("policy cursor store: create dir: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath) // nosemgrep: d4-sql-open-without-defer-close -- stored in struct, closed via Close() [permanent]
+	if err != nil {
+		return nil, fmt.Errorf("policy cursor store: open db: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{
+		`PRAGMA journal_mode=WAL`,
+		`PRAGMA busy_timeout=5000`,
+		`CREATE TABLE IF NOT EXISTS policy_cursors (
+		name       TEXT PRIMARY KEY,
+		seq_nr     INTEGER NOT NULL,
+		updated_at TEXT NOT NULL
+	)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("policy cursor store: init: %w", err)
+		}
+	}
+	return &SQLitePolicyCursorStore{db: db}, nil
+}
+
+// NewPolicyCursorStoreForDir opens the cursor store of continent.
+func NewPolicyCursorStoreForDir(continent string) (*SQLitePolicyCursorStore, error) {
+	return NewSQLitePolicyCursorStore(filepath.Join(domain.RunDir(continent), "policy_cursors.db"))
+}
+
+// LoadCursor returns the cursor of the named subscription.
+func (s *SQLitePolicyCursorStore) LoadCursor(ctx context.Context, name string) (uint64, bool, error) {
+	var seqNr int64
+	err := s.db.QueryRowContext(ctx, `SELECT seq_nr FROM policy_cursors WHERE name = ?`, name).Scan(&seqNr)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("policy cursor: load %s: %w", name, err)
+	}
+	return uint64(seqNr), true, nil
+}
+
+// SaveCursor moves the cursor of the named subscription forward to
+// seqNr; an older seqNr leaves it unchanged.
+func (s *SQLitePolicyCursorStore) SaveCursor(ctx context.Context, name string, seqNr uint64) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO policy_cursors (name, seq_nr, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET seq_nr = excluded.seq_nr, updated_at = excluded.updated_at
+		WHERE excluded.seq_nr > policy_cursors.seq_nr`,
+		name, int64(seqNr), time.Now().UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("policy cursor: save %s: %w", name, err)
+	}
+	return nil
+}
+
+// Close closes the underlying database connection.
+func (s *SQLitePolicyCursorStore) Close() error {
+	return s.db.Close()
+}
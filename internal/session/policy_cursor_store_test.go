@@ -0,0 +1,33 @@
+package session_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hironow/paintress/internal/session"
+)
+
+func TestSQLitePolicyCursorStore_CursorOnlyMovesForward(t *testing.T) {
+	// given
+	ctx := context.Background()
+	store, err := session.NewPolicyCursorStoreForDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+	if _, ok, err := store.LoadCursor(ctx, "p"); ok || err != nil {
+		t.Fatalf("new cursor: ok=%v err=%v, want absent", ok, err)
+	}
+
+	// when
+	for _, seq := range []uint64{0, 7, 3} {
+		if err := store.SaveCursor(ctx, "p", seq); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// then
+	if seq, ok, err := store.LoadCursor(ctx, "p"); seq != 7 || !ok || err != nil {
+		t.Errorf("cursor = %d (ok=%v, err=%v), want 7", seq, ok, err)
+	}
+}
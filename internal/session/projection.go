@@ -23,6 +23,9 @@ type ExpeditionState struct {
 	DMailsStaged        int       `json:"dmails_staged"`
 	DMailsFlushed       int       `json:"dmails_flushed"`
 	InboxReceived       int       `json:"inbox_received"`
+	// RetryAttempts is the highest recorded retry attempt per retry key
+	// (canonical issue set), read by inbox triage.
+	RetryAttempts map[string]int `json:"retry_attempts,omitempty"`
 }
 
 // ErrorRate returns the fraction of failed expeditions (0.0 to 1.0).
@@ -34,6 +37,17 @@ func (s *ExpeditionState) ErrorRate() float64 {
 	return float64(s.Failed) / float64(s.TotalExpeditions)
 }
 
+// SuccessRate returns the fraction of non-skipped expeditions that
+// succeeded (0.0 to 1.0), matching domain.SuccessRate over the same events.
+// Returns 0.0 when no non-skipped expeditions have been recorded.
+func (s *ExpeditionState) SuccessRate() float64 {
+	total := s.TotalExpeditions - s.Skipped
+	if total == 0 {
+		return 0.0
+	}
+	return float64(s.Succeeded) / float64(total)
+}
+
 // ProjectState replays events to produce an ExpeditionState.
 // Unknown event types are silently skipped for forward compatibility.
 // Returns a zero-value ExpeditionState for nil/empty input.
@@ -93,6 +107,18 @@ func applyEvent(state *ExpeditionState, ev domain.Event) {
 	case domain.EventGommageTriggered:
 		state.GommageCount++
 
+	case domain.EventRetryAttempted:
+		var data domain.RetryAttemptedData
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return
+		}
+		if data.Attempt > state.RetryAttempts[data.DMail] {
+			if state.RetryAttempts == nil {
+				state.RetryAttempts = make(map[string]int)
+			}
+			state.RetryAttempts[data.DMail] = data.Attempt
+		}
+
 	case domain.EventSpecRegistered:
 		// Tracked by WaveStepProgress Read Model; no counter needed in ExpeditionState.
 
@@ -2,6 +2,8 @@ package session
 
 import (
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/hironow/paintress/internal/domain"
 )
@@ -36,14 +38,37 @@ func (p *ProjectionApplier) Rebuild(events []domain.Event) error {
 	return nil
 }
 
-// Serialize returns the projection state as JSON bytes.
+// Serialize returns the projection state as JSON bytes, tagged with
+// expeditionStateSchemaVersion and the time it was taken.
 func (p *ProjectionApplier) Serialize() ([]byte, error) {
-	return json.Marshal(p.state)
+	return json.Marshal(projectionSnapshot{
+		Schema:  expeditionStateSchemaVersion,
+		TakenAt: time.Now().UTC(),
+		State:   p.state,
+	})
 }
 
-// Deserialize restores projection state from JSON bytes.
+// Deserialize restores projection state from JSON bytes produced by
+// Serialize. Snapshots of another schema version (including the "null"
+// cutover sentinel and pre-versioning snapshots) are rejected with
+// errProjectionSchemaMismatch, leaving the state unchanged.
 func (p *ProjectionApplier) Deserialize(data []byte) error {
-	return json.Unmarshal(data, p.state)
+	_, err := p.restore(data)
+	return err
+}
+
+// restore is Deserialize that also returns when the snapshot was taken.
+func (p *ProjectionApplier) restore(data []byte) (time.Time, error) {
+	var snap projectionSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return time.Time{}, err
+	}
+	if snap.Schema != expeditionStateSchemaVersion || snap.State == nil {
+		return time.Time{}, fmt.Errorf("%w: snapshot schema %d, projection schema %d",
+			errProjectionSchemaMismatch, snap.Schema, expeditionStateSchemaVersion)
+	}
+	p.state = snap.State
+	return snap.TakenAt, nil
 }
 
 // State returns the current materialized projection state.
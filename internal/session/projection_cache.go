@@ -0,0 +1,265 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// ExpeditionStateAggregateType is the snapshot key of the ExpeditionState
+// projection, shared by ProjectionCache, `paintress rebuild` and the
+// SeqNr cutover sentinel.
+const ExpeditionStateAggregateType = "paintress.state"
+
+// expeditionStateSchemaVersion identifies the ExpeditionState layout and
+// applyEvent semantics a snapshot was produced with. Bump it whenever
+// either changes: older snapshots are then ignored (full replay) and
+// overwritten by the next snapshot.
+const expeditionStateSchemaVersion = 2
+
+// projectionSnapshotInterval is the number of events ProjectionCache
+// applies on top of a snapshot (or replays without one) before it saves
+// a fresh snapshot.
+const projectionSnapshotInterval = 100
+
+var errProjectionSchemaMismatch = errors.New("projection snapshot schema mismatch")
+
+// projectionSnapshot is the state payload of an ExpeditionState snapshot.
+type projectionSnapshot struct {
+	Schema  int              `json:"schema"`
+	TakenAt time.Time        `json:"taken_at"`
+	State   *ExpeditionState `json:"state"`
+}
+
+// ProjectionCache serves the ExpeditionState read model from the latest
+// snapshot plus the events appended after its SeqNr watermark
+// (LoadAfterSeqNr), instead of replaying the full history through
+// ProjectState. It falls back to full replay when there is no usable
+// snapshot: none saved yet, the cutover sentinel, a schema mismatch, or
+// events since the snapshot that are not globally sequenced after it.
+type ProjectionCache struct {
+	stateDir  string
+	events    port.EventStore
+	snapshots port.SnapshotStore
+	interval  int
+	logger    domain.Logger
+}
+
+// ProjectionLoad describes how ProjectionCache.State obtained the state.
+type ProjectionLoad struct {
+	FromSnapshot  bool   // state was restored from a snapshot
+	SnapshotSeqNr uint64 // watermark of the snapshot used or saved
+	Applied       int    // events applied on top of the snapshot, or replayed
+	Fallback      string // why a saved snapshot was not used (empty if used or none existed)
+	SnapshotSaved bool   // a fresh snapshot was written
+}
+
+// NewProjectionCache creates a ProjectionCache over the event history
+// (archived segments included, see NewEventHistoryStore), snapshot store
+// and SeqNr counter of stateDir.
+func NewProjectionCache(stateDir string, logger domain.Logger) *ProjectionCache {
+	if logger == nil {
+		logger = &domain.NopLogger{}
+	}
+	return &ProjectionCache{
+		stateDir:  stateDir,
+		events:    NewEventHistoryStore(stateDir, logger),
+		snapshots: NewSnapshotStore(stateDir),
+		interval:  projectionSnapshotInterval,
+		logger:    logger,
+	}
+}
+
+// State returns the current ExpeditionState. Snapshot problems never
+// fail the read: they only select full replay. Event store errors are
+// returned.
+func (c *ProjectionCache) State(ctx context.Context) (*ExpeditionState, ProjectionLoad, error) {
+	applier := NewProjectionApplier()
+	seq, data, err := c.snapshots.Load(ctx, ExpeditionStateAggregateType)
+	var load ProjectionLoad
+	switch {
+	case err != nil:
+		load.Fallback = err.Error()
+	case data != nil:
+		takenAt, restoreErr := applier.restore(data)
+		if restoreErr != nil {
+			load.Fallback = restoreErr.Error()
+			break
+		}
+		state, tailLoad, ok, tailErr := c.applyTail(ctx, applier, seq, takenAt)
+		if tailErr != nil {
+			return nil, tailLoad, tailErr
+		}
+		if ok {
+			return state, tailLoad, nil
+		}
+		load.Fallback = tailLoad.Fallback
+	}
+	if load.Fallback != "" {
+		c.logger.Debug("projection cache: full replay: %s", load.Fallback)
+	}
+	return c.replay(ctx, load)
+}
+
+// GradientLevel returns the current gradient level from State.
+func (c *ProjectionCache) GradientLevel(ctx context.Context) (int, error) {
+	state, _, err := c.State(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return state.GradientLevel, nil
+}
+
+// applyTail brings the restored snapshot up to date. The events after
+// its watermark (LoadAfterSeqNr) must be exactly the events appended
+// after it was taken (LoadSince); anything else — an event without a
+// global SeqNr, a pre-cutover aggregate-local SeqNr, a SeqNr allocated
+// before the snapshot but appended after it — means the snapshot cannot
+// be trusted and ok is false. The events are applied in replay order, so
+// the result equals a full ProjectState.
+func (c *ProjectionCache) applyTail(ctx context.Context, applier *ProjectionApplier, seq uint64, takenAt time.Time) (*ExpeditionState, ProjectionLoad, bool, error) {
+	load := ProjectionLoad{FromSnapshot: true, SnapshotSeqNr: seq}
+	if seq == 0 {
+		return nil, ProjectionLoad{Fallback: "snapshot has no SeqNr watermark"}, false, nil
+	}
+	tail, _, err := c.events.LoadAfterSeqNr(ctx, seq)
+	if err != nil {
+		return nil, load, false, fmt.Errorf("projection cache: load events after SeqNr %d: %w", seq, err)
+	}
+	recent, _, err := c.events.LoadSince(ctx, takenAt)
+	if err != nil {
+		return nil, load, false, fmt.Errorf("projection cache: load recent events: %w", err)
+	}
+	watermark := seq
+	sequenced := make(map[string]bool, len(tail))
+	for _, ev := range tail {
+		sequenced[ev.ID] = true
+		watermark = max(watermark, ev.SeqNr)
+	}
+	for _, ev := range recent {
+		if !sequenced[ev.ID] {
+			return nil, ProjectionLoad{Fallback: fmt.Sprintf("event %s (SeqNr %d) appended after the snapshot is not past its watermark %d", ev.ID, ev.SeqNr, seq)}, false, nil
+		}
+	}
+	if len(recent) != len(tail) {
+		return nil, ProjectionLoad{Fallback: fmt.Sprintf("%d event(s) past the watermark %d predate the snapshot", len(tail)-len(recent), seq)}, false, nil
+	}
+	for _, ev := range recent {
+		_ = applier.Apply(ev)
+	}
+	load.Applied = len(recent)
+	if len(recent) >= c.interval {
+		if load.SnapshotSaved = c.save(ctx, applier, watermark); load.SnapshotSaved {
+			load.SnapshotSeqNr = watermark
+		}
+	}
+	return applier.State(), load, true, nil
+}
+
+// replay folds the full history. When it is long enough and the
+// continent allocates global SeqNrs, the result is saved as a snapshot
+// at min(global counter, highest SeqNr replayed): the counter guards
+// against pre-cutover aggregate-local SeqNrs, the replayed maximum
+// against SeqNrs allocated but not yet appended.
+func (c *ProjectionCache) replay(ctx context.Context, load ProjectionLoad) (*ExpeditionState, ProjectionLoad, error) {
+	all, result, err := c.events.LoadAll(ctx)
+	if err != nil {
+		return nil, load, fmt.Errorf("projection cache: load events: %w", err)
+	}
+	if result.CorruptLineCount > 0 {
+		c.logger.Warn("event store: %d corrupt line(s) skipped", result.CorruptLineCount)
+	}
+	applier := NewProjectionApplier()
+	_ = applier.Rebuild(all)
+	load.Applied = len(all)
+	if len(all) < c.interval {
+		return applier.State(), load, nil
+	}
+	var replayed uint64
+	for _, ev := range all {
+		replayed = max(replayed, ev.SeqNr)
+	}
+	counter, ok := c.latestGlobalSeqNr(ctx)
+	if !ok || replayed == 0 {
+		return applier.State(), load, nil
+	}
+	watermark := min(counter, replayed)
+	if load.SnapshotSaved = c.save(ctx, applier, watermark); load.SnapshotSaved {
+		load.SnapshotSeqNr = watermark
+	}
+	return applier.State(), load, nil
+}
+
+// Compact replays the full history and saves it as a snapshot whatever
+// its length, so the events it covers can leave the live store (see
+// PruneEventFiles). Without global SeqNrs there is no watermark to save
+// at: SnapshotSaved stays false and the state remains derivable by
+// replay, which reads the archive.
+func (c *ProjectionCache) Compact(ctx context.Context) (ProjectionLoad, error) {
+	all, result, err := c.events.LoadAll(ctx)
+	if err != nil {
+		return ProjectionLoad{}, fmt.Errorf("projection cache: load events: %w", err)
+	}
+	if result.CorruptLineCount > 0 {
+		c.logger.Warn("event store: %d corrupt line(s) skipped", result.CorruptLineCount)
+	}
+	applier := NewProjectionApplier()
+	_ = applier.Rebuild(all)
+	load := ProjectionLoad{Applied: len(all)}
+	var replayed uint64
+	for _, ev := range all {
+		replayed = max(replayed, ev.SeqNr)
+	}
+	counter, ok := c.latestGlobalSeqNr(ctx)
+	if !ok || replayed == 0 {
+		return load, nil
+	}
+	watermark := min(counter, replayed)
+	if err := c.saveSnapshot(ctx, applier, watermark); err != nil {
+		return load, fmt.Errorf("projection cache: save snapshot: %w", err)
+	}
+	load.SnapshotSaved, load.SnapshotSeqNr = true, watermark
+	return load, nil
+}
+
+// latestGlobalSeqNr reads seq.db without creating it; ok is false when
+// the continent has no SeqNr counter yet.
+func (c *ProjectionCache) latestGlobalSeqNr(ctx context.Context) (uint64, bool) {
+	if _, err := os.Stat(filepath.Join(c.stateDir, "seq.db")); err != nil {
+		return 0, false
+	}
+	counter, err := NewSeqCounter(c.stateDir)
+	if err != nil {
+		return 0, false
+	}
+	defer func() { _ = counter.Close() }()
+	latest, err := counter.LatestSeqNr(ctx)
+	if err != nil {
+		return 0, false
+	}
+	return latest, true
+}
+
+// save writes a snapshot; failures are logged, not returned, since the
+// state itself is already correct.
+func (c *ProjectionCache) save(ctx context.Context, applier *ProjectionApplier, watermark uint64) bool {
+	if err := c.saveSnapshot(ctx, applier, watermark); err != nil {
+		c.logger.Warn("projection cache: save snapshot: %v", err)
+		return false
+	}
+	return true
+}
+
+func (c *ProjectionCache) saveSnapshot(ctx context.Context, applier *ProjectionApplier, watermark uint64) error {
+	data, err := applier.Serialize()
+	if err != nil {
+		return err
+	}
+	return c.snapshots.Save(ctx, ExpeditionStateAggregateType, watermark, data)
+}
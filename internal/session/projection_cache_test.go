@@ -0,0 +1,152 @@
+package session_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+// appendCompleted appends an expedition.completed event at "at", with a
+// global SeqNr from seq.db when sequenced is true.
+func appendCompleted(t *testing.T, stateDir string, expedition int, status string, at time.Time, sequenced bool) domain.Event {
+	t.Helper()
+	ctx := context.Background()
+	ev, err := domain.NewEvent(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: expedition, Status: status}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sequenced {
+		counter, err := session.NewSeqCounter(stateDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer counter.Close()
+		if ev.SeqNr, err = counter.AllocSeqNr(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := session.NewEventStore(stateDir, &domain.NopLogger{}).Append(ctx, ev); err != nil {
+		t.Fatal(err)
+	}
+	return ev
+}
+
+func fullReplay(t *testing.T, stateDir string) *session.ExpeditionState {
+	t.Helper()
+	events, _, err := session.NewEventStore(stateDir, &domain.NopLogger{}).LoadAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session.ProjectState(events)
+}
+
+func TestProjectionCache_SnapshotPlusTailMatchesFullReplay(t *testing.T) {
+	// given: three sequenced events, enough for a snapshot at interval 3
+	stateDir := filepath.Join(t.TempDir(), domain.StateDir)
+	past := time.Now().Add(-time.Hour)
+	for i, status := range []string{"success", "failed", "skipped"} {
+		appendCompleted(t, stateDir, i+1, status, past.Add(time.Duration(i)*time.Second), true)
+	}
+	cache := session.ExportNewProjectionCacheWithInterval(stateDir, 3)
+	_, first, err := cache.State(context.Background())
+	if err != nil || first.FromSnapshot || !first.SnapshotSaved || first.SnapshotSeqNr != 3 {
+		t.Fatalf("first load = %+v, %v; want full replay saving a snapshot at SeqNr 3", first, err)
+	}
+	appendCompleted(t, stateDir, 4, "success", time.Now().Add(time.Second), true)
+
+	// when
+	state, load, err := cache.State(context.Background())
+
+	// then
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+	if !load.FromSnapshot || load.Applied != 1 || load.SnapshotSaved {
+		t.Errorf("load = %+v, want snapshot plus one tail event", load)
+	}
+	if want := fullReplay(t, stateDir); !reflect.DeepEqual(state, want) {
+		t.Errorf("state = %+v, want %+v", state, want)
+	}
+}
+
+func TestProjectionCache_SchemaMismatchFallsBackToReplay(t *testing.T) {
+	// given: a snapshot written by an older projection layout
+	stateDir := filepath.Join(t.TempDir(), domain.StateDir)
+	appendCompleted(t, stateDir, 1, "success", time.Now().Add(-time.Minute), true)
+	stale := []byte(`{"total_expeditions":42}`)
+	if err := session.NewSnapshotStore(stateDir).Save(context.Background(), session.ExpeditionStateAggregateType, 1, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	state, load, err := session.NewProjectionCache(stateDir, &domain.NopLogger{}).State(context.Background())
+
+	// then
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+	if load.FromSnapshot || !strings.Contains(load.Fallback, "schema") {
+		t.Errorf("load = %+v, want full replay on schema mismatch", load)
+	}
+	if state.TotalExpeditions != 1 {
+		t.Errorf("TotalExpeditions = %d, want 1 (stale snapshot ignored)", state.TotalExpeditions)
+	}
+}
+
+func TestProjectionCache_UnsequencedEventFallsBackToReplay(t *testing.T) {
+	// given: a snapshot, then an event appended without a global SeqNr
+	stateDir := filepath.Join(t.TempDir(), domain.StateDir)
+	appendCompleted(t, stateDir, 1, "success", time.Now().Add(-time.Minute), true)
+	cache := session.ExportNewProjectionCacheWithInterval(stateDir, 1)
+	if _, first, err := cache.State(context.Background()); err != nil || !first.SnapshotSaved {
+		t.Fatalf("first load = %+v, %v; want a snapshot", first, err)
+	}
+	appendCompleted(t, stateDir, 2, "failed", time.Now().Add(time.Second), false)
+
+	// when
+	state, load, err := cache.State(context.Background())
+
+	// then
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+	if load.FromSnapshot || load.Fallback == "" {
+		t.Errorf("load = %+v, want full replay", load)
+	}
+	if want := fullReplay(t, stateDir); !reflect.DeepEqual(state, want) {
+		t.Errorf("state = %+v, want %+v", state, want)
+	}
+}
+
+func TestProjectionCache_RefreshesSnapshotEveryInterval(t *testing.T) {
+	// given: a snapshot at SeqNr 1, then two more sequenced events
+	stateDir := filepath.Join(t.TempDir(), domain.StateDir)
+	appendCompleted(t, stateDir, 1, "success", time.Now().Add(-time.Minute), true)
+	cache := session.ExportNewProjectionCacheWithInterval(stateDir, 1)
+	if _, first, err := cache.State(context.Background()); err != nil || first.SnapshotSeqNr != 1 {
+		t.Fatalf("first load = %+v, %v; want a snapshot at SeqNr 1", first, err)
+	}
+	appendCompleted(t, stateDir, 2, "success", time.Now().Add(time.Second), true)
+	appendCompleted(t, stateDir, 3, "failed", time.Now().Add(2*time.Second), true)
+
+	// when
+	_, load, err := cache.State(context.Background())
+	seq, _, loadErr := session.NewSnapshotStore(stateDir).Load(context.Background(), session.ExpeditionStateAggregateType)
+
+	// then
+	if err != nil || loadErr != nil {
+		t.Fatalf("State: %v, snapshot: %v", err, loadErr)
+	}
+	if !load.FromSnapshot || load.Applied != 2 || !load.SnapshotSaved {
+		t.Errorf("load = %+v, want two tail events and a fresh snapshot", load)
+	}
+	if seq != 3 {
+		t.Errorf("snapshot SeqNr = %d, want 3", seq)
+	}
+}
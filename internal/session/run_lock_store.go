@@ -3,12 +3,14 @@ package session
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hironow/paintress/internal/domain"
 	"github.com/hironow/paintress/internal/usecase/port"
 
 	_ "modernc.org/sqlite"
@@ -18,7 +20,8 @@ import (
 var _ port.RunLockStore = (*SQLiteRunLockStore)(nil)
 
 // SQLiteRunLockStore implements RunLockStore using SQLite WAL.
-// Provides cross-process run locking with automatic stale lock cleanup.
+// Provides cross-process run locking with automatic stale lock cleanup,
+// plus durable expedition number reservations (start_expedition).
 type SQLiteRunLockStore struct {
 	db       *sql.DB
 	holderID string
@@ -41,6 +44,10 @@ func NewSQLiteRunLockStore(dbPath string) (*SQLiteRunLockStore, error) {
 		_ = db.Close()
 		return nil, fmt.Errorf("run lock store: set WAL: %w", err)
 	}
+	if _, err := db.Exec(`PRAGMA busy_timeout=5000`); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("run lock store: set busy_timeout: %w", err)
+	}
 
 	if err := createRunLockSchema(db); err != nil {
 		_ = db.Close()
@@ -53,13 +60,48 @@ func NewSQLiteRunLockStore(dbPath string) (*SQLiteRunLockStore, error) {
 	}, nil
 }
 
+// NewRunLockStoreForDir opens the run lock store at the conventional
+// path derived from the continent directory: .expedition/.run/run_locks.db.
+func NewRunLockStoreForDir(continent string) (*SQLiteRunLockStore, error) {
+	return NewSQLiteRunLockStore(runLockStorePath(continent))
+}
+
+func runLockStorePath(continent string) string {
+	return filepath.Join(domain.RunDir(continent), "run_locks.db")
+}
+
 func createRunLockSchema(db *sql.DB) error {
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS run_locks (
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS run_locks (
 		key         TEXT PRIMARY KEY,
 		holder      TEXT NOT NULL,
 		acquired_at TEXT NOT NULL,
 		expires_at  TEXT NOT NULL
-	)`)
+	)`,
+		`CREATE TABLE IF NOT EXISTS expedition_reservations (
+		expedition  INTEGER PRIMARY KEY,
+		issue_id    TEXT NOT NULL,
+		reserved_at TEXT NOT NULL,
+		closed_at   TEXT
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_expedition_reservations_issue ON expedition_reservations(issue_id)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return migrateReservationColumns(db)
+}
+
+// migrateReservationColumns adds closed_at to reservation tables created
+// before reservations were closed on journaling.
+func migrateReservationColumns(db *sql.DB) error {
+	var found int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('expedition_reservations') WHERE name = 'closed_at'`).Scan(&found)
+	if err != nil || found > 0 {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE expedition_reservations ADD COLUMN closed_at TEXT`)
 	return err
 }
 
@@ -139,6 +181,81 @@ func (s *SQLiteRunLockStore) IsHeld(ctx context.Context, runKey string) (bool, s
 	return true, holder, nil
 }
 
+// ClaimExpedition returns the open reservation of issueID (reused=true)
+// or, when it holds none, allocates the next number for it: one past the
+// highest reserved number or floor, whichever is larger. floor carries
+// numbers already used before reservations existed (journal /
+// pr-index). A reservation is open until CloseExpedition or
+// until it is older than domain.ExpeditionReservationTTL at now. The
+// reuse check and the allocation are one INSERT ... WHERE NOT EXISTS
+// statement, so concurrent claims for the same issue share one number.
+func (s *SQLiteRunLockStore) ClaimExpedition(ctx context.Context, issueID string, floor int, now time.Time) (expedition int, reused bool, err error) {
+	cutoff := now.Add(-domain.ExpeditionReservationTTL).UTC().Format(time.RFC3339Nano)
+	// The open reservation can be closed between the two statements; the
+	// next round then allocates.
+	for range 3 {
+		err = s.db.QueryRowContext(ctx,
+			`INSERT INTO expedition_reservations (expedition, issue_id, reserved_at)
+			 SELECT MAX(COALESCE((SELECT MAX(expedition) FROM expedition_reservations), 0), ?) + 1, ?, ?
+			 WHERE NOT EXISTS (SELECT 1 FROM expedition_reservations
+			                   WHERE issue_id = ? AND closed_at IS NULL AND julianday(reserved_at) > julianday(?))
+			 RETURNING expedition`,
+			floor, issueID, now.UTC().Format(time.RFC3339Nano), issueID, cutoff).Scan(&expedition)
+		if err == nil {
+			return expedition, false, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, false, fmt.Errorf("run lock: claim expedition: %w", err)
+		}
+		err = s.db.QueryRowContext(ctx,
+			`SELECT expedition FROM expedition_reservations
+			 WHERE issue_id = ? AND closed_at IS NULL AND julianday(reserved_at) > julianday(?)
+			 ORDER BY expedition DESC LIMIT 1`,
+			issueID, cutoff).Scan(&expedition)
+		if err == nil {
+			return expedition, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, false, fmt.Errorf("run lock: claim expedition: %w", err)
+		}
+	}
+	return 0, false, fmt.Errorf("run lock: claim expedition: reservation for %s kept changing", issueID)
+}
+
+// CloseExpedition marks the reservation of expedition as journaled, so
+// ClaimExpedition no longer resumes it. Closing an unknown or already
+// closed number is a no-op.
+func (s *SQLiteRunLockStore) CloseExpedition(ctx context.Context, expedition int) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE expedition_reservations SET closed_at = ? WHERE expedition = ? AND closed_at IS NULL`,
+		time.Now().UTC().Format(time.RFC3339Nano), expedition)
+	if err != nil {
+		return fmt.Errorf("run lock: close expedition: %w", err)
+	}
+	return nil
+}
+
+// ExpeditionReservations lists every reservation ordered by number.
+func (s *SQLiteRunLockStore) ExpeditionReservations(ctx context.Context) ([]domain.ExpeditionReservation, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT expedition, issue_id, reserved_at, closed_at IS NOT NULL FROM expedition_reservations ORDER BY expedition`)
+	if err != nil {
+		return nil, fmt.Errorf("run lock: list reservations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var out []domain.ExpeditionReservation
+	for rows.Next() {
+		var r domain.ExpeditionReservation
+		var reservedAt string
+		if err := rows.Scan(&r.Expedition, &r.IssueID, &reservedAt, &r.Closed); err != nil {
+			return nil, fmt.Errorf("run lock: scan reservation: %w", err)
+		}
+		r.ReservedAt, _ = time.Parse(time.RFC3339Nano, reservedAt)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
 // Close releases database resources.
 func (s *SQLiteRunLockStore) Close() error {
 	return s.db.Close()
@@ -3,9 +3,11 @@ package session_test
 import (
 	"context"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/hironow/paintress/internal/domain"
 	"github.com/hironow/paintress/internal/session"
 )
 
@@ -131,3 +133,118 @@ func TestSQLiteRunLockStore_IsHeld_NotHeld(t *testing.T) {
 		t.Errorf("expected empty holder, got %q", holder)
 	}
 }
+
+func TestSQLiteRunLockStore_ClaimExpedition_RespectsFloorAndIncrements(t *testing.T) {
+	// given
+	store, err := session.NewSQLiteRunLockStore(filepath.Join(t.TempDir(), ".run", "run_locks.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	// when
+	first, _, err := store.ClaimExpedition(ctx, "MY-1", 4, time.Now())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	second, _, err := store.ClaimExpedition(ctx, "MY-2", 0, time.Now())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	// then
+	if first != 5 || second != 6 {
+		t.Errorf("claimed %d, %d; want 5, 6", first, second)
+	}
+	reservations, err := store.ExpeditionReservations(ctx)
+	if err != nil || len(reservations) != 2 || reservations[0].IssueID != "MY-1" {
+		t.Errorf("reservations = %+v (%v), want 5 reserved for MY-1", reservations, err)
+	}
+}
+
+func TestSQLiteRunLockStore_ClaimExpedition_ConcurrentClaimsShareNumber(t *testing.T) {
+	// given: two stores on the same DB (= two mcp processes)
+	dbPath := filepath.Join(t.TempDir(), ".run", "run_locks.db")
+	a, err := session.NewSQLiteRunLockStore(dbPath)
+	if err != nil {
+		t.Fatalf("open a: %v", err)
+	}
+	defer a.Close()
+	b, err := session.NewSQLiteRunLockStore(dbPath)
+	if err != nil {
+		t.Fatalf("open b: %v", err)
+	}
+	defer b.Close()
+
+	// when: both claim the same issue at once
+	var mu sync.Mutex
+	seen := make(map[int]int)
+	fresh := 0
+	var wg sync.WaitGroup
+	for i := range 20 {
+		store := a
+		if i%2 == 1 {
+			store = b
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, reused, err := store.ClaimExpedition(context.Background(), "MY-1", 0, time.Now())
+			if err != nil {
+				t.Errorf("claim: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			seen[n]++
+			if !reused {
+				fresh++
+			}
+		}()
+	}
+	wg.Wait()
+
+	// then
+	if len(seen) != 1 || fresh != 1 {
+		t.Errorf("numbers = %v with %d fresh, want one number allocated once", seen, fresh)
+	}
+}
+
+func TestSQLiteRunLockStore_ClaimExpedition_ClosedOrExpiredIsNotReused(t *testing.T) {
+	// given
+	store, err := session.NewSQLiteRunLockStore(filepath.Join(t.TempDir(), ".run", "run_locks.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	now := time.Now()
+	closed, _, _ := store.ClaimExpedition(ctx, "MY-1", 0, now)
+	if err := store.CloseExpedition(ctx, closed); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	abandoned, _, _ := store.ClaimExpedition(ctx, "MY-2", 0, now)
+
+	// when
+	afterClose, closedReused, err := store.ClaimExpedition(ctx, "MY-1", 0, now)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	afterExpiry, expiredReused, err := store.ClaimExpedition(ctx, "MY-2", 0, now.Add(domain.ExpeditionReservationTTL+time.Minute))
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	// then
+	if closedReused || afterClose == closed {
+		t.Errorf("after close got %d (reused %v), want a number past %d", afterClose, closedReused, closed)
+	}
+	if expiredReused || afterExpiry == abandoned {
+		t.Errorf("after expiry got %d (reused %v), want a number past %d", afterExpiry, expiredReused, abandoned)
+	}
+	reservations, err := store.ExpeditionReservations(ctx)
+	if err != nil || len(reservations) != 4 || !reservations[0].Closed {
+		t.Errorf("reservations = %+v (%v), want 4 with the first closed", reservations, err)
+	}
+}
@@ -37,6 +37,56 @@ func (s *SpanEventStore) Append(ctx context.Context, events ...domain.Event) (do
 	return result, nil
 }
 
+func (s *SpanEventStore) AppendExpecting(ctx context.Context, expected domain.ExpectedVersion, events ...domain.Event) (domain.AppendResult, error) {
+	ctx, span := platform.Tracer.Start(ctx, "eventsource.append")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("event.count.in", len(events)),
+		attribute.Int64("event.expected_version", int64(expected)),
+	)
+	result, err := s.inner.AppendExpecting(ctx, expected, events...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(attribute.String("error.stage", "eventsource.append"))
+		return result, err
+	}
+	span.SetAttributes(attribute.Int("event.append.bytes", result.BytesWritten))
+	return result, nil
+}
+
+func (s *SpanEventStore) AppendAllocating(ctx context.Context, expected domain.ExpectedVersion, alloc func(context.Context) (uint64, error), events ...domain.Event) (domain.AppendResult, error) {
+	ctx, span := platform.Tracer.Start(ctx, "eventsource.append")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("event.count.in", len(events)),
+		attribute.Int64("event.expected_version", int64(expected)),
+	)
+	result, err := s.inner.AppendAllocating(ctx, expected, alloc, events...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(attribute.String("error.stage", "eventsource.append"))
+		return result, err
+	}
+	span.SetAttributes(attribute.Int("event.append.bytes", result.BytesWritten))
+	return result, nil
+}
+
+func (s *SpanEventStore) StreamVersion(ctx context.Context, stream string) (uint64, error) {
+	ctx, span := platform.Tracer.Start(ctx, "eventsource.stream_version")
+	defer span.End()
+
+	version, err := s.inner.StreamVersion(ctx, stream)
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(attribute.String("error.stage", "eventsource.stream_version"))
+		return 0, err
+	}
+	span.SetAttributes(attribute.Int64("event.stream_version", int64(version)))
+	return version, nil
+}
+
 func (s *SpanEventStore) LoadAll(ctx context.Context) ([]domain.Event, domain.LoadResult, error) {
 	ctx, span := platform.Tracer.Start(ctx, "eventsource.load_all")
 	defer span.End()
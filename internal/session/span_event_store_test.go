@@ -49,6 +49,18 @@ func (s *stubEventStore) Append(_ context.Context, _ ...domain.Event) (domain.Ap
 	return s.appendResult, nil
 }
 
+func (s *stubEventStore) AppendExpecting(_ context.Context, _ domain.ExpectedVersion, _ ...domain.Event) (domain.AppendResult, error) {
+	return s.appendResult, nil
+}
+
+func (s *stubEventStore) AppendAllocating(_ context.Context, _ domain.ExpectedVersion, _ func(context.Context) (uint64, error), _ ...domain.Event) (domain.AppendResult, error) {
+	return s.appendResult, nil
+}
+
+func (s *stubEventStore) StreamVersion(_ context.Context, _ string) (uint64, error) {
+	return 0, nil
+}
+
 func (s *stubEventStore) LoadAll(_ context.Context) ([]domain.Event, domain.LoadResult, error) {
 	return s.loadEvents, s.loadResult, nil
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hironow/paintress/internal/domain"
@@ -14,6 +15,16 @@ import (
 // Status collects current operational status from the event store and filesystem.
 // baseDir is the repository root (the "continent" containing .expedition/).
 func Status(ctx context.Context, baseDir string, logger domain.Logger) domain.StatusReport {
+	report, _ := statusWithState(ctx, baseDir, logger)
+	return report
+}
+
+// statusWithState builds the StatusReport and also returns the projected
+// ExpeditionState it was derived from, so get_status can embed it without
+// projecting twice. Expedition stats come from the ProjectionCache
+// (snapshot plus tail) rather than a full replay; they stay zero when the
+// store is empty or unreadable.
+func statusWithState(ctx context.Context, baseDir string, logger domain.Logger) (domain.StatusReport, *ExpeditionState) {
 	report := domain.StatusReport{
 		Continent: baseDir,
 	}
@@ -26,36 +37,30 @@ func Status(ctx context.Context, baseDir string, logger domain.Logger) domain.St
 	// Count archive files
 	report.ArchiveCount = countDirFiles(domain.ArchiveDir(baseDir))
 
-	// Load all events for expedition stats
-	store := NewEventStore(stateDir, logger)
-
-	allEvents, loadResult, err := store.LoadAll(ctx)
+	state, _, err := NewProjectionCache(stateDir, logger).State(ctx)
 	if err != nil {
-		return report
-	}
-	if loadResult.CorruptLineCount > 0 {
-		logger.Warn("event store: %d corrupt line(s) skipped", loadResult.CorruptLineCount)
-	}
-	if len(allEvents) == 0 {
-		return report
+		return report, &ExpeditionState{}
 	}
+	applyStateToReport(&report, state)
+	return report, state
+}
 
-	// Project state from events
-	state := ProjectState(allEvents)
+// applyStateToReport copies the expedition stats of state into report.
+func applyStateToReport(report *domain.StatusReport, state *ExpeditionState) {
 	report.Expeditions = state.TotalExpeditions
 	report.Successes = state.Succeeded
 	report.Failures = state.Failed
 	report.GradientLevel = state.GradientLevel
 	report.LastExpedition = state.LastExpeditionAt
-
-	// Compute success rate using the domain package pure function
-	report.SuccessRate = domain.SuccessRate(allEvents)
-
-	return report
+	report.SuccessRate = state.SuccessRate()
 }
 
 func applyLatestProviderMetadata(ctx context.Context, stateDir string, report *domain.StatusReport) {
 	dbPath := filepath.Join(stateDir, ".run", "sessions.db")
+	// Check DB file exists before opening (status is read-only; avoid creating dirs/DB as side effect)
+	if _, err := os.Stat(dbPath); err != nil {
+		return
+	}
 	store, err := NewSQLiteCodingSessionStore(dbPath)
 	if err != nil {
 		return
@@ -82,6 +87,7 @@ func applyLatestProviderMetadata(ctx context.Context, stateDir string, report *d
 }
 
 // countDirFiles returns the number of non-directory entries in the given directory.
+// An envelope's body file is not counted: the envelope stands for both.
 // Returns 0 if the directory does not exist or cannot be read.
 func countDirFiles(dir string) int {
 	entries, err := os.ReadDir(dir)
@@ -90,7 +96,7 @@ func countDirFiles(dir string) int {
 	}
 	count := 0
 	for _, e := range entries {
-		if !e.IsDir() {
+		if !e.IsDir() && !strings.HasSuffix(e.Name(), domain.EnvelopeBodyExt) {
 			count++
 		}
 	}
@@ -0,0 +1,152 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+)
+
+// StatusSnapshot is the status read model as it stood at a cut-off:
+// ExpeditionState, the pending wave steps of WaveStepProgress and the
+// windowed success rate, all projected from the events recorded up to it
+// (archived segments included). Inbox, archive and provider state live
+// outside the event store and have no history, so a snapshot omits them.
+type StatusSnapshot struct {
+	AsOf                string           `json:"as_of"`
+	Cutoff              time.Time        `json:"cutoff"`
+	Events              int              `json:"events"`
+	State               *ExpeditionState `json:"state"`
+	SuccessRate         float64          `json:"success_rate"`
+	Window              int              `json:"window"`
+	WindowedSuccessRate float64          `json:"windowed_success_rate"`
+	Trend               string           `json:"trend"`
+	PendingSteps        []string         `json:"pending_steps"`
+}
+
+// StatusChange is one field that differs between two snapshots.
+type StatusChange struct { // nosemgrep: structure.multiple-exported-structs-go -- change row of StatusDiff; one read model [permanent]
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// StatusDiff is what changed between two snapshots: the differing
+// fields, the wave steps completed (pending before, not after) and the
+// steps registered in between (pending after, unknown before).
+type StatusDiff struct { // nosemgrep: structure.multiple-exported-structs-go -- diff of two StatusSnapshots; one read model [permanent]
+	From           StatusSnapshot `json:"from"`
+	To             StatusSnapshot `json:"to"`
+	Changes        []StatusChange `json:"changes"`
+	StepsCompleted []string       `json:"steps_completed"`
+	StepsAdded     []string       `json:"steps_added"`
+}
+
+// StatusAsOf projects the status of baseDir at asOf. window is the
+// number of completed expeditions per success-rate window (<= 0 selects
+// the get_status default).
+func StatusAsOf(ctx context.Context, baseDir string, asOf domain.AsOf, window int, logger domain.Logger) (StatusSnapshot, error) {
+	events, err := loadStatusHistory(ctx, baseDir, logger)
+	if err != nil {
+		return StatusSnapshot{}, err
+	}
+	return projectStatusAsOf(events, asOf, window)
+}
+
+// DiffStatus projects the status of baseDir at from and at to and
+// compares them.
+func DiffStatus(ctx context.Context, baseDir string, from, to domain.AsOf, window int, logger domain.Logger) (StatusDiff, error) {
+	events, err := loadStatusHistory(ctx, baseDir, logger)
+	if err != nil {
+		return StatusDiff{}, err
+	}
+	a, err := projectStatusAsOf(events, from, window)
+	if err != nil {
+		return StatusDiff{}, err
+	}
+	b, err := projectStatusAsOf(events, to, window)
+	if err != nil {
+		return StatusDiff{}, err
+	}
+	return diffSnapshots(a, b), nil
+}
+
+func loadStatusHistory(ctx context.Context, baseDir string, logger domain.Logger) ([]domain.Event, error) {
+	events, _, err := NewEventHistoryStore(filepath.Join(baseDir, domain.StateDir), logger).LoadAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load event history: %w", err)
+	}
+	return events, nil
+}
+
+func projectStatusAsOf(events []domain.Event, asOf domain.AsOf, window int) (StatusSnapshot, error) {
+	cutoff, err := asOf.Cutoff(events)
+	if err != nil {
+		return StatusSnapshot{}, err
+	}
+	if window <= 0 {
+		window = defaultStatusTrendWindow
+	}
+	past := domain.EventsAsOf(events, cutoff)
+	state := ProjectState(past)
+	pending := make([]string, 0)
+	for _, target := range domain.ProjectWaveStepProgress(past).PendingTargets() {
+		pending = append(pending, target.ID)
+	}
+	return StatusSnapshot{
+		AsOf:                asOf.String(),
+		Cutoff:              cutoff,
+		Events:              len(past),
+		State:               state,
+		SuccessRate:         state.SuccessRate(),
+		Window:              window,
+		WindowedSuccessRate: domain.WindowedSuccessRate(past, window),
+		Trend:               string(domain.DetectSuccessRateTrend(past, window)),
+		PendingSteps:        pending,
+	}, nil
+}
+
+func diffSnapshots(a, b StatusSnapshot) StatusDiff {
+	diff := StatusDiff{From: a, To: b, Changes: make([]StatusChange, 0), StepsCompleted: make([]string, 0), StepsAdded: make([]string, 0)}
+	fields := []struct {
+		name     string
+		from, to any
+	}{
+		{"events", a.Events, b.Events},
+		{"total_expeditions", a.State.TotalExpeditions, b.State.TotalExpeditions},
+		{"succeeded", a.State.Succeeded, b.State.Succeeded},
+		{"failed", a.State.Failed, b.State.Failed},
+		{"skipped", a.State.Skipped, b.State.Skipped},
+		{"last_expedition", a.State.LastExpedition, b.State.LastExpedition},
+		{"last_status", a.State.LastStatus, b.State.LastStatus},
+		{"last_issue_id", a.State.LastIssueID, b.State.LastIssueID},
+		{"consecutive_failures", a.State.ConsecutiveFailures, b.State.ConsecutiveFailures},
+		{"gommage_count", a.State.GommageCount, b.State.GommageCount},
+		{"gradient_level", a.State.GradientLevel, b.State.GradientLevel},
+		{"dmails_staged", a.State.DMailsStaged, b.State.DMailsStaged},
+		{"dmails_flushed", a.State.DMailsFlushed, b.State.DMailsFlushed},
+		{"inbox_received", a.State.InboxReceived, b.State.InboxReceived},
+		{"success_rate", a.SuccessRate, b.SuccessRate},
+		{"windowed_success_rate", a.WindowedSuccessRate, b.WindowedSuccessRate},
+		{"trend", a.Trend, b.Trend},
+	}
+	for _, f := range fields {
+		if f.from != f.to {
+			diff.Changes = append(diff.Changes, StatusChange{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+	for _, id := range a.PendingSteps {
+		if !slices.Contains(b.PendingSteps, id) {
+			diff.StepsCompleted = append(diff.StepsCompleted, id)
+		}
+	}
+	for _, id := range b.PendingSteps {
+		if !slices.Contains(a.PendingSteps, id) {
+			diff.StepsAdded = append(diff.StepsAdded, id)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,137 @@
+package session_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+// appendWaveHistory records wave "auth" (steps s1, s2) and three
+// expeditions: #1 completes auth:s1, #2 fails, #3 completes auth:s2.
+func appendWaveHistory(t *testing.T, continent string) {
+	t.Helper()
+	base := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	mk := func(typ domain.EventType, data any, at time.Time) domain.Event {
+		ev, err := domain.NewEvent(typ, data, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ev
+	}
+	events := []domain.Event{
+		mk(domain.EventSpecRegistered, domain.SpecRegisteredData{WaveID: "auth", Steps: []domain.WaveStepDef{{ID: "s1", Title: "Login"}, {ID: "s2", Title: "Logout"}}}, base),
+		mk(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 1}, base.Add(time.Hour)),
+		mk(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 1, Status: "success", IssueID: "MY-1", WaveID: "auth", StepID: "s1"}, base.Add(2*time.Hour)),
+		mk(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 2}, base.Add(3*time.Hour)),
+		mk(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 2, Status: "failed", IssueID: "MY-2"}, base.Add(4*time.Hour)),
+		mk(domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 3}, base.Add(5*time.Hour)),
+		mk(domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 3, Status: "success", IssueID: "MY-3", WaveID: "auth", StepID: "s2"}, base.Add(6*time.Hour)),
+	}
+	store := session.NewEventStore(filepath.Join(continent, domain.StateDir), nil)
+	if _, err := store.Append(context.Background(), events...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatusAsOf_ProjectsUpToExpedition(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	appendWaveHistory(t, continent)
+
+	// when
+	snap, err := session.StatusAsOf(context.Background(), continent, domain.AsOf{Expedition: 1}, 0, nil)
+
+	// then: only expedition 1 and the spec are visible
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.State.TotalExpeditions != 1 || snap.State.Succeeded != 1 || snap.WindowedSuccessRate != 1 {
+		t.Errorf("snapshot = %+v, state = %+v", snap, snap.State)
+	}
+	if !slices.Equal(snap.PendingSteps, []string{"auth:s2"}) {
+		t.Errorf("PendingSteps = %v, want [auth:s2]", snap.PendingSteps)
+	}
+	if snap.Events != 3 || snap.AsOf != "expedition 1" {
+		t.Errorf("Events = %d, AsOf = %q", snap.Events, snap.AsOf)
+	}
+}
+
+func TestDiffStatus_ReportsChangedFieldsAndSteps(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	appendWaveHistory(t, continent)
+
+	// when
+	diff, err := session.DiffStatus(context.Background(), continent, domain.AsOf{Expedition: 1}, domain.AsOf{Expedition: 3}, 0, nil)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := map[string]session.StatusChange{}
+	for _, c := range diff.Changes {
+		changed[c.Field] = c
+	}
+	if c := changed["total_expeditions"]; c.From != 1 || c.To != 3 {
+		t.Errorf("total_expeditions change = %+v", c)
+	}
+	if c := changed["failed"]; c.From != 0 || c.To != 1 {
+		t.Errorf("failed change = %+v", c)
+	}
+	if _, ok := changed["gradient_level"]; ok {
+		t.Error("unchanged gradient_level reported as a change")
+	}
+	if !slices.Equal(diff.StepsCompleted, []string{"auth:s2"}) || len(diff.StepsAdded) != 0 {
+		t.Errorf("StepsCompleted = %v, StepsAdded = %v", diff.StepsCompleted, diff.StepsAdded)
+	}
+}
+
+func TestMCPServer_GetStatusAndNextIssue_AsOf(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	appendWaveHistory(t, continent)
+
+	// when
+	status := callTool(t, continent, nil, "get_status", `{"as_of":"expedition 2"}`)
+	next := callTool(t, continent, nil, "next_issue", `{"as_of":"expedition 2"}`)
+
+	// then
+	if state := status["state"].(map[string]any); state["total_expeditions"] != float64(2) || state["failed"] != float64(1) {
+		t.Errorf("get_status state = %v", state)
+	}
+	if report := status["report"].(map[string]any); report["expeditions"] != float64(2) {
+		t.Errorf("get_status report = %v", report)
+	}
+	if asOf, ok := status["as_of"].(map[string]any); !ok || asOf["as_of"] != "expedition 2" {
+		t.Errorf("get_status as_of = %v", status["as_of"])
+	}
+	if next["next_expedition_number"] != float64(3) {
+		t.Errorf("next_expedition_number = %v, want 3", next["next_expedition_number"])
+	}
+	if ids := next["completed_issue_ids"].([]any); len(ids) != 1 || ids[0] != "MY-1" {
+		t.Errorf("completed_issue_ids = %v, want [MY-1]", ids)
+	}
+	if steps := next["as_of"].(map[string]any)["pending_steps"].([]any); len(steps) != 1 || steps[0] != "auth:s2" {
+		t.Errorf("pending_steps = %v", steps)
+	}
+}
+
+func TestMCPServer_GetStatus_AsOfRejectsBadCutoff(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	appendWaveHistory(t, continent)
+
+	// when
+	bad := callToolResult(t, continent, "get_status", `{"as_of":"last tuesday"}`)
+	missing := callToolResult(t, continent, "next_issue", `{"as_of":"expedition 9"}`)
+
+	// then
+	if bad["isError"] != true || missing["isError"] != true {
+		t.Errorf("bad = %v, missing = %v; want isError results", bad, missing)
+	}
+}
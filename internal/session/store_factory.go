@@ -2,9 +2,11 @@ package session
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 
@@ -24,12 +26,113 @@ func EnsureRunDir(stateDir string) error {
 	return nil
 }
 
-// NewEventStore creates an event store for the given state directory.
+// NewEventStore creates an event store for the given state directory,
+// using the backend selected by event_store in the project config
+// (JSONL daily files by default, or SQLite). Loaded events are upcast to
+// the current schema version of their type.
 // eventsource is the event persistence adapter (AWS Event Sourcing pattern).
 // cmd layer should use this instead of importing eventsource directly (ADR S0008).
 func NewEventStore(stateDir string, logger domain.Logger) port.EventStore {
-	raw := eventsource.NewFileEventStore(eventsource.EventsDir(stateDir), logger)
-	return NewSpanEventStore(raw)
+	return NewSpanEventStore(newUpcastEventStore(rawEventStore(stateDir, logger), logger))
+}
+
+// rawEventStore returns the uninstrumented store for the configured backend.
+func rawEventStore(stateDir string, logger domain.Logger) port.EventStore {
+	if EventStoreBackend(stateDir) == domain.EventStoreSQLite {
+		return eventsource.NewSQLiteEventStore(eventsource.EventsDBPath(stateDir), logger)
+	}
+	return eventsource.NewFileEventStore(eventsource.EventsDir(stateDir), logger)
+}
+
+// EventStoreBackend returns the event store backend configured for the
+// continent owning stateDir. An unreadable config falls back to JSONL,
+// the backend every continent starts on.
+func EventStoreBackend(stateDir string) string {
+	cfg, err := LoadProjectConfig(filepath.Dir(stateDir))
+	if err != nil {
+		return domain.EventStoreJSONL
+	}
+	return cfg.EventStoreBackend()
+}
+
+// EventMigrationResult reports what MigrateEventStore did.
+type EventMigrationResult struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Events int    `json:"events"`
+	Backup string `json:"backup,omitempty"`
+}
+
+// MigrateEventStore converts the continent's events to the to backend:
+// it copies and verifies every event, switches event_store in the
+// project config, and finally moves the old store aside as a timestamped
+// backup. A failed config switch removes the copy again, leaving the
+// continent as it was.
+func MigrateEventStore(ctx context.Context, continent, to string, logger domain.Logger) (EventMigrationResult, error) {
+	if !domain.ValidEventStoreBackend(to) {
+		return EventMigrationResult{}, fmt.Errorf("invalid event store backend %q: must be %s or %s", to, domain.EventStoreJSONL, domain.EventStoreSQLite)
+	}
+	cfg, err := LoadProjectConfig(continent)
+	if err != nil {
+		return EventMigrationResult{}, fmt.Errorf("load config: %w", err)
+	}
+	stateDir := filepath.Join(continent, domain.StateDir)
+	result := EventMigrationResult{From: cfg.EventStoreBackend(), To: to}
+
+	n, err := eventsource.MigrateEvents(ctx, stateDir, result.From, to, logger)
+	if err != nil {
+		return result, err
+	}
+	result.Events = n
+
+	cfg.EventStore = to // nosemgrep: immutability.no-pointer-field-mutation-go -- config setter pattern: mutation is intentional before SaveProjectConfig [permanent]
+	if err := SaveProjectConfig(continent, cfg); err != nil {
+		return result, errors.Join(fmt.Errorf("switch event_store: %w", err), eventsource.RemoveEvents(stateDir, to))
+	}
+
+	backup, err := eventsource.BackupEvents(stateDir, result.From, time.Now())
+	if err != nil {
+		return result, fmt.Errorf("events migrated and config switched, but backing up the %s store failed: %w", result.From, err)
+	}
+	result.Backup = backup
+	return result, nil
+}
+
+// EventUpgradeResult reports what UpgradeEventStore did (or would do).
+type EventUpgradeResult struct { // nosemgrep: structure.multiple-exported-structs-go -- event store maintenance results co-locate with their factories [permanent]
+	Backend  string `json:"backend"`
+	Events   int    `json:"events"`
+	Upgraded int    `json:"upgraded"`
+	DryRun   bool   `json:"dry_run,omitempty"`
+	Backup   string `json:"backup,omitempty"`
+}
+
+// UpgradeEventStore rewrites the continent's stored events at the current
+// schema version of their type, keeping the previous store as a
+// timestamped backup. With dryRun it only counts the events that would
+// change.
+func UpgradeEventStore(ctx context.Context, continent string, dryRun bool, logger domain.Logger) (EventUpgradeResult, error) {
+	stateDir := filepath.Join(continent, domain.StateDir)
+	result := EventUpgradeResult{Backend: EventStoreBackend(stateDir), DryRun: dryRun}
+	up, err := eventsource.UpgradeEvents(ctx, stateDir, result.Backend, dryRun, time.Now(), logger)
+	result.Events, result.Upgraded, result.Backup = up.Events, up.Upgraded, up.Backup
+	return result, err
+}
+
+// VerifyEventStore checks the integrity of the continent's event store:
+// hash chain links, forks, SeqNr order, duplicate IDs, undecodable lines
+// and missing segments (see eventsource.VerifyEvents).
+func VerifyEventStore(ctx context.Context, continent string) (eventsource.VerifyReport, error) {
+	stateDir := filepath.Join(continent, domain.StateDir)
+	return eventsource.VerifyEvents(ctx, stateDir, EventStoreBackend(stateDir))
+}
+
+// FsckEventStore checks the continent's event store line by line and,
+// with repair, quarantines undecodable and invalid lines, drops duplicate
+// event IDs and moves misfiled events (see eventsource.FsckEvents).
+func FsckEventStore(ctx context.Context, continent string, repair bool) (eventsource.FsckReport, error) {
+	stateDir := filepath.Join(continent, domain.StateDir)
+	return eventsource.FsckEvents(ctx, stateDir, EventStoreBackend(stateDir), repair, time.Now())
 }
 
 // NewSnapshotStore creates a FileSnapshotStore at {stateDir}/snapshots/.
@@ -43,7 +146,7 @@ func NewSeqCounter(stateDir string) (*eventsource.SeqCounter, error) {
 	return eventsource.NewSeqCounter(filepath.Join(stateDir, "seq.db"))
 }
 
-// EnsureCutover creates a SeqCounter, SnapshotStore, and raw FileEventStore,
+// EnsureCutover creates a SeqCounter, SnapshotStore, and raw event store,
 // then runs the one-time cutover migration. Returns the SeqCounter for
 // ongoing SeqNr allocation (caller must defer Close).
 func EnsureCutover(ctx context.Context, stateDir, aggregateType string, logger domain.Logger) (*eventsource.SeqCounter, error) {
@@ -52,8 +155,7 @@ func EnsureCutover(ctx context.Context, stateDir, aggregateType string, logger d
 		return nil, fmt.Errorf("ensure cutover: seq counter: %w", err)
 	}
 	ss := eventsource.NewFileSnapshotStore(filepath.Join(stateDir, "snapshots"))
-	raw := eventsource.NewFileEventStore(eventsource.EventsDir(stateDir), logger)
-	if _, err := eventsource.RunCutover(ctx, raw, ss, sc, aggregateType, logger); err != nil {
+	if _, err := eventsource.RunCutover(ctx, rawEventStore(stateDir, logger), ss, sc, aggregateType, logger); err != nil {
 		_ = sc.Close()
 		return nil, fmt.Errorf("ensure cutover: %w", err)
 	}
@@ -63,6 +165,10 @@ func EnsureCutover(ctx context.Context, stateDir, aggregateType string, logger d
 // NewCheckpointScanner creates a checkpoint scanner for the given continent.
 // cmd layer should use this instead of importing eventsource directly (ADR S0008).
 func NewCheckpointScanner(continent string) port.CheckpointScanner {
+	stateDir := filepath.Join(continent, domain.StateDir)
+	if EventStoreBackend(stateDir) == domain.EventStoreSQLite {
+		return eventsource.NewSQLiteCheckpointScanner(stateDir)
+	}
 	return eventsource.NewCheckpointScanner(continent)
 }
 
@@ -81,18 +187,30 @@ func ListExpiredEventFiles(ctx context.Context, stateDir string, days int) ([]st
 	return files, err
 }
 
-// PruneEventFiles deletes the named .jsonl files from the events directory.
+// PruneEventFiles retires the named daily .jsonl files from the events
+// directory without losing history: it first snapshots the projection
+// over everything stored (ProjectionCache.Compact), then moves the files
+// into a compressed, checksummed archive segment. It returns the names
+// archived; files that no longer exist are skipped.
 // cmd layer should use this instead of importing eventsource directly (ADR S0008).
 func PruneEventFiles(ctx context.Context, stateDir string, files []string) ([]string, error) {
-	_, span := platform.Tracer.Start(ctx, "eventsource.prune")
+	ctx, span := platform.Tracer.Start(ctx, "eventsource.prune")
 	defer span.End()
 
 	span.SetAttributes(attribute.Int("event.count.in", len(files)))
-	deleted, err := eventsource.PruneEventFiles(stateDir, files)
+	if len(files) == 0 {
+		return nil, nil
+	}
+	if _, err := NewProjectionCache(stateDir, nil).Compact(ctx); err != nil {
+		span.RecordError(err)
+		span.SetAttributes(attribute.String("error.stage", "eventsource.prune.snapshot"))
+		return nil, fmt.Errorf("snapshot before prune: %w", err)
+	}
+	seg, err := eventsource.ArchiveEventFiles(stateDir, files, time.Now())
 	if err != nil {
 		span.RecordError(err)
 		span.SetAttributes(attribute.String("error.stage", "eventsource.prune"))
 	}
-	span.SetAttributes(attribute.Int("event.count.out", len(deleted)))
-	return deleted, err
+	span.SetAttributes(attribute.Int("event.count.out", len(seg.Files)))
+	return seg.Files, err
 }
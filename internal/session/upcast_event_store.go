@@ -0,0 +1,72 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// upcastEventStore upcasts every loaded event to the current schema
+// version of its type (domain.UpcastEvent), so projections never see
+// legacy payload shapes. Appends pass through: the backends upcast on
+// validation. An event whose upcast fails is returned as stored.
+type upcastEventStore struct {
+	inner  port.EventStore
+	logger domain.Logger
+}
+
+func newUpcastEventStore(inner port.EventStore, logger domain.Logger) port.EventStore {
+	if logger == nil {
+		logger = &domain.NopLogger{}
+	}
+	return &upcastEventStore{inner: inner, logger: logger}
+}
+
+func (s *upcastEventStore) Append(ctx context.Context, events ...domain.Event) (domain.AppendResult, error) {
+	return s.inner.Append(ctx, events...)
+}
+
+func (s *upcastEventStore) AppendExpecting(ctx context.Context, expected domain.ExpectedVersion, events ...domain.Event) (domain.AppendResult, error) {
+	return s.inner.AppendExpecting(ctx, expected, events...)
+}
+
+func (s *upcastEventStore) AppendAllocating(ctx context.Context, expected domain.ExpectedVersion, alloc func(context.Context) (uint64, error), events ...domain.Event) (domain.AppendResult, error) {
+	return s.inner.AppendAllocating(ctx, expected, alloc, events...)
+}
+
+func (s *upcastEventStore) StreamVersion(ctx context.Context, stream string) (uint64, error) {
+	return s.inner.StreamVersion(ctx, stream)
+}
+
+func (s *upcastEventStore) LoadAll(ctx context.Context) ([]domain.Event, domain.LoadResult, error) {
+	events, result, err := s.inner.LoadAll(ctx)
+	return s.upcast(events), result, err
+}
+
+func (s *upcastEventStore) LoadSince(ctx context.Context, after time.Time) ([]domain.Event, domain.LoadResult, error) {
+	events, result, err := s.inner.LoadSince(ctx, after)
+	return s.upcast(events), result, err
+}
+
+func (s *upcastEventStore) LoadAfterSeqNr(ctx context.Context, afterSeqNr uint64) ([]domain.Event, domain.LoadResult, error) {
+	events, result, err := s.inner.LoadAfterSeqNr(ctx, afterSeqNr)
+	return s.upcast(events), result, err
+}
+
+func (s *upcastEventStore) LatestSeqNr(ctx context.Context) (uint64, error) {
+	return s.inner.LatestSeqNr(ctx)
+}
+
+func (s *upcastEventStore) upcast(events []domain.Event) []domain.Event {
+	for i, ev := range events {
+		up, err := domain.UpcastEvent(ev)
+		if err != nil {
+			s.logger.Warn("event store: %v (using the stored payload)", err)
+			continue
+		}
+		events[i] = up
+	}
+	return events
+}
@@ -0,0 +1,206 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/harness"
+	"github.com/hironow/paintress/internal/platform"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+// WatchPass counts what one InboxWatchDaemon triage pass did.
+type WatchPass struct {
+	Escalated int `json:"escalated"`
+	Resolved  int `json:"resolved"`
+	Notified  int `json:"notified"`
+	Pending   int `json:"pending"` // left in inbox/ for the session
+}
+
+// InboxWatchDaemon applies the deterministic pre-flight triage to inbox
+// D-Mails without a session: D-Mails whose decision is escalate or
+// resolve (including a retry over its budget) are archived with the
+// events archive_inbox records, everything else stays in inbox/ for
+// /expedition-next. HIGH severity and stall-escalation D-Mails raise a
+// notification once, tracked in the inbox ledger across daemon runs, so
+// a human knows to start a session. The daemon reads the inbox without
+// stamping seen_at: nobody has seen a D-Mail it only triaged.
+type InboxWatchDaemon struct { // nosemgrep: structure.multiple-exported-structs-go -- watch daemon family; WatchPass is its per-pass result [permanent]
+	continent string
+	emitter   port.ExpeditionEventEmitter
+	notifier  port.Notifier
+	logger    domain.Logger
+}
+
+// NewInboxWatchDaemon creates a daemon for continent. A nil notifier
+// disables notifications.
+func NewInboxWatchDaemon(continent string, emitter port.ExpeditionEventEmitter, notifier port.Notifier, logger domain.Logger) *InboxWatchDaemon {
+	if notifier == nil {
+		notifier = &port.NopNotifier{}
+	}
+	if logger == nil {
+		logger = &domain.NopLogger{}
+	}
+	return &InboxWatchDaemon{
+		continent: continent,
+		emitter:   emitter,
+		notifier:  notifier,
+		logger:    logger,
+	}
+}
+
+// Run triages the inbox at start, whenever watchInbox sees a D-Mail file
+// change and every interval (which also catches envelopes), until ctx
+// is cancelled. A failed pass is logged and the daemon keeps running.
+func (d *InboxWatchDaemon) Run(ctx context.Context, interval time.Duration) error {
+	trigger := make(chan struct{}, 1)
+	go watchInbox(ctx, d.continent, func(domain.DMail) {
+		select {
+		case trigger <- struct{}{}:
+		default:
+		}
+	}, nil)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		pass, err := d.TriagePass(ctx)
+		switch {
+		case err != nil:
+			d.logger.Warn("watch: triage pass: %v", err)
+		case pass.Escalated+pass.Resolved+pass.Notified > 0:
+			d.logger.Info("watch: escalated %d, resolved %d, notified %d, pending %d",
+				pass.Escalated, pass.Resolved, pass.Notified, pass.Pending)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-trigger:
+		case <-ticker.C:
+		}
+	}
+}
+
+// TriagePass triages every D-Mail currently in inbox/ once.
+func (d *InboxWatchDaemon) TriagePass(ctx context.Context) (WatchPass, error) {
+	ctx, span := platform.Tracer.Start(ctx, "paintress.watch.pass")
+	defer span.End()
+
+	var pass WatchPass
+	dmails, err := peekInbox(ctx, d.continent)
+	if err != nil {
+		span.RecordError(err)
+		return pass, fmt.Errorf("scan inbox: %w", err)
+	}
+	maxRetries := inboxMaxRetries(d.continent)
+	retries := retryCounts(ctx, d.continent, d.logger)
+	if pass.Notified, err = d.notify(ctx, wakeHuman(dmails)); err != nil {
+		span.RecordError(err)
+		return pass, err
+	}
+	for _, dm := range dmails {
+		retryKey := harness.RetryKey(dm.Issues)
+		decision := harness.DeterminePreFlightDecision(dm, retries[retryKey], maxRetries)
+		if !decision.Escalate && !decision.Resolve {
+			pass.Pending++
+			continue
+		}
+		// Retries are tracked when a session consumes the D-Mail, not here.
+		decision.TrackRetry = false
+		if err := d.consume(ctx, dm, decision, retryKey, retries[retryKey]); err != nil {
+			d.logger.Warn("watch: triage %s: %v", dm.Name, err)
+			pass.Pending++
+			continue
+		}
+		if decision.Escalate {
+			pass.Escalated++
+		} else {
+			pass.Resolved++
+		}
+	}
+	span.SetAttributes(
+		attribute.Int("watch.escalated.count", pass.Escalated),
+		attribute.Int("watch.resolved.count", pass.Resolved),
+		attribute.Int("watch.notified.count", pass.Notified),
+		attribute.Int("watch.pending.count", pass.Pending),
+	)
+	return pass, nil
+}
+
+// consume archives a triaged-out D-Mail and records the events
+// archive_inbox would; if recording fails the D-Mail goes back to inbox/.
+// A D-Mail consumed before (see inboxDuplicate) is archived without
+// events.
+func (d *InboxWatchDaemon) consume(ctx context.Context, dm domain.DMail, decision harness.PreFlightDecision, retryKey string, retryCount int) error {
+	duplicate, err := inboxDuplicate(ctx, d.continent, dm.Name, nil)
+	if err != nil {
+		return err
+	}
+	if err := ArchiveInboxDMail(ctx, d.continent, dm.Name, nil); err != nil {
+		return err
+	}
+	if d.emitter == nil || duplicate {
+		return nil
+	}
+	if err := recordInboxConsumed(ctx, d.continent, d.emitter, inboxConsumption(dm, dm.Name, decision, retryKey, retryCount), d.logger); err != nil {
+		if restoreErr := restoreInboxDMail(ctx, d.continent, dm.Name); restoreErr != nil {
+			d.logger.Warn("watch: restore %s after emit failure: %v", dm.Name, restoreErr)
+		}
+		return fmt.Errorf("record triage (d-mail left in inbox): %w", err)
+	}
+	return nil
+}
+
+// notify sends one notification per D-Mail in wake not notified about
+// before, by this or an earlier daemon run. A D-Mail is marked before
+// its notification is sent, so a failed send is not retried.
+func (d *InboxWatchDaemon) notify(ctx context.Context, wake []domain.DMail) (int, error) {
+	if len(wake) == 0 {
+		return 0, nil
+	}
+	ledger, err := NewEnvelopeLedgerForDir(d.continent)
+	if err != nil {
+		return 0, fmt.Errorf("open inbox ledger: %w", err)
+	}
+	defer func() { _ = ledger.Close() }()
+	notified := 0
+	for _, dm := range wake {
+		first, err := ledger.MarkNotified(ctx, dm.Name, time.Now())
+		if err != nil {
+			return notified, err
+		}
+		if !first {
+			continue
+		}
+		if err := d.notifier.Notify(ctx, "Paintress: D-Mail needs attention", watchNotice(dm)); err != nil {
+			d.logger.Warn("watch: notify %s: %v", dm.Name, err)
+			continue
+		}
+		notified++
+	}
+	return notified, nil
+}
+
+// wakeHuman returns the D-Mails a human should hear about: HIGH
+// severity ones and stall escalations.
+func wakeHuman(dmails []domain.DMail) []domain.DMail {
+	wake := harness.FilterHighSeverity(dmails)
+	for _, dm := range dmails {
+		if dm.Kind == domain.KindStallEscalation && dm.Severity != "high" {
+			wake = append(wake, dm)
+		}
+	}
+	return wake
+}
+
+func watchNotice(dm domain.DMail) string {
+	kind := string(dm.Kind)
+	if dm.Severity != "" {
+		kind = dm.Severity + " " + kind
+	}
+	return fmt.Sprintf("%s %s: %s. Start /expedition-next.", kind, dm.Name, dm.Description)
+}
@@ -0,0 +1,167 @@
+package session_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/session"
+)
+
+func TestInboxWatchDaemon_TriagePass(t *testing.T) {
+	// given: escalate / resolve / over-budget retry / plain spec mails,
+	// one retry budget already spent on MY-3
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-escalate", Kind: domain.KindImplFeedback, Description: "Stuck", Issues: []string{"MY-1"}, Action: "escalate", Severity: "high"})
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-resolve", Kind: domain.KindImplFeedback, Description: "Done", Issues: []string{"MY-2"}, Action: "resolve"})
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-retry", Kind: domain.KindImplFeedback, Description: "Again", Issues: []string{"MY-3"}, Action: "retry"})
+	writeInboxDMail(t, continent, domain.DMail{Name: "stall-my-5", Kind: domain.KindStallEscalation, Description: "Stalled", Issues: []string{"MY-5"}})
+	writeInboxDMail(t, continent, domain.DMail{Name: "spec-my-4", Kind: domain.KindSpecification, Description: "Next", Issues: []string{"MY-4"}})
+	spent, err := domain.NewEvent(domain.EventRetryAttempted, domain.RetryAttemptedData{DMail: "MY-3", Attempt: 4}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.NewEventStore(filepath.Join(continent, domain.StateDir), &domain.NopLogger{}).Append(context.Background(), spent); err != nil {
+		t.Fatal(err)
+	}
+	emitter := &inboxEmitter{}
+	var notices []string
+	notifier := &inboxCallbackNotifier{fn: func(_, msg string) { notices = append(notices, msg) }}
+	daemon := session.NewInboxWatchDaemon(continent, emitter, notifier, nil)
+
+	// when: two passes
+	first, err := daemon.TriagePass(context.Background())
+	if err != nil {
+		t.Fatalf("first pass: %v", err)
+	}
+	second, err := daemon.TriagePass(context.Background())
+	if err != nil {
+		t.Fatalf("second pass: %v", err)
+	}
+
+	// then
+	if first.Escalated != 2 || first.Resolved != 1 || first.Pending != 2 || first.Notified != 2 {
+		t.Errorf("first pass = %+v, want 2 escalated, 1 resolved, 2 pending, 2 notified", first)
+	}
+	if second.Escalated+second.Resolved+second.Notified != 0 || second.Pending != 2 {
+		t.Errorf("second pass = %+v, want nothing new and 2 pending", second)
+	}
+	for _, name := range []string{"fb-escalate", "fb-resolve", "fb-retry"} {
+		if _, err := os.Stat(filepath.Join(domain.ArchiveDir(continent), name+".md")); err != nil {
+			t.Errorf("%s not archived: %v", name, err)
+		}
+	}
+	for _, name := range []string{"spec-my-4", "stall-my-5"} {
+		if _, err := os.Stat(filepath.Join(domain.InboxDir(continent), name+".md")); err != nil {
+			t.Errorf("%s should stay in inbox: %v", name, err)
+		}
+	}
+	if got := strings.Join(emitter.calls, " "); strings.Count(got, "issue.escalated") != 2 || strings.Count(got, "issue.resolved") != 1 || strings.Contains(got, "retry.attempted") {
+		t.Errorf("events = %v", emitter.calls)
+	}
+	if len(notices) != 2 || !strings.Contains(notices[0], "fb-escalate") || !strings.Contains(notices[1], "stall-my-5") {
+		t.Errorf("notices = %q, want the HIGH mail and the stall escalation once", notices)
+	}
+}
+
+func TestInboxWatchDaemon_EmitFailureLeavesDMailInInbox(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-resolve", Kind: domain.KindImplFeedback, Description: "Done", Issues: []string{"MY-2"}, Action: "resolve"})
+	daemon := session.NewInboxWatchDaemon(continent, &inboxEmitter{failOn: "issue.resolved"}, nil, nil)
+
+	// when
+	pass, err := daemon.TriagePass(context.Background())
+
+	// then
+	if err != nil {
+		t.Fatalf("TriagePass: %v", err)
+	}
+	if pass.Resolved != 0 || pass.Pending != 1 {
+		t.Errorf("pass = %+v, want the mail pending", pass)
+	}
+	if _, err := os.Stat(filepath.Join(domain.InboxDir(continent), "fb-resolve.md")); err != nil {
+		t.Errorf("fb-resolve not restored to inbox: %v", err)
+	}
+}
+
+func TestInboxWatchDaemon_RunTriagesArrivals(t *testing.T) {
+	// given: a running daemon over an empty inbox
+	continent := t.TempDir()
+	if err := os.MkdirAll(domain.InboxDir(continent), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- session.NewInboxWatchDaemon(continent, &inboxEmitter{}, nil, nil).Run(ctx, 50*time.Millisecond)
+	}()
+
+	// when: a resolve mail arrives
+	writeInboxDMail(t, continent, domain.DMail{Name: "fb-resolve", Kind: domain.KindImplFeedback, Description: "Done", Issues: []string{"MY-2"}, Action: "resolve"})
+
+	// then: it is archived, and the daemon stops on cancel
+	archived := filepath.Join(domain.ArchiveDir(continent), "fb-resolve.md")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(archived); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("fb-resolve was not archived")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run: %v", err)
+	}
+}
+
+func TestInboxWatchDaemon_NotifiesOnceAcrossRuns(t *testing.T) {
+	// given: a HIGH D-Mail waiting in the inbox, and a daemon that
+	// already ran over it (as with a cron-driven watch --once)
+	continent := t.TempDir()
+	writeInboxDMail(t, continent, domain.DMail{Name: "spec-urgent", Kind: domain.KindSpecification, Description: "Urgent", Issues: []string{"MY-1"}, Severity: "high"})
+	var notices []string
+	notifier := &inboxCallbackNotifier{fn: func(_, msg string) { notices = append(notices, msg) }}
+	if _, err := session.NewInboxWatchDaemon(continent, &inboxEmitter{}, notifier, nil).TriagePass(context.Background()); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	// when: a new daemon runs
+	pass, err := session.NewInboxWatchDaemon(continent, &inboxEmitter{}, notifier, nil).TriagePass(context.Background())
+
+	// then
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if pass.Notified != 0 || len(notices) != 1 {
+		t.Errorf("pass = %+v, notices = %q, want a single notification overall", pass, notices)
+	}
+}
+
+func TestInboxWatchDaemon_DoesNotStampSeen(t *testing.T) {
+	// given
+	continent := t.TempDir()
+	writeInboxEnvelope(t, continent, "msg-1", "key-1", "# Scan\n")
+
+	// when
+	pass, err := session.NewInboxWatchDaemon(continent, &inboxEmitter{}, nil, nil).TriagePass(context.Background())
+
+	// then
+	if err != nil {
+		t.Fatalf("TriagePass: %v", err)
+	}
+	if pass.Pending != 1 {
+		t.Errorf("pass = %+v, want the envelope pending", pass)
+	}
+	env := readEnvelope(t, filepath.Join(domain.InboxDir(continent), "msg-1"+domain.EnvelopeFileExt))
+	if env.SeenAt != nil {
+		t.Errorf("seen_at = %v, want unset after a headless pass", env.SeenAt)
+	}
+}
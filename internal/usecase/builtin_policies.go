@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/harness"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+const (
+	// flushOutboxGrace leaves a staged D-Mail to the flush SendDMail
+	// performs right after staging; the policy only steps in when that
+	// flush did not happen (crash, write failure).
+	flushOutboxGrace = time.Minute
+	// stageReportGrace is how long the session has to send its own
+	// report D-Mail after append_journal, unless it starts the next
+	// expedition first.
+	stageReportGrace = 10 * time.Minute
+)
+
+// RegisterBuiltinPolicies subscribes the deterministic policies of
+// domain.Policies, the ones that need no LLM:
+//
+//   - DMailStagedFlushOutbox flushes the outbox when a staged D-Mail was
+//     not followed by a dmail.flushed event.
+//   - ExpeditionCompletedStageReport stages a report D-Mail
+//     (harness.NewReportDMail) for a completed expedition the session
+//     sent no report for.
+//
+// now is the clock the grace periods are measured with; gradientLevel
+// reads the current gradient level, which sets a staged report's
+// severity.
+func RegisterBuiltinPolicies(engine *PolicyEngine, events port.EventStore, outbox port.OutboxStore, emitter port.ExpeditionEventEmitter, sender port.DMailSender, now func() time.Time, gradientLevel func(context.Context) (int, error)) {
+	engine.Subscribe("DMailStagedFlushOutbox", domain.EventDMailStaged, flushOutboxPolicy(events, outbox, emitter, now))
+	engine.Subscribe("ExpeditionCompletedStageReport", domain.EventExpeditionCompleted, stageReportPolicy(events, sender, now, gradientLevel))
+}
+
+func flushOutboxPolicy(events port.EventStore, outbox port.OutboxStore, emitter port.ExpeditionEventEmitter, now func() time.Time) PolicyHandler {
+	return func(ctx context.Context, ev domain.Event) error {
+		later, _, err := events.LoadAfterSeqNr(ctx, ev.SeqNr)
+		if err != nil {
+			return fmt.Errorf("load events: %w", err)
+		}
+		for _, l := range later {
+			if l.Type == domain.EventDMailFlushed {
+				return nil
+			}
+		}
+		if now().Sub(ev.Timestamp) < flushOutboxGrace {
+			return DeferPolicyUntil(ev.Timestamp.Add(flushOutboxGrace))
+		}
+		n, err := outbox.Flush(ctx)
+		if err != nil {
+			return fmt.Errorf("flush outbox: %w", err)
+		}
+		if n > 0 {
+			return emitter.EmitDMailFlushed(n, now())
+		}
+		return nil
+	}
+}
+
+func stageReportPolicy(events port.EventStore, sender port.DMailSender, now func() time.Time, gradientLevel func(context.Context) (int, error)) PolicyHandler {
+	return func(ctx context.Context, ev domain.Event) error {
+		var data domain.ExpeditionCompletedData
+		if err := json.Unmarshal(ev.Data, &data); err != nil || data.IssueID == "" || data.Status == "skipped" {
+			return nil
+		}
+		// The session may also have sent the report just before
+		// append_journal, so look back by the grace period as well.
+		nearby, _, err := events.LoadSince(ctx, ev.Timestamp.Add(-stageReportGrace))
+		if err != nil {
+			return fmt.Errorf("load events: %w", err)
+		}
+		movedOn := false
+		for _, other := range nearby {
+			switch other.Type {
+			case domain.EventDMailStaged:
+				var staged domain.DMailStagedData
+				if json.Unmarshal(other.Data, &staged) == nil && slices.Contains(staged.Issues, data.IssueID) {
+					return nil
+				}
+			case domain.EventExpeditionStarted:
+				movedOn = movedOn || other.SeqNr > ev.SeqNr
+			}
+		}
+		if !movedOn && now().Sub(ev.Timestamp) < stageReportGrace {
+			return DeferPolicyUntil(ev.Timestamp.Add(stageReportGrace))
+		}
+
+		level, err := gradientLevel(ctx)
+		if err != nil {
+			return fmt.Errorf("read gradient level: %w", err)
+		}
+		report := &domain.ExpeditionReport{
+			Expedition:  data.Expedition,
+			IssueID:     data.IssueID,
+			IssueTitle:  data.IssueID,
+			MissionType: "expedition",
+			Status:      data.Status,
+			Reason:      "The session completed this expedition without sending a report D-Mail; the ExpeditionCompletedStageReport policy staged this one from the expedition.completed event.",
+			WaveID:      data.WaveID,
+			StepID:      data.StepID,
+		}
+		if err := sender.SendDMail(ctx, harness.NewReportDMail(report, level)); err != nil {
+			return fmt.Errorf("stage report for expedition %d: %w", data.Expedition, err)
+		}
+		return nil
+	}
+}
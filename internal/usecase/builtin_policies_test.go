@@ -0,0 +1,150 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/usecase"
+	"github.com/hironow/paintress/internal/usecase/port"
+)
+
+type countingOutbox struct {
+	flushes int
+	pending int
+}
+
+func (o *countingOutbox) Stage(context.Context, string, []byte) error { return nil }
+func (o *countingOutbox) Close() error                                { return nil }
+
+func (o *countingOutbox) Flush(context.Context) (int, error) {
+	o.flushes++
+	n := o.pending
+	o.pending = 0
+	return n, nil
+}
+
+type flushRecorder struct {
+	port.NopExpeditionEventEmitter
+	flushed []int
+}
+
+func (r *flushRecorder) EmitDMailFlushed(count int, _ time.Time) error {
+	r.flushed = append(r.flushed, count)
+	return nil
+}
+
+type sentDMails []domain.DMail
+
+func (s *sentDMails) SendDMail(_ context.Context, d domain.DMail) error {
+	*s = append(*s, d)
+	return nil
+}
+
+// builtinEngine subscribes the built-in policies with cursors at 0, so
+// every stored event is offered.
+func builtinEngine(store *fakeEventStore, outbox port.OutboxStore, emitter port.ExpeditionEventEmitter, sender port.DMailSender, now time.Time) *usecase.PolicyEngine {
+	engine := usecase.NewPolicyEngine(nil).WithSubscriptionStore(store, memCursorStore{
+		"DMailStagedFlushOutbox":         0,
+		"ExpeditionCompletedStageReport": 0,
+	})
+	usecase.RegisterBuiltinPolicies(engine, store, outbox, emitter, sender, func() time.Time { return now }, func(context.Context) (int, error) {
+		level := 0
+		for _, ev := range store.appended {
+			var data domain.GradientChangedData
+			if ev.Type == domain.EventGradientChanged && json.Unmarshal(ev.Data, &data) == nil {
+				level = data.Level
+			}
+		}
+		return level, nil
+	})
+	return engine
+}
+
+func TestDMailStagedFlushOutbox_FlushesOnlyUnflushedStagesAfterGrace(t *testing.T) {
+	// given: "a" was flushed by SendDMail, "b" never was; both are old
+	staged := time.Now().UTC().Add(-time.Hour)
+	store := &fakeEventStore{appended: []domain.Event{
+		seqEvent(t, 1, domain.EventDMailStaged, domain.DMailStagedData{Name: "a"}, staged),
+		seqEvent(t, 2, domain.EventDMailFlushed, domain.DMailFlushedData{Count: 1}, staged),
+		seqEvent(t, 3, domain.EventDMailStaged, domain.DMailStagedData{Name: "b"}, staged),
+	}}
+	outbox := &countingOutbox{pending: 1}
+	emitter := &flushRecorder{}
+
+	// when
+	err := builtinEngine(store, outbox, emitter, &sentDMails{}, time.Now().UTC()).CatchUp(context.Background())
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outbox.flushes != 1 || !slices.Equal(emitter.flushed, []int{1}) {
+		t.Errorf("flushes = %d, flushed events = %v; want one flush of 1 item", outbox.flushes, emitter.flushed)
+	}
+}
+
+func TestDMailStagedFlushOutbox_LeavesFreshStageToSendDMail(t *testing.T) {
+	// given
+	now := time.Now().UTC()
+	store := &fakeEventStore{appended: []domain.Event{
+		seqEvent(t, 1, domain.EventDMailStaged, domain.DMailStagedData{Name: "a"}, now),
+	}}
+	outbox := &countingOutbox{pending: 1}
+
+	// when
+	err := builtinEngine(store, outbox, &flushRecorder{}, &sentDMails{}, now).CatchUp(context.Background())
+
+	// then
+	if err != nil || outbox.flushes != 0 {
+		t.Errorf("err = %v, flushes = %d; want the fresh stage deferred", err, outbox.flushes)
+	}
+}
+
+func TestExpeditionCompletedStageReport_StagesMissingReport(t *testing.T) {
+	// given: MY-1 got its report, MY-2 did not and the session moved on
+	at := time.Now().UTC()
+	store := &fakeEventStore{appended: []domain.Event{
+		seqEvent(t, 1, domain.EventGradientChanged, domain.GradientChangedData{Level: 3}, at),
+		seqEvent(t, 2, domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 1, Status: "success", IssueID: "MY-1"}, at),
+		seqEvent(t, 3, domain.EventDMailStaged, domain.DMailStagedData{Name: "pt-report-my-1-1", Issues: []string{"MY-1"}}, at),
+		seqEvent(t, 4, domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 2, Status: "failed", IssueID: "MY-2"}, at),
+		seqEvent(t, 5, domain.EventExpeditionStarted, domain.ExpeditionStartedData{Expedition: 3}, at),
+	}}
+	sent := &sentDMails{}
+
+	// when
+	err := builtinEngine(store, &countingOutbox{}, &flushRecorder{}, sent, at).CatchUp(context.Background())
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*sent) != 1 {
+		t.Fatalf("sent %d D-Mails, want 1", len(*sent))
+	}
+	dm := (*sent)[0]
+	if dm.Kind != "report" || !slices.Equal(dm.Issues, []string{"MY-2"}) || dm.Severity != "low" {
+		t.Errorf("report = kind %q, issues %v, severity %q", dm.Kind, dm.Issues, dm.Severity)
+	}
+}
+
+func TestExpeditionCompletedStageReport_WaitsForTheSession(t *testing.T) {
+	// given: a fresh completion, no report yet, no next expedition
+	at := time.Now().UTC()
+	store := &fakeEventStore{appended: []domain.Event{
+		seqEvent(t, 1, domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 1, Status: "success", IssueID: "MY-1"}, at),
+	}}
+	sent := &sentDMails{}
+
+	// when
+	err := builtinEngine(store, &countingOutbox{}, &flushRecorder{}, sent, at.Add(time.Minute)).CatchUp(context.Background())
+
+	// then
+	if err != nil || len(*sent) != 0 {
+		t.Errorf("err = %v, sent = %d; want the completion deferred", err, len(*sent))
+	}
+}
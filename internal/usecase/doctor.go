@@ -1,41 +1,16 @@
 package usecase
 
 import (
-	"context"
-	"encoding/json"
-
 	"github.com/hironow/paintress/internal/domain"
-	"github.com/hironow/paintress/internal/usecase/port"
 )
 
-// ComputeSuccessRate loads all events and computes success rate metrics.
-// Returns nil metrics when no events exist or loading fails.
-func ComputeSuccessRate(ctx context.Context, eventStore port.EventStore, logger domain.Logger) *domain.DoctorMetrics {
-	events, loadResult, err := eventStore.LoadAll(ctx)
-	if err != nil || len(events) == 0 {
-		return &domain.DoctorMetrics{SuccessRate: "no events"}
-	}
-	if loadResult.CorruptLineCount > 0 && logger != nil {
-		logger.Warn("doctor: %d corrupt event lines skipped", loadResult.CorruptLineCount)
-	}
-
-	rate := domain.SuccessRate(events)
-	var success, total int
-	for _, ev := range events {
-		if ev.Type != domain.EventExpeditionCompleted {
-			continue
-		}
-		var data domain.ExpeditionCompletedData
-		if json.Unmarshal(ev.Data, &data) != nil {
-			continue
-		}
-		if data.Status == "skipped" {
-			continue
-		}
-		total++
-		if data.Status == "success" {
-			success++
-		}
+// ComputeSuccessRate formats success rate metrics from the projected
+// expedition counts: success out of total non-skipped expeditions.
+// Reports "no events" when no non-skipped expedition has been recorded.
+func ComputeSuccessRate(success, total int) *domain.DoctorMetrics {
+	var rate float64
+	if total > 0 {
+		rate = float64(success) / float64(total)
 	}
 	return &domain.DoctorMetrics{
 		SuccessRate: domain.FormatSuccessRate(rate, success, total),
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/hironow/paintress/internal/domain"
@@ -12,7 +13,9 @@ import (
 // expeditionEventEmitter implements port.ExpeditionEventEmitter.
 // It wraps the aggregate + event store + dispatcher.
 // Emit chain: agg.Record*() → store.Append() → dispatch (best-effort).
+// Safe for concurrent use.
 type expeditionEventEmitter struct {
+	mu           sync.Mutex // serializes agg, seqAlloc, store appends and prevID
 	agg          *domain.ExpeditionAggregate
 	store        port.EventStore
 	dispatcher   port.EventDispatcher
@@ -25,7 +28,9 @@ type expeditionEventEmitter struct {
 
 // SetSeqAllocator injects a SeqAllocator for SeqNr allocation into emitted events.
 func (e *expeditionEventEmitter) SetSeqAllocator(alloc port.SeqAllocator) {
+	e.mu.Lock()
 	e.seqAlloc = alloc
+	e.mu.Unlock()
 }
 
 // NewExpeditionEventEmitter creates an ExpeditionEventEmitter that wraps the aggregate event chain.
@@ -48,149 +53,179 @@ func NewExpeditionEventEmitter(
 	}
 }
 
-// emit enriches events with correlation metadata, persists, and dispatches.
-func (e *expeditionEventEmitter) emit(events ...domain.Event) error {
+// emit records events on the aggregate with record, persists them with
+// correlation metadata, and dispatches them.
+func (e *expeditionEventEmitter) emit(record func() ([]domain.Event, error)) error {
+	return e.emitExpecting(domain.AnyVersion, record)
+}
+
+// emitExpecting is emit with an expected stream version for the append.
+// A rejected append leaves the aggregate's version ahead of the stream;
+// GradientVersion resyncs it before a retry.
+//
+// Recording, SeqNr allocation and the append run under mu, because MCP
+// tool calls and the policy handlers RunDeferred fires emit concurrently
+// through one emitter. Dispatch runs after it: handlers emit in turn.
+func (e *expeditionEventEmitter) emitExpecting(expected domain.ExpectedVersion, record func() ([]domain.Event, error)) error {
 	ctx := e.ctx
+	e.mu.Lock()
+	events, err := record()
+	if err == nil {
+		err = e.persist(ctx, expected, events)
+	}
+	e.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if e.dispatcher != nil {
+		for _, ev := range events {
+			if err := e.dispatcher.Dispatch(ctx, ev); err != nil {
+				e.logger.Warn("policy dispatch %s: %v", ev.Type, err)
+			}
+		}
+	}
+	return nil
+}
+
+// persist enriches events with correlation metadata and appends them.
+// Global SeqNrs are allocated by the store inside its append lock: other
+// processes append to it too, and allocating here would let them store
+// a later SeqNr first. The caller holds mu.
+func (e *expeditionEventEmitter) persist(ctx context.Context, expected domain.ExpectedVersion, events []domain.Event) error {
 	for i := range events {
 		events[i].CorrelationID = e.expeditionID
 		if e.prevID != "" {
 			events[i].CausationID = e.prevID
 		}
-		if e.seqAlloc != nil {
-			seq, err := e.seqAlloc.AllocSeqNr(ctx)
-			if err != nil {
-				return fmt.Errorf("alloc seq nr: %w", err)
-			}
-			events[i].SeqNr = seq
-		}
 	}
 	if e.store != nil {
-		if _, err := e.store.Append(ctx, events...); err != nil {
+		var alloc func(context.Context) (uint64, error)
+		if e.seqAlloc != nil {
+			alloc = e.seqAlloc.AllocSeqNr
+		}
+		stored, err := e.store.AppendAllocating(ctx, expected, alloc, events...)
+		if err != nil {
 			return fmt.Errorf("append events: %w", err)
 		}
+		for i, seq := range stored.SeqNrs {
+			events[i].SeqNr = seq
+		}
 	}
 	// Update causation chain after successful store
 	if len(events) > 0 {
 		e.prevID = events[len(events)-1].ID
 	}
-	if e.dispatcher != nil {
-		for _, ev := range events {
-			if err := e.dispatcher.Dispatch(ctx, ev); err != nil {
-				e.logger.Warn("policy dispatch %s: %v", ev.Type, err)
-			}
-		}
-	}
 	return nil
 }
 
-func (e *expeditionEventEmitter) EmitStartExpedition(expedition, worker int, model string, now time.Time) error {
-	ev, err := e.agg.StartExpedition(expedition, worker, model, now)
+// one adapts a single-event aggregate command to emit.
+func one(ev domain.Event, err error) ([]domain.Event, error) {
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return e.emit(ev)
+	return []domain.Event{ev}, nil
+}
+
+func (e *expeditionEventEmitter) EmitStartExpedition(expedition, worker int, model string, now time.Time) error {
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.StartExpedition(expedition, worker, model, now))
+	})
 }
 
 func (e *expeditionEventEmitter) EmitCompleteExpedition(expedition int, status, issueID, bugsFound, waveID, stepID string, now time.Time) error { // nosemgrep: domain-primitives.multiple-string-params-go -- status/issueID/bugsFound/waveID/stepID are semantically distinct [permanent]
-	events, err := e.agg.CompleteExpedition(expedition, status, issueID, bugsFound, waveID, stepID, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(events...)
+	return e.emit(func() ([]domain.Event, error) {
+		return e.agg.CompleteExpedition(expedition, status, issueID, bugsFound, waveID, stepID, now)
+	})
 }
 
 func (e *expeditionEventEmitter) EmitSpecRegistered(waveID string, steps []domain.WaveStepDef, source string, now time.Time) error {
-	ev, err := e.agg.RecordSpecRegistered(waveID, steps, source, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(ev)
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.RecordSpecRegistered(waveID, steps, source, now))
+	})
 }
 
 func (e *expeditionEventEmitter) EmitInboxReceived(name, severity string, now time.Time) error {
-	ev, err := e.agg.RecordInboxReceived(name, severity, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(ev)
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.RecordInboxReceived(name, severity, now))
+	})
 }
 
 func (e *expeditionEventEmitter) EmitGommage(expedition int, now time.Time) error {
-	ev, err := e.agg.RecordGommage(expedition, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(ev)
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.RecordGommage(expedition, now))
+	})
 }
 
-func (e *expeditionEventEmitter) EmitGradientChange(level int, operator string, now time.Time) error {
-	ev, err := e.agg.RecordGradientChange(level, operator, now)
+func (e *expeditionEventEmitter) GradientVersion() (domain.ExpectedVersion, error) {
+	if e.store == nil {
+		return domain.AnyVersion, nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	version, err := e.store.StreamVersion(e.ctx, domain.GradientStreamID)
 	if err != nil {
-		return err
+		return domain.AnyVersion, fmt.Errorf("load gradient version: %w", err)
 	}
-	return e.emit(ev)
+	e.agg.SetGradientVersion(version)
+	return domain.ExpectedVersion(version), nil
+}
+
+func (e *expeditionEventEmitter) EmitGradientChange(expected domain.ExpectedVersion, level, delta int, operator string, now time.Time) error {
+	return e.emitExpecting(expected, func() ([]domain.Event, error) {
+		return one(e.agg.RecordGradientChange(level, delta, operator, now))
+	})
 }
 
 func (e *expeditionEventEmitter) EmitRetryAttempted(dmailKey string, attempt int, now time.Time) error {
-	ev, err := e.agg.RecordRetryAttempted(dmailKey, attempt, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(ev)
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.RecordRetryAttempted(dmailKey, attempt, now))
+	})
 }
 
 func (e *expeditionEventEmitter) EmitEscalated(dmailName string, issues []string, now time.Time) error {
-	ev, err := e.agg.RecordEscalated(dmailName, issues, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(ev)
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.RecordEscalated(dmailName, issues, now))
+	})
 }
 
 func (e *expeditionEventEmitter) EmitResolved(dmailName string, issues []string, now time.Time) error {
-	ev, err := e.agg.RecordResolved(dmailName, issues, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(ev)
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.RecordResolved(dmailName, issues, now))
+	})
 }
 
-func (e *expeditionEventEmitter) EmitDMailStaged(name string, now time.Time) error {
-	ev, err := e.agg.RecordDMailStaged(name, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(ev)
+func (e *expeditionEventEmitter) EmitDMailStaged(name string, issues []string, now time.Time) error {
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.RecordDMailStaged(name, issues, now))
+	})
 }
 
 func (e *expeditionEventEmitter) EmitDMailFlushed(count int, now time.Time) error {
-	ev, err := e.agg.RecordDMailFlushed(count, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(ev)
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.RecordDMailFlushed(count, now))
+	})
 }
 
 func (e *expeditionEventEmitter) EmitDMailArchived(name string, now time.Time) error {
-	ev, err := e.agg.RecordDMailArchived(name, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(ev)
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.RecordDMailArchived(name, now))
+	})
+}
+
+func (e *expeditionEventEmitter) EmitInboxConsumed(consumed domain.InboxConsumption, now time.Time) error {
+	return e.emit(func() ([]domain.Event, error) {
+		return e.agg.RecordInboxConsumed(consumed, now)
+	})
 }
 
 func (e *expeditionEventEmitter) EmitGommageRecovery(expedition int, class, action string, retryNum int, cooldown string, now time.Time) error {
-	ev, err := e.agg.RecordGommageRecovery(expedition, domain.GommageClass(class), action, retryNum, cooldown, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(ev)
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.RecordGommageRecovery(expedition, domain.GommageClass(class), action, retryNum, cooldown, now))
+	})
 }
 
 func (e *expeditionEventEmitter) EmitCheckpoint(expedition int, phase, workDir string, commitCount int, now time.Time) error {
-	ev, err := e.agg.RecordCheckpoint(expedition, phase, workDir, commitCount, now)
-	if err != nil {
-		return err
-	}
-	return e.emit(ev)
+	return e.emit(func() ([]domain.Event, error) {
+		return one(e.agg.RecordCheckpoint(expedition, phase, workDir, commitCount, now))
+	})
 }
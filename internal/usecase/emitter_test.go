@@ -3,6 +3,8 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,32 +13,91 @@ import (
 )
 
 type fakeEventStore struct {
+	mu       sync.Mutex
 	appended []domain.Event
 	err      error
 }
 
-func (s *fakeEventStore) Append(_ context.Context, events ...domain.Event) (domain.AppendResult, error) {
+func (s *fakeEventStore) Append(ctx context.Context, events ...domain.Event) (domain.AppendResult, error) {
+	return s.AppendExpecting(ctx, domain.AnyVersion, events...)
+}
+
+func (s *fakeEventStore) AppendExpecting(ctx context.Context, expected domain.ExpectedVersion, events ...domain.Event) (domain.AppendResult, error) {
+	return s.AppendAllocating(ctx, expected, nil, events...)
+}
+
+func (s *fakeEventStore) AppendAllocating(ctx context.Context, expected domain.ExpectedVersion, alloc func(context.Context) (uint64, error), events ...domain.Event) (domain.AppendResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.err != nil {
 		return domain.AppendResult{}, s.err
 	}
-	s.appended = append(s.appended, events...)
-	return domain.AppendResult{}, nil
+	if err := domain.CheckExpectedVersion(events, expected, func(stream string) uint64 {
+		return domain.StreamVersion(s.appended, stream)
+	}); err != nil {
+		return domain.AppendResult{}, err
+	}
+	var result domain.AppendResult
+	batch := slices.Clone(events)
+	for i := range batch {
+		if alloc == nil {
+			break
+		}
+		seq, err := alloc(ctx)
+		if err != nil {
+			return domain.AppendResult{}, err
+		}
+		batch[i].SeqNr = seq
+		result.SeqNrs = append(result.SeqNrs, seq)
+	}
+	s.appended = append(s.appended, batch...)
+	return result, nil
+}
+
+func (s *fakeEventStore) StreamVersion(_ context.Context, stream string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return domain.StreamVersion(s.appended, stream), nil
 }
 
 func (s *fakeEventStore) LoadAll(_ context.Context) ([]domain.Event, domain.LoadResult, error) {
-	return nil, domain.LoadResult{}, nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.appended), domain.LoadResult{}, nil
 }
 
-func (s *fakeEventStore) LoadSince(_ context.Context, _ time.Time) ([]domain.Event, domain.LoadResult, error) {
-	return nil, domain.LoadResult{}, nil
+func (s *fakeEventStore) LoadSince(_ context.Context, after time.Time) ([]domain.Event, domain.LoadResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []domain.Event
+	for _, ev := range s.appended {
+		if ev.Timestamp.After(after) {
+			events = append(events, ev)
+		}
+	}
+	return events, domain.LoadResult{}, nil
 }
 
-func (s *fakeEventStore) LoadAfterSeqNr(_ context.Context, _ uint64) ([]domain.Event, domain.LoadResult, error) {
-	return nil, domain.LoadResult{}, nil
+func (s *fakeEventStore) LoadAfterSeqNr(_ context.Context, afterSeqNr uint64) ([]domain.Event, domain.LoadResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []domain.Event
+	for _, ev := range s.appended {
+		if ev.SeqNr > afterSeqNr {
+			events = append(events, ev)
+		}
+	}
+	return events, domain.LoadResult{}, nil
 }
 
 func (s *fakeEventStore) LatestSeqNr(_ context.Context) (uint64, error) {
-	return 0, nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var latest uint64
+	for _, ev := range s.appended {
+		latest = max(latest, ev.SeqNr)
+	}
+	return latest, nil
 }
 
 type fakeDispatcher struct {
@@ -78,7 +139,7 @@ func TestExpeditionEventEmitter_CorrelationCausationChain(t *testing.T) {
 	if err := emitter.EmitStartExpedition(1, 0, "opus", time.Now()); err != nil {
 		t.Fatalf("emit 1: %v", err)
 	}
-	if err := emitter.EmitGradientChange(3, "charge", time.Now()); err != nil {
+	if err := emitter.EmitGradientChange(domain.AnyVersion, 3, 3, "charge", time.Now()); err != nil {
 		t.Fatalf("emit 2: %v", err)
 	}
 
@@ -120,3 +181,153 @@ func TestExpeditionEventEmitter_StoreFailurePropagates(t *testing.T) {
 		t.Fatal("expected error from store failure")
 	}
 }
+
+func TestExpeditionEventEmitter_GradientChangeExpectsSyncedVersion(t *testing.T) {
+	// given: an emitter synced to the gradient stream, then a gradient
+	// change by another process
+	store := &fakeEventStore{}
+	agg := domain.NewExpeditionAggregate()
+	emitter := usecase.NewExpeditionEventEmitter(context.Background(), agg, store, nil, &domain.NopLogger{}, "paintress.mcp")
+	version, err := emitter.GradientVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := domain.NewExpeditionAggregate()
+	competing, _ := other.RecordGradientChange(5, 5, "other-session", time.Now())
+	store.appended = append(store.appended, competing)
+
+	// when
+	staleErr := emitter.EmitGradientChange(version, 1, 1, "mcp", time.Now())
+	resynced, _ := emitter.GradientVersion()
+	retryErr := emitter.EmitGradientChange(resynced, 6, 1, "mcp", time.Now())
+
+	// then
+	if !domain.IsConcurrencyConflict(staleErr) {
+		t.Errorf("stale emit err = %v, want a concurrency conflict", staleErr)
+	}
+	if resynced != 1 || retryErr != nil {
+		t.Errorf("resynced version = %d, retry err = %v; want 1 / nil", resynced, retryErr)
+	}
+	if last := store.appended[len(store.appended)-1]; last.SeqNr != 2 {
+		t.Errorf("retried event SeqNr = %d, want 2 (continuing the gradient stream)", last.SeqNr)
+	}
+}
+
+func TestExpeditionEventEmitter_GradientChangeIgnoresOtherStreams(t *testing.T) {
+	// given: an emitter synced to the gradient stream, then an unrelated
+	// MCP event appended by another session
+	store := &fakeEventStore{}
+	agg := domain.NewExpeditionAggregate()
+	emitter := usecase.NewExpeditionEventEmitter(context.Background(), agg, store, nil, &domain.NopLogger{}, "paintress.mcp")
+	version, err := emitter.GradientVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := usecase.NewExpeditionEventEmitter(context.Background(), domain.NewExpeditionAggregate(), store, nil, &domain.NopLogger{}, "paintress.mcp")
+	if err := other.EmitInboxReceived("fb-my-1", "low", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	err = emitter.EmitGradientChange(version, 1, 1, "mcp", time.Now())
+
+	// then
+	if err != nil {
+		t.Errorf("gradient change after an unrelated append = %v, want no conflict", err)
+	}
+}
+
+func TestExpeditionEventEmitter_InboxConsumedIsOneAppend(t *testing.T) {
+	// given: a store that rejects the batch
+	store := &fakeEventStore{err: errors.New("disk full")}
+	agg := domain.NewExpeditionAggregate()
+	emitter := usecase.NewExpeditionEventEmitter(context.Background(), agg, store, &fakeDispatcher{}, &domain.NopLogger{}, "exp-1")
+	consumed := domain.InboxConsumption{Name: "fb-my-1", Resolve: true}
+
+	// when
+	failed := emitter.EmitInboxConsumed(consumed, time.Now())
+	store.err = nil
+	err := emitter.EmitInboxConsumed(consumed, time.Now())
+
+	// then: nothing of the failed batch was stored
+	if failed == nil {
+		t.Fatal("expected the first append to fail")
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.appended) != 3 {
+		t.Errorf("stored %d events, want received, resolved and archived once", len(store.appended))
+	}
+}
+
+// runDeferredKey marks the context RunDeferred catches up with.
+type runDeferredKey struct{}
+
+func TestExpeditionEventEmitter_ConcurrentWithRunDeferred(t *testing.T) {
+	// given: a staged D-Mail whose policy, once RunDeferred catches up,
+	// flushes through the same emitter the tool calls use
+	store := &fakeEventStore{}
+	engine := usecase.NewPolicyEngine(nil).WithSubscriptionStore(store, memCursorStore{"flush": 0})
+	emitter := usecase.NewExpeditionEventEmitter(context.Background(), domain.NewExpeditionAggregate(), store, engine, &domain.NopLogger{}, "exp-1")
+	firing := make(chan struct{})
+	engine.Subscribe("flush", domain.EventDMailStaged, func(ctx context.Context, _ domain.Event) error {
+		if ctx.Value(runDeferredKey{}) == nil {
+			return usecase.DeferPolicyUntil(time.Now())
+		}
+		close(firing)
+		for range 50 {
+			if err := emitter.EmitDMailFlushed(1, time.Now()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err := emitter.EmitDMailStaged("report", nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), runDeferredKey{}, true))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.RunDeferred(ctx)
+	}()
+
+	// when: tool calls emit while the deferred policy runs
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-firing:
+			case <-time.After(5 * time.Second):
+				t.Error("RunDeferred did not fire")
+				return
+			}
+			for range 50 {
+				if err := emitter.EmitInboxReceived("fb-1", "low", time.Now()); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	cancel()
+	<-done
+
+	// then: one causation chain with one SeqNr per event
+	events, _, _ := store.LoadAll(context.Background())
+	if len(events) != 1+50+4*50 {
+		t.Fatalf("stored %d events, want %d", len(events), 1+50+4*50)
+	}
+	for i, ev := range events {
+		if ev.SeqNr != uint64(i+1) {
+			t.Fatalf("event %d has SeqNr %d", i, ev.SeqNr)
+		}
+		if i > 0 && ev.CausationID != events[i-1].ID {
+			t.Fatalf("event %d caused by %q, want the event before it", i, ev.CausationID)
+		}
+	}
+}
@@ -2,6 +2,11 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hironow/paintress/internal/domain"
 	"github.com/hironow/paintress/internal/usecase/port"
@@ -10,16 +15,60 @@ import (
 // Compile-time check that PolicyEngine implements port.EventDispatcher.
 var _ port.EventDispatcher = (*PolicyEngine)(nil)
 
+// ErrPolicyDeferred is returned by a durable handler whose event is not
+// due yet. The subscription stays at that event and offers it again on
+// the next CatchUp; it is not reported as a failure. Handlers that know
+// when the event falls due return DeferPolicyUntil instead.
+var ErrPolicyDeferred = errors.New("policy deferred")
+
+// policyRetryInterval is how long RunDeferred waits before catching up
+// again after a failed catch-up or a deferral without a due time.
+const policyRetryInterval = time.Minute
+
+// DeferPolicyUntil returns an error matching ErrPolicyDeferred for an
+// event that falls due at until; RunDeferred catches up again then.
+func DeferPolicyUntil(until time.Time) error {
+	return &policyDeferral{until: until}
+}
+
+// policyDeferral is ErrPolicyDeferred with a due time.
+type policyDeferral struct {
+	until time.Time
+}
+
+func (d *policyDeferral) Error() string {
+	return fmt.Sprintf("%v until %s", ErrPolicyDeferred, d.until.Format(time.RFC3339))
+}
+
+func (d *policyDeferral) Is(target error) bool { return target == ErrPolicyDeferred }
+
 // PolicyHandler processes a domain event as part of a policy reaction.
 // WHEN [EVENT] THEN [handler logic].
 type PolicyHandler func(ctx context.Context, event domain.Event) error
 
+// subscription is a durable handler registered with Subscribe.
+type subscription struct {
+	name    string
+	trigger domain.EventType
+	handler PolicyHandler
+}
+
 // PolicyEngine dispatches domain events to registered policy handlers.
 // This connects the POLICY registry (domain.Policies) to executable handlers.
 // nosemgrep: naming.ambiguous-suffix-struct-go -- cross-tool architecture: PolicyEngine is intentional (all 4 tools share this pattern) [permanent]
 type PolicyEngine struct {
-	handlers map[domain.EventType][]PolicyHandler
-	logger   domain.Logger
+	handlers      map[domain.EventType][]PolicyHandler
+	subscriptions []subscription
+	events        port.EventStore
+	cursors       port.PolicyCursorStore
+	logger        domain.Logger
+
+	catchUpMu sync.Mutex
+	pending   atomic.Bool // events arrived while a catch-up was running
+
+	dueMu sync.Mutex
+	due   time.Time     // when the earliest deferred or failed event is retried
+	wake  chan struct{} // signals RunDeferred that due moved
 }
 
 // NewPolicyEngine creates a PolicyEngine. Pass nil logger for silent operation.
@@ -27,6 +76,7 @@ func NewPolicyEngine(logger domain.Logger) *PolicyEngine {
 	return &PolicyEngine{
 		handlers: make(map[domain.EventType][]PolicyHandler),
 		logger:   logger,
+		wake:     make(chan struct{}, 1),
 	}
 }
 
@@ -36,19 +86,226 @@ func (e *PolicyEngine) Register(trigger domain.EventType, handler PolicyHandler)
 	e.handlers[trigger] = append(e.handlers[trigger], handler)
 }
 
-// Dispatch sends an event to all handlers registered for its type.
+// Subscribe adds a durable handler under a unique name. Unlike Register,
+// it is fed from the event store by CatchUp, in SeqNr order, and its
+// cursor only moves past events it handled: after an error or a crash
+// the event is offered again. Handlers must therefore be idempotent.
+// Requires WithSubscriptionStore.
+func (e *PolicyEngine) Subscribe(name string, trigger domain.EventType, handler PolicyHandler) {
+	e.subscriptions = append(e.subscriptions, subscription{name: name, trigger: trigger, handler: handler})
+}
+
+// WithSubscriptionStore sets where durable subscriptions read events
+// from and where they checkpoint their cursors.
+func (e *PolicyEngine) WithSubscriptionStore(events port.EventStore, cursors port.PolicyCursorStore) *PolicyEngine {
+	e.events = events
+	e.cursors = cursors
+	return e
+}
+
+// Dispatch sends an event to all handlers registered for its type, then
+// lets the durable subscriptions catch up with the store.
 // Best-effort: handler errors are logged but never block event processing.
 func (e *PolicyEngine) Dispatch(ctx context.Context, event domain.Event) error {
-	handlers, ok := e.handlers[event.Type]
-	if !ok {
-		return nil
-	}
-	for _, h := range handlers {
+	for _, h := range e.handlers[event.Type] {
 		if err := h(ctx, event); err != nil {
 			if e.logger != nil {
 				e.logger.Debug("policy dispatch %s: %v", event.Type, err)
 			}
 		}
 	}
+	if err := e.CatchUp(ctx); err != nil && e.logger != nil {
+		e.logger.Warn("policy catch-up (retried on the next event or by RunDeferred): %v", err)
+	}
+	return nil
+}
+
+// CatchUp feeds every durable subscription the stored events after its
+// cursor, reading them once from the lowest cursor. A subscription
+// without a cursor starts at the latest SeqNr: it reacts to what happens
+// from now on, not to the history before it. A call made while another
+// catch-up runs (e.g. from a handler that emits events) returns at once;
+// the running one picks the new events up. Deferred and failed events
+// are scheduled for RunDeferred.
+func (e *PolicyEngine) CatchUp(ctx context.Context) error {
+	if e.events == nil || e.cursors == nil || len(e.subscriptions) == 0 {
+		return nil
+	}
+	e.pending.Store(true)
+	var err error
+	for e.pending.Load() {
+		if !e.catchUpMu.TryLock() {
+			return nil
+		}
+		for e.pending.Swap(false) {
+			e.clearDue()
+			if err = e.catchUpOnce(ctx); err != nil {
+				e.deferUntil(time.Now().Add(policyRetryInterval))
+			}
+		}
+		e.catchUpMu.Unlock()
+	}
+	return err
+}
+
+// catchUpOnce runs one catch-up pass over every subscription.
+func (e *PolicyEngine) catchUpOnce(ctx context.Context) error {
+	var errs []error
+	cursors := make([]uint64, len(e.subscriptions))
+	active := make([]bool, len(e.subscriptions))
+	var from uint64
+	found := false
+	for i, sub := range e.subscriptions {
+		cursor, ok, err := e.cursors.LoadCursor(ctx, sub.name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("policy %s: load cursor: %w", sub.name, err))
+			continue
+		}
+		if !ok {
+			if err := e.startSubscription(ctx, sub); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if !found || cursor < from {
+			from = cursor
+		}
+		cursors[i], active[i], found = cursor, true, true
+	}
+	if !found {
+		return errors.Join(errs...)
+	}
+	events, _, err := e.events.LoadAfterSeqNr(ctx, from)
+	if err != nil {
+		return errors.Join(append(errs, fmt.Errorf("policy catch-up: load events: %w", err))...)
+	}
+	for i, sub := range e.subscriptions {
+		if !active[i] {
+			continue
+		}
+		if err := e.catchUpSubscription(ctx, sub, cursors[i], events); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// startSubscription puts the cursor of a new subscription at the latest
+// SeqNr.
+func (e *PolicyEngine) startSubscription(ctx context.Context, sub subscription) error {
+	latest, err := e.events.LatestSeqNr(ctx)
+	if err != nil {
+		return fmt.Errorf("policy %s: latest seq nr: %w", sub.name, err)
+	}
+	if err := e.cursors.SaveCursor(ctx, sub.name, latest); err != nil {
+		return fmt.Errorf("policy %s: save cursor: %w", sub.name, err)
+	}
+	return nil
+}
+
+// catchUpSubscription offers sub the events after cursor; events is
+// ordered by SeqNr and may start before it.
+func (e *PolicyEngine) catchUpSubscription(ctx context.Context, sub subscription, cursor uint64, events []domain.Event) error {
+	start := cursor
+	for _, ev := range events {
+		if ev.SeqNr <= start {
+			continue
+		}
+		if ev.Type == sub.trigger {
+			if err := sub.handler(ctx, ev); err != nil {
+				if saveErr := e.saveCursor(ctx, sub.name, start, cursor); saveErr != nil {
+					return saveErr
+				}
+				if errors.Is(err, ErrPolicyDeferred) {
+					until := time.Now().Add(policyRetryInterval)
+					var d *policyDeferral
+					if errors.As(err, &d) {
+						until = d.until
+					}
+					e.deferUntil(until)
+					return nil
+				}
+				return fmt.Errorf("policy %s: %s %s: %w", sub.name, ev.Type, ev.ID, err)
+			}
+			// Checkpoint each handled event, so a crash replays at most one.
+			if err := e.saveCursor(ctx, sub.name, start, ev.SeqNr); err != nil {
+				return err
+			}
+			start = ev.SeqNr
+		}
+		cursor = ev.SeqNr
+	}
+	return e.saveCursor(ctx, sub.name, start, cursor)
+}
+
+// clearDue forgets the scheduled retry before a pass, which schedules
+// whatever it defers anew.
+func (e *PolicyEngine) clearDue() {
+	e.dueMu.Lock()
+	e.due = time.Time{}
+	e.dueMu.Unlock()
+}
+
+// deferUntil schedules a catch-up at until unless one is due earlier.
+// A due time already past is pushed a second out, so an event deferred
+// again and again does not spin RunDeferred.
+func (e *PolicyEngine) deferUntil(until time.Time) {
+	if soonest := time.Now().Add(time.Second); until.Before(soonest) {
+		until = soonest
+	}
+	e.dueMu.Lock()
+	if e.due.IsZero() || until.Before(e.due) {
+		e.due = until
+	}
+	e.dueMu.Unlock()
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nextDue returns when the next scheduled catch-up is due, zero if none.
+func (e *PolicyEngine) nextDue() time.Time {
+	e.dueMu.Lock()
+	defer e.dueMu.Unlock()
+	return e.due
+}
+
+// RunDeferred catches up whenever a deferred event falls due or a failed
+// catch-up is to be retried, so neither waits for the next dispatched
+// event. It returns when ctx is done.
+func (e *PolicyEngine) RunDeferred(ctx context.Context) {
+	for {
+		var fire <-chan time.Time
+		var timer *time.Timer
+		if due := e.nextDue(); !due.IsZero() {
+			timer = time.NewTimer(time.Until(due))
+			fire = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-e.wake:
+		case <-fire:
+			if err := e.CatchUp(ctx); err != nil && e.logger != nil {
+				e.logger.Warn("policy catch-up (retried in %s): %v", policyRetryInterval, err)
+			}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// saveCursor saves cursor when it moved past the saved position from.
+func (e *PolicyEngine) saveCursor(ctx context.Context, name string, from, cursor uint64) error {
+	if cursor <= from {
+		return nil
+	}
+	if err := e.cursors.SaveCursor(ctx, name, cursor); err != nil {
+		return fmt.Errorf("policy %s: save cursor: %w", name, err)
+	}
 	return nil
 }
@@ -0,0 +1,210 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hironow/paintress/internal/domain"
+	"github.com/hironow/paintress/internal/usecase"
+)
+
+type memCursorStore map[string]uint64
+
+func (m memCursorStore) LoadCursor(_ context.Context, name string) (uint64, bool, error) {
+	seq, ok := m[name]
+	return seq, ok, nil
+}
+
+func (m memCursorStore) SaveCursor(_ context.Context, name string, seqNr uint64) error {
+	if seqNr > m[name] {
+		m[name] = seqNr
+	}
+	return nil
+}
+
+// seqEvent builds an event with a global SeqNr, as the emitter stores it.
+func seqEvent(t *testing.T, seq uint64, typ domain.EventType, data any, at time.Time) domain.Event {
+	t.Helper()
+	ev, err := domain.NewEvent(typ, data, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev.SeqNr = seq
+	return ev
+}
+
+func TestPolicyEngine_Subscribe_StartsAtLatestAndRetriesFailures(t *testing.T) {
+	// given: history recorded before the subscription existed
+	now := time.Now().UTC()
+	store := &fakeEventStore{appended: []domain.Event{
+		seqEvent(t, 1, domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 1, Status: "success"}, now),
+	}}
+	cursors := memCursorStore{}
+	var handled []uint64
+	fail := true
+	engine := usecase.NewPolicyEngine(nil).WithSubscriptionStore(store, cursors)
+	engine.Subscribe("count", domain.EventExpeditionCompleted, func(_ context.Context, ev domain.Event) error {
+		if fail {
+			return errors.New("boom")
+		}
+		handled = append(handled, ev.SeqNr)
+		return nil
+	})
+	if err := engine.CatchUp(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// when: a new completion arrives and the handler fails once
+	store.appended = append(store.appended,
+		seqEvent(t, 2, domain.EventGradientChanged, domain.GradientChangedData{Level: 1}, now),
+		seqEvent(t, 3, domain.EventExpeditionCompleted, domain.ExpeditionCompletedData{Expedition: 2, Status: "success"}, now),
+	)
+	failErr := engine.CatchUp(context.Background())
+	fail = false
+	retryErr := engine.CatchUp(context.Background())
+
+	// then: history is skipped, the failed event is kept and handled once on retry
+	if failErr == nil {
+		t.Error("CatchUp with a failing handler returned nil")
+	}
+	if cursors["count"] != 3 || retryErr != nil {
+		t.Errorf("cursor = %d, retry err = %v; want 3, nil", cursors["count"], retryErr)
+	}
+	if len(handled) != 1 || handled[0] != 3 {
+		t.Errorf("handled = %v, want [3]", handled)
+	}
+
+	// when: a fresh engine (a restarted process) catches up with the same cursors
+	restarted := usecase.NewPolicyEngine(nil).WithSubscriptionStore(store, cursors)
+	restarted.Subscribe("count", domain.EventExpeditionCompleted, func(_ context.Context, ev domain.Event) error {
+		handled = append(handled, ev.SeqNr)
+		return nil
+	})
+	if err := restarted.CatchUp(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// then: nothing is handled twice
+	if len(handled) != 1 {
+		t.Errorf("handled after restart = %v, want [3]", handled)
+	}
+}
+
+func TestPolicyEngine_Subscribe_DeferredEventIsOfferedAgain(t *testing.T) {
+	// given
+	now := time.Now().UTC()
+	store := &fakeEventStore{}
+	cursors := memCursorStore{"wait": 0}
+	due := false
+	calls := 0
+	engine := usecase.NewPolicyEngine(nil).WithSubscriptionStore(store, cursors)
+	engine.Subscribe("wait", domain.EventDMailStaged, func(_ context.Context, _ domain.Event) error {
+		calls++
+		if !due {
+			return usecase.ErrPolicyDeferred
+		}
+		return nil
+	})
+	store.appended = append(store.appended, seqEvent(t, 1, domain.EventDMailStaged, domain.DMailStagedData{Name: "a"}, now))
+
+	// when
+	deferredErr := engine.Dispatch(context.Background(), store.appended[0])
+	cursorWhileDeferred := cursors["wait"]
+	due = true
+	dueErr := engine.CatchUp(context.Background())
+
+	// then
+	if deferredErr != nil || dueErr != nil {
+		t.Fatalf("errors = %v, %v", deferredErr, dueErr)
+	}
+	if cursorWhileDeferred != 0 || cursors["wait"] != 1 || calls != 2 {
+		t.Errorf("cursor while deferred = %d, after = %d, calls = %d; want 0, 1, 2", cursorWhileDeferred, cursors["wait"], calls)
+	}
+}
+
+func TestPolicyEngine_RunDeferred_RetriesWhenDue(t *testing.T) {
+	// given: an event deferred for a moment, and no event after it
+	now := time.Now().UTC()
+	store := &fakeEventStore{}
+	cursors := memCursorStore{"wait": 0}
+	handled := make(chan uint64, 1)
+	deferred := false
+	engine := usecase.NewPolicyEngine(nil).WithSubscriptionStore(store, cursors)
+	engine.Subscribe("wait", domain.EventDMailStaged, func(_ context.Context, ev domain.Event) error {
+		if !deferred {
+			deferred = true
+			return usecase.DeferPolicyUntil(time.Now().Add(10 * time.Millisecond))
+		}
+		handled <- ev.SeqNr
+		return nil
+	})
+	store.appended = append(store.appended, seqEvent(t, 1, domain.EventDMailStaged, domain.DMailStagedData{Name: "a"}, now))
+	if err := engine.Dispatch(context.Background(), store.appended[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.RunDeferred(ctx)
+	}()
+
+	// then: the event is handled without another dispatch
+	select {
+	case seq := <-handled:
+		if seq != 1 {
+			t.Errorf("handled SeqNr %d, want 1", seq)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("deferred event not retried")
+	}
+	cancel()
+	<-done
+}
+
+// loadCountingStore counts LoadAfterSeqNr calls.
+type loadCountingStore struct {
+	*fakeEventStore
+	loads int
+}
+
+func (s *loadCountingStore) LoadAfterSeqNr(ctx context.Context, afterSeqNr uint64) ([]domain.Event, domain.LoadResult, error) {
+	s.loads++
+	return s.fakeEventStore.LoadAfterSeqNr(ctx, afterSeqNr)
+}
+
+func TestPolicyEngine_CatchUp_ReadsEventsOnceForAllSubscriptions(t *testing.T) {
+	// given: two subscriptions at different cursors
+	now := time.Now().UTC()
+	store := &loadCountingStore{fakeEventStore: &fakeEventStore{appended: []domain.Event{
+		seqEvent(t, 1, domain.EventDMailStaged, domain.DMailStagedData{Name: "a"}, now),
+		seqEvent(t, 2, domain.EventDMailStaged, domain.DMailStagedData{Name: "b"}, now),
+	}}}
+	cursors := memCursorStore{"behind": 0, "ahead": 1}
+	seen := map[string][]uint64{}
+	engine := usecase.NewPolicyEngine(nil).WithSubscriptionStore(store, cursors)
+	for _, name := range []string{"behind", "ahead"} {
+		engine.Subscribe(name, domain.EventDMailStaged, func(_ context.Context, ev domain.Event) error {
+			seen[name] = append(seen[name], ev.SeqNr)
+			return nil
+		})
+	}
+
+	// when
+	err := engine.CatchUp(context.Background())
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.loads != 1 {
+		t.Errorf("LoadAfterSeqNr calls = %d, want 1", store.loads)
+	}
+	if len(seen["behind"]) != 2 || len(seen["ahead"]) != 1 || seen["ahead"][0] != 2 {
+		t.Errorf("seen = %v, want behind [1 2] and ahead [2]", seen)
+	}
+}
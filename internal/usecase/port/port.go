@@ -14,7 +14,7 @@ var ErrUnsupportedOS = errors.New("notify: unsupported OS for local notification
 
 // CheckpointScanner finds incomplete expeditions from the event store.
 // Implemented in eventsource layer, injected into session.
-type CheckpointScanner interface { // nosemgrep: structure.multiple-exported-interfaces-go -- port interface cluster; all interfaces in this file are usecase/port contracts (CheckpointScanner/RecoveryDecider/InitRunner/EventDispatcher/Approver/Notifier/GitExecutor/PolicyMetrics/ContextEventApplier/EventStore/SnapshotStore/SeqAllocator/OutboxStore/ArchiveOps/ArchiveReader/InboxReader/StepProgressReader/TargetProvider/PreFlightTriager/FeedbackActionHandler/FollowUpRunner/InboxArchiver/ExpeditionEventEmitter/ExpeditionRunner/ProjectOps/DoctorOps/RunLockStore); splitting would fragment the port contract file that cmd uses as composition root [permanent]
+type CheckpointScanner interface { // nosemgrep: structure.multiple-exported-interfaces-go -- port interface cluster; all interfaces in this file are usecase/port contracts (CheckpointScanner/RecoveryDecider/InitRunner/EventDispatcher/Approver/Notifier/GitExecutor/PolicyMetrics/ContextEventApplier/EventStore/SnapshotStore/SeqAllocator/OutboxStore/ArchiveOps/ArchiveReader/InboxReader/StepProgressReader/TargetProvider/PreFlightTriager/FeedbackActionHandler/FollowUpRunner/InboxArchiver/ExpeditionEventEmitter/ExpeditionRunner/ProjectOps/DoctorOps/RunLockStore/PolicyCursorStore/DMailSender); splitting would fragment the port contract file that cmd uses as composition root [permanent]
 	// FindIncompleteCheckpoints returns checkpoint events that have no
 	// subsequent expedition.completed event for the same expedition number.
 	FindIncompleteCheckpoints() []domain.ExpeditionCheckpointData
@@ -140,8 +140,27 @@ type ContextEventApplier interface { // nosemgrep: structure.multiple-exported-i
 // EventStore is the append-only event persistence interface.
 type EventStore interface { // nosemgrep: structure.multiple-exported-interfaces-go -- port interface cluster cohesive set; see CheckpointScanner [permanent]
 	// Append persists one or more events. Validation is performed before any writes.
+	// It is AppendExpecting with domain.AnyVersion.
 	Append(ctx context.Context, events ...domain.Event) (domain.AppendResult, error)
 
+	// AppendExpecting persists events like Append, provided their aggregate
+	// stream (one stream for the whole batch) holds exactly expected events.
+	// Otherwise nothing is written and a *domain.ConcurrencyConflictError
+	// is returned.
+	AppendExpecting(ctx context.Context, expected domain.ExpectedVersion, events ...domain.Event) (domain.AppendResult, error)
+
+	// AppendAllocating is AppendExpecting that stamps each event with a
+	// SeqNr from alloc (see SeqAllocator) inside the append's critical
+	// section, after the version check. SeqNrs are thus stored in the
+	// order they were allocated even with several writers, so a reader
+	// that has seen SeqNr N never meets a smaller one later. The result
+	// lists the allocated SeqNrs; a nil alloc keeps the events' own.
+	AppendAllocating(ctx context.Context, expected domain.ExpectedVersion, alloc func(context.Context) (uint64, error), events ...domain.Event) (domain.AppendResult, error)
+
+	// StreamVersion returns the number of stored events of stream
+	// (see domain.Event.StreamID).
+	StreamVersion(ctx context.Context, stream string) (uint64, error)
+
 	// LoadAll returns all events in chronological order.
 	LoadAll(ctx context.Context) ([]domain.Event, domain.LoadResult, error)
 
@@ -190,6 +209,7 @@ type ArchiveOps interface { // nosemgrep: structure.multiple-exported-interfaces
 	ListExpiredEventFiles(ctx context.Context, stateDir string, days int) ([]string, error)
 	PruneEventFiles(ctx context.Context, stateDir string, files []string) ([]string, error)
 	PruneFlushedOutbox(ctx context.Context, repoPath string) (int, error)
+	PruneArtifacts(ctx context.Context, repoPath string, dropped []string, execute bool) ([]string, error)
 }
 
 // ArchiveReader reads D-Mails from the archive directory.
@@ -244,13 +264,22 @@ type ExpeditionEventEmitter interface { // nosemgrep: structure.multiple-exporte
 	EmitSpecRegistered(waveID string, steps []domain.WaveStepDef, source string, now time.Time) error
 	EmitInboxReceived(name, severity string, now time.Time) error
 	EmitGommage(expedition int, now time.Time) error
-	EmitGradientChange(level int, operator string, now time.Time) error
+	// GradientVersion syncs the emitter's aggregate with the stored
+	// version of the gradient stream (domain.GradientStreamID) and returns
+	// it. Passing it to EmitGradientChange makes a read-modify-write of the
+	// gradient fail with a *domain.ConcurrencyConflictError when another
+	// gradient change got in between.
+	GradientVersion() (domain.ExpectedVersion, error)
+	EmitGradientChange(expected domain.ExpectedVersion, level, delta int, operator string, now time.Time) error
 	EmitRetryAttempted(dmailKey string, attempt int, now time.Time) error
 	EmitEscalated(dmailName string, issues []string, now time.Time) error
 	EmitResolved(dmailName string, issues []string, now time.Time) error
-	EmitDMailStaged(name string, now time.Time) error
+	EmitDMailStaged(name string, issues []string, now time.Time) error
 	EmitDMailFlushed(count int, now time.Time) error
 	EmitDMailArchived(name string, now time.Time) error
+	// EmitInboxConsumed appends all events of one consumed inbox D-Mail
+	// in a single append: either all of them are stored or none.
+	EmitInboxConsumed(consumed domain.InboxConsumption, now time.Time) error
 	EmitGommageRecovery(expedition int, class, action string, retryNum int, cooldown string, now time.Time) error
 	EmitCheckpoint(expedition int, phase, workDir string, commitCount int, now time.Time) error
 }
@@ -270,15 +299,23 @@ func (*NopExpeditionEventEmitter) EmitSpecRegistered(_ string, _ []domain.WaveSt
 }
 func (*NopExpeditionEventEmitter) EmitInboxReceived(_, _ string, _ time.Time) error { return nil }
 func (*NopExpeditionEventEmitter) EmitGommage(_ int, _ time.Time) error             { return nil }
-func (*NopExpeditionEventEmitter) EmitGradientChange(_ int, _ string, _ time.Time) error {
+func (*NopExpeditionEventEmitter) GradientVersion() (domain.ExpectedVersion, error) {
+	return domain.AnyVersion, nil
+}
+func (*NopExpeditionEventEmitter) EmitGradientChange(_ domain.ExpectedVersion, _, _ int, _ string, _ time.Time) error {
 	return nil
 }
 func (*NopExpeditionEventEmitter) EmitRetryAttempted(_ string, _ int, _ time.Time) error { return nil }
 func (*NopExpeditionEventEmitter) EmitEscalated(_ string, _ []string, _ time.Time) error { return nil }
 func (*NopExpeditionEventEmitter) EmitResolved(_ string, _ []string, _ time.Time) error  { return nil }
-func (*NopExpeditionEventEmitter) EmitDMailStaged(_ string, _ time.Time) error           { return nil }
-func (*NopExpeditionEventEmitter) EmitDMailFlushed(_ int, _ time.Time) error             { return nil }
-func (*NopExpeditionEventEmitter) EmitDMailArchived(_ string, _ time.Time) error         { return nil }
+func (*NopExpeditionEventEmitter) EmitDMailStaged(_ string, _ []string, _ time.Time) error {
+	return nil
+}
+func (*NopExpeditionEventEmitter) EmitDMailFlushed(_ int, _ time.Time) error     { return nil }
+func (*NopExpeditionEventEmitter) EmitDMailArchived(_ string, _ time.Time) error { return nil }
+func (*NopExpeditionEventEmitter) EmitInboxConsumed(_ domain.InboxConsumption, _ time.Time) error {
+	return nil
+}
 func (*NopExpeditionEventEmitter) EmitGommageRecovery(_ int, _, _ string, _ int, _ string, _ time.Time) error {
 	return nil
 }
@@ -305,3 +342,21 @@ type RunLockStore interface { // nosemgrep: structure.multiple-exported-interfac
 	// Close releases database resources.
 	Close() error
 }
+
+// PolicyCursorStore persists the SeqNr cursor of each durable policy
+// subscription: the last event the named handler has processed.
+type PolicyCursorStore interface { // nosemgrep: structure.multiple-exported-interfaces-go -- port interface cluster cohesive set; see CheckpointScanner [permanent]
+	// LoadCursor returns the cursor of name; ok is false for a
+	// subscription that has never saved one.
+	LoadCursor(ctx context.Context, name string) (seqNr uint64, ok bool, err error)
+	// SaveCursor moves the cursor of name forward to seqNr. A cursor
+	// never moves backwards, so a slower process cannot rewind it.
+	SaveCursor(ctx context.Context, name string, seqNr uint64) error
+}
+
+// DMailSender delivers a D-Mail through the transactional outbox, with
+// the same validation and dmail.staged / dmail.flushed events as the
+// dmail MCP tool. Implemented in session layer, injected by cmd.
+type DMailSender interface { // nosemgrep: structure.multiple-exported-interfaces-go -- port interface cluster cohesive set; see CheckpointScanner [permanent]
+	SendDMail(ctx context.Context, d domain.DMail) error
+}